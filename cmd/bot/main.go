@@ -1,18 +1,22 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
+	"telegram-summarizer/internal/archive"
 	"telegram-summarizer/internal/bot"
 	"telegram-summarizer/internal/config"
 	"telegram-summarizer/internal/db"
 	"telegram-summarizer/internal/gemini"
 	"telegram-summarizer/internal/logger"
+	"telegram-summarizer/internal/ocr"
 	"telegram-summarizer/internal/scheduler"
 	"telegram-summarizer/internal/summarizer"
+	"telegram-summarizer/internal/timezone"
 )
 
 var (
@@ -51,16 +55,32 @@ func main() {
 	logger.Debug("  Database Path: %s", cfg.DatabasePath)
 	logger.Info("  Summary Interval: %d hours", cfg.SummaryInterval)
 	logger.Info("  Daily Summary Time: %s", cfg.DailySummaryTime)
+	
+	// Summary windows and dates use the configured timezone
+	location, err := timezone.Load(cfg.Timezone)
+	if err != nil {
+		logger.Error("Configuration error: %v", err)
+		os.Exit(1)
+	}
+	timezone.SetDefault(location)
+	logger.Info("  Timezone: %s", location)
 
 	// Initialize database
 	logger.Info("\n📦 Initializing database...")
-	database, err := db.InitDB(cfg.DatabasePath)
+	database, err := db.Open(cfg.DatabaseDriver, cfg.DatabaseSource())
 	if err != nil {
 		logger.Error("Failed to initialize database: %v", err)
 		os.Exit(1)
 	}
 	defer database.Close()
 
+	// Summaries went to a single target chat before subscriptions existed
+	if added, err := database.SubscribeLegacyTarget(cfg.TargetChatID); err != nil {
+		logger.Error("Failed to subscribe the former target chat: %v", err)
+	} else if added > 0 {
+		logger.Info("📬 Subscribed former target chat %d to the daily summaries of %d groups", cfg.TargetChatID, added)
+	}
+
 	// Create Gemini client
 	logger.Info("\n🧠 Initializing Gemini AI client...")
 	geminiClient := gemini.NewClient(cfg.GeminiAPIKey, cfg.GeminiModel)
@@ -70,6 +90,13 @@ func main() {
 	logger.Info("\n📝 Initializing summarizer service...")
 	summarizerService := summarizer.NewSummarizer(database, geminiClient)
 	logger.Info("✅ Summarizer service ready")
+	
+	// Link product mentions stored before the product catalog existed
+	go func() {
+		if _, err := summarizerService.GetCatalog().Backfill(); err != nil {
+			logger.Error("Product catalog backfill failed: %v", err)
+		}
+	}()
 
 	// Create message handler
 	logger.Info("\n💬 Initializing message handler...")
@@ -84,22 +111,40 @@ func main() {
 		os.Exit(1)
 	}
 	
+	// Optional OCR of image attachments (OCR_COMMAND)
+	if ocrEngine := ocr.NewFromEnv(); ocrEngine != nil {
+		messageHandler.SetOCR(ocrEngine, telegramBot.DownloadFile)
+	}
+	
+	// Group admin flag of user profiles
+	messageHandler.SetAdminLookup(telegramBot.IsChatAdmin)
+	
 	// Create command handler
 	logger.Info("\n🔧 Initializing command handler...")
 	commandHandler := bot.NewCommandHandler(telegramBot, database)
+	
+	// Message retention (archive before deleting expired messages)
+	retention := archive.NewService(database, archive.Config{
+		RetentionDays: cfg.RetentionDays,
+		Mode:          cfg.ArchiveMode,
+		Dir:           cfg.ArchiveDir,
+		DBPath:        cfg.ArchiveDBPath,
+	})
+	defer retention.Close()
+	commandHandler.SetRetention(retention)
+	commandHandler.SetOwnerID(cfg.OwnerID)
 	telegramBot.SetCommandHandler(commandHandler)
 	telegramBot.SetSummarizer(summarizerService)
 	logger.Info("✅ Command handler ready")
 
-	// Hardcoded target chat ID for auto-summaries
-	targetChatID := int64(6491485169) // Hardcoded target chat ID
-	
-	// Create and start scheduler
+	// Create and start scheduler (summaries go to each group's subscribers)
 	var summaryScheduler *scheduler.Scheduler
 	logger.Info("\n📅 Initializing daily summary scheduler...")
-	logger.Info("   Target Chat ID: %d (hardcoded)", targetChatID)
-	summaryScheduler = scheduler.NewScheduler(database, summarizerService, telegramBot.GetAPI(), targetChatID)
-	summaryScheduler.Start(cfg.DailySummaryTime)
+	summaryScheduler = scheduler.NewScheduler(database, summarizerService, telegramBot.GetAPI())
+	summaryScheduler.SetReportChatID(cfg.ReportChatID)
+	summaryScheduler.SetWorkers(cfg.SummaryWorkers)
+	summaryScheduler.SetRetention(retention)
+	summaryScheduler.Start(context.Background(), cfg.DailySummaryTime)
 	logger.Info("✅ Scheduler ready (Daily summary at %s)", cfg.DailySummaryTime)
 
 	// Setup graceful shutdown
@@ -7,15 +7,21 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"telegram-summarizer/internal/archive"
 	"telegram-summarizer/internal/bot"
 	"telegram-summarizer/internal/client"
 	"telegram-summarizer/internal/config"
 	"telegram-summarizer/internal/db"
+	"telegram-summarizer/internal/filter"
 	"telegram-summarizer/internal/gemini"
+	"telegram-summarizer/internal/leader"
 	"telegram-summarizer/internal/logger"
+	"telegram-summarizer/internal/ocr"
 	"telegram-summarizer/internal/scheduler"
 	"telegram-summarizer/internal/summarizer"
+	"telegram-summarizer/internal/timezone"
 )
 
 var (
@@ -40,21 +46,15 @@ func main() {
 	// Initialize logger
 	logger.Init(cfg.DebugMode)
 	
-	// Initialize Telegram notifier for remote logging
+	// Initialize Telegram notifier for remote logging, only when configured
 	monitorBotToken := os.Getenv("MONITOR_BOT_TOKEN")
 	monitorChatID := os.Getenv("MONITOR_CHAT_ID")
 	
-	// Use default monitoring bot if not specified
-	if monitorBotToken == "" {
-		monitorBotToken = "8458117186:AAGywdxpEdRqgM2_8rgUi1Ch8TPqdFOszNY"
-	}
-	if monitorChatID == "" {
-		monitorChatID = "6491485169" // Your Telegram user ID
-	}
-	
 	// Convert chat ID to int64
 	var chatID int64
-	if _, err := fmt.Sscanf(monitorChatID, "%d", &chatID); err == nil {
+	if monitorBotToken == "" || monitorChatID == "" {
+		logger.Debug("Telegram notifier disabled (MONITOR_BOT_TOKEN or MONITOR_CHAT_ID not set)")
+	} else if _, err := fmt.Sscanf(monitorChatID, "%d", &chatID); err == nil {
 		if err := logger.InitTelegramNotifier(monitorBotToken, chatID); err != nil {
 			logger.Warn("Failed to initialize Telegram notifier: %v", err)
 		}
@@ -74,13 +74,20 @@ func main() {
 
 	// Initialize database (shared by both bot and scraper)
 	logger.Info("\n📦 Initializing database...")
-	database, err := db.InitDB(cfg.DatabasePath)
+	database, err := db.Open(cfg.DatabaseDriver, cfg.DatabaseSource())
 	if err != nil {
 		logger.Error("Failed to initialize database: %v", err)
 		os.Exit(1)
 	}
 	defer database.Close()
 
+	// Summaries went to a single target chat before subscriptions existed
+	if added, err := database.SubscribeLegacyTarget(cfg.TargetChatID); err != nil {
+		logger.Error("Failed to subscribe the former target chat: %v", err)
+	} else if added > 0 {
+		logger.Info("📬 Subscribed former target chat %d to the daily summaries of %d groups", cfg.TargetChatID, added)
+	}
+
 	// Create context for cancellation
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -96,20 +103,42 @@ func main() {
 		cancel()
 	}()
 
+	// Scraper login prompts go through the bot, which only runs in 'all' mode
+	var authPrompter *bot.AuthPrompter
+	if *mode == "all" && cfg.ScraperAuthMode == client.AuthModeBot {
+		if cfg.AuthAdminChatID == 0 {
+			logger.Error("SCRAPER_AUTH_MODE=bot requires AUTH_ADMIN_CHAT_ID")
+			os.Exit(1)
+		}
+		authPrompter = bot.NewAuthPrompter(cfg.AuthAdminChatID)
+	}
+
+	// Ingest filters are shared so config changes via bot apply to the scraper immediately
+	filters := filter.NewManager(database)
+	
 	// Start services based on mode
 	switch *mode {
 	case "bot":
-		runBot(cfg, database, ctx)
+		runBot(cfg, database, ctx, nil, filters, nil)
 	case "scraper":
-		runScraper(cfg, database, ctx)
+		runScraper(cfg, database, ctx, nil, filters)
 	case "all":
+		// With leader election the scraper only runs on the instance leading the bot, so
+		// the bot receiving login replies is the one the scraper prompted through
+		if cfg.LeaderElection {
+			runBot(cfg, database, ctx, authPrompter, filters, newScraper(cfg, database, authPrompter, filters))
+			break
+		}
+
 		// Run both bot and scraper in parallel
-		go runScraper(cfg, database, ctx)
-		runBot(cfg, database, ctx)
+		go runScraper(cfg, database, ctx, authPrompter, filters)
+		runBot(cfg, database, ctx, authPrompter, filters, nil)
 	}
 }
 
-func runBot(cfg *config.Config, database *db.DB, ctx context.Context) {
+// runBot runs the bot service until ctx is done. scrape, if not nil, runs the scraper
+// while this instance leads the bot.
+func runBot(cfg *config.Config, database db.Store, ctx context.Context, authPrompter *bot.AuthPrompter, filters *filter.Manager, scrape func(context.Context)) {
 	logger.Info("\n🤖 Starting BOT service...")
 	logger.Info("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 
@@ -127,6 +156,15 @@ func runBot(cfg *config.Config, database *db.DB, ctx context.Context) {
 	logger.Info("  Summary Interval: %d hours", cfg.SummaryInterval)
 	logger.Info("  Daily Summary Time: %s", cfg.DailySummaryTime)
 
+	// Summary windows and dates use the configured timezone
+	location, err := timezone.Load(cfg.Timezone)
+	if err != nil {
+		logger.Error("Configuration error: %v", err)
+		os.Exit(1)
+	}
+	timezone.SetDefault(location)
+	logger.Info("  Timezone: %s", location)
+
 	// Create Gemini client
 	logger.Info("\n🧠 Initializing Gemini AI client...")
 	geminiClient := gemini.NewClient(cfg.GeminiAPIKey, cfg.GeminiModel)
@@ -136,10 +174,18 @@ func runBot(cfg *config.Config, database *db.DB, ctx context.Context) {
 	logger.Info("\n📝 Initializing summarizer service...")
 	summarizerService := summarizer.NewSummarizer(database, geminiClient)
 	logger.Info("✅ Summarizer service ready")
+	
+	// Link product mentions stored before the product catalog existed
+	go func() {
+		if _, err := summarizerService.GetCatalog().Backfill(); err != nil {
+			logger.Error("Product catalog backfill failed: %v", err)
+		}
+	}()
 
 	// Create message handler
 	logger.Info("\n💬 Initializing message handler...")
 	messageHandler := bot.NewMessageHandler(database)
+	messageHandler.SetFilters(filters)
 	logger.Info("✅ Message handler ready")
 
 	// Create Telegram bot
@@ -149,34 +195,72 @@ func runBot(cfg *config.Config, database *db.DB, ctx context.Context) {
 		logger.Error("Failed to create bot: %v", err)
 		os.Exit(1)
 	}
+	
+	// Optional OCR of image attachments (OCR_COMMAND)
+	if ocrEngine := ocr.NewFromEnv(); ocrEngine != nil {
+		messageHandler.SetOCR(ocrEngine, telegramBot.DownloadFile)
+	}
+	
+	// Group admin flag of user profiles
+	messageHandler.SetAdminLookup(telegramBot.IsChatAdmin)
 
 	// Create command handler
 	logger.Info("\n🔧 Initializing command handler...")
 	commandHandler := bot.NewCommandHandler(telegramBot, database)
+	
+	// Message retention (archive before deleting expired messages)
+	retention := archive.NewService(database, archive.Config{
+		RetentionDays: cfg.RetentionDays,
+		Mode:          cfg.ArchiveMode,
+		Dir:           cfg.ArchiveDir,
+		DBPath:        cfg.ArchiveDBPath,
+	})
+	defer retention.Close()
+	commandHandler.SetRetention(retention)
+	commandHandler.SetOwnerID(cfg.OwnerID)
 	telegramBot.SetCommandHandler(commandHandler)
 	telegramBot.SetSummarizer(summarizerService)
+	if authPrompter != nil {
+		telegramBot.SetAuthPrompter(authPrompter)
+	}
 	logger.Info("✅ Command handler ready")
 
-	// Hardcoded target chat ID for auto-summaries
-	targetChatID := int64(6491485169)
-
-	// Create and start scheduler
-	logger.Info("\n📅 Initializing daily summary scheduler...")
-	logger.Info("   Target Chat ID: %d (hardcoded)", targetChatID)
-	summaryScheduler := scheduler.NewScheduler(database, summarizerService, telegramBot.GetAPI(), targetChatID)
-	summaryScheduler.Start(cfg.DailySummaryTime)
-	logger.Info("✅ Scheduler ready (Daily summary at %s)", cfg.DailySummaryTime)
-
-	// Setup graceful shutdown for bot
-	go func() {
-		<-ctx.Done()
-		logger.Info("\n🛑 Stopping bot service...")
-		if summaryScheduler != nil {
-			summaryScheduler.Stop()
+	// The scheduler and update polling run on one instance only: this one, or the
+	// elected leader when several instances share the database (LEADER_ELECTION)
+	var elector *leader.Elector
+	if cfg.LeaderElection {
+		elector = newElector(cfg, database, "bot")
+	}
+	lead := func(ctx context.Context) {
+		// Create and start scheduler (summaries go to each group's subscribers)
+		logger.Info("\n📅 Initializing daily summary scheduler...")
+		summaryScheduler := scheduler.NewScheduler(database, summarizerService, telegramBot.GetAPI())
+		summaryScheduler.SetReportChatID(cfg.ReportChatID)
+		summaryScheduler.SetWorkers(cfg.SummaryWorkers)
+		summaryScheduler.SetRetention(retention)
+		if elector != nil {
+			// Jobs the previous leader is still running are only requeued once it had time to finish them
+			summaryScheduler.SetTakeoverDelay(elector.Lease())
 		}
-		telegramBot.Stop()
-		logger.Info("✅ Bot service stopped")
-	}()
+		summaryScheduler.Start(ctx, cfg.DailySummaryTime)
+		logger.Info("✅ Scheduler ready (Daily summary at %s)", cfg.DailySummaryTime)
+
+		// The scraper runs alongside, under the bot's lease
+		scraped := make(chan struct{})
+		go func() {
+			defer close(scraped)
+			if scrape != nil {
+				scrape(ctx)
+			}
+		}()
+
+		// Handle updates until stopped or no longer the leader; the lease is released
+		// only once the scheduler and scraper have stopped
+		logger.Info("🚀 Listening for messages...")
+		telegramBot.Poll(ctx)
+		summaryScheduler.Stop()
+		<-scraped
+	}
 
 	logger.Info("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	logger.Info("✅ ✅ ✅ Bot is fully operational!")
@@ -198,18 +282,23 @@ func runBot(cfg *config.Config, database *db.DB, ctx context.Context) {
 	logger.Info("  /summary <chat_id> - Generate 24h summary for a group")
 	logger.Info("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
 
-	// Start bot (blocks until stopped)
-	if err := telegramBot.Start(); err != nil {
-		if ctx.Err() == context.Canceled {
-			logger.Info("Bot stopped by context cancellation")
-		} else {
-			logger.Error("Bot error: %v", err)
-			os.Exit(1)
-		}
+	// Run the bot (blocks until stopped)
+	if elector != nil {
+		elector.Run(ctx, lead)
+	} else {
+		lead(ctx)
 	}
+	logger.Info("✅ Bot service stopped")
 }
 
-func runScraper(cfg *config.Config, database *db.DB, ctx context.Context) {
+func runScraper(cfg *config.Config, database db.Store, ctx context.Context, authPrompter *bot.AuthPrompter, filters *filter.Manager) {
+	newScraper(cfg, database, authPrompter, filters)(ctx)
+	logger.Info("\n✅ Scraper service stopped")
+}
+
+// newScraper sets up the scraper service and returns the function running it until
+// ctx is done
+func newScraper(cfg *config.Config, database db.Store, authPrompter *bot.AuthPrompter, filters *filter.Manager) func(context.Context) {
 	logger.Info("\n📱 Starting SCRAPER service...")
 	logger.Info("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 
@@ -225,6 +314,18 @@ func runScraper(cfg *config.Config, database *db.DB, ctx context.Context) {
 
 	logger.Info("Phone: %s", phoneNumber)
 
+	// Login code / 2FA password provider
+	var prompt client.PromptFunc
+	if authPrompter != nil {
+		prompt = authPrompter.Prompt
+	}
+	authProvider, err := client.NewAuthProvider(cfg.ScraperAuthMode, prompt)
+	if err != nil {
+		logger.Warn("Invalid scraper auth mode: %v (falling back to terminal)", err)
+		authProvider = client.TerminalAuthProvider{}
+	}
+	logger.Info("Auth mode: %s", cfg.ScraperAuthMode)
+
 	// Create client
 	logger.Info("\n📱 Initializing Telegram Client...")
 
@@ -233,11 +334,14 @@ func runScraper(cfg *config.Config, database *db.DB, ctx context.Context) {
 	apiHash := "4f595e6aac7dfe58a2cf6051360c3f14"
 
 	telegramClient := client.NewClient(client.Config{
-		AppID:      apiID,
-		AppHash:    apiHash,
-		Phone:      phoneNumber,
-		SessionDir: ".",
-		Database:   database,
+		AppID:        apiID,
+		AppHash:      apiHash,
+		Phone:        phoneNumber,
+		SessionDir:   ".",
+		Database:     database,
+		AuthProvider: authProvider,
+		OCR:          ocr.NewFromEnv(),
+		Filters:      filters,
 	})
 
 	logger.Info("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
@@ -245,26 +349,48 @@ func runScraper(cfg *config.Config, database *db.DB, ctx context.Context) {
 	logger.Info("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	logger.Info("\n📝 Features:")
 	logger.Info("  • Auto-save messages from all joined groups")
-	logger.Info("  • Smart filtering (min 10 characters)")
+	logger.Info("  • Configurable filtering (/filters, default min 10 characters)")
 	logger.Info("  • Track group activity")
 	logger.Info("  • Shared database with Bot service")
-	logger.Info("\n⚠️  First run: You'll need to enter verification code")
+	logger.Info("\n⚠️  First run: You'll need to provide the verification code (SCRAPER_AUTH_MODE)")
 	logger.Info("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
 
-	// Start client
-	logger.Info("🚀 Starting client...\n")
-
-	if err := telegramClient.Start(ctx); err != nil {
-		if err == context.Canceled {
-			logger.Info("\n✅ Scraper stopped successfully")
-		} else {
-			logger.Error("Scraper error: %v", err)
-			// Don't exit if running in 'all' mode
-			if *mode != "all" {
-				os.Exit(1)
+	return func(ctx context.Context) {
+		// Several instances sharing the database scrape on the elected one only. Messages of
+		// supergroups and channels are saved once per chat and message ID, so a takeover
+		// does not duplicate them.
+		if cfg.LeaderElection {
+			newElector(cfg, database, "scraper").Run(ctx, func(ctx context.Context) {
+				logger.Info("🚀 Starting client...\n")
+				if err := telegramClient.Start(ctx); err != nil && ctx.Err() == nil {
+					logger.Error("Scraper error: %v", err)
+				}
+			})
+			return
+		}
+
+		// Start client
+		logger.Info("🚀 Starting client...\n")
+
+		if err := telegramClient.Start(ctx); err != nil {
+			if err == context.Canceled {
+				logger.Info("\n✅ Scraper stopped successfully")
+			} else {
+				logger.Error("Scraper error: %v", err)
+				// Don't exit if running in 'all' mode
+				if *mode != "all" {
+					os.Exit(1)
+				}
 			}
 		}
 	}
+}
 
-	logger.Info("\n✅ Scraper service stopped")
+// newElector creates the elector of a role for this instance
+func newElector(cfg *config.Config, database db.Store, role string) *leader.Elector {
+	instanceID := cfg.InstanceID
+	if instanceID == "" {
+		instanceID = leader.InstanceID()
+	}
+	return leader.NewElector(database, role, instanceID, time.Duration(cfg.LeaderLease)*time.Second)
 }
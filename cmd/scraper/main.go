@@ -12,6 +12,7 @@ import (
 	"telegram-summarizer/internal/config"
 	"telegram-summarizer/internal/db"
 	"telegram-summarizer/internal/logger"
+	"telegram-summarizer/internal/ocr"
 )
 
 var (
@@ -54,7 +55,7 @@ func main() {
 
 	// Initialize database
 	logger.Info("\n📦 Initializing database...")
-	database, err := db.InitDB(cfg.DatabasePath)
+	database, err := db.Open(cfg.DatabaseDriver, cfg.DatabaseSource())
 	if err != nil {
 		logger.Error("Failed to initialize database: %v", err)
 		os.Exit(1)
@@ -113,12 +114,21 @@ func main() {
 	apiID := 22527852
 	apiHash := "4f595e6aac7dfe58a2cf6051360c3f14"
 	
+	// Login code / 2FA password provider (bot DM prompts need the unified binary)
+	authProvider, err := client.NewAuthProvider(cfg.ScraperAuthMode, nil)
+	if err != nil {
+		logger.Warn("Invalid scraper auth mode: %v (falling back to terminal)", err)
+		authProvider = client.TerminalAuthProvider{}
+	}
+	
 	telegramClient := client.NewClient(client.Config{
-		AppID:      apiID,
-		AppHash:    apiHash,
-		Phone:      phoneNumber,
-		SessionDir: ".",
-		Database:   database,
+		AppID:        apiID,
+		AppHash:      apiHash,
+		Phone:        phoneNumber,
+		SessionDir:   ".",
+		Database:     database,
+		AuthProvider: authProvider,
+		OCR:          ocr.NewFromEnv(),
 	})
 
 	logger.Info("\n═══════════════════════════════════════════════════════")
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"telegram-summarizer/internal/db"
+	"telegram-summarizer/internal/db/storetest"
+)
+
+var (
+	sqlitePath  = flag.String("sqlite", "", "SQLite database to check (default: temporary file)")
+	postgresURL = flag.String("postgres", os.Getenv("STORETEST_POSTGRES_URL"), "PostgreSQL connection URL to check (skipped if empty)")
+)
+
+// report collects the failures of one backend
+type report struct {
+	failures []string
+}
+
+// Errorf implements storetest.T
+func (r *report) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func main() {
+	flag.Parse()
+	
+	path := *sqlitePath
+	if path == "" {
+		dir, err := os.MkdirTemp("", "storecheck")
+		if err != nil {
+			fmt.Printf("❌ Failed to create temporary directory: %v\n", err)
+			os.Exit(1)
+		}
+		defer os.RemoveAll(dir)
+		path = filepath.Join(dir, "storecheck.db")
+	}
+	
+	backends := []struct {
+		driver string
+		source string
+	}{
+		{db.DriverSQLite, path},
+		{db.DriverPostgres, *postgresURL},
+	}
+	
+	failed := false
+	for _, backend := range backends {
+		if backend.source == "" {
+			fmt.Printf("⏭️  %s: skipped (no connection URL)\n", backend.driver)
+			continue
+		}
+		if !check(backend.driver, backend.source) {
+			failed = true
+		}
+	}
+	
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// check runs the store conformance suite against one backend
+func check(driver, source string) bool {
+	store, err := db.Open(driver, source)
+	if err != nil {
+		fmt.Printf("❌ %s: %v\n", driver, err)
+		return false
+	}
+	defer store.Close()
+	
+	ok := true
+	for _, c := range storetest.Cases() {
+		r := &report{}
+		c.Run(r, store)
+		if len(r.failures) == 0 {
+			fmt.Printf("✅ %s: %s\n", driver, c.Name)
+			continue
+		}
+		ok = false
+		fmt.Printf("❌ %s: %s\n", driver, c.Name)
+		for _, failure := range r.failures {
+			fmt.Printf("     %s\n", failure)
+		}
+	}
+	return ok
+}
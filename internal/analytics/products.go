@@ -0,0 +1,284 @@
+package analytics
+
+import (
+	"sort"
+	"strings"
+	"telegram-summarizer/internal/db"
+	"time"
+)
+
+// ProductStats aggregates the mentions of a product over a period
+type ProductStats struct {
+	ProductID   int64
+	Name        string
+	Mentions    int     // Sum of mention counts
+	Summaries   int     // Summaries mentioning the product
+	Groups      int     // Distinct groups mentioning the product
+	Credibility float64 // Average credibility score (1-5)
+	Sentiment   float64 // Average sentiment from -1 (negative) to 1 (positive)
+	Suspicious  int     // Mentions marked suspicious
+	RedFlags    int     // Red flags of the summaries mentioning the product
+}
+
+// SentimentValue maps a sentiment label to a score from -1 to 1
+func SentimentValue(sentiment string) float64 {
+	switch strings.ToLower(sentiment) {
+	case "positive":
+		return 1
+	case "negative":
+		return -1
+	}
+	return 0
+}
+
+// Aggregate computes the stats of each product in a set of mentions
+func Aggregate(activity []db.ProductActivity) map[int64]*ProductStats {
+	stats := make(map[int64]*ProductStats)
+	groups := make(map[int64]map[int64]bool)
+	
+	for _, a := range activity {
+		s := stats[a.ProductID]
+		if s == nil {
+			s = &ProductStats{ProductID: a.ProductID, Name: a.ProductTitle}
+			stats[a.ProductID] = s
+			groups[a.ProductID] = make(map[int64]bool)
+		}
+	
+		s.Mentions += max(a.MentionCount, 1)
+		s.Summaries++
+		s.Credibility += float64(a.CredibilityScore)
+		s.Sentiment += SentimentValue(a.Sentiment)
+		s.RedFlags += a.RedFlags
+		if a.ValidationStatus == "suspicious" {
+			s.Suspicious++
+		}
+		groups[a.ProductID][a.ChatID] = true
+	}
+	
+	for id, s := range stats {
+		s.Credibility /= float64(s.Summaries)
+		s.Sentiment /= float64(s.Summaries)
+		s.Groups = len(groups[id])
+	}
+	return stats
+}
+
+// ProductTrend is the stats of a product in a period compared to the period before
+type ProductTrend struct {
+	ProductStats
+	MentionsDelta    int
+	CredibilityDelta float64
+	SentimentDelta   float64
+	New              bool      // Not mentioned in the previous period
+	Daily            []float64 // Mentions per day of the period
+}
+
+// Trends ranks the products of a period of days starting at start by mentions
+// and compares them to the previous period
+func Trends(current, previous []db.ProductActivity, start time.Time, days int) []ProductTrend {
+	now := Aggregate(current)
+	before := Aggregate(previous)
+	
+	daily := make(map[int64]*Daily)
+	for _, a := range current {
+		if daily[a.ProductID] == nil {
+			daily[a.ProductID] = NewDaily(start, days)
+		}
+		daily[a.ProductID].Add(a.PeriodEnd, float64(max(a.MentionCount, 1)))
+	}
+	
+	trends := make([]ProductTrend, 0, len(now))
+	for id, s := range now {
+		trend := ProductTrend{ProductStats: *s, Daily: daily[id].Values()}
+		if prev, ok := before[id]; ok {
+			trend.MentionsDelta = s.Mentions - prev.Mentions
+			trend.CredibilityDelta = s.Credibility - prev.Credibility
+			trend.SentimentDelta = s.Sentiment - prev.Sentiment
+		} else {
+			trend.MentionsDelta = s.Mentions
+			trend.New = true
+		}
+		trends = append(trends, trend)
+	}
+	
+	sort.Slice(trends, func(i, j int) bool {
+		if trends[i].Mentions != trends[j].Mentions {
+			return trends[i].Mentions > trends[j].Mentions
+		}
+		return trends[i].Name < trends[j].Name
+	})
+	return trends
+}
+
+// Movers compares the products of a period to the period before: winners gained
+// mentions, losers lost mentions or were no longer mentioned. Each list is ordered by
+// the size of the change and holds at most limit products.
+func Movers(current, previous []db.ProductActivity, limit int) (winners, losers []ProductTrend) {
+	now := Aggregate(current)
+	before := Aggregate(previous)
+	
+	for id, s := range now {
+		trend := ProductTrend{ProductStats: *s, MentionsDelta: s.Mentions}
+		if prev, ok := before[id]; ok {
+			trend.MentionsDelta = s.Mentions - prev.Mentions
+			trend.CredibilityDelta = s.Credibility - prev.Credibility
+			trend.SentimentDelta = s.Sentiment - prev.Sentiment
+		} else {
+			trend.New = true
+		}
+		switch {
+		case trend.MentionsDelta > 0:
+			winners = append(winners, trend)
+		case trend.MentionsDelta < 0:
+			losers = append(losers, trend)
+		}
+	}
+	for id, prev := range before {
+		if _, ok := now[id]; !ok {
+			losers = append(losers, ProductTrend{
+				ProductStats:  ProductStats{ProductID: id, Name: prev.Name},
+				MentionsDelta: -prev.Mentions,
+			})
+		}
+	}
+	
+	byChange := func(trends []ProductTrend) {
+		sort.Slice(trends, func(i, j int) bool {
+			a, b := trends[i].MentionsDelta, trends[j].MentionsDelta
+			if a < 0 {
+				a, b = -a, -b
+			}
+			if a != b {
+				return a > b
+			}
+			return trends[i].Name < trends[j].Name
+		})
+	}
+	byChange(winners)
+	byChange(losers)
+	return winners[:min(len(winners), limit)], losers[:min(len(losers), limit)]
+}
+
+// CrossGroup returns the stats of the products mentioned in at least minGroups groups,
+// mentioned in the most groups first
+func CrossGroup(activity []db.ProductActivity, minGroups int) []ProductStats {
+	var products []ProductStats
+	for _, s := range Aggregate(activity) {
+		if s.Groups >= minGroups {
+			products = append(products, *s)
+		}
+	}
+	
+	sort.Slice(products, func(i, j int) bool {
+		if products[i].Groups != products[j].Groups {
+			return products[i].Groups > products[j].Groups
+		}
+		if products[i].Mentions != products[j].Mentions {
+			return products[i].Mentions > products[j].Mentions
+		}
+		return products[i].Name < products[j].Name
+	})
+	return products
+}
+
+// GroupMentions is the number of mentions of a product in a group
+type GroupMentions struct {
+	ChatID    int64
+	GroupName string
+	Mentions  int
+	LastSeen  time.Time
+}
+
+// ByGroup counts the mentions per group, most mentions first
+func ByGroup(activity []db.ProductActivity) []GroupMentions {
+	index := make(map[int64]*GroupMentions)
+	for _, a := range activity {
+		g := index[a.ChatID]
+		if g == nil {
+			g = &GroupMentions{ChatID: a.ChatID, GroupName: a.GroupName}
+			index[a.ChatID] = g
+		}
+		g.Mentions += max(a.MentionCount, 1)
+		if a.PeriodEnd.After(g.LastSeen) {
+			g.LastSeen = a.PeriodEnd
+		}
+	}
+	
+	groups := make([]GroupMentions, 0, len(index))
+	for _, g := range index {
+		groups = append(groups, *g)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Mentions != groups[j].Mentions {
+			return groups[i].Mentions > groups[j].Mentions
+		}
+		return groups[i].LastSeen.After(groups[j].LastSeen)
+	})
+	return groups
+}
+
+// CodeSighting is the last time a FamilyCode was mentioned with a product
+type CodeSighting struct {
+	Code      string
+	GroupName string
+	LastSeen  time.Time
+}
+
+// LatestFamilyCodes returns up to limit FamilyCodes mentioned with a product, most recent first
+func LatestFamilyCodes(activity []db.ProductActivity, limit int) []CodeSighting {
+	var codes []CodeSighting
+	seen := make(map[string]bool)
+	
+	// Activity is ordered oldest first
+	for i := len(activity) - 1; i >= 0 && len(codes) < limit; i-- {
+		for _, code := range strings.Split(activity[i].FamilyCodes, ",") {
+			if code == "" || seen[code] || len(codes) >= limit {
+				continue
+			}
+			seen[code] = true
+			codes = append(codes, CodeSighting{Code: code, GroupName: activity[i].GroupName, LastSeen: activity[i].PeriodEnd})
+		}
+	}
+	return codes
+}
+
+// RedFlags returns the mentions marked suspicious or in summaries with red flags, most recent first
+func RedFlags(activity []db.ProductActivity) []db.ProductActivity {
+	var flagged []db.ProductActivity
+	for i := len(activity) - 1; i >= 0; i-- {
+		if activity[i].ValidationStatus == "suspicious" || activity[i].RedFlags > 0 {
+			flagged = append(flagged, activity[i])
+		}
+	}
+	return flagged
+}
+
+// PriceStats summarizes the prices of a product
+type PriceStats struct {
+	Count  int
+	Min    int64
+	Max    int64
+	Median int64
+	Latest int64
+}
+
+// Prices computes the price range of a price history ordered oldest first
+func Prices(prices []db.ProductPrice) PriceStats {
+	if len(prices) == 0 {
+		return PriceStats{}
+	}
+	
+	values := make([]int64, len(prices))
+	for i, p := range prices {
+		values[i] = p.Price
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	
+	return PriceStats{
+		Count:  len(values),
+		Min:    values[0],
+		Max:    values[len(values)-1],
+		Median: values[len(values)/2],
+		Latest: prices[len(prices)-1].Price,
+	}
+}
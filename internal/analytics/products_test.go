@@ -0,0 +1,167 @@
+package analytics
+
+import (
+	"path/filepath"
+	"telegram-summarizer/internal/db"
+	"testing"
+	"time"
+)
+
+var day0 = time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+
+// mention returns the activity of a product mentioned in a summary of a group ending
+// on a day after day0
+func mention(productID, chatID int64, count, day int, sentiment string) db.ProductActivity {
+	return db.ProductActivity{
+		ProductMention: db.ProductMention{ProductID: productID, MentionCount: count, Sentiment: sentiment},
+		ProductTitle:   map[int64]string{1: "XL Akrab", 2: "Telkomsel Orbit", 3: "Indosat Freedom"}[productID],
+		ChatID:         chatID,
+		PeriodEnd:      day0.AddDate(0, 0, day).Add(12 * time.Hour),
+	}
+}
+
+func TestTrends(t *testing.T) {
+	current := []db.ProductActivity{
+		mention(1, 100, 2, 0, "positive"),
+		mention(1, 200, 3, 2, "negative"),
+		mention(2, 100, 0, 1, "neutral"),  // Counted as one mention
+		mention(3, 100, 1, 5, "positive"), // After the period's days
+	}
+	previous := []db.ProductActivity{
+		mention(1, 100, 4, -3, "positive"),
+		mention(2, 100, 2, -2, "negative"),
+	}
+	trends := Trends(current, previous, day0, 3)
+	
+	tests := []struct {
+		name      string
+		mentions  int
+		delta     int
+		groups    int
+		sentiment float64
+		new       bool
+		daily     []float64
+	}{
+		{"XL Akrab", 5, 1, 2, 0, false, []float64{2, 0, 3}},
+		{"Indosat Freedom", 1, 1, 1, 1, true, []float64{0, 0, 0}}, // Ties by name
+		{"Telkomsel Orbit", 1, -1, 1, 0, false, []float64{0, 1, 0}},
+	}
+	if len(trends) != len(tests) {
+		t.Fatalf("got %d trends, want %d", len(trends), len(tests))
+	}
+	for i, tt := range tests {
+		got := trends[i]
+		if got.Name != tt.name || got.Mentions != tt.mentions || got.MentionsDelta != tt.delta || got.Groups != tt.groups ||
+			got.Sentiment != tt.sentiment || got.New != tt.new {
+			t.Errorf("trend %d = %s: %d mentions (%+d) in %d groups, sentiment %v, new %v; want %s: %d (%+d) in %d, %v, %v",
+				i, got.Name, got.Mentions, got.MentionsDelta, got.Groups, got.Sentiment, got.New,
+				tt.name, tt.mentions, tt.delta, tt.groups, tt.sentiment, tt.new)
+		}
+		for d := range tt.daily {
+			if len(got.Daily) != len(tt.daily) || got.Daily[d] != tt.daily[d] {
+				t.Errorf("%s: daily mentions %v, want %v", tt.name, got.Daily, tt.daily)
+				break
+			}
+		}
+	}
+	
+	// Winners and losers of the same periods
+	winners, losers := Movers(current, previous, 5)
+	if len(winners) != 2 || winners[0].Name != "Indosat Freedom" || winners[1].Name != "XL Akrab" || !winners[0].New {
+		t.Errorf("got winners %+v, want Indosat Freedom (new), then XL Akrab", winners)
+	}
+	if len(losers) != 1 || losers[0].Name != "Telkomsel Orbit" || losers[0].MentionsDelta != -1 {
+		t.Errorf("got losers %+v, want Telkomsel Orbit (-1)", losers)
+	}
+}
+
+func TestMovers(t *testing.T) {
+	tests := []struct {
+		name              string
+		current, previous []db.ProductActivity
+		limit             int
+		winners, losers   []string
+	}{
+		{"no activity", nil, nil, 5, nil, nil},
+		{"unchanged", []db.ProductActivity{mention(1, 100, 2, 0, "")}, []db.ProductActivity{mention(1, 100, 2, -1, "")}, 5, nil, nil},
+		{"no longer mentioned", nil, []db.ProductActivity{mention(1, 100, 2, -1, ""), mention(2, 100, 3, -1, "")}, 5,
+			nil, []string{"Telkomsel Orbit", "XL Akrab"}},
+		{"largest change first", []db.ProductActivity{mention(1, 100, 2, 0, ""), mention(2, 100, 6, 0, ""), mention(3, 100, 1, 0, "")},
+			[]db.ProductActivity{mention(1, 100, 1, -1, ""), mention(3, 100, 4, -1, "")}, 5,
+			[]string{"Telkomsel Orbit", "XL Akrab"}, []string{"Indosat Freedom"}},
+		{"limited", []db.ProductActivity{mention(1, 100, 2, 0, ""), mention(2, 100, 6, 0, "")}, nil, 1,
+			[]string{"Telkomsel Orbit"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			winners, losers := Movers(tt.current, tt.previous, tt.limit)
+			if got := names(winners); !equal(got, tt.winners) {
+				t.Errorf("winners = %v, want %v", got, tt.winners)
+			}
+			if got := names(losers); !equal(got, tt.losers) {
+				t.Errorf("losers = %v, want %v", got, tt.losers)
+			}
+		})
+	}
+}
+
+// TestTrendsCountOneSummaryLevel checks that a mention repeated by the hourly, daily
+// and manual summaries of a day is counted once
+func TestTrendsCountOneSummaryLevel(t *testing.T) {
+	store, err := db.Open(db.DriverSQLite, filepath.Join(t.TempDir(), "analytics.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer store.Close()
+	
+	product, err := store.CreateProduct("XL Akrab", "akrab xl")
+	if err != nil {
+		t.Fatalf("CreateProduct: %v", err)
+	}
+	chatID := db.ChannelChatID(1234).Int64()
+	for _, level := range []struct {
+		summaryType string
+		start, end  time.Time
+	}{
+		{"1h", day0.Add(9 * time.Hour), day0.Add(10 * time.Hour)},
+		{"daily", day0, day0.AddDate(0, 0, 1)},
+		{"manual-24h", day0.Add(8 * time.Hour), day0.Add(32 * time.Hour)},
+	} {
+		summary := &db.Summary{ChatID: chatID, SummaryType: level.summaryType, PeriodStart: level.start, PeriodEnd: level.end}
+		mentions := []db.ProductMention{{ProductName: "XL Akrab", MentionCount: 2, ProductID: product.ID}}
+		if err := store.SaveSummaryWithProducts(summary, mentions); err != nil {
+			t.Fatalf("SaveSummaryWithProducts: %v", err)
+		}
+	}
+	
+	activity, err := store.GetProductActivity(0, "daily", day0, day0.AddDate(0, 0, 2))
+	if err != nil {
+		t.Fatalf("GetProductActivity: %v", err)
+	}
+	trends := Trends(activity, nil, day0, 2)
+	if len(trends) != 1 || trends[0].Mentions != 2 || trends[0].Summaries != 1 {
+		t.Errorf("got trends %+v, want XL Akrab with 2 mentions in 1 summary", trends)
+	}
+}
+
+// names returns the product names of trends
+func names(trends []ProductTrend) []string {
+	var names []string
+	for _, t := range trends {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+// equal reports whether two lists of names are equal
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,64 @@
+package analytics
+
+import (
+	"strings"
+	"time"
+)
+
+// sparkBlocks are the bar heights of a sparkline, lowest first
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders values as a line of block characters scaled between the
+// smallest and largest value, e.g. "▁▃▅█▂"
+func Sparkline(values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+	
+	low, high := values[0], values[0]
+	for _, v := range values {
+		low = min(low, v)
+		high = max(high, v)
+	}
+	
+	var b strings.Builder
+	for _, v := range values {
+		level := 0
+		if high > low {
+			level = int((v - low) / (high - low) * float64(len(sparkBlocks)-1))
+		} else if high > 0 {
+			level = len(sparkBlocks) / 2
+		}
+		b.WriteRune(sparkBlocks[level])
+	}
+	return b.String()
+}
+
+// Daily accumulates values into one bucket per day starting at a day
+type Daily struct {
+	start  time.Time
+	values []float64
+}
+
+// NewDaily creates daily buckets for days days starting at the day of start
+func NewDaily(start time.Time, days int) *Daily {
+	y, m, d := start.Date()
+	return &Daily{
+		start:  time.Date(y, m, d, 0, 0, 0, 0, start.Location()),
+		values: make([]float64, max(days, 0)),
+	}
+}
+
+// Add adds a value to the bucket of a time; times outside the buckets are ignored
+func (d *Daily) Add(t time.Time, value float64) {
+	day := int(t.In(d.start.Location()).Sub(d.start).Hours() / 24)
+	if t.Before(d.start) || day >= len(d.values) {
+		return
+	}
+	d.values[day] += value
+}
+
+// Values returns the bucket values, oldest day first
+func (d *Daily) Values() []float64 {
+	return d.values
+}
@@ -0,0 +1,48 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		values []float64
+		want   string
+	}{
+		{nil, ""},
+		{[]float64{0, 7}, "▁█"},
+		{[]float64{1, 2, 3, 4, 5, 6, 7, 8}, "▁▂▃▄▅▆▇█"},
+		{[]float64{-1, 0, 1}, "▁▄█"},
+		{[]float64{3, 3, 3}, "▅▅▅"}, // Flat but not empty
+		{[]float64{0, 0}, "▁▁"},
+	}
+	for _, tt := range tests {
+		if got := Sparkline(tt.values); got != tt.want {
+			t.Errorf("Sparkline(%v) = %q, want %q", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestDaily(t *testing.T) {
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+	start := time.Date(2026, 1, 5, 15, 0, 0, 0, jakarta)
+	daily := NewDaily(start, 3)
+	
+	for _, at := range []time.Time{
+		time.Date(2026, 1, 5, 0, 30, 0, 0, jakarta),   // The first day, before start
+		time.Date(2026, 1, 5, 23, 0, 0, 0, jakarta),   // The first day
+		time.Date(2026, 1, 6, 17, 30, 0, 0, time.UTC), // The third day in Jakarta
+		time.Date(2026, 1, 4, 23, 0, 0, 0, jakarta),   // Before the first day
+		time.Date(2026, 1, 8, 0, 0, 0, 0, jakarta),    // After the last day
+	} {
+		daily.Add(at, 1)
+	}
+	if got := daily.Values(); len(got) != 3 || got[0] != 2 || got[1] != 0 || got[2] != 1 {
+		t.Errorf("Values() = %v, want [2 0 1]", got)
+	}
+}
@@ -0,0 +1,190 @@
+package archive
+
+import (
+	"fmt"
+	"telegram-summarizer/internal/db"
+	"telegram-summarizer/internal/logger"
+	"time"
+)
+
+// Archive modes
+const (
+	ModeNone  = "none"  // Delete expired messages without archiving
+	ModeJSONL = "jsonl" // Gzip-compressed JSONL files, one per group and day
+	ModeDB    = "db"    // Separate SQLite archive database
+)
+
+// KeepForever is the retention days value that disables deletion for a group
+const KeepForever = -1
+
+// Archiver stores expired messages and reads them back for restoring
+type Archiver interface {
+	// Archive stores messages of a group
+	Archive(chatID int64, messages []db.Message) error
+	
+	// Load returns archived messages of a group within a time range
+	Load(chatID int64, startTime, endTime time.Time) ([]db.Message, error)
+	
+	// GetName returns the archive mode of the archiver
+	GetName() string
+}
+
+// Config holds the default retention policy and archive locations
+type Config struct {
+	RetentionDays int    // Days of messages to keep (default: 1)
+	Mode          string // Default archive mode (default: jsonl)
+	Dir           string // Directory of JSONL archives (default: message_archive)
+	DBPath        string // Path of the archive database (default: archive.db)
+}
+
+// Policy is the effective retention policy of a group
+type Policy struct {
+	RetentionDays int
+	Mode          string
+}
+
+// Result reports what a retention run did for a group
+type Result struct {
+	Archived int
+	Deleted  int64
+}
+
+// Service applies retention policies and restores archived messages
+type Service struct {
+	database  db.Store
+	defaults  Policy
+	archivers map[string]Archiver
+	dbArchive *DBArchiver
+}
+
+// NewService creates a retention service
+func NewService(database db.Store, cfg Config) *Service {
+	if cfg.RetentionDays == 0 {
+		cfg.RetentionDays = 1
+	}
+	if cfg.Mode == "" {
+		cfg.Mode = ModeJSONL
+	}
+	if cfg.Dir == "" {
+		cfg.Dir = "message_archive"
+	}
+	if cfg.DBPath == "" {
+		cfg.DBPath = "archive.db"
+	}
+	if err := ValidateMode(cfg.Mode); err != nil {
+		logger.Warn("Invalid archive mode %q, using %s: %v", cfg.Mode, ModeJSONL, err)
+		cfg.Mode = ModeJSONL
+	}
+	
+	dbArchive := NewDBArchiver(cfg.DBPath)
+	return &Service{
+		database: database,
+		defaults: Policy{RetentionDays: cfg.RetentionDays, Mode: cfg.Mode},
+		archivers: map[string]Archiver{
+			ModeJSONL: NewJSONLArchiver(cfg.Dir),
+			ModeDB:    dbArchive,
+		},
+		dbArchive: dbArchive,
+	}
+}
+
+// Close closes the archive database
+func (s *Service) Close() error {
+	return s.dbArchive.Close()
+}
+
+// ValidateMode checks an archive mode name
+func ValidateMode(mode string) error {
+	switch mode {
+	case ModeNone, ModeJSONL, ModeDB:
+		return nil
+	}
+	return fmt.Errorf("unknown archive mode %q (use %s, %s or %s)", mode, ModeNone, ModeJSONL, ModeDB)
+}
+
+// Defaults returns the policy of groups without their own settings
+func (s *Service) Defaults() Policy {
+	return s.defaults
+}
+
+// PolicyFor returns the effective policy of a group
+func (s *Service) PolicyFor(group db.TrackedGroup) Policy {
+	policy := s.defaults
+	if group.RetentionDays != 0 {
+		policy.RetentionDays = group.RetentionDays
+	}
+	if group.ArchiveMode != "" {
+		policy.Mode = group.ArchiveMode
+	}
+	return policy
+}
+
+// Apply archives (depending on the policy) and deletes the expired messages of a group.
+// Nothing is deleted if archiving fails.
+func (s *Service) Apply(group db.TrackedGroup, now time.Time) (Result, error) {
+	var result Result
+	
+	policy := s.PolicyFor(group)
+	if policy.RetentionDays < 0 {
+		logger.Debug("Retention: keeping all messages of %s", group.GroupName)
+		return result, nil
+	}
+	
+	cutoff := now.Add(-time.Duration(policy.RetentionDays) * 24 * time.Hour)
+	
+	if policy.Mode != ModeNone {
+		archiver, ok := s.archivers[policy.Mode]
+		if !ok {
+			return result, fmt.Errorf("unknown archive mode %q", policy.Mode)
+		}
+	
+		messages, err := s.database.GetMessagesOlderThan(group.ChatID, cutoff)
+		if err != nil {
+			return result, err
+		}
+	
+		if len(messages) > 0 {
+			if err := archiver.Archive(group.ChatID, messages); err != nil {
+				return result, fmt.Errorf("failed to archive messages: %w", err)
+			}
+			result.Archived = len(messages)
+			logger.Info("📦 Archived %d messages of %s (%s)", len(messages), group.GroupName, archiver.GetName())
+		}
+	}
+	
+	deleted, err := s.database.DeleteMessagesOlderThan(group.ChatID, cutoff)
+	if err != nil {
+		return result, err
+	}
+	result.Deleted = deleted
+	
+	return result, nil
+}
+
+// Restore loads archived messages of a time range from all archives back into the
+// messages table, so the window can be summarized again. Returns the number restored.
+// Restored messages are removed again (without re-archiving) on the next retention run.
+func (s *Service) Restore(chatID int64, startTime, endTime time.Time) (int, error) {
+	restored := 0
+	
+	for _, mode := range []string{ModeJSONL, ModeDB} {
+		messages, err := s.archivers[mode].Load(chatID, startTime, endTime)
+		if err != nil {
+			return restored, fmt.Errorf("failed to load %s archive: %w", mode, err)
+		}
+	
+		for i := range messages {
+			ok, err := s.database.RestoreMessage(&messages[i])
+			if err != nil {
+				return restored, err
+			}
+			if ok {
+				restored++
+			}
+		}
+	}
+	
+	logger.Info("♻️  Restored %d archived messages for chat %d (%s - %s)", restored, chatID,
+		startTime.Format("2006-01-02 15:04"), endTime.Format("2006-01-02 15:04"))
+	return restored, nil
+}
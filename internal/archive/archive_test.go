@@ -0,0 +1,146 @@
+package archive
+
+import (
+	"path/filepath"
+	"telegram-summarizer/internal/db"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+// newTestStore opens a temporary store with messages of a group 3 days, 2 days and
+// 1 hour before now
+func newTestStore(t *testing.T, chatID int64, now time.Time) db.Store {
+	t.Helper()
+	store, err := db.Open(db.DriverSQLite, filepath.Join(t.TempDir(), "messages.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	
+	for i, age := range []time.Duration{72 * time.Hour, 48 * time.Hour, time.Hour} {
+		text := "message " + age.String()
+		msg := &db.Message{ChatID: chatID, MessageID: int64(i + 1), UserID: 42, Username: "tester",
+			MessageText: text, MessageLength: len(text), Timestamp: now.Add(-age)}
+		if _, err := store.SaveMessage(msg); err != nil {
+			t.Fatalf("SaveMessage: %v", err)
+		}
+	}
+	return store
+}
+
+func TestApplyAndRestore(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	chatID := db.ChannelChatID(1234).Int64()
+	
+	tests := []struct {
+		mode     string
+		archived int
+	}{
+		{ModeJSONL, 2},
+		{ModeDB, 2},
+		{ModeNone, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			store := newTestStore(t, chatID, now)
+			dir := t.TempDir()
+			service := NewService(store, Config{Dir: filepath.Join(dir, "archive"), DBPath: filepath.Join(dir, "archive.db")})
+			defer service.Close()
+			group := db.TrackedGroup{ChatID: chatID, GroupName: "Test", RetentionDays: 1, ArchiveMode: tt.mode}
+			
+			result, err := service.Apply(group, now)
+			if err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			if result.Archived != tt.archived || result.Deleted != 2 {
+				t.Errorf("Apply = %+v, want %d archived and 2 deleted", result, tt.archived)
+			}
+			
+			restored, err := service.Restore(chatID, now.Add(-96*time.Hour), now)
+			if err != nil {
+				t.Fatalf("Restore: %v", err)
+			}
+			if restored != tt.archived {
+				t.Errorf("Restore = %d, want %d", restored, tt.archived)
+			}
+			messages, _ := store.GetMessagesByTimeRange(chatID, now.Add(-96*time.Hour), now)
+			if len(messages) != 1+tt.archived {
+				t.Fatalf("got %d messages after restoring, want %d", len(messages), 1+tt.archived)
+			}
+			if tt.archived > 0 && (!messages[0].Restored || messages[0].MessageText != "message 72h0m0s" ||
+				!messages[0].Timestamp.Equal(now.Add(-72*time.Hour))) {
+				t.Errorf("got restored message %+v, want the oldest message", messages[0])
+			}
+			
+			// Restoring twice adds nothing, and restored messages are deleted without
+			// being archived again
+			if restored, err := service.Restore(chatID, now.Add(-96*time.Hour), now); err != nil || restored != 0 {
+				t.Errorf("second Restore = %d (err=%v), want 0", restored, err)
+			}
+			result, err = service.Apply(group, now)
+			if err != nil || result.Archived != 0 || result.Deleted != int64(tt.archived) {
+				t.Errorf("second Apply = %+v (err=%v), want 0 archived and %d deleted", result, err, tt.archived)
+			}
+		})
+	}
+}
+
+func TestApplyPolicies(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	chatID := db.ChannelChatID(1234).Int64()
+	
+	tests := []struct {
+		name    string
+		group   db.TrackedGroup
+		deleted int64
+	}{
+		{"default retention", db.TrackedGroup{}, 2},
+		{"longer retention", db.TrackedGroup{RetentionDays: 3}, 0},
+		{"kept forever", db.TrackedGroup{RetentionDays: KeepForever}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestStore(t, chatID, now)
+			service := NewService(store, Config{Mode: ModeNone, DBPath: filepath.Join(t.TempDir(), "archive.db")})
+			defer service.Close()
+			
+			tt.group.ChatID = chatID
+			result, err := service.Apply(tt.group, now)
+			if err != nil || result.Deleted != tt.deleted {
+				t.Errorf("Apply = %+v (err=%v), want %d deleted", result, err, tt.deleted)
+			}
+		})
+	}
+}
+
+func TestJSONLLoadInOtherTimezone(t *testing.T) {
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+	archiver := NewJSONLArchiver(t.TempDir())
+	
+	// 2026-01-05 20:00 UTC is 2026-01-06 03:00 in Jakarta
+	at := time.Date(2026, 1, 5, 20, 0, 0, 0, time.UTC)
+	messages := []db.Message{{ChatID: 1, UserID: 42, MessageText: "late", Timestamp: at}}
+	if err := archiver.Archive(1, messages); err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+	
+	tests := []struct {
+		name       string
+		start, end time.Time
+		want       int
+	}{
+		{"UTC day", time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), time.Date(2026, 1, 6, 0, 0, 0, 0, time.UTC), 1},
+		{"Jakarta day", time.Date(2026, 1, 6, 0, 0, 0, 0, jakarta), time.Date(2026, 1, 7, 0, 0, 0, 0, jakarta), 1},
+		{"Jakarta day before", time.Date(2026, 1, 5, 0, 0, 0, 0, jakarta), time.Date(2026, 1, 6, 0, 0, 0, 0, jakarta), 0},
+	}
+	for _, tt := range tests {
+		loaded, err := archiver.Load(1, tt.start, tt.end)
+		if err != nil || len(loaded) != tt.want {
+			t.Errorf("%s: Load = %d messages (err=%v), want %d", tt.name, len(loaded), err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package archive
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"telegram-summarizer/internal/db"
+	"time"
+)
+
+// DBArchiver moves messages to a separate SQLite database with the same schema.
+// The database is only created when the first message is archived.
+type DBArchiver struct {
+	path string
+	
+	mu       sync.Mutex
+	database *db.DB
+}
+
+// NewDBArchiver creates an archiver for a database file
+func NewDBArchiver(path string) *DBArchiver {
+	return &DBArchiver{path: path}
+}
+
+// GetName implements Archiver
+func (a *DBArchiver) GetName() string {
+	return ModeDB
+}
+
+// open opens the archive database; if create is false a missing file is not created
+func (a *DBArchiver) open(create bool) (*db.DB, error) {
+	if a.database != nil {
+		return a.database, nil
+	}
+	
+	if !create {
+		if _, err := os.Stat(a.path); os.IsNotExist(err) {
+			return nil, nil
+		}
+	}
+	
+	database, err := db.InitDB(a.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive database: %w", err)
+	}
+	a.database = database
+	return database, nil
+}
+
+// Archive implements Archiver
+func (a *DBArchiver) Archive(chatID int64, messages []db.Message) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	
+	database, err := a.open(true)
+	if err != nil {
+		return err
+	}
+	
+	// RestoreMessage skips messages already stored by an interrupted earlier run
+	for i := range messages {
+		msg := messages[i]
+		if _, err := database.RestoreMessage(&msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Load implements Archiver
+func (a *DBArchiver) Load(chatID int64, startTime, endTime time.Time) ([]db.Message, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	
+	database, err := a.open(false)
+	if err != nil || database == nil {
+		return nil, err
+	}
+	
+	return database.GetMessagesByTimeRange(chatID, startTime, endTime)
+}
+
+// Close closes the archive database if it was opened
+func (a *DBArchiver) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	
+	if a.database == nil {
+		return nil
+	}
+	err := a.database.Close()
+	a.database = nil
+	return err
+}
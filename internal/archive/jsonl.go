@@ -0,0 +1,181 @@
+package archive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"telegram-summarizer/internal/db"
+	"time"
+)
+
+// JSONLArchiver writes messages to <dir>/<chat_id>/<YYYY-MM-DD>.jsonl.gz, by UTC date.
+// Each archive run appends a new gzip member, which readers decode as one stream.
+type JSONLArchiver struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// archivedMessage is the JSONL record of a message
+type archivedMessage struct {
+	ChatID      int64     `json:"chat_id"`
+	UserID      int64     `json:"user_id"`
+	Username    string    `json:"username"`
+	Text        string    `json:"text"`
+	Timestamp   time.Time `json:"timestamp"`
+	MediaType   string    `json:"media_type,omitempty"`
+	MediaFileID string    `json:"media_file_id,omitempty"`
+	MediaSize   int64     `json:"media_size,omitempty"`
+	OCRText     string    `json:"ocr_text,omitempty"`
+}
+
+// NewJSONLArchiver creates a JSONL archiver in a directory
+func NewJSONLArchiver(dir string) *JSONLArchiver {
+	return &JSONLArchiver{dir: dir}
+}
+
+// GetName implements Archiver
+func (a *JSONLArchiver) GetName() string {
+	return ModeJSONL
+}
+
+// path returns the archive file of a group and the UTC date of a time
+func (a *JSONLArchiver) path(chatID int64, t time.Time) string {
+	return filepath.Join(a.dir, fmt.Sprintf("%d", chatID), t.UTC().Format("2006-01-02")+".jsonl.gz")
+}
+
+// Archive implements Archiver
+func (a *JSONLArchiver) Archive(chatID int64, messages []db.Message) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	
+	// Group by day so each file covers one date
+	byDay := make(map[string][]db.Message)
+	var days []string
+	for _, msg := range messages {
+		day := msg.Timestamp.UTC().Format("2006-01-02")
+		if _, ok := byDay[day]; !ok {
+			days = append(days, day)
+		}
+		byDay[day] = append(byDay[day], msg)
+	}
+	
+	for _, day := range days {
+		if err := a.appendDay(chatID, byDay[day]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendDay appends messages of one day as a new gzip member
+func (a *JSONLArchiver) appendDay(chatID int64, messages []db.Message) error {
+	path := a.path(chatID, messages[0].Timestamp)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open archive file: %w", err)
+	}
+	defer file.Close()
+	
+	gz := gzip.NewWriter(file)
+	encoder := json.NewEncoder(gz)
+	for _, msg := range messages {
+		record := archivedMessage{
+			ChatID:      msg.ChatID,
+			UserID:      msg.UserID,
+			Username:    msg.Username,
+			Text:        msg.MessageText,
+			Timestamp:   msg.Timestamp,
+			MediaType:   msg.MediaType,
+			MediaFileID: msg.MediaFileID,
+			MediaSize:   msg.MediaSize,
+			OCRText:     msg.OCRText,
+		}
+		if err := encoder.Encode(record); err != nil {
+			gz.Close()
+			return fmt.Errorf("failed to write archive record: %w", err)
+		}
+	}
+	
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive file: %w", err)
+	}
+	return file.Sync()
+}
+
+// Load implements Archiver
+func (a *JSONLArchiver) Load(chatID int64, startTime, endTime time.Time) ([]db.Message, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	
+	var messages []db.Message
+	
+	// Files are per UTC date, whatever the timezone of the range
+	start := startTime.UTC()
+	startDay := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	for day := startDay; !day.After(endTime); day = day.AddDate(0, 0, 1) {
+		dayMessages, err := a.readDay(a.path(chatID, day), startTime, endTime)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, dayMessages...)
+	}
+	
+	return messages, nil
+}
+
+// readDay reads the messages of an archive file within a time range
+func (a *JSONLArchiver) readDay(path string, startTime, endTime time.Time) ([]db.Message, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive file: %w", err)
+	}
+	defer file.Close()
+	
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive file %s: %w", path, err)
+	}
+	defer gz.Close()
+	
+	var messages []db.Message
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var record archivedMessage
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("invalid archive record in %s: %w", path, err)
+		}
+		if record.Timestamp.Before(startTime) || record.Timestamp.After(endTime) {
+			continue
+		}
+	
+		messages = append(messages, db.Message{
+			ChatID:        record.ChatID,
+			UserID:        record.UserID,
+			Username:      record.Username,
+			MessageText:   record.Text,
+			MessageLength: len(record.Text),
+			Timestamp:     record.Timestamp,
+			MediaType:     record.MediaType,
+			MediaFileID:   record.MediaFileID,
+			MediaSize:     record.MediaSize,
+			OCRText:       record.OCRText,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read archive file %s: %w", path, err)
+	}
+	
+	return messages, nil
+}
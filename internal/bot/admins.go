@@ -0,0 +1,111 @@
+package bot
+
+import (
+	"sync"
+	"telegram-summarizer/internal/logger"
+	"time"
+	
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// adminCacheTTL is how long the administrator list of a chat is cached
+const adminCacheTTL = 1 * time.Hour
+
+// adminCache caches the administrators of chats
+type adminCache struct {
+	mu       sync.Mutex
+	chats    map[int64]cachedAdmins
+	fetching map[int64]chan struct{} // Closed when the list of a chat being fetched is cached
+}
+
+// cachedAdmins is the administrator list of a chat and when it was fetched
+type cachedAdmins struct {
+	admins    map[int64]bool
+	fetchedAt time.Time
+}
+
+// IsChatAdmin reports whether a user is an administrator of a group.
+// known is false if the list could not be fetched (e.g. the bot lacks access).
+func (b *Bot) IsChatAdmin(chatID, userID int64) (isAdmin bool, known bool) {
+	cached := b.chatAdmins(chatID)
+	if cached.admins == nil {
+		return false, false
+	}
+	return cached.admins[userID], true
+}
+
+// chatAdmins returns the cached administrator list of a chat, fetching it when it
+// expired. The list is fetched without holding the cache lock, once per chat at a time.
+func (b *Bot) chatAdmins(chatID int64) cachedAdmins {
+	for {
+		b.admins.mu.Lock()
+		if b.admins.chats == nil {
+			b.admins.chats = make(map[int64]cachedAdmins)
+			b.admins.fetching = make(map[int64]chan struct{})
+		}
+		
+		cached, ok := b.admins.chats[chatID]
+		if ok && time.Since(cached.fetchedAt) <= adminCacheTTL {
+			b.admins.mu.Unlock()
+			return cached
+		}
+		if fetched, busy := b.admins.fetching[chatID]; busy {
+			// Another message of the chat is fetching the list already
+			b.admins.mu.Unlock()
+			<-fetched
+			continue
+		}
+		fetched := make(chan struct{})
+		b.admins.fetching[chatID] = fetched
+		b.admins.mu.Unlock()
+		
+		cached = b.fetchAdmins(chatID)
+		
+		b.admins.mu.Lock()
+		b.admins.chats[chatID] = cached
+		delete(b.admins.fetching, chatID)
+		close(fetched)
+		b.admins.mu.Unlock()
+		return cached
+	}
+}
+
+// fetchAdmins gets the administrator list of a chat. A failed list has no admins and is
+// cached too, so fetching is not retried on every message.
+func (b *Bot) fetchAdmins(chatID int64) cachedAdmins {
+	members, err := b.api.GetChatAdministrators(tgbotapi.ChatAdministratorsConfig{
+		ChatConfig: tgbotapi.ChatConfig{ChatID: chatID},
+	})
+	if err != nil {
+		logger.Debug("Failed to get administrators of chat %d: %v", chatID, err)
+		return cachedAdmins{fetchedAt: time.Now()}
+	}
+	
+	cached := cachedAdmins{admins: make(map[int64]bool), fetchedAt: time.Now()}
+	for _, member := range members {
+		if member.User != nil {
+			cached.admins[member.User.ID] = true
+		}
+	}
+	return cached
+}
+
+// IsChatMember reports whether a user is a member or administrator of a group.
+// known is false if the membership could not be checked (e.g. the bot is not in the group).
+func (b *Bot) IsChatMember(chatID, userID int64) (isMember bool, known bool) {
+	member, err := b.api.GetChatMember(tgbotapi.GetChatMemberConfig{
+		ChatConfigWithUser: tgbotapi.ChatConfigWithUser{ChatID: chatID, UserID: userID},
+	})
+	if err != nil {
+		logger.Debug("Failed to get member %d of chat %d: %v", userID, chatID, err)
+		return false, false
+	}
+	
+	switch member.Status {
+	case "creator", "administrator", "member":
+		return true, true
+	case "restricted":
+		return member.IsMember, true
+	}
+	return false, true
+}
@@ -0,0 +1,86 @@
+package bot
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestAdminListFetchedOutsideLock(t *testing.T) {
+	const slowChat, fastChat = -1001, -1002
+	
+	// Bot API holding the administrators of the slow chat until released
+	release := make(chan struct{})
+	var slowFetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/getMe"):
+			fmt.Fprint(w, `{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"Bot","username":"test_bot"}}`)
+		case strings.HasSuffix(r.URL.Path, "/getChatAdministrators"):
+			r.ParseForm()
+			if r.Form.Get("chat_id") == fmt.Sprint(slowChat) {
+				slowFetches.Add(1)
+				<-release
+			}
+			fmt.Fprint(w, `{"ok":true,"result":[{"status":"creator","user":{"id":7,"is_bot":false,"first_name":"Admin"}}]}`)
+		default:
+			fmt.Fprint(w, `{"ok":true,"result":true}`)
+		}
+	}))
+	defer server.Close()
+	
+	api, err := tgbotapi.NewBotAPIWithClient("token", server.URL+"/bot%s/%s", server.Client())
+	if err != nil {
+		t.Fatalf("NewBotAPIWithClient: %v", err)
+	}
+	b := &Bot{api: api, stopCh: make(chan struct{})}
+	
+	// Several messages of the slow chat wait for one fetch
+	var wg sync.WaitGroup
+	results := make(chan bool, 3)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			isAdmin, known := b.IsChatAdmin(slowChat, 7)
+			results <- isAdmin && known
+		}()
+	}
+	for slowFetches.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	
+	// Meanwhile other chats are not blocked
+	checked := make(chan bool, 1)
+	go func() {
+		isAdmin, known := b.IsChatAdmin(fastChat, 7)
+		checked <- isAdmin && known
+	}()
+	select {
+	case ok := <-checked:
+		if !ok {
+			t.Error("admin of the other chat not recognized")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("admin check of another chat waited for the slow chat")
+	}
+	
+	close(release)
+	wg.Wait()
+	close(results)
+	for ok := range results {
+		if !ok {
+			t.Error("admin of the slow chat not recognized")
+		}
+	}
+	if n := slowFetches.Load(); n != 1 {
+		t.Errorf("administrators of the slow chat fetched %d times, want once", n)
+	}
+}
@@ -0,0 +1,117 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"telegram-summarizer/internal/logger"
+	
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// AuthPrompter asks the admin for scraper login input via bot DM and waits for the reply.
+// It can be created before the bot and is attached with Bot.SetAuthPrompter, so the
+// scraper may start prompting while the bot is still connecting.
+type AuthPrompter struct {
+	adminChatID int64
+	
+	mu       sync.Mutex
+	api      *tgbotapi.BotAPI
+	ready    chan struct{}
+	replies  chan string
+	promptID int // Message ID of the pending prompt (0 if none)
+}
+
+// NewAuthPrompter creates a prompter that sends questions to the admin chat
+func NewAuthPrompter(adminChatID int64) *AuthPrompter {
+	return &AuthPrompter{
+		adminChatID: adminChatID,
+		ready:       make(chan struct{}),
+		replies:     make(chan string, 1),
+	}
+}
+
+// attach connects the prompter to the bot API
+func (p *AuthPrompter) attach(api *tgbotapi.BotAPI) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	
+	if p.api == nil {
+		p.api = api
+		close(p.ready)
+	}
+}
+
+// Prompt sends a question to the admin and blocks until they reply or ctx is done
+func (p *AuthPrompter) Prompt(ctx context.Context, question string) (string, error) {
+	// Wait for the bot to be attached
+	select {
+	case <-p.ready:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	
+	msg := tgbotapi.NewMessage(p.adminChatID, question)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.ForceReply{ForceReply: true, Selective: true}
+	
+	sent, err := p.api.Send(msg)
+	if err != nil {
+		return "", fmt.Errorf("failed to send auth prompt: %w", err)
+	}
+	
+	p.mu.Lock()
+	p.promptID = sent.MessageID
+	p.mu.Unlock()
+	
+	defer func() {
+		p.mu.Lock()
+		p.promptID = 0
+		p.mu.Unlock()
+	}()
+	
+	logger.Info("📨 Auth prompt sent to admin (ChatID=%d), waiting for reply...", p.adminChatID)
+	
+	select {
+	case reply := <-p.replies:
+		return reply, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// handleReply consumes the admin's reply to a pending prompt.
+// Returns true if the message was an answer and must not be processed further.
+func (p *AuthPrompter) handleReply(message *tgbotapi.Message) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	
+	if p.promptID == 0 || message.Chat.ID != p.adminChatID || message.IsCommand() {
+		return false
+	}
+	
+	// Accept a direct reply to the prompt or any plain message in the admin DM
+	if message.ReplyToMessage != nil && message.ReplyToMessage.MessageID != p.promptID {
+		return false
+	}
+	
+	queued := true
+	select {
+	case p.replies <- message.Text:
+	default:
+		// An answer is already queued; a second one may be a code as well
+		queued = false
+	}
+	
+	// Don't leave codes and passwords in the chat history
+	if _, err := p.api.Request(tgbotapi.NewDeleteMessage(message.Chat.ID, message.MessageID)); err != nil {
+		logger.Warn("Failed to delete auth reply: %v", err)
+	}
+	
+	if queued {
+		logger.Info("✅ Auth reply received from admin")
+	} else {
+		logger.Warn("⚠️  Auth reply ignored, an answer is already pending")
+	}
+	return true
+}
@@ -0,0 +1,116 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"telegram-summarizer/internal/logger"
+	"testing"
+	"time"
+	
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// syncBuffer is a buffer written by several goroutines
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestAuthReplyIsNeverLogged(t *testing.T) {
+	const adminChatID = 777
+	const code = "52719"
+	
+	// Bot API answering the prompt with the code once it is pending
+	prompter := NewAuthPrompter(adminChatID)
+	pending := func() bool {
+		prompter.mu.Lock()
+		defer prompter.mu.Unlock()
+		return prompter.promptID != 0
+	}
+	var deleted atomic.Bool
+	var served atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/getMe"):
+			fmt.Fprint(w, `{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"Bot","username":"test_bot"}}`)
+		case strings.HasSuffix(r.URL.Path, "/sendMessage"):
+			fmt.Fprintf(w, `{"ok":true,"result":{"message_id":10,"date":1700000000,"chat":{"id":%d,"type":"private"}}}`, adminChatID)
+		case strings.HasSuffix(r.URL.Path, "/deleteMessage"):
+			deleted.Store(true)
+			fmt.Fprint(w, `{"ok":true,"result":true}`)
+		case strings.HasSuffix(r.URL.Path, "/getUpdates"):
+			if pending() && served.Add(1) == 1 {
+				fmt.Fprintf(w, `{"ok":true,"result":[{"update_id":1,"message":{"message_id":11,"date":1700000000,`+
+					`"chat":{"id":%d,"type":"private"},"from":{"id":%[1]d,"is_bot":false,"first_name":"Admin"},"text":%q}}]}`, adminChatID, code)
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+			fmt.Fprint(w, `{"ok":true,"result":[]}`)
+		default:
+			fmt.Fprint(w, `{"ok":true,"result":true}`)
+		}
+	}))
+	defer server.Close()
+	
+	// Everything logged by the bot and the API goes to one buffer, in debug mode
+	var logs syncBuffer
+	logger.Init(true)
+	logger.SetOutput(&logs)
+	tgbotapi.SetLogger(log.New(&logs, "", 0))
+	t.Cleanup(func() {
+		logger.SetOutput(os.Stdout)
+		tgbotapi.SetLogger(log.New(os.Stderr, "", log.LstdFlags))
+	})
+	
+	api, err := tgbotapi.NewBotAPIWithClient("token", server.URL+"/bot%s/%s", server.Client())
+	if err != nil {
+		t.Fatalf("NewBotAPIWithClient: %v", err)
+	}
+	api.Debug = true
+	b := &Bot{api: api, stopCh: make(chan struct{})}
+	b.SetAuthPrompter(prompter)
+	
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	polled := make(chan struct{})
+	go func() {
+		defer close(polled)
+		b.Poll(ctx)
+	}()
+	
+	reply, err := prompter.Prompt(ctx, "Enter the login code")
+	cancel()
+	<-polled
+	if err != nil {
+		t.Fatalf("Prompt: %v", err)
+	}
+	if reply != code {
+		t.Errorf("Prompt returned %q, want %q", reply, code)
+	}
+	if !deleted.Load() {
+		t.Errorf("auth reply was not deleted from the chat")
+	}
+	if strings.Contains(logs.String(), code) {
+		t.Errorf("auth reply was logged:\n%s", logs.String())
+	}
+}
@@ -1,12 +1,16 @@
 package bot
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"net/http"
 	"strconv"
 	"strings"
 	"telegram-summarizer/internal/logger"
 	"telegram-summarizer/internal/summarizer"
-
+	"time"
+	
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
@@ -17,6 +21,8 @@ type Bot struct {
 	messageHandler *MessageHandler
 	commandHandler *CommandHandler
 	summarizer     *summarizer.Summarizer
+	authPrompter   *AuthPrompter
+	admins         adminCache
 }
 
 // NewBot creates a new Telegram bot instance
@@ -45,25 +51,69 @@ func NewBot(token string, debug bool, messageHandler *MessageHandler, commandHan
 func (b *Bot) Start() error {
 	logger.Info("🚀 Starting bot message listener...")
 	
-	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 60
-	
-	updates := b.api.GetUpdatesChan(u)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-b.stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
 	
 	logger.Info("✅ Bot is running... Listening for messages")
 	logger.Info("Press Ctrl+C to stop")
 	
+	b.Poll(ctx)
+	logger.Info("Bot stopped")
+	return nil
+}
+
+// Poll receives and handles updates until ctx is done. Polling can start again later,
+// e.g. when this instance becomes the leader again; each poll confirms the updates
+// handled before, so the next instance polling does not receive them again.
+func (b *Bot) Poll(ctx context.Context) {
+	u := tgbotapi.NewUpdate(0)
+	u.Timeout = 60
+	
+	// The long poll is cancelled with ctx, so an instance stepping down does not keep
+	// polling next to the new leader
+	poller := *b.api
+	poller.Client = contextClient{ctx: ctx, client: b.api.Client}
 	for {
-		select {
-		case update := <-updates:
+		updates, err := poller.GetUpdates(u)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			logger.Error("Failed to get updates, retrying in 3 seconds: %v", err)
+			select {
+			case <-time.After(3 * time.Second):
+			case <-ctx.Done():
+				return
+			}
+			continue
+		}
+	
+		for _, update := range updates {
+			if update.UpdateID >= u.Offset {
+				u.Offset = update.UpdateID + 1
+			}
 			b.handleUpdate(update)
-		case <-b.stopCh:
-			logger.Info("Bot stopped")
-			return nil
 		}
 	}
 }
 
+// contextClient sends the requests of a Bot API client with a context
+type contextClient struct {
+	ctx    context.Context
+	client tgbotapi.HTTPClient
+}
+
+func (c contextClient) Do(req *http.Request) (*http.Response, error) {
+	return c.client.Do(req.WithContext(c.ctx))
+}
+
 // handleUpdate processes incoming updates from Telegram
 func (b *Bot) handleUpdate(update tgbotapi.Update) {
 	// Handle messages
@@ -83,6 +133,11 @@ func (b *Bot) handleUpdate(update tgbotapi.Update) {
 
 // handleMessage processes incoming messages
 func (b *Bot) handleMessage(message *tgbotapi.Message) {
+	// Answers to scraper login prompts are never stored or logged
+	if b.authPrompter != nil && b.authPrompter.handleReply(message) {
+		return
+	}
+	
 	logger.Debug("Received message: ChatID=%d, UserID=%d, User=%s, Text=%q",
 		message.Chat.ID,
 		message.From.ID,
@@ -96,8 +151,8 @@ func (b *Bot) handleMessage(message *tgbotapi.Message) {
 		return
 	}
 	
-	// Process and save regular messages
-	if message.Text != "" && b.messageHandler != nil {
+	// Process and save regular messages (text, or media with optional caption)
+	if b.messageHandler != nil {
 		if err := b.messageHandler.ProcessMessage(message); err != nil {
 			logger.Error("Error processing message: %v", err)
 		}
@@ -152,6 +207,116 @@ func (b *Bot) handleCommand(message *tgbotapi.Message) {
 			b.commandHandler.HandleSummary(message, args)
 			return
 		}
+	case "retention":
+		if b.commandHandler != nil {
+			b.commandHandler.HandleRetention(message, args)
+			return
+		}
+	case "restore":
+		if b.commandHandler != nil {
+			b.commandHandler.HandleRestore(message, args)
+			return
+		}
+	case "timezone":
+		if b.commandHandler != nil {
+			b.commandHandler.HandleTimezone(message, args)
+			return
+		}
+	case "windows":
+		if b.commandHandler != nil {
+			b.commandHandler.HandleWindows(message, args)
+			return
+		}
+	case "weekly":
+		if b.commandHandler != nil {
+			b.commandHandler.HandleWeekly(message, args)
+			return
+		}
+	case "monthly":
+		if b.commandHandler != nil {
+			b.commandHandler.HandleMonthly(message, args)
+			return
+		}
+	case "filters":
+		if b.commandHandler != nil {
+			b.commandHandler.HandleFilters(message, args)
+			return
+		}
+	case "setfilter":
+		if b.commandHandler != nil {
+			b.commandHandler.HandleSetFilter(message, args)
+			return
+		}
+	case "resetfilter":
+		if b.commandHandler != nil {
+			b.commandHandler.HandleResetFilter(message, args)
+			return
+		}
+	case "user":
+		if b.commandHandler != nil {
+			b.commandHandler.HandleUser(message, args)
+			return
+		}
+	case "alias":
+		if b.commandHandler != nil {
+			b.commandHandler.HandleAlias(message, args)
+			return
+		}
+	case "unalias":
+		if b.commandHandler != nil {
+			b.commandHandler.HandleUnalias(message, args)
+			return
+		}
+	case "aliases":
+		if b.commandHandler != nil {
+			b.commandHandler.HandleAliases(message, args)
+			return
+		}
+	case "trends":
+		if b.commandHandler != nil {
+			b.commandHandler.HandleTrends(message, args)
+			return
+		}
+	case "product":
+		if b.commandHandler != nil {
+			b.commandHandler.HandleProduct(message, args)
+			return
+		}
+	case "compare":
+		if b.commandHandler != nil {
+			b.commandHandler.HandleCompare(message, args)
+			return
+		}
+	case "fc":
+		if b.commandHandler != nil {
+			b.commandHandler.HandleFamilyCodes(message, args)
+			return
+		}
+	case "subscribe":
+		if b.commandHandler != nil {
+			b.commandHandler.HandleSubscribe(message, args)
+			return
+		}
+	case "unsubscribe":
+		if b.commandHandler != nil {
+			b.commandHandler.HandleUnsubscribe(message, args)
+			return
+		}
+	case "schedule":
+		if b.commandHandler != nil {
+			b.commandHandler.HandleSchedule(message, args)
+			return
+		}
+	case "jobs":
+		if b.commandHandler != nil {
+			b.commandHandler.HandleJobs(message, args)
+			return
+		}
+	case "retry":
+		if b.commandHandler != nil {
+			b.commandHandler.HandleRetry(message, args)
+			return
+		}
 	default:
 		logger.Debug("Unknown command: /%s", command)
 		return
@@ -199,11 +364,55 @@ func (b *Bot) handleHelpCommand(message *tgbotapi.Message) string {
 /disable <chat_id> - Disable auto-summary for a group
 /disableall - Disable ALL groups at once
 /groupstats - Show detailed group statistics
+/timezone <chat_id> [zone|default] - Show or set a group's timezone
+/windows <chat_id> [setting value|default] - Show or set a group's summary windows
+
+*Retention & Archive:*
+/retention <id> [days|forever] [none|jsonl|db] - Show or set message retention
+/restore <id> <YYYY-MM-DD> [YYYY-MM-DD] - Restore archived days and summarize
+
+*Ingest Filters:*
+/filters <id|default> - Show filter rules and drop counts
+/setfilter <id|default> <setting> <value> - Change a filter
+/resetfilter <id|default> - Use default filters again
+
+*Users:*
+/user <id|@name> - Profile and activity across groups
+
+*Product Catalog:*
+/alias <alias> = <product> - Map a name to a product
+/unalias <alias> - Remove an alias
+/aliases <product> - List the names of a product
+/trends [days] - Top products with sentiment and credibility changes
+/product <name> - Mentions, groups, prices, FamilyCodes and red flags
+/compare <a> vs <b> - Compare two products
+
+*FamilyCodes:*
+/fc [list [days]] - Active codes with works/not working replies
+/fc search <text> - Find codes by product or code prefix
+/fc <code> - Code details and groups
+
+*Delivery:*
+/subscribe <chat_id> [hourly|daily] - Send a group's summaries to this chat
+/unsubscribe <chat_id> [hourly|daily] - Stop sending them here
+/subscribe - List the subscriptions of this chat
+/subscribe digest - Send the daily cross-group digest to this chat
+
+*Schedules:*
+/schedule [chat_id] - List cron summary schedules
+/schedule <chat_id> add <1h|4h|24h|daily|weekly|monthly> <cron> - Add a schedule
+/schedule <chat_id> remove <id> - Remove a schedule
+
+*Jobs:*
+/jobs [status] - Summary job queue (pending, running, failed, succeeded)
+/retry <job_id|all> - Run failed jobs again
 
 *Summary Commands:*
 /summary <chat_id> - Generate on-demand summary
 /summary <chat_id> 4h - Last 4 hours summary
 /summary <chat_id> daily - Today's summary
+/weekly <chat_id> - Latest weekly rollup
+/monthly <chat_id> - Latest monthly rollup
 
 *General:*
 /start - Introduction and welcome message
@@ -213,7 +422,7 @@ func (b *Bot) handleHelpCommand(message *tgbotapi.Message) string {
 1. Add bot to your group
 2. Bot records all messages
 3. Enable group with /enable <chat_id>
-4. Automatic summaries every 4 hours
+4. Subscribe a chat with /subscribe <chat_id>
 5. Daily summary at 23:59
 
 *Privacy:*
@@ -376,6 +585,34 @@ func (b *Bot) handleCallbackQuery(query *tgbotapi.CallbackQuery) {
 			b.api.Request(callback)
 			b.commandHandler.HandleGroupStatsEdit(message, page)
 		}
+	case "trends", "product", "fc":
+		// Format: "trends:<page>:<days>", "product:<page>:<product_id>" or "fc:<page>:<days>"
+		if b.commandHandler != nil && len(parts) > 2 {
+			requestedPage := parts[1]
+			// Check if already on this page
+			if currentPage == requestedPage {
+				logger.Debug("Already on page %s, skipping edit", requestedPage)
+				callback := tgbotapi.NewCallback(query.ID, "")
+				b.api.Request(callback)
+				return
+			}
+			page, err := strconv.Atoi(requestedPage)
+			if err != nil || page < 1 {
+				page = 1
+			}
+			arg, _ := strconv.ParseInt(parts[2], 10, 64)
+			// Answer the callback to remove the loading state
+			callback := tgbotapi.NewCallback(query.ID, "")
+			b.api.Request(callback)
+			switch command {
+			case "trends":
+				b.commandHandler.HandleTrendsEdit(message, page, int(arg))
+			case "product":
+				b.commandHandler.HandleProductEdit(message, page, arg)
+			case "fc":
+				b.commandHandler.HandleFamilyCodesEdit(message, page, int(arg))
+			}
+		}
 	default:
 		logger.Debug("Unknown callback command: %s", command)
 		// Answer the callback to remove the loading state
@@ -388,7 +625,6 @@ func (b *Bot) handleCallbackQuery(query *tgbotapi.CallbackQuery) {
 func (b *Bot) Stop() {
 	logger.Info("Stopping bot...")
 	close(b.stopCh)
-	b.api.StopReceivingUpdates()
 }
 
 // GetAPI returns the underlying bot API (for advanced usage)
@@ -396,6 +632,26 @@ func (b *Bot) GetAPI() *tgbotapi.BotAPI {
 	return b.api
 }
 
+// DownloadFile downloads a file by its Bot API file_id
+func (b *Bot) DownloadFile(fileID string) ([]byte, error) {
+	url, err := b.api.GetFileDirectURL(fileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file URL: %w", err)
+	}
+	
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download file: status %d", resp.StatusCode)
+	}
+	
+	return io.ReadAll(resp.Body)
+}
+
 // SetCommandHandler sets the command handler (for initialization after bot creation)
 func (b *Bot) SetCommandHandler(handler *CommandHandler) {
 	b.commandHandler = handler
@@ -405,3 +661,11 @@ func (b *Bot) SetCommandHandler(handler *CommandHandler) {
 func (b *Bot) SetSummarizer(s *summarizer.Summarizer) {
 	b.summarizer = s
 }
+
+// SetAuthPrompter lets the bot answer scraper login prompts sent to the admin. The
+// API's debug log is turned off, as it logs the raw updates carrying the answers.
+func (b *Bot) SetAuthPrompter(p *AuthPrompter) {
+	b.api.Debug = false
+	b.authPrompter = p
+	p.attach(b.api)
+}
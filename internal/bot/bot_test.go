@@ -0,0 +1,65 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestPollCancelsLongPoll(t *testing.T) {
+	// Bot API holding long polls until the client gives up
+	polling := make(chan struct{}, 1)
+	cancelled := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/getMe"):
+			fmt.Fprint(w, `{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"Bot","username":"test_bot"}}`)
+		case strings.HasSuffix(r.URL.Path, "/getUpdates"):
+			// The server notices the client leaving once it read the request
+			r.ParseForm()
+			polling <- struct{}{}
+			select {
+			case <-r.Context().Done():
+				cancelled <- struct{}{}
+			case <-time.After(time.Minute):
+				fmt.Fprint(w, `{"ok":true,"result":[]}`)
+			}
+		default:
+			fmt.Fprint(w, `{"ok":true,"result":true}`)
+		}
+	}))
+	defer server.Close()
+	
+	api, err := tgbotapi.NewBotAPIWithClient("token", server.URL+"/bot%s/%s", server.Client())
+	if err != nil {
+		t.Fatalf("NewBotAPIWithClient: %v", err)
+	}
+	b := &Bot{api: api, stopCh: make(chan struct{})}
+	
+	ctx, cancel := context.WithCancel(context.Background())
+	polled := make(chan struct{})
+	go func() {
+		defer close(polled)
+		b.Poll(ctx)
+	}()
+	<-polling
+	
+	// Stepping down ends the long poll in flight, not only Poll
+	cancel()
+	select {
+	case <-polled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Poll did not return after ctx was done")
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("long poll was left running after Poll returned")
+	}
+}
@@ -5,24 +5,87 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"telegram-summarizer/internal/archive"
+	"telegram-summarizer/internal/catalog"
 	"telegram-summarizer/internal/db"
 	"telegram-summarizer/internal/logger"
-
+	"telegram-summarizer/internal/summarizer"
+	"telegram-summarizer/internal/timezone"
+	
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
 // CommandHandler handles bot commands
 type CommandHandler struct {
-	bot      *Bot
-	database *db.DB
+	bot       *Bot
+	database  db.Store
+	retention *archive.Service
+	catalog   *catalog.Catalog
+	ownerID   int64 // User who may subscribe to and manage any group, and the digest (0 = none)
 }
 
 // NewCommandHandler creates a new command handler
-func NewCommandHandler(bot *Bot, database *db.DB) *CommandHandler {
+func NewCommandHandler(bot *Bot, database db.Store) *CommandHandler {
 	return &CommandHandler{
-		bot:      bot,
-		database: database,
+		bot:       bot,
+		database:  database,
+		retention: archive.NewService(database, archive.Config{}),
+		catalog:   catalog.New(database),
+	}
+}
+
+// SetRetention sets the service used by /retention and /restore
+func (h *CommandHandler) SetRetention(retention *archive.Service) {
+	h.retention = retention
+}
+
+// SetOwnerID sets the user who may subscribe to the summaries of any group, including
+// groups the bot is not in, and to the cross-group digest
+func (h *CommandHandler) SetOwnerID(userID int64) {
+	h.ownerID = userID
+}
+
+// canManageGroup reports whether the sender may change the settings of a group: its
+// administrators, and the owner for any group. Settings of no single group (chat ID 0)
+// are the owner's only. Administrators that can't be checked count as none. Replies on
+// refusal.
+func (h *CommandHandler) canManageGroup(message *tgbotapi.Message, chatID int64, name string) bool {
+	if h.ownerID != 0 && message.From.ID == h.ownerID {
+		return true
+	}
+	if chatID == 0 {
+		h.bot.sendMessage(message.Chat.ID, "❌ Only the owner of the bot can change this.")
+		return false
+	}
+	
+	isAdmin, known := h.bot.IsChatAdmin(chatID, message.From.ID)
+	switch {
+	case !known:
+		h.sendMessageWithoutHeader(message.Chat.ID, fmt.Sprintf("❌ Could not check the administrators of %s. "+
+			"Only they can change its settings.", name))
+		return false
+	case !isAdmin:
+		h.sendMessageWithoutHeader(message.Chat.ID, fmt.Sprintf("❌ Only administrators of %s can change its settings.", name))
+		return false
+	}
+	return true
+}
+
+// manageableGroup resolves a chat ID argument to the tracked group whose settings a
+// command shows or changes, replying on failure. Changes are checked with canManageGroup.
+func (h *CommandHandler) manageableGroup(message *tgbotapi.Message, arg string) *db.TrackedGroup {
+	resolvedID, err := h.database.ResolveChatID(arg)
+	if err != nil {
+		h.bot.sendMessage(message.Chat.ID, "❌ Invalid chat ID. Must be a number.")
+		return nil
+	}
+	
+	group := h.database.GetTrackedGroup(resolvedID.Int64())
+	if group == nil {
+		h.bot.sendMessage(message.Chat.ID, fmt.Sprintf("❌ Group with chat ID `%d` not found.\n\nUse /listgroups to see available groups.", resolvedID.Int64()))
+		return nil
 	}
+	return group
 }
 
 // HandleListGroups handles /listgroups command with pagination
@@ -200,11 +263,12 @@ func (h *CommandHandler) HandleEnableGroup(message *tgbotapi.Message, args []str
 		return
 	}
 	
-	chatID, err := strconv.ParseInt(args[0], 10, 64)
+	resolvedID, err := h.database.ResolveChatID(args[0])
 	if err != nil {
 		h.bot.sendMessage(message.Chat.ID, "❌ Invalid chat ID. Must be a number.\n\nExample: `/enable -1001234567890`")
 		return
 	}
+	chatID := resolvedID.Int64()
 	
 	// Check if group exists
 	groups := h.database.GetTrackedGroups()
@@ -251,11 +315,12 @@ func (h *CommandHandler) HandleDisableGroup(message *tgbotapi.Message, args []st
 		return
 	}
 	
-	chatID, err := strconv.ParseInt(args[0], 10, 64)
+	resolvedID, err := h.database.ResolveChatID(args[0])
 	if err != nil {
 		h.bot.sendMessage(message.Chat.ID, "❌ Invalid chat ID. Must be a number.\n\nExample: `/disable -1001234567890`")
 		return
 	}
+	chatID := resolvedID.Int64()
 	
 	// Check if group exists
 	groups := h.database.GetTrackedGroups()
@@ -332,11 +397,12 @@ func (h *CommandHandler) HandleSummary(message *tgbotapi.Message, args []string)
 		return
 	}
 	
-	chatID, err := strconv.ParseInt(args[0], 10, 64)
+	resolvedID, err := h.database.ResolveChatID(args[0])
 	if err != nil {
 		h.bot.sendMessage(message.Chat.ID, "❌ Invalid chat ID. Must be a number.\n\nExample: `/summary 3103764752`")
 		return
 	}
+	chatID := resolvedID.Int64()
 	
 	// Check if group exists and is active
 	group := h.database.GetTrackedGroup(chatID)
@@ -353,10 +419,18 @@ func (h *CommandHandler) HandleSummary(message *tgbotapi.Message, args []string)
 	// Send "generating" message
 	h.bot.sendMessage(message.Chat.ID, fmt.Sprintf("⏳ Generating summary for *%s*...\n\nThis may take a few seconds.", escapeMarkdown(group.GroupName)))
 	
-	// Get messages from last 24 hours
-	endTime := time.Now()
+	// Get messages from last 24 hours, with times in the group's timezone
+	endTime := time.Now().In(timezone.Of(*group))
 	startTime := endTime.Add(-24 * time.Hour)
 	
+	h.summarizeWindow(message, group, startTime, endTime, "manual-24h",
+		fmt.Sprintf("📭 No messages found in last 24 hours for *%s*.\n\nThe group might have been recently enabled.", escapeMarkdown(group.GroupName)))
+}
+
+// summarizeWindow generates, sends and saves a summary of a group's messages in a time range
+func (h *CommandHandler) summarizeWindow(message *tgbotapi.Message, group *db.TrackedGroup, startTime, endTime time.Time, summaryType, emptyText string) {
+	chatID := group.ChatID
+	
 	messages, err := h.database.GetMessagesByTimeRange(chatID, startTime, endTime)
 	if err != nil {
 		logger.Error("Failed to get messages: %v", err)
@@ -365,7 +439,7 @@ func (h *CommandHandler) HandleSummary(message *tgbotapi.Message, args []string)
 	}
 	
 	if len(messages) == 0 {
-		h.bot.sendMessage(message.Chat.ID, fmt.Sprintf("📭 No messages found in last 24 hours for *%s*.\n\nThe group might have been recently enabled.", escapeMarkdown(group.GroupName)))
+		h.bot.sendMessage(message.Chat.ID, emptyText)
 		return
 	}
 	
@@ -393,47 +467,46 @@ func (h *CommandHandler) HandleSummary(message *tgbotapi.Message, args []string)
 	
 	// Use hierarchical summarization (automatic chunking for large chats)
 	logger.Info("Using streaming summarization for %d messages", len(messages))
-	summary, err := h.bot.summarizer.GenerateSummaryHierarchical(messages, group.GroupName, startTime, endTime, progressCallback, summaryCallback)
-	if err != nil {
+	result, err := h.bot.summarizer.SummarizeAndStore(summarizer.Request{
+		ChatID:      chatID,
+		GroupName:   group.GroupName,
+		SummaryType: summaryType,
+		StartTime:   startTime,
+		EndTime:     endTime,
+		Messages:    messages,
+		OnProgress:  progressCallback,
+		OnPartial:   summaryCallback,
+	})
+	if result == nil {
 		logger.Error("Failed to generate summary: %v", err)
-		h.bot.sendMessage(message.Chat.ID, fmt.Sprintf("❌ Failed to generate summary: %v\n\nPlease check your configuration and try again.", err))
+		
+		// Retry in the background and send the summary here once it succeeds
+		job := &db.SummaryJob{
+			Kind:         db.JobManual,
+			ChatID:       chatID,
+			SummaryType:  summaryType,
+			WindowStart:  startTime,
+			WindowEnd:    endTime,
+			TargetChatID: message.Chat.ID,
+		}
+		if queued, qerr := h.database.EnqueueJob(job); qerr != nil || !queued {
+			if qerr != nil {
+				logger.Error("Failed to queue summary job: %v", qerr)
+			}
+			h.bot.sendMessage(message.Chat.ID, fmt.Sprintf("❌ Failed to generate summary: %v\n\nPlease check your configuration and try again.", err))
+			return
+		}
+		h.bot.sendMessage(message.Chat.ID, fmt.Sprintf("❌ Failed to generate summary: %v\n\n🔁 Queued as job %d: it is retried in the background and the summary is sent here. See /jobs", err, job.ID))
 		return
 	}
 	
 	// Send completion message (already formatted by formatter)
 	// Use sendMessageWithoutHeader to avoid Markdown parsing errors
-	h.sendMessageWithoutHeader(message.Chat.ID, summary)
-	
-	// Parse metadata from summary (use raw summary text without formatting)
-	parser := h.bot.summarizer.GetMetadataParser()
-	metadata := parser.Parse(summary)
-	
-	// Save summary to database with metadata
-	dbSummary := &db.Summary{
-		ChatID:            chatID,
-		SummaryType:       "manual-24h",
-		PeriodStart:       startTime,
-		PeriodEnd:         endTime,
-		SummaryText:       summary,
-		MessageCount:      len(messages),
-		Sentiment:         metadata.Sentiment,
-		CredibilityScore:  metadata.CredibilityScore,
-		ProductsMentioned: metadata.ProductsJSON,
-		RedFlagsCount:     metadata.RedFlagsCount,
-		ValidationStatus:  metadata.ValidationStatus,
-	}
-	
-	if err := h.database.SaveSummary(dbSummary); err != nil {
+	h.sendMessageWithoutHeader(message.Chat.ID, result.Text())
+	
+	if err != nil {
 		logger.Error("Failed to save summary: %v", err)
 		// Don't fail, just log
-	} else {
-		// Save product mentions
-		for i := range metadata.Products {
-			metadata.Products[i].SummaryID = dbSummary.ID
-			if err := h.database.SaveProductMention(&metadata.Products[i]); err != nil {
-				logger.Error("Failed to save product mention: %v", err)
-			}
-		}
 	}
 	
 	logger.Info("✅ Summary generated successfully for group %s (%d messages)", group.GroupName, len(messages))
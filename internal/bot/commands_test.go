@@ -0,0 +1,330 @@
+package bot
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"telegram-summarizer/internal/db"
+	"telegram-summarizer/internal/filter"
+	"testing"
+	"time"
+	
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// newTestCommandHandler creates a command handler on a temporary store tracking a group
+// administered by adminID, and a Bot API recording the replies sent. The bot can't check
+// the administrators of any other chat.
+func newTestCommandHandler(t *testing.T, adminID int64) (*CommandHandler, *db.TrackedGroup, func() []string) {
+	t.Helper()
+	store, err := db.Open(db.DriverSQLite, filepath.Join(t.TempDir(), "bot.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	
+	chatID := db.ChannelChatID(1234).Int64()
+	if err := store.AddTrackedGroup(chatID, "Test group", ""); err != nil {
+		t.Fatalf("AddTrackedGroup: %v", err)
+	}
+	group := store.GetTrackedGroup(chatID)
+	
+	var mu sync.Mutex
+	var replies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/getMe"):
+			fmt.Fprint(w, `{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"Bot","username":"test_bot"}}`)
+		case strings.HasSuffix(r.URL.Path, "/getChatAdministrators"):
+			if r.Form.Get("chat_id") != fmt.Sprint(chatID) {
+				fmt.Fprint(w, `{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`)
+				return
+			}
+			fmt.Fprintf(w, `{"ok":true,"result":[{"status":"administrator","user":{"id":%d,"is_bot":false,"first_name":"Admin"}}]}`, adminID)
+		case strings.HasSuffix(r.URL.Path, "/sendMessage"):
+			mu.Lock()
+			replies = append(replies, r.Form.Get("text"))
+			mu.Unlock()
+			fmt.Fprintf(w, `{"ok":true,"result":{"message_id":10,"date":1700000000,"chat":{"id":%s,"type":"private"}}}`, r.Form.Get("chat_id"))
+		default:
+			fmt.Fprint(w, `{"ok":true,"result":true}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+	
+	api, err := tgbotapi.NewBotAPIWithClient("token", server.URL+"/bot%s/%s", server.Client())
+	if err != nil {
+		t.Fatalf("NewBotAPIWithClient: %v", err)
+	}
+	h := NewCommandHandler(&Bot{api: api, messageHandler: NewMessageHandler(store), stopCh: make(chan struct{})}, store)
+	takeReplies := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		sent := replies
+		replies = nil
+		return sent
+	}
+	return h, group, takeReplies
+}
+
+// privateMessage creates a command message sent by a user in a private chat with the bot
+func privateMessage(userID int64) *tgbotapi.Message {
+	return &tgbotapi.Message{
+		From: &tgbotapi.User{ID: userID},
+		Chat: &tgbotapi.Chat{ID: userID, Type: "private"},
+	}
+}
+
+// wantRefused checks that a command only sent a refusal
+func wantRefused(t *testing.T, command string, sent []string) {
+	t.Helper()
+	if len(sent) != 1 || !strings.HasPrefix(sent[0], "❌") {
+		t.Errorf("%s: got replies %q, want a refusal", command, sent)
+	}
+}
+
+// gatedCommand is a command that only administrators of its group and the owner, or
+// only the owner, may run
+type gatedCommand struct {
+	name      string
+	ownerOnly bool
+	setup     func(t *testing.T, h *CommandHandler, group *db.TrackedGroup) // Optional
+	command   func(h *CommandHandler, message *tgbotapi.Message, args []string)
+	args      func(h *CommandHandler, group *db.TrackedGroup) []string
+	done      func(h *CommandHandler, group *db.TrackedGroup, sent []string) bool // Whether the command took effect
+}
+
+// chatArgs returns the arguments of a command following the test group's chat ID
+func chatArgs(args ...string) func(*CommandHandler, *db.TrackedGroup) []string {
+	return func(_ *CommandHandler, group *db.TrackedGroup) []string {
+		return append([]string{fmt.Sprint(group.ChatID)}, args...)
+	}
+}
+
+// fixedArgs returns the arguments of a command
+func fixedArgs(args ...string) func(*CommandHandler, *db.TrackedGroup) []string {
+	return func(*CommandHandler, *db.TrackedGroup) []string {
+		return args
+	}
+}
+
+// hasFilters reports whether a chat has filters of its own with a minimum length
+func hasFilters(h *CommandHandler, chatID int64, minRunes int) bool {
+	config, found := h.bot.messageHandler.filters.GetConfig(chatID)
+	return found && config.MinRunes == minRunes
+}
+
+// failJob queues a job of the group that failed for good
+func failJob(t *testing.T, h *CommandHandler, group *db.TrackedGroup) {
+	t.Helper()
+	start := time.Now().Truncate(time.Hour).Add(-time.Hour)
+	job := &db.SummaryJob{Kind: db.JobHourly, ChatID: group.ChatID, SummaryType: "1h",
+		WindowStart: start, WindowEnd: start.Add(time.Hour), NextRunAt: start}
+	if _, err := h.database.EnqueueJob(job); err != nil {
+		t.Fatalf("EnqueueJob: %v", err)
+	}
+	if _, err := h.database.ClaimJob(time.Now()); err != nil {
+		t.Fatalf("ClaimJob: %v", err)
+	}
+	if err := h.database.FailJob(job.ID, "failure", time.Time{}); err != nil {
+		t.Fatalf("FailJob: %v", err)
+	}
+}
+
+// jobRetried reports whether the failed job of the group was queued again
+func jobRetried(h *CommandHandler, _ *db.TrackedGroup, _ []string) bool {
+	jobs, err := h.database.GetJobs(db.JobPending, 10)
+	return err == nil && len(jobs) == 1
+}
+
+func TestCommandsNeedAdmin(t *testing.T) {
+	const adminID, memberID, ownerID = 7, 8, 9
+	// The bot can't check the administrators of this group
+	otherGroup := db.ChannelChatID(9999).Int64()
+	
+	commands := []gatedCommand{
+		{
+			name:    "/setfilter",
+			command: (*CommandHandler).HandleSetFilter,
+			args:    chatArgs("min_runes", "20"),
+			done: func(h *CommandHandler, group *db.TrackedGroup, _ []string) bool {
+				return hasFilters(h, group.ChatID, 20)
+			},
+		},
+		{
+			name: "/resetfilter",
+			setup: func(t *testing.T, h *CommandHandler, group *db.TrackedGroup) {
+				h.HandleSetFilter(privateMessage(ownerID), []string{fmt.Sprint(group.ChatID), "min_runes", "20"})
+			},
+			command: (*CommandHandler).HandleResetFilter,
+			args:    chatArgs(),
+			done: func(h *CommandHandler, group *db.TrackedGroup, _ []string) bool {
+				return !hasFilters(h, group.ChatID, 20)
+			},
+		},
+		{
+			name:      "/setfilter of a group whose administrators can't be checked",
+			ownerOnly: true,
+			command:   (*CommandHandler).HandleSetFilter,
+			args:      fixedArgs(fmt.Sprint(otherGroup), "min_runes", "5"),
+			done: func(h *CommandHandler, _ *db.TrackedGroup, _ []string) bool {
+				return hasFilters(h, otherGroup, 5)
+			},
+		},
+		{
+			name:      "/setfilter default",
+			ownerOnly: true,
+			command:   (*CommandHandler).HandleSetFilter,
+			args:      fixedArgs("default", "min_runes", "5"),
+			done: func(h *CommandHandler, _ *db.TrackedGroup, _ []string) bool {
+				return hasFilters(h, filter.DefaultChatID, 5)
+			},
+		},
+		{
+			name:      "/resetfilter default",
+			ownerOnly: true,
+			setup: func(t *testing.T, h *CommandHandler, _ *db.TrackedGroup) {
+				h.HandleSetFilter(privateMessage(ownerID), []string{"default", "min_runes", "5"})
+			},
+			command: (*CommandHandler).HandleResetFilter,
+			args:    fixedArgs("default"),
+			done: func(h *CommandHandler, _ *db.TrackedGroup, _ []string) bool {
+				return !hasFilters(h, filter.DefaultChatID, 5)
+			},
+		},
+		{
+			name:    "/retention",
+			command: (*CommandHandler).HandleRetention,
+			args:    chatArgs("20"),
+			done: func(h *CommandHandler, group *db.TrackedGroup, _ []string) bool {
+				return h.database.GetTrackedGroup(group.ChatID).RetentionDays == 20
+			},
+		},
+		{
+			name:    "/restore",
+			command: (*CommandHandler).HandleRestore,
+			args:    chatArgs("2026-01-01"),
+			done: func(_ *CommandHandler, _ *db.TrackedGroup, sent []string) bool {
+				return len(sent) > 0 && strings.HasPrefix(sent[0], "♻️ Restoring")
+			},
+		},
+		{
+			name:      "/alias",
+			ownerOnly: true,
+			command:   (*CommandHandler).HandleAlias,
+			args:      fixedArgs("vit", "c", "=", "Vitamin", "C", "1000mg"),
+			done: func(h *CommandHandler, _ *db.TrackedGroup, _ []string) bool {
+				product, err := h.catalog.Lookup("vit c")
+				return err == nil && product != nil
+			},
+		},
+		{
+			name:      "/unalias",
+			ownerOnly: true,
+			setup: func(t *testing.T, h *CommandHandler, _ *db.TrackedGroup) {
+				h.HandleAlias(privateMessage(ownerID), []string{"vit", "c", "=", "Vitamin", "C", "1000mg"})
+			},
+			command: (*CommandHandler).HandleUnalias,
+			args:    fixedArgs("vit", "c"),
+			done: func(h *CommandHandler, _ *db.TrackedGroup, _ []string) bool {
+				product, err := h.catalog.Lookup("vit c")
+				return err == nil && product == nil
+			},
+		},
+		{
+			name:    "/schedule add",
+			command: (*CommandHandler).HandleSchedule,
+			args:    chatArgs("add", "4h", "0", "*/4", "*", "*", "*"),
+			done: func(h *CommandHandler, group *db.TrackedGroup, _ []string) bool {
+				schedules, err := h.database.GetSummarySchedules(group.ChatID)
+				return err == nil && len(schedules) == 1
+			},
+		},
+		{
+			name: "/schedule remove",
+			setup: func(t *testing.T, h *CommandHandler, group *db.TrackedGroup) {
+				h.HandleSchedule(privateMessage(ownerID), []string{fmt.Sprint(group.ChatID), "add", "4h", "0", "*/4", "*", "*", "*"})
+				if schedules, err := h.database.GetSummarySchedules(group.ChatID); err != nil || len(schedules) != 1 {
+					t.Fatalf("got schedules %+v (err=%v), want the owner's schedule", schedules, err)
+				}
+			},
+			command: (*CommandHandler).HandleSchedule,
+			args: func(h *CommandHandler, group *db.TrackedGroup) []string {
+				schedules, _ := h.database.GetSummarySchedules(group.ChatID)
+				return []string{fmt.Sprint(group.ChatID), "remove", fmt.Sprint(schedules[0].ID)}
+			},
+			done: func(h *CommandHandler, group *db.TrackedGroup, _ []string) bool {
+				schedules, err := h.database.GetSummarySchedules(group.ChatID)
+				return err == nil && len(schedules) == 0
+			},
+		},
+		{
+			name:    "/retry",
+			setup:   failJob,
+			command: (*CommandHandler).HandleRetry,
+			args: func(h *CommandHandler, _ *db.TrackedGroup) []string {
+				jobs, _ := h.database.GetJobs(db.JobFailed, 1)
+				return []string{fmt.Sprint(jobs[0].ID)}
+			},
+			done: jobRetried,
+		},
+		{
+			name:      "/retry all",
+			ownerOnly: true,
+			setup:     failJob,
+			command:   (*CommandHandler).HandleRetry,
+			args:      fixedArgs("all"),
+			done:      jobRetried,
+		},
+		{
+			name:    "/timezone",
+			command: (*CommandHandler).HandleTimezone,
+			args:    chatArgs("Europe/Berlin"),
+			done: func(h *CommandHandler, group *db.TrackedGroup, _ []string) bool {
+				return h.database.GetTrackedGroup(group.ChatID).Timezone == "Europe/Berlin"
+			},
+		},
+		{
+			name:    "/windows",
+			command: (*CommandHandler).HandleWindows,
+			args:    chatArgs("min", "10"),
+			done: func(h *CommandHandler, group *db.TrackedGroup, _ []string) bool {
+				return h.database.GetTrackedGroup(group.ChatID).Windows.MinMessages == 10
+			},
+		},
+	}
+	users := []struct {
+		name string
+		id   int64
+	}{
+		{"member", memberID},
+		{"admin", adminID},
+		{"owner", ownerID},
+	}
+	for _, command := range commands {
+		for _, user := range users {
+			t.Run(command.name+" by "+user.name, func(t *testing.T) {
+				h, group, replies := newTestCommandHandler(t, adminID)
+				h.SetOwnerID(ownerID)
+				if command.setup != nil {
+					command.setup(t, h, group)
+					replies()
+				}
+				
+				command.command(h, privateMessage(user.id), command.args(h, group))
+				sent := replies()
+				allowed := user.id == ownerID || user.id == adminID && !command.ownerOnly
+				if done := command.done(h, group, sent); done != allowed {
+					t.Errorf("took effect: %v, replies %q; want %v", done, sent, allowed)
+				}
+				if !allowed {
+					wantRefused(t, command.name, sent)
+				}
+			})
+		}
+	}
+}
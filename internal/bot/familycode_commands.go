@@ -0,0 +1,218 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"telegram-summarizer/internal/db"
+	"telegram-summarizer/internal/familycode"
+	"telegram-summarizer/internal/logger"
+	"time"
+	
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	defaultFamilyCodeDays = 7
+	familyCodesPerPage    = 10
+	maxFamilyCodes        = 500 // Codes read for /fc list
+	familyCodeSearchLimit = 20
+)
+
+// HandleFamilyCodes handles /fc command - lists active FamilyCodes, searches them or shows one code
+func (h *CommandHandler) HandleFamilyCodes(message *tgbotapi.Message, args []string) {
+	logger.Info("Handling /fc command from user %d", message.From.ID)
+	
+	if len(args) == 0 {
+		h.sendFamilyCodeList(message.Chat.ID, defaultFamilyCodeDays)
+		return
+	}
+	
+	switch strings.ToLower(args[0]) {
+	case "list":
+		days := defaultFamilyCodeDays
+		if len(args) > 1 {
+			d, err := strconv.Atoi(strings.TrimSuffix(args[1], "d"))
+			if err != nil || d < 1 {
+				h.bot.sendMessage(message.Chat.ID, "❌ Usage: `/fc list [days]`")
+				return
+			}
+			days = d
+		}
+		h.sendFamilyCodeList(message.Chat.ID, days)
+	case "search":
+		text := strings.TrimSpace(strings.Join(args[1:], " "))
+		if text == "" {
+			h.bot.sendMessage(message.Chat.ID, "❌ Usage: `/fc search <product text or code>`")
+			return
+		}
+		h.sendFamilyCodeSearch(message.Chat.ID, text)
+	default:
+		h.sendFamilyCodeDetails(message.Chat.ID, args[0])
+	}
+}
+
+// HandleFamilyCodesEdit handles /fc list pagination by editing the existing message
+func (h *CommandHandler) HandleFamilyCodesEdit(message *tgbotapi.Message, page, days int) {
+	text, keyboard, hasKeyboard := h.buildFamilyCodeList(page, days)
+	if hasKeyboard {
+		h.bot.editMessageWithKeyboard(message.Chat.ID, message.MessageID, text, keyboard)
+	} else {
+		h.bot.sendMessage(message.Chat.ID, text)
+	}
+}
+
+// sendFamilyCodeList sends the first page of active FamilyCodes
+func (h *CommandHandler) sendFamilyCodeList(chatID int64, days int) {
+	text, keyboard, hasKeyboard := h.buildFamilyCodeList(1, days)
+	if hasKeyboard {
+		h.bot.sendMessageWithKeyboard(chatID, text, keyboard)
+	} else {
+		h.bot.sendMessage(chatID, text)
+	}
+}
+
+// buildFamilyCodeList builds a page of the FamilyCodes seen in the last days that
+// are not mostly reported as failing
+func (h *CommandHandler) buildFamilyCodeList(page, days int) (string, tgbotapi.InlineKeyboardMarkup, bool) {
+	logger.Info("Building /fc response for page %d (%d days)", page, days)
+	
+	recent, err := h.database.GetRecentFamilyCodes(time.Now().AddDate(0, 0, -days), maxFamilyCodes)
+	if err != nil {
+		logger.Error("Failed to get family codes: %v", err)
+		return "❌ Failed to get FamilyCodes. Check logs.", tgbotapi.InlineKeyboardMarkup{}, false
+	}
+	
+	var active []db.FamilyCode
+	failing := 0
+	for _, fc := range recent {
+		if fc.FailsCount > fc.WorksCount {
+			failing++
+			continue
+		}
+		active = append(active, fc)
+	}
+	if len(active) == 0 {
+		return fmt.Sprintf("🔑 No active FamilyCodes in the last %d days.", days), tgbotapi.InlineKeyboardMarkup{}, false
+	}
+	
+	totalPages := (len(active) + familyCodesPerPage - 1) / familyCodesPerPage
+	page = max(1, min(page, totalPages))
+	startIdx := (page - 1) * familyCodesPerPage
+	endIdx := min(startIdx+familyCodesPerPage, len(active))
+	
+	var response strings.Builder
+	response.WriteString(fmt.Sprintf("🔑 *Active FamilyCodes* (last %d days, page %d/%d)\n\n", days, page, totalPages))
+	for _, fc := range active[startIdx:endIdx] {
+		writeFamilyCodeLine(&response, fc)
+	}
+	if failing > 0 {
+		response.WriteString(fmt.Sprintf("Hidden: %d codes mostly reported not working\n", failing))
+	}
+	response.WriteString("Use /fc <code> for details")
+	
+	if totalPages > 1 {
+		return response.String(), paginationKeyboard("fc", page, totalPages, strconv.Itoa(days)), true
+	}
+	return response.String(), tgbotapi.InlineKeyboardMarkup{}, false
+}
+
+// sendFamilyCodeSearch sends the FamilyCodes matching a product text or code prefix
+func (h *CommandHandler) sendFamilyCodeSearch(chatID int64, text string) {
+	codes, err := h.database.SearchFamilyCodes(text, familyCodeSearchLimit)
+	if err != nil {
+		logger.Error("Failed to search family codes: %v", err)
+		h.bot.sendMessage(chatID, "❌ Failed to search FamilyCodes. Check logs.")
+		return
+	}
+	if len(codes) == 0 {
+		h.sendMessageWithoutHeader(chatID, fmt.Sprintf("🔑 No FamilyCodes found for \"%s\".", text))
+		return
+	}
+	
+	var response strings.Builder
+	response.WriteString(fmt.Sprintf("🔎 *FamilyCodes matching* \"%s\" (%d)\n\n", escapeMarkdownV1(text), len(codes)))
+	for _, fc := range codes {
+		writeFamilyCodeLine(&response, fc)
+	}
+	h.bot.sendMessage(chatID, response.String())
+}
+
+// sendFamilyCodeDetails sends a FamilyCode with its groups; a code prefix lists the matching codes
+func (h *CommandHandler) sendFamilyCodeDetails(chatID int64, code string) {
+	codes := familycode.Extract(code)
+	if len(codes) == 0 {
+		// Not a full code: search by prefix or text
+		h.sendFamilyCodeSearch(chatID, code)
+		return
+	}
+	
+	fc, err := h.database.GetFamilyCode(codes[0])
+	if err != nil {
+		logger.Error("Failed to get family code: %v", err)
+		h.bot.sendMessage(chatID, "❌ Failed to get FamilyCode. Check logs.")
+		return
+	}
+	if fc == nil {
+		h.bot.sendMessage(chatID, fmt.Sprintf("🔑 FamilyCode `%s` was never shared in a tracked group.", codes[0]))
+		return
+	}
+	
+	groups, err := h.database.GetFamilyCodeGroups(fc.Code)
+	if err != nil {
+		logger.Error("Failed to get family code groups: %v", err)
+	}
+	
+	const dateFormat = "2006-01-02 15:04"
+	var response strings.Builder
+	response.WriteString(fmt.Sprintf("🔑 `%s`\n\n", fc.Code))
+	if fc.ProductText != "" {
+		response.WriteString(fmt.Sprintf("📦 %s\n\n", escapeMarkdownV1(fc.ProductText)))
+	}
+	response.WriteString(fmt.Sprintf("Status: %s (%d works, %d not working)\n", familyCodeStatus(fc), fc.WorksCount, fc.FailsCount))
+	response.WriteString(fmt.Sprintf("Shared: %d times in %d groups\n", fc.MentionCount, fc.Groups))
+	response.WriteString(fmt.Sprintf("First seen: %s\n", fc.FirstSeen.Format(dateFormat)))
+	response.WriteString(fmt.Sprintf("Last seen: %s\n", fc.LastSeen.Format(dateFormat)))
+	
+	if len(groups) > 0 {
+		response.WriteString("\n*Groups:*\n")
+		for i, g := range groups {
+			if i == productPageLines {
+				response.WriteString(fmt.Sprintf("... and %d more groups\n", len(groups)-i))
+				break
+			}
+			name := g.GroupName
+			if name == "" {
+				name = strconv.FormatInt(g.ChatID, 10)
+			}
+			response.WriteString(fmt.Sprintf("• %s: %d times (last %s)\n", escapeMarkdownV1(name), g.MentionCount, g.LastSeen.Format("Jan 02")))
+		}
+	}
+	
+	h.bot.sendMessage(chatID, response.String())
+}
+
+// writeFamilyCodeLine writes one FamilyCode of a list
+func writeFamilyCodeLine(b *strings.Builder, fc db.FamilyCode) {
+	b.WriteString(fmt.Sprintf("%s `%s`\n", familyCodeStatus(&fc), fc.Code))
+	if fc.ProductText != "" {
+		text := []rune(fc.ProductText)
+		if len(text) > 80 {
+			text = append(text[:80], '…')
+		}
+		b.WriteString(fmt.Sprintf("   %s\n", escapeMarkdownV1(string(text))))
+	}
+	b.WriteString(fmt.Sprintf("   %d× in %d groups · 👍 %d 👎 %d · last %s\n\n",
+		fc.MentionCount, fc.Groups, fc.WorksCount, fc.FailsCount, fc.LastSeen.Format("Jan 02 15:04")))
+}
+
+// familyCodeStatus summarizes the confirmations of a FamilyCode as an emoji
+func familyCodeStatus(fc *db.FamilyCode) string {
+	switch {
+	case fc.WorksCount > fc.FailsCount:
+		return "✅"
+	case fc.FailsCount > fc.WorksCount:
+		return "❌"
+	}
+	return "❔"
+}
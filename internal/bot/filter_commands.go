@@ -0,0 +1,171 @@
+package bot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"telegram-summarizer/internal/filter"
+	"telegram-summarizer/internal/logger"
+	
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// filterStatsDays is the period covered by the drop counters in /filters
+const filterStatsDays = 7
+
+// filterTarget resolves a /filters argument: a chat ID or "default"
+func (h *CommandHandler) filterTarget(arg string) (int64, string, error) {
+	if strings.EqualFold(arg, "default") {
+		return filter.DefaultChatID, "Default (all groups)", nil
+	}
+	
+	resolvedID, err := h.database.ResolveChatID(arg)
+	if err != nil {
+		return 0, "", err
+	}
+	chatID := resolvedID.Int64()
+	
+	name := fmt.Sprintf("%d", chatID)
+	if group := h.database.GetTrackedGroup(chatID); group != nil {
+		name = group.GroupName
+	}
+	return chatID, name, nil
+}
+
+// HandleFilters handles /filters command - shows filter rules and drop counters of a group
+func (h *CommandHandler) HandleFilters(message *tgbotapi.Message, args []string) {
+	logger.Info("Handling /filters command from user %d", message.From.ID)
+	
+	if len(args) < 1 {
+		h.bot.sendMessage(message.Chat.ID, "❌ Usage: `/filters <chat_id|default>`\n\nExample: `/filters -1001234567890`")
+		return
+	}
+	
+	chatID, name, err := h.filterTarget(args[0])
+	if err != nil {
+		h.bot.sendMessage(message.Chat.ID, "❌ Invalid chat ID. Must be a number or `default`.")
+		return
+	}
+	
+	filters := h.bot.messageHandler.filters
+	config, custom := filters.GetConfig(chatID)
+	
+	var response strings.Builder
+	response.WriteString(fmt.Sprintf("🧹 Ingest Filters: %s\n\n", name))
+	if custom {
+		response.WriteString("Group-specific configuration\n\n")
+	} else {
+		response.WriteString("Using default configuration\n\n")
+	}
+	
+	rules := config.Describe()
+	if len(rules) == 0 {
+		response.WriteString("No filters active - all messages are saved.\n")
+	}
+	for _, rule := range rules {
+		response.WriteString(fmt.Sprintf("• %s\n", rule))
+	}
+	
+	if chatID != filter.DefaultChatID {
+		counts, err := filters.DropCounts(chatID, filterStatsDays)
+		if err != nil {
+			logger.Error("Failed to get filter drops: %v", err)
+		}
+	
+		response.WriteString(fmt.Sprintf("\nDropped (last %d days):\n", filterStatsDays))
+		if len(counts) == 0 {
+			response.WriteString("None\n")
+		}
+	
+		names := make([]string, 0, len(counts))
+		for rule := range counts {
+			names = append(names, rule)
+		}
+		sort.Slice(names, func(i, j int) bool { return counts[names[i]] > counts[names[j]] })
+		for _, rule := range names {
+			response.WriteString(fmt.Sprintf("• %s: %d\n", rule, counts[rule]))
+		}
+	}
+	
+	response.WriteString("\nChange with /setfilter <chat_id|default> <setting> <value>")
+	
+	// Plain text: patterns may contain Markdown characters
+	h.sendMessageWithoutHeader(message.Chat.ID, response.String())
+}
+
+// HandleSetFilter handles /setfilter command - changes one filter setting of a group
+func (h *CommandHandler) HandleSetFilter(message *tgbotapi.Message, args []string) {
+	logger.Info("Handling /setfilter command from user %d", message.From.ID)
+	
+	if len(args) < 3 {
+		h.bot.sendMessage(message.Chat.ID, "❌ Usage: `/setfilter <chat_id|default> <setting> <value>`\n\n"+
+			"*Settings:*\n"+
+			"• `min_runes 15` - minimum characters (text-only)\n"+
+			"• `emoji_only on|off` - drop emoji-only messages\n"+
+			"• `bots on|off` - drop messages from bots\n"+
+			"• `deny <regex>` / `deny clear` - drop matching messages\n"+
+			"• `allow <regex>` / `allow clear` - keep only matching messages\n"+
+			"• `duplicate 600` - drop repeats within N seconds (0 = off)\n"+
+			"• `flood 5/60` - max messages per user per N seconds (off)\n"+
+			"• `languages id,en` / `languages any`")
+		return
+	}
+	
+	chatID, name, err := h.filterTarget(args[0])
+	if err != nil {
+		h.bot.sendMessage(message.Chat.ID, "❌ Invalid chat ID. Must be a number or `default`.")
+		return
+	}
+	if !h.canManageGroup(message, chatID, name) {
+		return
+	}
+	
+	filters := h.bot.messageHandler.filters
+	config, _ := filters.GetConfig(chatID)
+	
+	// Regex values may contain spaces
+	if err := config.Set(strings.ToLower(args[1]), strings.Join(args[2:], " ")); err != nil {
+		h.sendMessageWithoutHeader(message.Chat.ID, fmt.Sprintf("❌ %v", err))
+		return
+	}
+	
+	if err := filters.SetConfig(chatID, config); err != nil {
+		logger.Error("Failed to save filter config: %v", err)
+		h.sendMessageWithoutHeader(message.Chat.ID, fmt.Sprintf("❌ Failed to save filter config: %v", err))
+		return
+	}
+	
+	h.sendMessageWithoutHeader(message.Chat.ID, fmt.Sprintf("✅ Filters updated for %s\n\n• %s",
+		name, strings.Join(config.Describe(), "\n• ")))
+}
+
+// HandleResetFilter handles /resetfilter command - removes a group's filter configuration
+func (h *CommandHandler) HandleResetFilter(message *tgbotapi.Message, args []string) {
+	logger.Info("Handling /resetfilter command from user %d", message.From.ID)
+	
+	if len(args) < 1 {
+		h.bot.sendMessage(message.Chat.ID, "❌ Usage: `/resetfilter <chat_id|default>`")
+		return
+	}
+	
+	chatID, name, err := h.filterTarget(args[0])
+	if err != nil {
+		h.bot.sendMessage(message.Chat.ID, "❌ Invalid chat ID. Must be a number or `default`.")
+		return
+	}
+	if !h.canManageGroup(message, chatID, name) {
+		return
+	}
+	
+	if err := h.bot.messageHandler.filters.ResetConfig(chatID); err != nil {
+		logger.Error("Failed to reset filter config: %v", err)
+		h.bot.sendMessage(message.Chat.ID, "❌ Failed to reset filters. Check logs.")
+		return
+	}
+	
+	if chatID == filter.DefaultChatID {
+		h.bot.sendMessage(message.Chat.ID, "✅ Default filters reset to built-in settings")
+		return
+	}
+	h.sendMessageWithoutHeader(message.Chat.ID, fmt.Sprintf("✅ %s now uses the default filters", name))
+}
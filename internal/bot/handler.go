@@ -1,9 +1,13 @@
 package bot
 
 import (
+	"context"
 	"strings"
 	"telegram-summarizer/internal/db"
+	"telegram-summarizer/internal/familycode"
+	"telegram-summarizer/internal/filter"
 	"telegram-summarizer/internal/logger"
+	"telegram-summarizer/internal/ocr"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -11,21 +15,52 @@ import (
 
 // MessageHandler handles message processing and storage
 type MessageHandler struct {
-	database *db.DB
+	database db.Store
+	filters  *filter.Manager
+	codes    *familycode.Registry
+	ocr      ocr.Engine
+	download func(fileID string) ([]byte, error)
+	isAdmin  func(chatID, userID int64) (bool, bool)
 }
 
 // NewMessageHandler creates a new message handler
-func NewMessageHandler(database *db.DB) *MessageHandler {
+func NewMessageHandler(database db.Store) *MessageHandler {
 	return &MessageHandler{
 		database: database,
+		filters:  filter.NewManager(database),
+		codes:    familycode.NewRegistry(database),
 	}
 }
 
+// SetFilters replaces the filter manager, e.g. to share it with the scraper
+func (h *MessageHandler) SetFilters(filters *filter.Manager) {
+	h.filters = filters
+}
+
+// SetOCR enables OCR of image attachments; download fetches a file by its Bot API file_id
+func (h *MessageHandler) SetOCR(engine ocr.Engine, download func(fileID string) ([]byte, error)) {
+	h.ocr = engine
+	h.download = download
+}
+
+// SetAdminLookup enables the admin flag of user profiles; lookup reports whether a
+// user is an administrator of a chat and whether that is known
+func (h *MessageHandler) SetAdminLookup(lookup func(chatID, userID int64) (bool, bool)) {
+	h.isAdmin = lookup
+}
+
 // ProcessMessage processes and potentially saves a message
 func (h *MessageHandler) ProcessMessage(message *tgbotapi.Message) error {
-	// Skip if no text
-	if message.Text == "" {
-		logger.Debug("Skipping message: no text content")
+	// Media messages carry their text in the caption
+	text := message.Text
+	if text == "" {
+		text = message.Caption
+	}
+	mediaType, fileID, fileSize, isImage := extractMedia(message)
+	
+	// Skip if no text and no media
+	if text == "" && mediaType == "" {
+		logger.Debug("Skipping message: no text or media content")
 		return nil
 	}
 	
@@ -35,32 +70,55 @@ func (h *MessageHandler) ProcessMessage(message *tgbotapi.Message) error {
 		return nil
 	}
 	
-	// Skip bot messages
-	if message.From.IsBot {
-		logger.Debug("Skipping message: from bot")
-		return nil
-	}
+	// FamilyCodes and their confirmations are recorded before filtering:
+	// short replies like "work" would not pass the filters
+	h.observeFamilyCodes(message, text)
 	
-	// Filter: minimum length
-	if len(message.Text) < 10 {
-		logger.Debug("Skipping message: too short (%d chars)", len(message.Text))
+	// An image without a caption is only content if it shows text, so it is recognized
+	// before filtering; in the background so slow engines don't block the update loop
+	if isImage && text == "" && h.canRecognize(fileSize) {
+		go func() {
+			h.ingest(message, text, h.extractImageText(fileID))
+		}()
 		return nil
 	}
 	
-	// Filter: only emoji or special characters
-	if isOnlyEmoji(message.Text) {
-		logger.Debug("Skipping message: only emoji/special chars")
+	return h.ingest(message, text, "")
+}
+
+// ingest filters and saves a message; ocrText is the text recognized in its image
+// before filtering, if any
+func (h *MessageHandler) ingest(message *tgbotapi.Message, text, ocrText string) error {
+	mediaType, fileID, fileSize, isImage := extractMedia(message)
+	
+	// Ingest filters (length, emoji, bots, patterns, duplicates, flood, language)
+	if !h.filters.Check(&filter.Message{
+		ChatID:    message.Chat.ID,
+		MessageID: int64(message.MessageID),
+		UserID:    int64(message.From.ID),
+		Text:      text,
+		OCRText:   ocrText,
+		HasMedia:  mediaType != "",
+		IsSticker: mediaType == db.MediaTypeSticker,
+		IsBot:     message.From.IsBot,
+		Timestamp: time.Unix(int64(message.Date), 0),
+	}) {
 		return nil
 	}
 	
 	// Create message object
 	msg := &db.Message{
 		ChatID:        message.Chat.ID,
+		MessageID:     int64(message.MessageID),
 		UserID:        int64(message.From.ID),
 		Username:      message.From.UserName,
-		MessageText:   message.Text,
-		MessageLength: len(message.Text),
+		MessageText:   text,
+		MessageLength: len(text),
 		Timestamp:     time.Unix(int64(message.Date), 0),
+		MediaType:     mediaType,
+		MediaFileID:   fileID,
+		MediaSize:     fileSize,
+		OCRText:       ocrText,
 	}
 	
 	// Handle empty username
@@ -79,11 +137,16 @@ func (h *MessageHandler) ProcessMessage(message *tgbotapi.Message) error {
 	// Add to tracked groups (will be ignored if already exists)
 	h.database.AddTrackedGroup(chatID, chatTitle, chatUsername)
 	
-	// Save to database
-	if err := h.database.SaveMessage(msg); err != nil {
+	// Save to database (the scraper may have saved it already)
+	saved, err := h.database.SaveMessage(msg)
+	if err != nil {
 		logger.Error("Failed to save message: %v", err)
 		return err
 	}
+	if !saved {
+		logger.Debug("Skipping message: already saved (MessageID=%d)", message.MessageID)
+		return nil
+	}
 	
 	logger.Info("💾 Saved message: [%s] %s: %q (ID=%d)", 
 		message.Chat.Title,
@@ -92,25 +155,120 @@ func (h *MessageHandler) ProcessMessage(message *tgbotapi.Message) error {
 		msg.ID,
 	)
 	
+	// The sender is tracked once per saved message, by whichever of the bot and the
+	// scraper saved it
+	h.trackUser(message)
+	
+	// Captioned images are recognized after saving, in the background so slow engines
+	// don't block the update loop
+	if isImage && text != "" && h.canRecognize(fileSize) {
+		go h.recognizeImage(msg.ID, fileID)
+	}
+	
 	return nil
 }
 
-// isOnlyEmoji checks if text contains only emoji and special characters
-func isOnlyEmoji(text string) bool {
-	text = strings.TrimSpace(text)
-	
-	// Check if string has any alphanumeric character
-	hasAlphaNum := false
-	for _, r := range text {
-		if (r >= 'a' && r <= 'z') || 
-		   (r >= 'A' && r <= 'Z') || 
-		   (r >= '0' && r <= '9') {
-			hasAlphaNum = true
-			break
-		}
+// trackUser updates the profile and group activity of a message's sender
+func (h *MessageHandler) trackUser(message *tgbotapi.Message) {
+	if message.Chat.IsPrivate() {
+		return
+	}
+	
+	sighting := db.UserSighting{
+		UserID:    message.From.ID,
+		ChatID:    message.Chat.ID,
+		Username:  message.From.UserName,
+		FirstName: message.From.FirstName,
+		LastName:  message.From.LastName,
+		IsBot:     message.From.IsBot,
+		Timestamp: time.Unix(int64(message.Date), 0),
+	}
+	if h.isAdmin != nil {
+		sighting.IsAdmin, sighting.AdminKnown = h.isAdmin(message.Chat.ID, message.From.ID)
+	}
+	
+	if err := h.database.TrackUser(sighting); err != nil {
+		logger.Error("Failed to track user %d: %v", message.From.ID, err)
+	}
+}
+
+// observeFamilyCodes records the FamilyCodes shared in a group message and replies confirming them
+func (h *MessageHandler) observeFamilyCodes(message *tgbotapi.Message, text string) {
+	if message.Chat.IsPrivate() {
+		return
+	}
+	
+	msg := familycode.Message{
+		ChatID:    message.Chat.ID,
+		MessageID: int64(message.MessageID),
+		UserID:    message.From.ID,
+		Text:      text,
+		Timestamp: time.Unix(int64(message.Date), 0),
+	}
+	if message.ReplyToMessage != nil {
+		msg.ReplyToID = int64(message.ReplyToMessage.MessageID)
+	}
+	h.codes.Observe(msg)
+}
+
+// extractMedia returns type, file ID and size of a message's attachment, and whether it is an image
+func extractMedia(message *tgbotapi.Message) (string, string, int64, bool) {
+	switch {
+	case len(message.Photo) > 0:
+		// Sizes are ordered from smallest to largest
+		photo := message.Photo[len(message.Photo)-1]
+		return db.MediaTypePhoto, photo.FileID, int64(photo.FileSize), true
+	case message.Animation != nil:
+		return db.MediaTypeAnimation, message.Animation.FileID, int64(message.Animation.FileSize), false
+	case message.Video != nil:
+		return db.MediaTypeVideo, message.Video.FileID, int64(message.Video.FileSize), false
+	case message.Document != nil:
+		isImage := strings.HasPrefix(message.Document.MimeType, "image/")
+		return db.MediaTypeDocument, message.Document.FileID, int64(message.Document.FileSize), isImage
+	case message.Audio != nil:
+		return db.MediaTypeAudio, message.Audio.FileID, int64(message.Audio.FileSize), false
+	case message.Voice != nil:
+		return db.MediaTypeVoice, message.Voice.FileID, int64(message.Voice.FileSize), false
+	case message.Sticker != nil:
+		return db.MediaTypeSticker, message.Sticker.FileID, int64(message.Sticker.FileSize), false
+	}
+	return "", "", 0, false
+}
+
+// canRecognize reports whether an image of fileSize bytes can be sent to the OCR engine
+func (h *MessageHandler) canRecognize(fileSize int64) bool {
+	return h.ocr != nil && h.download != nil && fileSize <= ocr.MaxImageSize
+}
+
+// extractImageText downloads an image and returns the text OCR finds in it ("" on failure)
+func (h *MessageHandler) extractImageText(fileID string) string {
+	image, err := h.download(fileID)
+	if err != nil {
+		logger.Warn("Failed to download image for OCR (FileID=%s): %v", fileID, err)
+		return ""
+	}
+	
+	text, err := h.ocr.ExtractText(context.Background(), image)
+	if err != nil {
+		logger.Warn("OCR failed (FileID=%s): %v", fileID, err)
+		return ""
+	}
+	return text
+}
+
+// recognizeImage downloads an image and stores the OCR text on the message
+func (h *MessageHandler) recognizeImage(messageID int64, fileID string) {
+	text := h.extractImageText(fileID)
+	if text == "" {
+		return
+	}
+	
+	if err := h.database.SetMessageOCRText(messageID, text); err != nil {
+		logger.Error("Failed to save OCR text: %v", err)
+		return
 	}
 	
-	return !hasAlphaNum
+	logger.Info("🔍 OCR text saved (MessageID=%d): %q", messageID, truncateText(text, 50))
 }
 
 // truncateText truncates text to specified length
@@ -0,0 +1,44 @@
+package bot
+
+import (
+	"path/filepath"
+	"telegram-summarizer/internal/db"
+	"testing"
+	"time"
+	
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestSenderTrackedOncePerMessage(t *testing.T) {
+	store, err := db.Open(db.DriverSQLite, filepath.Join(t.TempDir(), "bot.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer store.Close()
+	handler := NewMessageHandler(store)
+	
+	message := func(messageID int, text string) *tgbotapi.Message {
+		return &tgbotapi.Message{
+			MessageID: messageID,
+			From:      &tgbotapi.User{ID: 42, UserName: "seller", FirstName: "Seller"},
+			Chat:      &tgbotapi.Chat{ID: db.ChannelChatID(1234).Int64(), Type: "supergroup", Title: "Group"},
+			Date:      int(time.Now().Unix()),
+			Text:      text,
+		}
+	}
+	
+	// The second copy of a message is not saved again, e.g. after the scraper saved it
+	for _, msg := range []*tgbotapi.Message{message(1, "Paket kuota 50GB murah"), message(1, "Paket kuota 50GB murah"), message(2, "ok")} {
+		if err := handler.ProcessMessage(msg); err != nil {
+			t.Fatalf("ProcessMessage: %v", err)
+		}
+	}
+	
+	user, err := store.GetUser(42)
+	if err != nil || user == nil {
+		t.Fatalf("GetUser: %+v, err %v", user, err)
+	}
+	if user.MessageCount != 1 {
+		t.Errorf("user has %d messages, want the saved message counted once", user.MessageCount)
+	}
+}
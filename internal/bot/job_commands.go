@@ -0,0 +1,175 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"telegram-summarizer/internal/db"
+	"telegram-summarizer/internal/logger"
+	"telegram-summarizer/internal/summarizer"
+	"telegram-summarizer/internal/timezone"
+	
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Number of jobs listed by /jobs
+const (
+	jobsOverviewLimit = 10
+	jobsListLimit     = 25
+)
+
+// jobStatuses are the job statuses in display order
+var jobStatuses = []string{db.JobPending, db.JobRunning, db.JobFailed, db.JobSucceeded}
+
+// jobStatusIcons are the icons of the job statuses
+var jobStatusIcons = map[string]string{
+	db.JobPending:   "⏳",
+	db.JobRunning:   "⚙️",
+	db.JobSucceeded: "✅",
+	db.JobFailed:    "❌",
+}
+
+// HandleJobs handles /jobs command - shows the summary job queue
+func (h *CommandHandler) HandleJobs(message *tgbotapi.Message, args []string) {
+	logger.Info("Handling /jobs command from user %d", message.From.ID)
+	
+	status, limit := "", jobsOverviewLimit
+	if len(args) > 0 {
+		status, limit = strings.ToLower(args[0]), jobsListLimit
+		if _, ok := jobStatusIcons[status]; !ok {
+			h.bot.sendMessage(message.Chat.ID, "❌ Usage: `/jobs [pending|running|failed|succeeded]`")
+			return
+		}
+	}
+	
+	counts, err := h.database.CountJobs()
+	if err != nil {
+		logger.Error("Failed to count jobs: %v", err)
+		h.bot.sendMessage(message.Chat.ID, "❌ Failed to get jobs. Check logs.")
+		return
+	}
+	jobs, err := h.database.GetJobs(status, limit)
+	if err != nil {
+		logger.Error("Failed to get jobs: %v", err)
+		h.bot.sendMessage(message.Chat.ID, "❌ Failed to get jobs. Check logs.")
+		return
+	}
+	
+	var response strings.Builder
+	response.WriteString("🔁 Summary jobs\n\n")
+	for _, s := range jobStatuses {
+		response.WriteString(fmt.Sprintf("%s %s: %d\n", jobStatusIcons[s], s, counts[s]))
+	}
+	
+	if len(jobs) == 0 {
+		response.WriteString("\nNo jobs to show.")
+		h.sendMessageWithoutHeader(message.Chat.ID, response.String())
+		return
+	}
+	
+	if status == "" {
+		response.WriteString("\nLatest jobs:\n")
+	} else {
+		response.WriteString(fmt.Sprintf("\nLatest %s jobs:\n", status))
+	}
+	for _, job := range jobs {
+		response.WriteString("\n" + formatJob(job))
+	}
+	if counts[db.JobFailed] > 0 {
+		response.WriteString("\nUse /retry <job_id> (or /retry all) to run failed jobs again.")
+	}
+	h.sendMessageWithoutHeader(message.Chat.ID, response.String())
+}
+
+// HandleRetry handles /retry command - queues failed jobs again
+func (h *CommandHandler) HandleRetry(message *tgbotapi.Message, args []string) {
+	logger.Info("Handling /retry command from user %d", message.From.ID)
+	
+	if len(args) != 1 {
+		h.bot.sendMessage(message.Chat.ID, "❌ Usage: `/retry <job_id>` or `/retry all`\n\nUse `/jobs failed` to see failed jobs.")
+		return
+	}
+	
+	if strings.EqualFold(args[0], "all") {
+		if !h.canManageGroup(message, 0, "") {
+			return
+		}
+		failed, err := h.database.GetJobs(db.JobFailed, 1000)
+		if err != nil {
+			logger.Error("Failed to get failed jobs: %v", err)
+			h.bot.sendMessage(message.Chat.ID, "❌ Failed to get jobs. Check logs.")
+			return
+		}
+		retried := 0
+		for _, job := range failed {
+			if ok, err := h.database.RetryJob(job.ID); err != nil {
+				logger.Error("Failed to retry job %d: %v", job.ID, err)
+			} else if ok {
+				retried++
+			}
+		}
+		h.sendMessageWithoutHeader(message.Chat.ID, fmt.Sprintf("🔁 Queued %d failed job(s) again.", retried))
+		return
+	}
+	
+	jobID, err := strconv.ParseInt(strings.TrimPrefix(args[0], "#"), 10, 64)
+	if err != nil {
+		h.bot.sendMessage(message.Chat.ID, "❌ Invalid job ID. Must be a number.")
+		return
+	}
+	job, err := h.database.GetJob(jobID)
+	switch {
+	case err != nil:
+		logger.Error("Failed to get job %d: %v", jobID, err)
+		h.bot.sendMessage(message.Chat.ID, "❌ Failed to retry job. Check logs.")
+		return
+	case job == nil:
+		h.sendMessageWithoutHeader(message.Chat.ID, fmt.Sprintf("❌ Job %d not found.", jobID))
+		return
+	}
+	if !h.canManageGroup(message, job.ChatID, jobGroupName(*job)) {
+		return
+	}
+	
+	retried, err := h.database.RetryJob(jobID)
+	if err != nil {
+		logger.Error("Failed to retry job %d: %v", jobID, err)
+		h.bot.sendMessage(message.Chat.ID, "❌ Failed to retry job. Check logs.")
+		return
+	}
+	if !retried {
+		h.sendMessageWithoutHeader(message.Chat.ID, fmt.Sprintf("ℹ️ Job %d is %s; only failed jobs can be retried.", jobID, job.Status))
+		return
+	}
+	h.sendMessageWithoutHeader(message.Chat.ID, fmt.Sprintf("🔁 Job %d queued again.", jobID))
+}
+
+// formatJob formats a job as a list entry, with times in the default timezone
+func formatJob(job db.SummaryJob) string {
+	name := jobGroupName(job)
+	loc := timezone.Default()
+	
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s #%d %s %s - %s\n", jobStatusIcons[job.Status], job.ID, job.Kind, job.SummaryType, name))
+	b.WriteString(fmt.Sprintf("   %s - %s, attempts %d/%d", job.WindowStart.In(loc).Format("2006-01-02 15:04"),
+		job.WindowEnd.In(loc).Format("15:04 MST"), job.Attempts, job.MaxAttempts))
+	if job.Status == db.JobPending && job.Attempts > 0 {
+		b.WriteString(fmt.Sprintf(", retry at %s", job.NextRunAt.In(loc).Format("15:04")))
+	}
+	b.WriteString("\n")
+	if job.LastError != "" && job.Status != db.JobSucceeded {
+		b.WriteString(fmt.Sprintf("   Error: %s\n", truncateText(job.LastError, 200)))
+	}
+	return b.String()
+}
+
+// jobGroupName returns the name of a job's group, or its chat ID if the group is unknown
+func jobGroupName(job db.SummaryJob) string {
+	switch {
+	case job.GroupName != "":
+		return job.GroupName
+	case job.Kind == db.JobDigest:
+		return summarizer.DigestName
+	}
+	return strconv.FormatInt(job.ChatID, 10)
+}
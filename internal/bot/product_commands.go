@@ -0,0 +1,99 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"telegram-summarizer/internal/logger"
+	
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// HandleAlias handles /alias command - maps a product name to a canonical product
+func (h *CommandHandler) HandleAlias(message *tgbotapi.Message, args []string) {
+	logger.Info("Handling /alias command from user %d", message.From.ID)
+	
+	// The catalog is shared by all groups, and aliases may merge products
+	if !h.canManageGroup(message, 0, "") {
+		return
+	}
+	
+	alias, canonical, ok := strings.Cut(strings.Join(args, " "), "=")
+	alias, canonical = strings.TrimSpace(alias), strings.TrimSpace(canonical)
+	if !ok || alias == "" || canonical == "" {
+		h.bot.sendMessage(message.Chat.ID, "❌ Usage: `/alias <alias> = <product name>`\n\nExample: `/alias vit c 1000 = Vitamin C 1000mg`\n\n"+
+			"If the alias is the name of another product, both products are merged.")
+		return
+	}
+	
+	product, err := h.catalog.SetAlias(alias, canonical)
+	if err != nil {
+		logger.Error("Failed to set product alias: %v", err)
+		h.sendMessageWithoutHeader(message.Chat.ID, fmt.Sprintf("❌ Failed to set alias: %v", err))
+		return
+	}
+	
+	h.sendMessageWithoutHeader(message.Chat.ID, fmt.Sprintf("✅ \"%s\" now refers to %s", alias, product.Name))
+}
+
+// HandleUnalias handles /unalias command - removes a product alias
+func (h *CommandHandler) HandleUnalias(message *tgbotapi.Message, args []string) {
+	logger.Info("Handling /unalias command from user %d", message.From.ID)
+	
+	if !h.canManageGroup(message, 0, "") {
+		return
+	}
+	
+	alias := strings.TrimSpace(strings.Join(args, " "))
+	if alias == "" {
+		h.bot.sendMessage(message.Chat.ID, "❌ Usage: `/unalias <alias>`")
+		return
+	}
+	
+	removed, err := h.catalog.RemoveAlias(alias)
+	if err != nil {
+		logger.Error("Failed to remove product alias: %v", err)
+		h.sendMessageWithoutHeader(message.Chat.ID, fmt.Sprintf("❌ Failed to remove alias: %v", err))
+		return
+	}
+	if !removed {
+		h.sendMessageWithoutHeader(message.Chat.ID, fmt.Sprintf("❌ Alias \"%s\" not found.", alias))
+		return
+	}
+	
+	h.sendMessageWithoutHeader(message.Chat.ID, fmt.Sprintf("✅ Alias \"%s\" removed", alias))
+}
+
+// HandleAliases handles /aliases command - lists the aliases of a product
+func (h *CommandHandler) HandleAliases(message *tgbotapi.Message, args []string) {
+	logger.Info("Handling /aliases command from user %d", message.From.ID)
+	
+	name := strings.TrimSpace(strings.Join(args, " "))
+	if name == "" {
+		h.bot.sendMessage(message.Chat.ID, "❌ Usage: `/aliases <product name>`")
+		return
+	}
+	
+	product, aliases, err := h.catalog.Aliases(name)
+	if err != nil {
+		logger.Error("Failed to get product aliases: %v", err)
+		h.bot.sendMessage(message.Chat.ID, "❌ Failed to get aliases. Check logs.")
+		return
+	}
+	if product == nil {
+		h.sendMessageWithoutHeader(message.Chat.ID, fmt.Sprintf("❌ Product \"%s\" not found in the catalog.", name))
+		return
+	}
+	
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("🏷️ %s (ID %d)\n\n", product.Name, product.ID))
+	b.WriteString(fmt.Sprintf("Aliases (%d):\n", len(aliases)))
+	for _, a := range aliases {
+		marker := ""
+		if a.AliasKey == product.NameKey {
+			marker = " (canonical)"
+		}
+		b.WriteString(fmt.Sprintf("• %s%s\n", a.Alias, marker))
+	}
+	
+	h.sendMessageWithoutHeader(message.Chat.ID, b.String())
+}
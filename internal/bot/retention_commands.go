@@ -0,0 +1,139 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"telegram-summarizer/internal/archive"
+	"telegram-summarizer/internal/logger"
+	"telegram-summarizer/internal/timezone"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// describePolicy formats a retention policy for display
+func describePolicy(policy archive.Policy) string {
+	keep := fmt.Sprintf("%d day(s)", policy.RetentionDays)
+	if policy.RetentionDays < 0 {
+		keep = "forever"
+	}
+	return fmt.Sprintf("Keep: %s\nArchive: %s", keep, policy.Mode)
+}
+
+// HandleRetention handles /retention command - shows or changes a group's retention policy
+func (h *CommandHandler) HandleRetention(message *tgbotapi.Message, args []string) {
+	logger.Info("Handling /retention command from user %d", message.From.ID)
+
+	if len(args) < 1 {
+		h.bot.sendMessage(message.Chat.ID, "❌ Usage: `/retention <chat_id> [days|forever|default] [none|jsonl|db|default]`\n\n"+
+			"Example: `/retention -1001234567890 30 jsonl`\n\n"+
+			"Expired messages are archived (jsonl/db) before deletion.")
+		return
+	}
+
+	group := h.manageableGroup(message, args[0])
+	if group == nil {
+		return
+	}
+
+	// Show current policy
+	if len(args) == 1 {
+		defaults := h.retention.Defaults()
+		h.sendMessageWithoutHeader(message.Chat.ID, fmt.Sprintf("🗄️ Retention for %s\n\n%s\n\nDefault: %s",
+			group.GroupName, describePolicy(h.retention.PolicyFor(*group)),
+			strings.ReplaceAll(describePolicy(defaults), "\n", ", ")))
+		return
+	}
+	if !h.canManageGroup(message, group.ChatID, group.GroupName) {
+		return
+	}
+
+	retentionDays := group.RetentionDays
+	switch strings.ToLower(args[1]) {
+	case "default":
+		retentionDays = 0
+	case "forever":
+		retentionDays = archive.KeepForever
+	default:
+		days, err := strconv.Atoi(args[1])
+		if err != nil || days <= 0 {
+			h.bot.sendMessage(message.Chat.ID, "❌ Days must be a positive number, `forever` or `default`.")
+			return
+		}
+		retentionDays = days
+	}
+
+	archiveMode := group.ArchiveMode
+	if len(args) > 2 {
+		archiveMode = strings.ToLower(args[2])
+		if archiveMode == "default" {
+			archiveMode = ""
+		} else if err := archive.ValidateMode(archiveMode); err != nil {
+			h.sendMessageWithoutHeader(message.Chat.ID, fmt.Sprintf("❌ %v", err))
+			return
+		}
+	}
+
+	if err := h.database.SetGroupRetention(group.ChatID, retentionDays, archiveMode); err != nil {
+		logger.Error("Failed to set retention: %v", err)
+		h.bot.sendMessage(message.Chat.ID, "❌ Failed to update retention. Check logs.")
+		return
+	}
+
+	group.RetentionDays = retentionDays
+	group.ArchiveMode = archiveMode
+	h.sendMessageWithoutHeader(message.Chat.ID, fmt.Sprintf("✅ Retention updated for %s\n\n%s\n\nApplied after the next daily summary.",
+		group.GroupName, describePolicy(h.retention.PolicyFor(*group))))
+}
+
+// HandleRestore handles /restore command - restores archived messages and re-summarizes them
+func (h *CommandHandler) HandleRestore(message *tgbotapi.Message, args []string) {
+	logger.Info("Handling /restore command from user %d", message.From.ID)
+
+	if len(args) < 2 {
+		h.bot.sendMessage(message.Chat.ID, "❌ Usage: `/restore <chat_id> <YYYY-MM-DD> [YYYY-MM-DD]`\n\n"+
+			"Example: `/restore -1001234567890 2025-01-10`\n\n"+
+			"Restores archived messages of the given day(s) and generates a summary for them.")
+		return
+	}
+
+	group := h.manageableGroup(message, args[0])
+	if group == nil || !h.canManageGroup(message, group.ChatID, group.GroupName) {
+		return
+	}
+
+	// Days are those of the group's timezone
+	loc := timezone.Of(*group)
+	startDay, err := time.ParseInLocation("2006-01-02", args[1], loc)
+	if err != nil {
+		h.bot.sendMessage(message.Chat.ID, "❌ Invalid date. Use format `YYYY-MM-DD`.")
+		return
+	}
+	endDay := startDay
+	if len(args) > 2 {
+		if endDay, err = time.ParseInLocation("2006-01-02", args[2], loc); err != nil || endDay.Before(startDay) {
+			h.bot.sendMessage(message.Chat.ID, "❌ Invalid end date. Use format `YYYY-MM-DD` (not before the start date).")
+			return
+		}
+	}
+
+	startTime := startDay
+	endTime := endDay.AddDate(0, 0, 1).Add(-time.Second)
+
+	h.bot.sendMessage(message.Chat.ID, fmt.Sprintf("♻️ Restoring archived messages for *%s* (%s - %s)...",
+		escapeMarkdown(group.GroupName), startDay.Format("2006-01-02"), endDay.Format("2006-01-02")))
+
+	restored, err := h.retention.Restore(group.ChatID, startTime, endTime)
+	if err != nil {
+		logger.Error("Failed to restore messages: %v", err)
+		h.sendMessageWithoutHeader(message.Chat.ID, fmt.Sprintf("❌ Failed to restore messages: %v", err))
+		return
+	}
+
+	h.bot.sendMessage(message.Chat.ID, fmt.Sprintf("✅ Restored %d messages. Generating summary...\n\n"+
+		"Restored messages are removed again by the next retention run.", restored))
+
+	h.summarizeWindow(message, group, startTime, endTime, "manual-restore",
+		fmt.Sprintf("📭 No messages found for *%s* in this period (nothing archived).", escapeMarkdown(group.GroupName)))
+}
@@ -0,0 +1,61 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"telegram-summarizer/internal/logger"
+	"telegram-summarizer/internal/summarizer"
+	"telegram-summarizer/internal/timezone"
+	"time"
+	
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// HandleWeekly handles /weekly command - shows a group's latest weekly rollup
+func (h *CommandHandler) HandleWeekly(message *tgbotapi.Message, args []string) {
+	logger.Info("Handling /weekly command from user %d", message.From.ID)
+	h.sendRollup(message, args, summarizer.PromptTypeWeekly)
+}
+
+// HandleMonthly handles /monthly command - shows a group's latest monthly rollup
+func (h *CommandHandler) HandleMonthly(message *tgbotapi.Message, args []string) {
+	logger.Info("Handling /monthly command from user %d", message.From.ID)
+	h.sendRollup(message, args, summarizer.PromptTypeMonthly)
+}
+
+// sendRollup sends the latest stored rollup of a type, or when the next one is due
+func (h *CommandHandler) sendRollup(message *tgbotapi.Message, args []string, rollupType string) {
+	if len(args) != 1 {
+		h.bot.sendMessage(message.Chat.ID, fmt.Sprintf("❌ Usage: `/%s <chat_id>`\n\nExample: `/%s -1001234567890`", rollupType, rollupType))
+		return
+	}
+	
+	group := h.manageableGroup(message, args[0])
+	if group == nil {
+		return
+	}
+	
+	summaries, err := h.database.GetSummaries(group.ChatID, rollupType, 1)
+	if err != nil {
+		logger.Error("Failed to get %s summaries: %v", rollupType, err)
+		h.bot.sendMessage(message.Chat.ID, "❌ Failed to get summaries. Check logs.")
+		return
+	}
+	
+	loc := timezone.Of(*group)
+	if len(summaries) == 0 {
+		_, end := summarizer.RollupWindow(rollupType, time.Now().In(loc))
+		h.sendMessageWithoutHeader(message.Chat.ID, fmt.Sprintf("ℹ️ No %s summary for %s yet.\n\nThe current period is summarized after the daily summary of %s.",
+			rollupType, group.GroupName, end.AddDate(0, 0, -1).Format("Mon 2006-01-02")))
+		return
+	}
+	
+	summary := summaries[0]
+	var response strings.Builder
+	response.WriteString(fmt.Sprintf("📆 %s Summary for %s\n\n", strings.ToUpper(rollupType[:1])+rollupType[1:], group.GroupName))
+	response.WriteString(fmt.Sprintf("📅 Period: %s - %s (%s)\n", summary.PeriodStart.In(loc).Format("2006-01-02"),
+		summary.PeriodEnd.In(loc).AddDate(0, 0, -1).Format("2006-01-02"), timezone.Name(loc)))
+	response.WriteString(fmt.Sprintf("💬 Messages: %d\n\n", summary.MessageCount))
+	response.WriteString(summary.SummaryText)
+	h.sendMessageWithoutHeader(message.Chat.ID, response.String())
+}
@@ -0,0 +1,153 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"telegram-summarizer/internal/cron"
+	"telegram-summarizer/internal/db"
+	"telegram-summarizer/internal/logger"
+	"telegram-summarizer/internal/summarizer"
+	"telegram-summarizer/internal/timezone"
+	"time"
+	
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// scheduleUsage is the usage of the /schedule command
+const scheduleUsage = "❌ Usage:\n" +
+	"`/schedule` - List all schedules\n" +
+	"`/schedule <chat_id>` - List a group's schedules\n" +
+	"`/schedule <chat_id> add <1h|4h|24h|daily|weekly|monthly> <cron>` - Add a schedule\n" +
+	"`/schedule <chat_id> remove <id>` - Remove a schedule\n\n" +
+	"Example: `/schedule -1001234567890 add 4h 0 */4 * * *`\n\n" +
+	"Groups with schedules only get their scheduled summaries."
+
+// HandleSchedule handles /schedule command - lists, adds or removes a group's cron summary schedules
+func (h *CommandHandler) HandleSchedule(message *tgbotapi.Message, args []string) {
+	logger.Info("Handling /schedule command from user %d", message.From.ID)
+	
+	if len(args) == 0 {
+		h.sendSchedules(message.Chat.ID, 0)
+		return
+	}
+	
+	group := h.manageableGroup(message, args[0])
+	if group == nil {
+		return
+	}
+	if len(args) == 1 {
+		h.sendSchedules(message.Chat.ID, group.ChatID)
+		return
+	}
+	if !h.canManageGroup(message, group.ChatID, group.GroupName) {
+		return
+	}
+	
+	switch strings.ToLower(args[1]) {
+	case "add":
+		if len(args) < 4 {
+			h.bot.sendMessage(message.Chat.ID, scheduleUsage)
+			return
+		}
+		h.addSchedule(message, group, strings.ToLower(args[2]), strings.Join(args[3:], " "))
+	case "remove", "rm", "delete":
+		if len(args) != 3 {
+			h.bot.sendMessage(message.Chat.ID, scheduleUsage)
+			return
+		}
+		scheduleID, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			h.bot.sendMessage(message.Chat.ID, "❌ Invalid schedule ID. Must be a number.")
+			return
+		}
+		removed, err := h.database.DeleteSummarySchedule(group.ChatID, scheduleID)
+		if err != nil {
+			logger.Error("Failed to delete summary schedule: %v", err)
+			h.bot.sendMessage(message.Chat.ID, "❌ Failed to remove schedule. Check logs.")
+			return
+		}
+		if !removed {
+			h.bot.sendMessage(message.Chat.ID, fmt.Sprintf("ℹ️ Schedule %d not found for `%d`.", scheduleID, group.ChatID))
+			return
+		}
+		h.sendMessageWithoutHeader(message.Chat.ID, fmt.Sprintf("✅ Removed schedule %d of %s.", scheduleID, group.GroupName))
+	default:
+		h.bot.sendMessage(message.Chat.ID, scheduleUsage)
+	}
+}
+
+// addSchedule validates and saves a new schedule for a group
+func (h *CommandHandler) addSchedule(message *tgbotapi.Message, group *db.TrackedGroup, promptType, expr string) {
+	if !summarizer.ValidPromptType(promptType) {
+		h.bot.sendMessage(message.Chat.ID, fmt.Sprintf("❌ Unknown prompt type `%s`. Use one of: %s",
+			promptType, strings.Join(summarizer.PromptTypes, ", ")))
+		return
+	}
+	parsed, err := cron.Parse(expr)
+	if err != nil {
+		h.sendMessageWithoutHeader(message.Chat.ID, fmt.Sprintf("❌ %v", err))
+		return
+	}
+	next := parsed.Next(time.Now().In(timezone.Of(*group)))
+	if next.IsZero() {
+		h.sendMessageWithoutHeader(message.Chat.ID, fmt.Sprintf("❌ Cron expression %q never runs.", expr))
+		return
+	}
+	
+	schedule := &db.SummarySchedule{
+		ChatID:     group.ChatID,
+		CronExpr:   parsed.String(),
+		PromptType: promptType,
+		CreatedBy:  message.From.ID,
+		CreatedAt:  time.Now(),
+	}
+	if err := h.database.AddSummarySchedule(schedule); err != nil {
+		logger.Error("Failed to add summary schedule: %v", err)
+		h.bot.sendMessage(message.Chat.ID, "❌ Failed to add schedule. Check logs.")
+		return
+	}
+	
+	response := fmt.Sprintf("✅ Schedule %d added for %s\n\nCron: %s\nPrompt: %s\nNext run: %s",
+		schedule.ID, group.GroupName, schedule.CronExpr, promptType, next.Format("2006-01-02 15:04 MST"))
+	if group.IsActive != 1 {
+		response += fmt.Sprintf("\n\n⚠️ Summaries are disabled for this group. Enable them with /enable %d", group.ChatID)
+	}
+	h.sendMessageWithoutHeader(message.Chat.ID, response)
+}
+
+// sendSchedules lists the schedules of a group (all groups if chatID is 0) with their next run
+func (h *CommandHandler) sendSchedules(replyChatID, chatID int64) {
+	schedules, err := h.database.GetSummarySchedules(chatID)
+	if err != nil {
+		logger.Error("Failed to get summary schedules: %v", err)
+		h.bot.sendMessage(replyChatID, "❌ Failed to get schedules. Check logs.")
+		return
+	}
+	if len(schedules) == 0 {
+		h.bot.sendMessage(replyChatID, "📭 No schedules. Groups use the default hourly and daily summaries.\n\n"+
+			"Usage: `/schedule <chat_id> add <1h|4h|24h|daily|weekly|monthly> <cron>`")
+		return
+	}
+	
+	var response strings.Builder
+	response.WriteString(fmt.Sprintf("🗓️ Summary schedules (%d)\n", len(schedules)))
+	lastChatID := int64(0)
+	for _, schedule := range schedules {
+		if schedule.ChatID != lastChatID {
+			name := schedule.GroupName
+			if name == "" {
+				name = "Unknown group"
+			}
+			response.WriteString(fmt.Sprintf("\n%s (%d)\n", name, schedule.ChatID))
+			lastChatID = schedule.ChatID
+		}
+	
+		next := "invalid expression"
+		if parsed, err := cron.Parse(schedule.CronExpr); err == nil {
+			next = "next " + parsed.Next(time.Now().In(h.groupLocation(schedule.ChatID))).Format("2006-01-02 15:04 MST")
+		}
+		response.WriteString(fmt.Sprintf("  #%d  %s  [%s]  %s\n", schedule.ID, schedule.CronExpr, schedule.PromptType, next))
+	}
+	h.sendMessageWithoutHeader(replyChatID, response.String())
+}
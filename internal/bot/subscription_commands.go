@@ -0,0 +1,203 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"telegram-summarizer/internal/db"
+	"telegram-summarizer/internal/logger"
+	"telegram-summarizer/internal/summarizer"
+	"time"
+	
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// HandleSubscribe handles /subscribe command - sends a group's summaries (or the
+// cross-group digest) to this chat, or lists this chat's subscriptions without arguments
+func (h *CommandHandler) HandleSubscribe(message *tgbotapi.Message, args []string) {
+	logger.Info("Handling /subscribe command from user %d", message.From.ID)
+	
+	if len(args) == 0 {
+		h.sendSubscriptions(message.Chat.ID)
+		return
+	}
+	
+	cadence := db.CadenceDaily
+	if len(args) > 1 {
+		var ok bool
+		if cadence, ok = parseCadence(args[1]); !ok {
+			h.bot.sendMessage(message.Chat.ID, "❌ Usage: `/subscribe <chat_id|digest> [hourly|daily]`\n\nExample: `/subscribe -1001234567890 daily`")
+			return
+		}
+	}
+	
+	if !h.canManageSubscriptions(message) {
+		return
+	}
+	group := h.subscriptionGroup(message, args[0])
+	if group == nil || !h.canReadGroup(message, group) {
+		return
+	}
+	if group.ChatID == db.DigestChatID && cadence != db.CadenceDaily {
+		h.bot.sendMessage(message.Chat.ID, "❌ The cross-group digest is only sent daily.")
+		return
+	}
+	
+	added, err := h.database.AddSubscription(&db.Subscription{
+		GroupChatID:  group.ChatID,
+		TargetChatID: message.Chat.ID,
+		Cadence:      cadence,
+		CreatedBy:    message.From.ID,
+		CreatedAt:    time.Now(),
+	})
+	if err != nil {
+		logger.Error("Failed to add subscription: %v", err)
+		h.bot.sendMessage(message.Chat.ID, "❌ Failed to subscribe. Check logs.")
+		return
+	}
+	if !added {
+		h.sendMessageWithoutHeader(message.Chat.ID, fmt.Sprintf("ℹ️ This chat already receives the %s summaries of %s.", cadence, group.GroupName))
+		return
+	}
+	
+	response := fmt.Sprintf("✅ This chat now receives the %s summaries of %s.", cadence, group.GroupName)
+	if group.IsActive != 1 {
+		response += fmt.Sprintf("\n\n⚠️ Summaries are disabled for this group. Enable them with /enable %d", group.ChatID)
+	}
+	h.sendMessageWithoutHeader(message.Chat.ID, response)
+}
+
+// HandleUnsubscribe handles /unsubscribe command - stops sending a group's summaries to this chat
+func (h *CommandHandler) HandleUnsubscribe(message *tgbotapi.Message, args []string) {
+	logger.Info("Handling /unsubscribe command from user %d", message.From.ID)
+	
+	cadence := "" // All cadences
+	if len(args) > 1 {
+		var ok bool
+		if cadence, ok = parseCadence(args[1]); !ok {
+			args = nil
+		}
+	}
+	if len(args) == 0 {
+		h.bot.sendMessage(message.Chat.ID, "❌ Usage: `/unsubscribe <chat_id|digest> [hourly|daily]`\n\nWithout a cadence both are removed. Use /subscribe to list subscriptions.")
+		return
+	}
+	
+	if !h.canManageSubscriptions(message) {
+		return
+	}
+	var resolvedID db.ChatID
+	if !strings.EqualFold(args[0], "digest") {
+		var err error
+		if resolvedID, err = h.database.ResolveChatID(args[0]); err != nil {
+			h.bot.sendMessage(message.Chat.ID, "❌ Invalid chat ID. Must be a number.")
+			return
+		}
+	}
+	
+	removed, err := h.database.RemoveSubscriptions(resolvedID.Int64(), message.Chat.ID, cadence)
+	if err != nil {
+		logger.Error("Failed to remove subscriptions: %v", err)
+		h.bot.sendMessage(message.Chat.ID, "❌ Failed to unsubscribe. Check logs.")
+		return
+	}
+	if removed == 0 {
+		h.bot.sendMessage(message.Chat.ID, fmt.Sprintf("ℹ️ This chat is not subscribed to `%d`.", resolvedID.Int64()))
+		return
+	}
+	h.bot.sendMessage(message.Chat.ID, fmt.Sprintf("✅ Removed %d subscription(s) to `%d`.", removed, resolvedID.Int64()))
+}
+
+// sendSubscriptions lists the groups whose summaries a chat receives
+func (h *CommandHandler) sendSubscriptions(chatID int64) {
+	subs, err := h.database.GetSubscriptionsByTarget(chatID)
+	if err != nil {
+		logger.Error("Failed to get subscriptions: %v", err)
+		h.bot.sendMessage(chatID, "❌ Failed to get subscriptions. Check logs.")
+		return
+	}
+	if len(subs) == 0 {
+		h.bot.sendMessage(chatID, "📭 This chat has no subscriptions.\n\nUsage: `/subscribe <chat_id|digest> [hourly|daily]`")
+		return
+	}
+	
+	var response strings.Builder
+	response.WriteString(fmt.Sprintf("📬 *Subscriptions of this chat* (%d)\n\n", len(subs)))
+	for _, sub := range subs {
+		if sub.GroupChatID == db.DigestChatID {
+			response.WriteString(fmt.Sprintf("• %s `digest`: %s\n", summarizer.DigestName, sub.Cadence))
+			continue
+		}
+		name := sub.GroupName
+		if name == "" {
+			name = "Unknown group"
+		}
+		response.WriteString(fmt.Sprintf("• %s `%d`: %s\n", escapeMarkdownV1(name), sub.GroupChatID, sub.Cadence))
+	}
+	response.WriteString("\nUse `/unsubscribe <chat_id|digest> [hourly|daily]` to stop")
+	h.bot.sendMessage(chatID, response.String())
+}
+
+// subscriptionGroup resolves the group of a subscription: a tracked group by chat ID,
+// or the cross-group digest for "digest". Replies if the group is not found.
+func (h *CommandHandler) subscriptionGroup(message *tgbotapi.Message, arg string) *db.TrackedGroup {
+	if strings.EqualFold(arg, "digest") {
+		return &db.TrackedGroup{ChatID: db.DigestChatID, GroupName: summarizer.DigestName, IsActive: 1}
+	}
+	return h.manageableGroup(message, arg)
+}
+
+// canManageSubscriptions reports whether the sender may change the subscriptions of
+// the chat: anyone in a private chat, administrators in groups. An administrator list
+// that can't be fetched refuses everyone. Replies on refusal.
+func (h *CommandHandler) canManageSubscriptions(message *tgbotapi.Message) bool {
+	if message.Chat.IsPrivate() {
+		return true
+	}
+	
+	isAdmin, known := h.bot.IsChatAdmin(message.Chat.ID, message.From.ID)
+	switch {
+	case !known:
+		h.bot.sendMessage(message.Chat.ID, "❌ Could not check the administrators of this chat. Try again later.")
+		return false
+	case !isAdmin:
+		h.bot.sendMessage(message.Chat.ID, "❌ Only group administrators can change the subscriptions of this chat.")
+		return false
+	}
+	return true
+}
+
+// canReadGroup reports whether the sender may have the summaries of a group sent to
+// the chat: members and administrators of the group, and the owner for any group and
+// the digest. A membership that can't be checked counts as none. Replies on refusal.
+func (h *CommandHandler) canReadGroup(message *tgbotapi.Message, group *db.TrackedGroup) bool {
+	if h.ownerID != 0 && message.From.ID == h.ownerID {
+		return true
+	}
+	if group.ChatID == db.DigestChatID {
+		h.bot.sendMessage(message.Chat.ID, "❌ Only the owner of the bot can subscribe to the cross-group digest.")
+		return false
+	}
+	
+	isMember, known := h.bot.IsChatMember(group.ChatID, message.From.ID)
+	switch {
+	case !known:
+		h.sendMessageWithoutHeader(message.Chat.ID, fmt.Sprintf("❌ Could not check your membership of %s. "+
+			"Only its members can subscribe to its summaries.", group.GroupName))
+		return false
+	case !isMember:
+		h.sendMessageWithoutHeader(message.Chat.ID, fmt.Sprintf("❌ Only members of %s can subscribe to its summaries.", group.GroupName))
+		return false
+	}
+	return true
+}
+
+// parseCadence parses a summary cadence argument
+func parseCadence(arg string) (string, bool) {
+	switch strings.ToLower(arg) {
+	case "hourly", "1h", "hour":
+		return db.CadenceHourly, true
+	case "daily", "day", "24h":
+		return db.CadenceDaily, true
+	}
+	return "", false
+}
@@ -0,0 +1,82 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"telegram-summarizer/internal/db"
+	"telegram-summarizer/internal/logger"
+	"telegram-summarizer/internal/timezone"
+	"time"
+	
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// timezoneUsage is the usage of /timezone
+const timezoneUsage = "❌ Usage: `/timezone <chat_id> [zone|default]`\n\n" +
+	"Example: `/timezone -1001234567890 Asia/Jakarta`\n\n" +
+	"Zones are IANA names. Hourly windows, daily summaries, schedules and times in summaries use the group's timezone."
+
+// HandleTimezone handles /timezone command - shows or changes a group's timezone
+func (h *CommandHandler) HandleTimezone(message *tgbotapi.Message, args []string) {
+	logger.Info("Handling /timezone command from user %d", message.From.ID)
+	
+	if len(args) < 1 || len(args) > 2 {
+		h.bot.sendMessage(message.Chat.ID, timezoneUsage)
+		return
+	}
+	
+	group := h.manageableGroup(message, args[0])
+	if group == nil {
+		return
+	}
+	
+	// Show current timezone
+	if len(args) == 1 {
+		h.sendMessageWithoutHeader(message.Chat.ID, fmt.Sprintf("🕐 Timezone of %s\n\n%s\n\nDefault: %s",
+			group.GroupName, describeTimezone(*group), timezone.Name(timezone.Default())))
+		return
+	}
+	if !h.canManageGroup(message, group.ChatID, group.GroupName) {
+		return
+	}
+	
+	name := args[1]
+	if strings.EqualFold(name, "default") {
+		name = ""
+	} else {
+		loc, err := timezone.Load(name)
+		if err != nil {
+			h.bot.sendMessage(message.Chat.ID, fmt.Sprintf("❌ Unknown timezone `%s`. Use an IANA name such as `Asia/Jakarta`.", name))
+			return
+		}
+		name = loc.String()
+	}
+	
+	if err := h.database.SetGroupTimezone(group.ChatID, name); err != nil {
+		logger.Error("Failed to set group timezone: %v", err)
+		h.bot.sendMessage(message.Chat.ID, "❌ Failed to update timezone. Check logs.")
+		return
+	}
+	
+	group.Timezone = name
+	h.sendMessageWithoutHeader(message.Chat.ID, fmt.Sprintf("✅ Timezone updated for %s\n\n%s\n\nApplied from the next hourly window.",
+		group.GroupName, describeTimezone(*group)))
+}
+
+// describeTimezone formats the timezone of a group and its current time for display
+func describeTimezone(group db.TrackedGroup) string {
+	loc := timezone.Of(group)
+	name := timezone.Name(loc)
+	if group.Timezone == "" {
+		name += " (default)"
+	}
+	return fmt.Sprintf("Zone: %s\nLocal time: %s", name, time.Now().In(loc).Format("2006-01-02 15:04 MST"))
+}
+
+// groupLocation returns the timezone of a group by chat ID
+func (h *CommandHandler) groupLocation(chatID int64) *time.Location {
+	if group := h.database.GetTrackedGroup(chatID); group != nil {
+		return timezone.Of(*group)
+	}
+	return timezone.Default()
+}
@@ -0,0 +1,398 @@
+package bot
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"telegram-summarizer/internal/analytics"
+	"telegram-summarizer/internal/db"
+	"telegram-summarizer/internal/logger"
+	"telegram-summarizer/internal/summarizer"
+	"time"
+	
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	defaultTrendDays  = 7
+	maxTrendDays      = 90
+	trendsPerPage     = 10
+	productReportDays = 30 // Period of /product and /compare
+	productPageLines  = 15 // Groups, prices, codes and red flags listed per /product page
+)
+
+// productPages are the pages of a /product report
+var productPages = []string{"Overview", "Groups", "Prices", "FamilyCodes & Red Flags"}
+
+// HandleTrends handles /trends command - top products by mentions with deltas to the previous period
+func (h *CommandHandler) HandleTrends(message *tgbotapi.Message, args []string) {
+	logger.Info("Handling /trends command from user %d", message.From.ID)
+	
+	days := defaultTrendDays
+	if len(args) > 0 {
+		d, err := strconv.Atoi(strings.TrimSuffix(args[0], "d"))
+		if err != nil || d < 1 || d > maxTrendDays {
+			h.bot.sendMessage(message.Chat.ID, fmt.Sprintf("❌ Usage: `/trends [days]` (1-%d, default %d)", maxTrendDays, defaultTrendDays))
+			return
+		}
+		days = d
+	}
+	
+	text, keyboard, hasKeyboard := h.buildTrendsResponse(1, days)
+	if hasKeyboard {
+		h.bot.sendMessageWithKeyboard(message.Chat.ID, text, keyboard)
+	} else {
+		h.bot.sendMessage(message.Chat.ID, text)
+	}
+}
+
+// HandleTrendsEdit handles /trends pagination by editing the existing message
+func (h *CommandHandler) HandleTrendsEdit(message *tgbotapi.Message, page, days int) {
+	text, keyboard, hasKeyboard := h.buildTrendsResponse(page, days)
+	if hasKeyboard {
+		h.bot.editMessageWithKeyboard(message.Chat.ID, message.MessageID, text, keyboard)
+	} else {
+		h.bot.sendMessage(message.Chat.ID, text)
+	}
+}
+
+// buildTrendsResponse builds the response text and keyboard for /trends
+func (h *CommandHandler) buildTrendsResponse(page, days int) (string, tgbotapi.InlineKeyboardMarkup, bool) {
+	logger.Info("Building /trends response for page %d (%d days)", page, days)
+	
+	end := time.Now()
+	start := end.AddDate(0, 0, -days)
+	current, err := h.database.GetProductActivity(0, summarizer.PromptTypeDaily, start, end)
+	if err != nil {
+		logger.Error("Failed to get product activity: %v", err)
+		return "❌ Failed to get product trends. Check logs.", tgbotapi.InlineKeyboardMarkup{}, false
+	}
+	previous, err := h.database.GetProductActivity(0, summarizer.PromptTypeDaily, start.AddDate(0, 0, -days), start)
+	if err != nil {
+		logger.Error("Failed to get product activity: %v", err)
+		return "❌ Failed to get product trends. Check logs.", tgbotapi.InlineKeyboardMarkup{}, false
+	}
+	
+	trends := analytics.Trends(current, previous, start, days+1)
+	if len(trends) == 0 {
+		return fmt.Sprintf("📈 No product mentions in the last %d days.", days), tgbotapi.InlineKeyboardMarkup{}, false
+	}
+	
+	totalPages := (len(trends) + trendsPerPage - 1) / trendsPerPage
+	page = max(1, min(page, totalPages))
+	startIdx := (page - 1) * trendsPerPage
+	endIdx := min(startIdx+trendsPerPage, len(trends))
+	
+	var response strings.Builder
+	response.WriteString(fmt.Sprintf("📈 *Product Trends* (last %d days, page %d/%d)\n", days, page, totalPages))
+	response.WriteString(fmt.Sprintf("Changes compared to the %d days before\n\n", days))
+	
+	for i, t := range trends[startIdx:endIdx] {
+		response.WriteString(fmt.Sprintf("%d. %s\n", startIdx+i+1, escapeMarkdownV1(t.Name)))
+		if t.New {
+			response.WriteString(fmt.Sprintf("   %d mentions 🆕 in %d groups\n", t.Mentions, t.Groups))
+		} else {
+			response.WriteString(fmt.Sprintf("   %d mentions (%s) in %d groups\n", t.Mentions, formatIntDelta(t.MentionsDelta), t.Groups))
+		}
+		response.WriteString(fmt.Sprintf("   %s\n", analytics.Sparkline(t.Daily)))
+		response.WriteString(fmt.Sprintf("   Sentiment %+.2f", t.Sentiment))
+		if !t.New {
+			response.WriteString(fmt.Sprintf(" (%s)", formatFloatDelta(t.SentimentDelta)))
+		}
+		response.WriteString(fmt.Sprintf(" · Credibility %.1f/5", t.Credibility))
+		if !t.New {
+			response.WriteString(fmt.Sprintf(" (%s)", formatFloatDelta(t.CredibilityDelta)))
+		}
+		response.WriteString("\n")
+		if t.Suspicious > 0 {
+			response.WriteString(fmt.Sprintf("   ⚠️ %d suspicious mentions\n", t.Suspicious))
+		}
+		response.WriteString("\n")
+	}
+	response.WriteString("Use /product <name> for details")
+	
+	if totalPages > 1 {
+		return response.String(), paginationKeyboard("trends", page, totalPages, strconv.Itoa(days)), true
+	}
+	return response.String(), tgbotapi.InlineKeyboardMarkup{}, false
+}
+
+// HandleProduct handles /product command - mention timeline, groups, prices, FamilyCodes and red flags of a product
+func (h *CommandHandler) HandleProduct(message *tgbotapi.Message, args []string) {
+	logger.Info("Handling /product command from user %d", message.From.ID)
+	
+	name := strings.TrimSpace(strings.Join(args, " "))
+	if name == "" {
+		h.bot.sendMessage(message.Chat.ID, "❌ Usage: `/product <name>`\n\nExample: `/product Xtra Combo`")
+		return
+	}
+	
+	product, err := h.catalog.Lookup(name)
+	if err != nil {
+		logger.Error("Failed to look up product: %v", err)
+		h.bot.sendMessage(message.Chat.ID, "❌ Failed to look up product. Check logs.")
+		return
+	}
+	if product == nil {
+		h.sendMessageWithoutHeader(message.Chat.ID, fmt.Sprintf("❌ Product \"%s\" not found in the catalog.\n\nUse /trends to see mentioned products.", name))
+		return
+	}
+	
+	text, keyboard := h.buildProductResponse(product, 1)
+	h.bot.sendMessageWithKeyboard(message.Chat.ID, text, keyboard)
+}
+
+// HandleProductEdit handles /product pagination by editing the existing message
+func (h *CommandHandler) HandleProductEdit(message *tgbotapi.Message, page int, productID int64) {
+	product, err := h.database.GetProduct(productID)
+	if err != nil || product == nil {
+		logger.Error("Failed to get product %d: %v", productID, err)
+		h.bot.sendMessage(message.Chat.ID, "❌ Product not found. It may have been merged into another product.")
+		return
+	}
+	
+	text, keyboard := h.buildProductResponse(product, page)
+	h.bot.editMessageWithKeyboard(message.Chat.ID, message.MessageID, text, keyboard)
+}
+
+// buildProductResponse builds one page of a /product report
+func (h *CommandHandler) buildProductResponse(product *db.Product, page int) (string, tgbotapi.InlineKeyboardMarkup) {
+	logger.Info("Building /product response for product %d page %d", product.ID, page)
+	
+	page = max(1, min(page, len(productPages)))
+	end := time.Now()
+	start := end.AddDate(0, 0, -productReportDays)
+	
+	activity, err := h.database.GetProductActivity(product.ID, summarizer.PromptTypeDaily, start, end)
+	if err != nil {
+		logger.Error("Failed to get product activity: %v", err)
+	}
+	prices, err := h.database.GetProductPrices(product.ID, start)
+	if err != nil {
+		logger.Error("Failed to get product prices: %v", err)
+	}
+	
+	var response strings.Builder
+	response.WriteString(fmt.Sprintf("🏷️ %s\n", escapeMarkdownV1(product.Name)))
+	response.WriteString(fmt.Sprintf("*%s* (last %d days)\n\n", productPages[page-1], productReportDays))
+	
+	switch page {
+	case 1:
+		writeProductOverview(&response, activity, prices, start)
+	case 2:
+		groups := analytics.ByGroup(activity)
+		if len(groups) == 0 {
+			response.WriteString("No mentions in tracked groups.\n")
+		}
+		for i, g := range groups {
+			if i == productPageLines {
+				response.WriteString(fmt.Sprintf("... and %d more groups\n", len(groups)-i))
+				break
+			}
+			name := g.GroupName
+			if name == "" {
+				name = strconv.FormatInt(g.ChatID, 10)
+			}
+			response.WriteString(fmt.Sprintf("• %s: %d mentions (last %s)\n", escapeMarkdownV1(name), g.Mentions, g.LastSeen.Format("Jan 02")))
+		}
+	case 3:
+		writeProductPrices(&response, prices)
+	case 4:
+		codes := analytics.LatestFamilyCodes(activity, productPageLines)
+		response.WriteString("*Latest FamilyCodes:*\n")
+		if len(codes) == 0 {
+			response.WriteString("None mentioned\n")
+		}
+		for _, c := range codes {
+			response.WriteString(fmt.Sprintf("• `%s` (%s, %s)\n", c.Code, escapeMarkdownV1(c.GroupName), c.LastSeen.Format("Jan 02")))
+		}
+	
+		flags := analytics.RedFlags(activity)
+		response.WriteString(fmt.Sprintf("\n*Red Flags:* %d mentions\n", len(flags)))
+		for i, f := range flags {
+			if i == productPageLines {
+				response.WriteString(fmt.Sprintf("... and %d more\n", len(flags)-i))
+				break
+			}
+			response.WriteString(fmt.Sprintf("• %s %s: %s, %d red flags in summary\n",
+				f.PeriodEnd.Format("Jan 02 15:04"), escapeMarkdownV1(f.GroupName), f.ValidationStatus, f.RedFlags))
+		}
+	}
+	
+	return response.String(), paginationKeyboard("product", page, len(productPages), strconv.FormatInt(product.ID, 10))
+}
+
+// writeProductOverview writes the stats and mention timeline of a product
+func writeProductOverview(b *strings.Builder, activity []db.ProductActivity, prices []db.ProductPrice, start time.Time) {
+	stats := analytics.Aggregate(activity)
+	if len(stats) == 0 {
+		b.WriteString("No mentions in this period.\n")
+		return
+	}
+	
+	for _, s := range stats {
+		b.WriteString(fmt.Sprintf("Mentions: %d in %d summaries, %d groups\n", s.Mentions, s.Summaries, s.Groups))
+		b.WriteString(fmt.Sprintf("Sentiment: %+.2f · Credibility: %.1f/5\n", s.Sentiment, s.Credibility))
+		if s.Suspicious > 0 {
+			b.WriteString(fmt.Sprintf("⚠️ Suspicious: %d mentions\n", s.Suspicious))
+		}
+	}
+	
+	daily := analytics.NewDaily(start, productReportDays+1)
+	for _, a := range activity {
+		daily.Add(a.PeriodEnd, float64(max(a.MentionCount, 1)))
+	}
+	b.WriteString(fmt.Sprintf("\nDaily mentions:\n%s\n", analytics.Sparkline(daily.Values())))
+	
+	if p := analytics.Prices(prices); p.Count > 0 {
+		b.WriteString(fmt.Sprintf("\nPrice: %s - %s (latest %s)\n", formatRupiah(p.Min), formatRupiah(p.Max), formatRupiah(p.Latest)))
+	}
+}
+
+// writeProductPrices writes the price range and latest prices of a product
+func writeProductPrices(b *strings.Builder, prices []db.ProductPrice) {
+	p := analytics.Prices(prices)
+	if p.Count == 0 {
+		b.WriteString("No prices mentioned.\n")
+		return
+	}
+	
+	values := make([]float64, len(prices))
+	for i, price := range prices {
+		values[i] = float64(price.Price)
+	}
+	b.WriteString(fmt.Sprintf("Range: %s - %s\n", formatRupiah(p.Min), formatRupiah(p.Max)))
+	b.WriteString(fmt.Sprintf("Median: %s · Latest: %s\n", formatRupiah(p.Median), formatRupiah(p.Latest)))
+	b.WriteString(fmt.Sprintf("%s\n\n", analytics.Sparkline(values)))
+	
+	b.WriteString("*Latest prices:*\n")
+	for i := len(prices) - 1; i >= 0 && i >= len(prices)-productPageLines; i-- {
+		b.WriteString(fmt.Sprintf("• %s: %s\n", prices[i].ObservedAt.Format("Jan 02"), formatRupiah(prices[i].Price)))
+	}
+}
+
+// HandleCompare handles /compare command - compares two products side by side
+func (h *CommandHandler) HandleCompare(message *tgbotapi.Message, args []string) {
+	logger.Info("Handling /compare command from user %d", message.From.ID)
+	
+	nameA, nameB, ok := splitCompareArgs(args)
+	if !ok {
+		h.bot.sendMessage(message.Chat.ID, "❌ Usage: `/compare <product> vs <product>`\n\nExample: `/compare Xtra Combo vs Akrab`\n\n"+
+			"Single-word names can be given without \"vs\".")
+		return
+	}
+	
+	var products [2]*db.Product
+	for i, name := range []string{nameA, nameB} {
+		product, err := h.catalog.Lookup(name)
+		if err != nil {
+			logger.Error("Failed to look up product: %v", err)
+			h.bot.sendMessage(message.Chat.ID, "❌ Failed to look up product. Check logs.")
+			return
+		}
+		if product == nil {
+			h.sendMessageWithoutHeader(message.Chat.ID, fmt.Sprintf("❌ Product \"%s\" not found in the catalog.", name))
+			return
+		}
+		products[i] = product
+	}
+	
+	end := time.Now()
+	start := end.AddDate(0, 0, -productReportDays)
+	
+	var response strings.Builder
+	response.WriteString(fmt.Sprintf("⚖️ *Product Comparison* (last %d days)\n", productReportDays))
+	
+	for _, product := range products {
+		activity, err := h.database.GetProductActivity(product.ID, summarizer.PromptTypeDaily, start, end)
+		if err != nil {
+			logger.Error("Failed to get product activity: %v", err)
+		}
+		prices, err := h.database.GetProductPrices(product.ID, start)
+		if err != nil {
+			logger.Error("Failed to get product prices: %v", err)
+		}
+	
+		response.WriteString(fmt.Sprintf("\n🏷️ %s\n", escapeMarkdownV1(product.Name)))
+		writeProductOverview(&response, activity, prices, start)
+	}
+	
+	h.bot.sendMessage(message.Chat.ID, response.String())
+}
+
+// splitCompareArgs splits "/compare" arguments into two product names
+func splitCompareArgs(args []string) (string, string, bool) {
+	for i, arg := range args {
+		if strings.EqualFold(arg, "vs") || arg == "|" {
+			a := strings.Join(args[:i], " ")
+			b := strings.Join(args[i+1:], " ")
+			return a, b, a != "" && b != ""
+		}
+	}
+	if len(args) == 2 {
+		return args[0], args[1], true
+	}
+	return "", "", false
+}
+
+// paginationKeyboard builds the previous / page / next keyboard of a paginated command.
+// Callback data is "command:page" followed by ":arg" if arg is set.
+func paginationKeyboard(command string, page, totalPages int, arg string) tgbotapi.InlineKeyboardMarkup {
+	data := func(p int) string {
+		if arg == "" {
+			return fmt.Sprintf("%s:%d", command, p)
+		}
+		return fmt.Sprintf("%s:%d:%s", command, p, arg)
+	}
+	
+	var row []tgbotapi.InlineKeyboardButton
+	if page > 1 {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData("⬅️ Previous", data(page-1)))
+	}
+	row = append(row, tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("📄 %d/%d", page, totalPages), "noop"))
+	if page < totalPages {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData("Next ➡️", data(page+1)))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(row)
+}
+
+// escapeMarkdownV1 escapes the characters of legacy Telegram Markdown (used by sendMessage)
+func escapeMarkdownV1(text string) string {
+	return strings.NewReplacer("_", "\\_", "*", "\\*", "`", "\\`", "[", "\\[").Replace(text)
+}
+
+// formatRupiah formats a price with Indonesian thousands separators, e.g. "Rp 150.000"
+func formatRupiah(price int64) string {
+	digits := strconv.FormatInt(price, 10)
+	var b strings.Builder
+	for i, r := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(r)
+	}
+	return "Rp " + b.String()
+}
+
+// formatIntDelta formats a change with an arrow, e.g. "▲5"
+func formatIntDelta(delta int) string {
+	switch {
+	case delta > 0:
+		return fmt.Sprintf("▲%d", delta)
+	case delta < 0:
+		return fmt.Sprintf("▼%d", -delta)
+	}
+	return "="
+}
+
+// formatFloatDelta formats a change of an average with an arrow, e.g. "▼0.25"
+func formatFloatDelta(delta float64) string {
+	switch {
+	case delta >= 0.005:
+		return fmt.Sprintf("▲%.2f", delta)
+	case delta <= -0.005:
+		return fmt.Sprintf("▼%.2f", math.Abs(delta))
+	}
+	return "="
+}
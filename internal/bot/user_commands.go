@@ -0,0 +1,133 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"telegram-summarizer/internal/db"
+	"telegram-summarizer/internal/logger"
+	
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// userProfileMaxGroups is the number of groups listed in a /user profile
+const userProfileMaxGroups = 15
+
+// HandleUser handles /user command - shows a user's profile and activity across tracked groups
+func (h *CommandHandler) HandleUser(message *tgbotapi.Message, args []string) {
+	logger.Info("Handling /user command from user %d", message.From.ID)
+	
+	if len(args) < 1 {
+		h.bot.sendMessage(message.Chat.ID, "❌ Usage: `/user <user_id|@username>`\n\nExample: `/user @seller123`\n\n"+
+			"Usernames also match users who had that name before.")
+		return
+	}
+	
+	user, others, err := h.findUser(args[0])
+	if err != nil {
+		logger.Error("Failed to look up user: %v", err)
+		h.bot.sendMessage(message.Chat.ID, "❌ Failed to look up user. Check logs.")
+		return
+	}
+	if user == nil {
+		h.sendMessageWithoutHeader(message.Chat.ID, fmt.Sprintf("❌ User %s not found.\n\nOnly users who wrote in a tracked group are known.", args[0]))
+		return
+	}
+	
+	history, err := h.database.GetUsernameHistory(user.UserID)
+	if err != nil {
+		logger.Error("Failed to get username history: %v", err)
+	}
+	activity, err := h.database.GetUserGroupActivity(user.UserID)
+	if err != nil {
+		logger.Error("Failed to get user activity: %v", err)
+	}
+	
+	h.sendMessageWithoutHeader(message.Chat.ID, formatUserProfile(user, history, activity, others))
+}
+
+// findUser resolves a user ID or username; other users who had the same username are returned too
+func (h *CommandHandler) findUser(arg string) (*db.User, []db.User, error) {
+	if userID, err := strconv.ParseInt(arg, 10, 64); err == nil {
+		user, err := h.database.GetUser(userID)
+		return user, nil, err
+	}
+	
+	users, err := h.database.FindUsersByUsername(arg)
+	if err != nil || len(users) == 0 {
+		return nil, nil, err
+	}
+	
+	// Prefer the user who currently has the name over former owners
+	name := strings.ToLower(strings.TrimPrefix(arg, "@"))
+	for i := range users {
+		if strings.ToLower(users[i].Username) == name {
+			users[0], users[i] = users[i], users[0]
+			break
+		}
+	}
+	return &users[0], users[1:], nil
+}
+
+// formatUserProfile formats a user profile as plain text
+func formatUserProfile(user *db.User, history []db.UsernameRecord, activity []db.UserGroupActivity, others []db.User) string {
+	const dateFormat = "2006-01-02 15:04"
+	
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("👤 User Profile: %s\n\n", user.DisplayName()))
+	b.WriteString(fmt.Sprintf("🆔 ID: %d\n", user.UserID))
+	if name := strings.TrimSpace(user.FirstName + " " + user.LastName); name != "" {
+		b.WriteString(fmt.Sprintf("📛 Name: %s\n", name))
+	}
+	
+	var flags []string
+	if user.IsBot {
+		flags = append(flags, "🤖 bot")
+	}
+	if user.IsAdmin {
+		flags = append(flags, "🛡️ group admin")
+	}
+	if len(flags) > 0 {
+		b.WriteString(fmt.Sprintf("🏷️ Flags: %s\n", strings.Join(flags, ", ")))
+	}
+	
+	b.WriteString(fmt.Sprintf("💬 Messages: %d in %d group(s)\n", user.MessageCount, len(activity)))
+	b.WriteString(fmt.Sprintf("📅 First seen: %s\n", user.FirstSeen.Format(dateFormat)))
+	b.WriteString(fmt.Sprintf("🕐 Last seen: %s\n", user.LastSeen.Format(dateFormat)))
+	
+	if len(history) > 1 || (len(history) == 1 && !strings.EqualFold(history[0].Username, user.Username)) {
+		b.WriteString("\n📜 Username history:\n")
+		for _, r := range history {
+			b.WriteString(fmt.Sprintf("• @%s (%s - %s)\n", r.Username,
+				r.FirstSeen.Format("2006-01-02"), r.LastSeen.Format("2006-01-02")))
+		}
+	}
+	
+	if len(activity) > 0 {
+		b.WriteString("\n📊 Activity per group:\n")
+		for i, a := range activity {
+			if i == userProfileMaxGroups {
+				b.WriteString(fmt.Sprintf("... and %d more group(s)\n", len(activity)-i))
+				break
+			}
+			name := a.GroupName
+			if name == "" {
+				name = fmt.Sprintf("%d", a.ChatID)
+			}
+			admin := ""
+			if a.IsAdmin {
+				admin = " 🛡️"
+			}
+			b.WriteString(fmt.Sprintf("• %s%s: %d msg (last %s)\n", name, admin, a.MessageCount, a.LastSeen.Format(dateFormat)))
+		}
+	}
+	
+	if len(others) > 0 {
+		b.WriteString("\n⚠️ Other users who had this username:\n")
+		for _, u := range others {
+			b.WriteString(fmt.Sprintf("• %d %s (last seen %s)\n", u.UserID, u.DisplayName(), u.LastSeen.Format(dateFormat)))
+		}
+	}
+	
+	return b.String()
+}
@@ -0,0 +1,116 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"telegram-summarizer/internal/db"
+	"telegram-summarizer/internal/logger"
+	
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// windowsUsage is the usage of /windows
+const windowsUsage = "❌ Usage: `/windows <chat_id> [min|maxage|busy|busywindow <value>|default]`\n\n" +
+	"Example: `/windows -1001234567890 maxage 6`\n\n" +
+	"• `min` - messages a window needs to be summarized (1-1000)\n" +
+	"• `maxage` - hours a quiet group's window may wait for them (1-24)\n" +
+	"• `busy` - messages per hour from which the group is busy (0 = never)\n" +
+	"• `busywindow` - window length of a busy group: 10, 15, 20 or 30 minutes"
+
+// HandleWindows handles /windows command - shows or changes a group's adaptive window policy
+func (h *CommandHandler) HandleWindows(message *tgbotapi.Message, args []string) {
+	logger.Info("Handling /windows command from user %d", message.From.ID)
+	
+	if len(args) < 1 || len(args) > 3 {
+		h.bot.sendMessage(message.Chat.ID, windowsUsage)
+		return
+	}
+	
+	group := h.manageableGroup(message, args[0])
+	if group == nil {
+		return
+	}
+	
+	// Show current policy
+	if len(args) == 1 {
+		h.sendMessageWithoutHeader(message.Chat.ID, fmt.Sprintf("📥 Summary windows of %s\n\n%s", group.GroupName, describeWindowPolicy(group.Windows)))
+		return
+	}
+	if !h.canManageGroup(message, group.ChatID, group.GroupName) {
+		return
+	}
+	
+	policy := group.Windows
+	setting := strings.ToLower(args[1])
+	if len(args) == 2 {
+		if setting != "default" {
+			h.bot.sendMessage(message.Chat.ID, windowsUsage)
+			return
+		}
+		policy = db.WindowPolicy{}
+	} else {
+		value, err := strconv.Atoi(args[2])
+		if err != nil || value < 0 {
+			h.bot.sendMessage(message.Chat.ID, "❌ The value must be a number.")
+			return
+		}
+		switch setting {
+		case "min":
+			policy.MinMessages = value
+		case "maxage":
+			policy.MaxWindowHours = value
+		case "busy":
+			policy.BusyMessages = value
+		case "busywindow":
+			policy.BusyWindowMinutes = value
+		default:
+			h.bot.sendMessage(message.Chat.ID, windowsUsage)
+			return
+		}
+	}
+	if err := policy.Validate(); err != nil {
+		h.bot.sendMessage(message.Chat.ID, fmt.Sprintf("❌ Invalid setting: %v", err))
+		return
+	}
+	
+	if err := h.database.SetGroupWindowPolicy(group.ChatID, policy); err != nil {
+		logger.Error("Failed to set group window policy: %v", err)
+		h.bot.sendMessage(message.Chat.ID, "❌ Failed to update summary windows. Check logs.")
+		return
+	}
+	
+	h.sendMessageWithoutHeader(message.Chat.ID, fmt.Sprintf("✅ Summary windows updated for %s\n\n%s\n\nApplied from the next window.",
+		group.GroupName, describeWindowPolicy(policy)))
+}
+
+// describeWindowPolicy formats the window policy of a group for display
+func describeWindowPolicy(policy db.WindowPolicy) string {
+	effective := policy.Effective()
+	
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Minimum messages: %d%s\n", effective.MinMessages, defaultMark(policy.MinMessages == 0)))
+	b.WriteString(fmt.Sprintf("Max window age: %dh%s\n", effective.MaxWindowHours, defaultMark(policy.MaxWindowHours == 0)))
+	if effective.BusyMessages > 0 {
+		b.WriteString(fmt.Sprintf("Busy from: %d messages/hour, in %d-minute windows%s\n", effective.BusyMessages,
+			effective.BusyWindowMinutes, defaultMark(policy.BusyWindowMinutes == 0)))
+	} else {
+		b.WriteString("Busy windows: off\n")
+	}
+	
+	if effective.MaxWindowHours > 1 {
+		b.WriteString(fmt.Sprintf("\nHours with fewer than %d messages are carried over until the window has them, is %dh old or the day ends.",
+			effective.MinMessages, effective.MaxWindowHours))
+	} else {
+		b.WriteString(fmt.Sprintf("\nHours with fewer than %d messages are skipped.", effective.MinMessages))
+	}
+	return b.String()
+}
+
+// defaultMark marks a setting that uses its default
+func defaultMark(isDefault bool) string {
+	if isDefault {
+		return " (default)"
+	}
+	return ""
+}
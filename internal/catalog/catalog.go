@@ -0,0 +1,278 @@
+package catalog
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"telegram-summarizer/internal/db"
+	"telegram-summarizer/internal/logger"
+	"time"
+)
+
+// MatchThreshold is the minimum Similarity for a name to match an existing alias
+const MatchThreshold = 0.85
+
+// backfillBatchSize is the number of unlinked mentions read per batch by Backfill
+const backfillBatchSize = 500
+
+// Catalog links product mentions to canonical products through normalized aliases.
+// Unknown names are matched fuzzily against known aliases and learned as new
+// aliases; names that match nothing become new products.
+type Catalog struct {
+	database db.Store
+	mu       sync.Mutex // Serializes alias changes so a name is not created twice
+}
+
+// New creates a catalog backed by a store
+func New(database db.Store) *Catalog {
+	return &Catalog{database: database}
+}
+
+// aliasIndex maps alias keys to product IDs
+type aliasIndex map[string]int64
+
+// loadAliases reads all aliases of the catalog
+func (c *Catalog) loadAliases() (aliasIndex, error) {
+	aliases, err := c.database.GetProductAliases(0)
+	if err != nil {
+		return nil, err
+	}
+	
+	index := make(aliasIndex, len(aliases))
+	for _, a := range aliases {
+		index[a.AliasKey] = a.ProductID
+	}
+	return index, nil
+}
+
+// match finds the product of a key: the exact alias, else the most similar alias
+func (index aliasIndex) match(key string) (productID int64, exact bool) {
+	if id, ok := index[key]; ok {
+		return id, true
+	}
+	
+	best := 0.0
+	for aliasKey, id := range index {
+		score := Similarity(key, aliasKey)
+		if score < MatchThreshold {
+			continue
+		}
+		// Ties go to the oldest product so matching does not depend on map order
+		if score > best || (score == best && id < productID) {
+			best = score
+			productID = id
+		}
+	}
+	return productID, false
+}
+
+// resolve returns the product ID of a name, learning a fuzzy match as alias or
+// creating a new product
+func (c *Catalog) resolve(index aliasIndex, name string) (int64, error) {
+	key := NormalizeName(name)
+	if key == "" {
+		return 0, nil
+	}
+	
+	productID, exact := index.match(key)
+	if exact {
+		return productID, nil
+	}
+	
+	if productID != 0 {
+		if err := c.database.SetProductAlias(productID, name, key); err != nil {
+			return 0, err
+		}
+		logger.Debug("Learned product alias %q for product %d", name, productID)
+	} else {
+		product, err := c.database.CreateProduct(strings.TrimSpace(name), key)
+		if err != nil {
+			return 0, err
+		}
+		productID = product.ID
+	}
+	
+	index[key] = productID
+	return productID, nil
+}
+
+// Link links stored product mentions of a summary to the catalog and records their
+// prices. Mentions are linked after the summary is committed, so a failed save leaves
+// no products or aliases behind; mentions that fail to link are left to Backfill.
+func (c *Catalog) Link(mentions []db.ProductMention, chatID int64, observedAt time.Time) error {
+	if len(mentions) == 0 {
+		return nil
+	}
+	
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	
+	index, err := c.loadAliases()
+	if err != nil {
+		return fmt.Errorf("failed to load product aliases: %w", err)
+	}
+	
+	for i := range mentions {
+		if _, err := c.link(index, &mentions[i], chatID, observedAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// link links a stored product mention to its product and records its prices; returns
+// false if the name matches no product
+func (c *Catalog) link(index aliasIndex, pm *db.ProductMention, chatID int64, observedAt time.Time) (bool, error) {
+	productID, err := c.resolve(index, pm.ProductName)
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve product %q: %w", pm.ProductName, err)
+	}
+	if productID == 0 {
+		return false, nil
+	}
+	
+	pm.ProductID = productID
+	pm.Prices = ParsePrices(pm.PriceMentioned)
+	if err := c.database.LinkProductMention(pm, chatID, observedAt); err != nil {
+		pm.ProductID = 0
+		return false, err
+	}
+	return true, nil
+}
+
+// Backfill links stored product mentions that predate the catalog and records
+// their prices. Returns the number of linked mentions.
+func (c *Catalog) Backfill() (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	
+	index, err := c.loadAliases()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load product aliases: %w", err)
+	}
+	
+	linked := 0
+	var afterID int64
+	for {
+		batch, err := c.database.GetUnlinkedProductMentions(afterID, backfillBatchSize)
+		if err != nil {
+			return linked, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+	
+		for _, u := range batch {
+			afterID = u.Mention.ID
+	
+			ok, err := c.link(index, &u.Mention, u.ChatID, u.PeriodEnd)
+			if err != nil {
+				return linked, err
+			}
+			if ok {
+				linked++
+			}
+		}
+	}
+	
+	if linked > 0 {
+		logger.Info("🏷️ Linked %d stored product mentions to the catalog", linked)
+	}
+	return linked, nil
+}
+
+// Lookup finds the product of a name without creating it; returns nil if unknown
+func (c *Catalog) Lookup(name string) (*db.Product, error) {
+	index, err := c.loadAliases()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load product aliases: %w", err)
+	}
+	
+	productID, _ := index.match(NormalizeName(name))
+	if productID == 0 {
+		return nil, nil
+	}
+	return c.database.GetProduct(productID)
+}
+
+// SetAlias makes alias a name of the canonical product, creating the product if needed.
+// If alias is the canonical name of another product, that product is merged into it.
+func (c *Catalog) SetAlias(alias, canonical string) (*db.Product, error) {
+	aliasKey := NormalizeName(alias)
+	if aliasKey == "" || NormalizeName(canonical) == "" {
+		return nil, fmt.Errorf("product names must contain letters or digits")
+	}
+	
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	
+	index, err := c.loadAliases()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load product aliases: %w", err)
+	}
+	
+	targetID, err := c.resolve(index, canonical)
+	if err != nil {
+		return nil, err
+	}
+	
+	if currentID, ok := index[aliasKey]; ok && currentID != targetID {
+		current, err := c.database.GetProduct(currentID)
+		if err != nil {
+			return nil, err
+		}
+		if current != nil && current.NameKey == aliasKey {
+			if err := c.database.MergeProducts(currentID, targetID); err != nil {
+				return nil, err
+			}
+		}
+	}
+	
+	if err := c.database.SetProductAlias(targetID, strings.TrimSpace(alias), aliasKey); err != nil {
+		return nil, err
+	}
+	return c.database.GetProduct(targetID)
+}
+
+// RemoveAlias removes an alias; the canonical name of a product cannot be removed.
+// Returns false if the alias does not exist.
+func (c *Catalog) RemoveAlias(alias string) (bool, error) {
+	aliasKey := NormalizeName(alias)
+	
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	
+	index, err := c.loadAliases()
+	if err != nil {
+		return false, fmt.Errorf("failed to load product aliases: %w", err)
+	}
+	
+	productID, ok := index[aliasKey]
+	if !ok {
+		return false, nil
+	}
+	
+	product, err := c.database.GetProduct(productID)
+	if err != nil {
+		return false, err
+	}
+	if product != nil && product.NameKey == aliasKey {
+		return false, fmt.Errorf("%q is the canonical name of %s", alias, product.Name)
+	}
+	
+	return c.database.DeleteProductAlias(aliasKey)
+}
+
+// Aliases gets the product of a name and all its aliases; the product is nil if unknown
+func (c *Catalog) Aliases(name string) (*db.Product, []db.ProductAlias, error) {
+	product, err := c.Lookup(name)
+	if err != nil || product == nil {
+		return nil, nil, err
+	}
+	
+	aliases, err := c.database.GetProductAliases(product.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return product, aliases, nil
+}
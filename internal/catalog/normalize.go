@@ -0,0 +1,135 @@
+package catalog
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// fillerWords are dropped from product names before matching
+var fillerWords = map[string]bool{
+	"paket":    true,
+	"produk":   true,
+	"product":  true,
+	"promo":    true,
+	"original": true,
+	"ori":      true,
+	"asli":     true,
+	"merk":     true,
+	"brand":    true,
+	"the":      true,
+}
+
+// NormalizeName returns the key of a product name used for matching:
+// lowercase alphanumeric tokens without filler words, sorted.
+// "Paket Vitamin-C 1000mg" and "vitamin c 1000MG" have the same key.
+func NormalizeName(name string) string {
+	tokens := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	
+	kept := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		if !fillerWords[token] {
+			kept = append(kept, token)
+		}
+	}
+	// A name made only of filler words is still a name
+	if len(kept) == 0 {
+		kept = tokens
+	}
+	
+	sort.Strings(kept)
+	return strings.Join(kept, " ")
+}
+
+// Similarity returns how similar two name keys are, from 0 to 1: the higher of
+// the token overlap and the edit distance ratio. Keys with different numbers
+// (e.g. dosages "500" and "1000") are never similar.
+func Similarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	
+	tokensA, tokensB := strings.Fields(a), strings.Fields(b)
+	if numbers(tokensA) != numbers(tokensB) {
+		return 0
+	}
+	
+	jaccard := jaccard(tokensA, tokensB)
+	ratio := levenshteinRatio(a, b)
+	if jaccard > ratio {
+		return jaccard
+	}
+	return ratio
+}
+
+// numbers returns the numeric tokens of a sorted key
+func numbers(tokens []string) string {
+	var nums []string
+	for _, token := range tokens {
+		if strings.IndexFunc(token, unicode.IsDigit) >= 0 {
+			nums = append(nums, token)
+		}
+	}
+	return strings.Join(nums, " ")
+}
+
+// jaccard returns the token overlap of two token sets
+func jaccard(a, b []string) float64 {
+	set := make(map[string]bool, len(a))
+	for _, token := range a {
+		set[token] = true
+	}
+	
+	union := len(set)
+	shared := 0
+	seen := make(map[string]bool, len(b))
+	for _, token := range b {
+		if seen[token] {
+			continue
+		}
+		seen[token] = true
+		if set[token] {
+			shared++
+		} else {
+			union++
+		}
+	}
+	
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
+
+// levenshteinRatio returns 1 minus the edit distance relative to the longer string
+func levenshteinRatio(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+	
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	
+	return 1 - float64(prev[len(rb)])/float64(longest)
+}
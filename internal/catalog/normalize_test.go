@@ -0,0 +1,50 @@
+package catalog
+
+import "testing"
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"Paket Vitamin-C 1000mg", "1000mg c vitamin"},
+		{"vitamin c 1000MG", "1000mg c vitamin"},
+		{"  Kuota   XL ORI ", "kuota xl"},
+		{"Promo Paket", "paket promo"},
+		{"!!!", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeName(tt.name); got != tt.want {
+			t.Errorf("NormalizeName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		match bool // At least MatchThreshold
+	}{
+		{"1000mg c vitamin", "1000mg c vitamin", true},
+		{"1000mg c vitamin", "1000mg c vitamn", true},    // Typo
+		{"50gb kuota xtra", "50gb kuota xtra xl", false}, // Another token
+		{"500mg c vitamin", "1000mg c vitamin", false},   // Another dosage
+		{"c vitamin", "1000mg c vitamin", false},         // Dosage missing
+		{"axis kuota", "kuota xl", false},
+	}
+	for _, tt := range tests {
+		score := Similarity(tt.a, tt.b)
+		if score < 0 || score > 1 {
+			t.Errorf("Similarity(%q, %q) = %v, want 0 to 1", tt.a, tt.b, score)
+		}
+		if match := score >= MatchThreshold; match != tt.match {
+			t.Errorf("Similarity(%q, %q) = %.2f, want match %v", tt.a, tt.b, score, tt.match)
+		}
+		if reverse := Similarity(tt.b, tt.a); reverse != score {
+			t.Errorf("Similarity(%q, %q) = %v, but %v reversed", tt.a, tt.b, score, reverse)
+		}
+	}
+	
+	if score := Similarity("500mg c vitamin", "1000mg c vitamin"); score != 0 {
+		t.Errorf("keys with different numbers have similarity %v, want 0", score)
+	}
+}
@@ -0,0 +1,85 @@
+package catalog
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// pricePattern matches a Rupiah amount: "Rp 150.000", "Rp150rb", "150 ribu", "75k", "1,5jt"
+var pricePattern = regexp.MustCompile(`(?i)\b(rp\.?\s*)?(\d+(?:[.,]\d+)*)\s*(rb|ribu|k|jt|juta)?\b`)
+
+// rangeSeparator matches the text between the two amounts of a price range
+var rangeSeparator = regexp.MustCompile(`^\s*(?:-|–|~|s/d|sampai|to)\s*$`)
+
+// Amounts outside these bounds are not treated as prices
+const (
+	minPlainPrice = 1000           // Without "Rp" or a suffix, smaller numbers are quantities
+	maxPrice      = 10_000_000_000 // 10 billion Rupiah
+)
+
+// ParsePrices extracts the Rupiah prices of a price text, e.g. "Rp 150.000 - 200rb"
+// gives 150000 and 200000. Numbers without "Rp" or a suffix below 1000 are ignored.
+func ParsePrices(text string) []int64 {
+	matches := pricePattern.FindAllStringSubmatchIndex(text, -1)
+	
+	var prices []int64
+	for i, m := range matches {
+		hasRp := m[2] >= 0
+		number := text[m[4]:m[5]]
+		suffix := ""
+		if m[6] >= 0 {
+			suffix = strings.ToLower(text[m[6]:m[7]])
+		}
+	
+		// "150-200rb": the first amount of a range takes the suffix of the second,
+		// unless it is a full amount already ("150.000 - 200rb")
+		if suffix == "" && i+1 < len(matches) {
+			next := matches[i+1]
+			plain, _ := parseAmount(number, "")
+			if next[6] >= 0 && plain < minPlainPrice && rangeSeparator.MatchString(text[m[1]:next[0]]) {
+				suffix = strings.ToLower(text[next[6]:next[7]])
+			}
+		}
+	
+		price, ok := parseAmount(number, suffix)
+		if !ok || price <= 0 || price > maxPrice {
+			continue
+		}
+		if !hasRp && suffix == "" && price < minPlainPrice {
+			continue
+		}
+		prices = append(prices, price)
+	}
+	return prices
+}
+
+// parseAmount converts a number with Indonesian separators and an optional suffix to Rupiah
+func parseAmount(number, suffix string) (int64, bool) {
+	parts := strings.FieldsFunc(number, func(r rune) bool { return r == '.' || r == ',' })
+	
+	multiplier := 1.0
+	switch suffix {
+	case "rb", "ribu", "k":
+		multiplier = 1_000
+	case "jt", "juta":
+		multiplier = 1_000_000
+	}
+	
+	// Groups of three digits are thousands ("150.000"), anything else is a decimal
+	// part ("1,5jt", "150.000,00")
+	integer, fraction := parts[0], ""
+	for _, part := range parts[1:] {
+		if len(part) == 3 && fraction == "" {
+			integer += part
+		} else {
+			fraction = part
+		}
+	}
+	
+	value, err := strconv.ParseFloat(integer+"."+fraction+"0", 64)
+	if err != nil {
+		return 0, false
+	}
+	return int64(value*multiplier + 0.5), true
+}
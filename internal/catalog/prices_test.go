@@ -0,0 +1,55 @@
+package catalog
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePrices(t *testing.T) {
+	tests := []struct {
+		text string
+		want []int64
+	}{
+		{"Rp 150.000", []int64{150000}},
+		{"Rp150rb", []int64{150000}},
+		{"150 ribu", []int64{150000}},
+		{"75k", []int64{75000}},
+		{"1,5jt", []int64{1500000}},
+		{"Rp 150.000,00", []int64{150000}},
+		{"harga 25000", []int64{25000}},
+		{"Rp 150.000 - 200rb", []int64{150000, 200000}},
+		{"150-200rb", []int64{150000, 200000}},
+		{"50 s/d 60k", []int64{50000, 60000}},
+		{"50GB 30 hari", nil},
+		{"Rp 0", nil},
+		{"Rp 20.000.000.000", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := ParsePrices(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParsePrices(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		number, suffix string
+		want           int64
+	}{
+		{"150", "", 150},
+		{"1.250.000", "", 1250000},
+		{"1,250,000", "", 1250000},
+		{"150.000,50", "", 150001},
+		{"2,5", "jt", 2500000},
+		{"1.5", "k", 1500},
+		{"10", "juta", 10000000},
+		{"99", "ribu", 99000},
+	}
+	for _, tt := range tests {
+		got, ok := parseAmount(tt.number, tt.suffix)
+		if !ok || got != tt.want {
+			t.Errorf("parseAmount(%q, %q) = %d, %v; want %d", tt.number, tt.suffix, got, ok, tt.want)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"telegram-summarizer/internal/logger"
+	"time"
+	
+	"github.com/gotd/td/telegram/auth"
+	"github.com/gotd/td/tg"
+)
+
+// Auth modes accepted by NewAuthProvider
+const (
+	AuthModeTerminal = "terminal" // Read code and password from stdin
+	AuthModeEnv      = "env"      // Read code and password from environment variables
+	AuthModeFile     = "file"     // Wait for code and password files to appear
+	AuthModeBot      = "bot"      // Ask the admin via bot DM
+)
+
+// Environment variables and files used by the env and file auth modes
+const (
+	envLoginCode        = "TELEGRAM_LOGIN_CODE"
+	envPassword         = "TELEGRAM_2FA_PASSWORD"
+	envLoginCodeFile    = "TELEGRAM_LOGIN_CODE_FILE"
+	envPasswordFile     = "TELEGRAM_2FA_PASSWORD_FILE"
+	defaultCodeFile     = "login_code.txt"
+	defaultPasswordFile = "2fa_password.txt"
+)
+
+// AuthProvider supplies the login code and 2FA password when a new session is needed
+type AuthProvider interface {
+	// Code returns the login code sent to the Telegram app
+	Code(ctx context.Context) (string, error)
+	// Password returns the 2FA password (only asked when the account has one)
+	Password(ctx context.Context) (string, error)
+}
+
+// PromptFunc asks a question out-of-band and returns the answer
+type PromptFunc func(ctx context.Context, question string) (string, error)
+
+// NewAuthProvider creates the auth provider for a mode.
+// prompt is only used by AuthModeBot and may be nil otherwise.
+func NewAuthProvider(mode string, prompt PromptFunc) (AuthProvider, error) {
+	switch mode {
+	case "", AuthModeTerminal:
+		return TerminalAuthProvider{}, nil
+	case AuthModeEnv:
+		return EnvAuthProvider{CodeVar: envLoginCode, PasswordVar: envPassword}, nil
+	case AuthModeFile:
+		return FileAuthProvider{
+			CodeFile:     getEnvOr(envLoginCodeFile, defaultCodeFile),
+			PasswordFile: getEnvOr(envPasswordFile, defaultPasswordFile),
+		}, nil
+	case AuthModeBot:
+		if prompt == nil {
+			return nil, fmt.Errorf("auth mode %q requires the bot to be running", mode)
+		}
+		return PromptAuthProvider{Prompt: prompt}, nil
+	}
+	return nil, fmt.Errorf("unknown auth mode %q (use terminal, env, file or bot)", mode)
+}
+
+// TerminalAuthProvider reads the code and password from stdin
+type TerminalAuthProvider struct{}
+
+// Code implements AuthProvider
+func (TerminalAuthProvider) Code(ctx context.Context) (string, error) {
+	logger.Info("📱 Verification code sent to your Telegram app")
+	logger.Info("Please enter the code:")
+	return readLine()
+}
+
+// Password implements AuthProvider
+func (TerminalAuthProvider) Password(ctx context.Context) (string, error) {
+	logger.Info("🔑 Two-step verification is enabled")
+	logger.Info("Please enter your 2FA password:")
+	return readLine()
+}
+
+// readLine reads a single trimmed line from stdin
+func readLine() (string, error) {
+	var value string
+	fmt.Print("> ")
+	if _, err := fmt.Scanln(&value); err != nil {
+		return "", fmt.Errorf("failed to read from stdin: %w", err)
+	}
+	return strings.TrimSpace(value), nil
+}
+
+// EnvAuthProvider reads the code and password from environment variables.
+// Variables are read when needed, so they can be set before a restart.
+type EnvAuthProvider struct {
+	CodeVar     string
+	PasswordVar string
+}
+
+// Code implements AuthProvider
+func (p EnvAuthProvider) Code(ctx context.Context) (string, error) {
+	return readEnv(p.CodeVar)
+}
+
+// Password implements AuthProvider
+func (p EnvAuthProvider) Password(ctx context.Context) (string, error) {
+	return readEnv(p.PasswordVar)
+}
+
+// readEnv returns a required environment variable
+func readEnv(name string) (string, error) {
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "" {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
+
+// FileAuthProvider waits for the code and password to be written to files.
+// The code file is removed after reading since login codes are single-use.
+type FileAuthProvider struct {
+	CodeFile     string
+	PasswordFile string
+}
+
+// fileAuthPollInterval is how often FileAuthProvider checks for the files
+const fileAuthPollInterval = 2 * time.Second
+
+// Code implements AuthProvider
+func (p FileAuthProvider) Code(ctx context.Context) (string, error) {
+	logger.Info("📱 Verification code sent to your Telegram app")
+	logger.Info("Write the code to %s to continue", p.CodeFile)
+	
+	code, err := waitForFile(ctx, p.CodeFile)
+	if err != nil {
+		return "", err
+	}
+	
+	if err := os.Remove(p.CodeFile); err != nil {
+		logger.Warn("Failed to remove code file: %v", err)
+	}
+	return code, nil
+}
+
+// Password implements AuthProvider
+func (p FileAuthProvider) Password(ctx context.Context) (string, error) {
+	logger.Info("🔑 Two-step verification is enabled")
+	logger.Info("Write the 2FA password to %s to continue", p.PasswordFile)
+	return waitForFile(ctx, p.PasswordFile)
+}
+
+// waitForFile polls until a file exists with non-empty content
+func waitForFile(ctx context.Context, path string) (string, error) {
+	ticker := time.NewTicker(fileAuthPollInterval)
+	defer ticker.Stop()
+	
+	for {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			if value := strings.TrimSpace(string(data)); value != "" {
+				return value, nil
+			}
+		} else if !os.IsNotExist(err) {
+			return "", fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
+
+// PromptAuthProvider asks for the code and password through a PromptFunc (e.g. a bot DM).
+// Telegram rejects login codes that were sent as-is in a chat, so the admin is asked
+// to split the digits and everything but digits is stripped from the answer.
+type PromptAuthProvider struct {
+	Prompt PromptFunc
+}
+
+// Code implements AuthProvider
+func (p PromptAuthProvider) Code(ctx context.Context) (string, error) {
+	logger.Info("📱 Verification code sent, waiting for admin reply via bot...")
+	
+	answer, err := p.Prompt(ctx, "🔐 *Scraper login required*\n\n"+
+		"A verification code was sent to the scraper account.\n"+
+		"Reply to this message with the code *split by spaces* (e.g. `1 2 3 4 5`).")
+	if err != nil {
+		return "", err
+	}
+	
+	code := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, answer)
+	if code == "" {
+		return "", fmt.Errorf("reply did not contain a code")
+	}
+	return code, nil
+}
+
+// Password implements AuthProvider
+func (p PromptAuthProvider) Password(ctx context.Context) (string, error) {
+	logger.Info("🔑 2FA password required, waiting for admin reply via bot...")
+	
+	answer, err := p.Prompt(ctx, "🔑 *Two-step verification*\n\n"+
+		"Reply to this message with the 2FA password of the scraper account.\n"+
+		"Your reply will be deleted after reading.")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(answer), nil
+}
+
+// userAuthenticator adapts an AuthProvider to gotd's auth.UserAuthenticator.
+// The 2FA password is checked by gotd using SRP, so it never leaves this process.
+type userAuthenticator struct {
+	phone    string
+	provider AuthProvider
+}
+
+// Phone implements auth.UserAuthenticator
+func (a userAuthenticator) Phone(ctx context.Context) (string, error) {
+	if a.phone == "" {
+		return "", fmt.Errorf("phone number is not configured")
+	}
+	return a.phone, nil
+}
+
+// Password implements auth.UserAuthenticator
+func (a userAuthenticator) Password(ctx context.Context) (string, error) {
+	return a.provider.Password(ctx)
+}
+
+// Code implements auth.CodeAuthenticator
+func (a userAuthenticator) Code(ctx context.Context, sentCode *tg.AuthSentCode) (string, error) {
+	return a.provider.Code(ctx)
+}
+
+// AcceptTermsOfService implements auth.UserAuthenticator
+func (a userAuthenticator) AcceptTermsOfService(ctx context.Context, tos tg.HelpTermsOfService) error {
+	return &auth.SignUpRequired{TermsOfService: tos}
+}
+
+// SignUp implements auth.UserAuthenticator
+func (a userAuthenticator) SignUp(ctx context.Context) (auth.UserInfo, error) {
+	return auth.UserInfo{}, fmt.Errorf("phone number %s is not registered on Telegram", a.phone)
+}
+
+// getEnvOr gets an environment variable or returns a default value
+func getEnvOr(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -2,15 +2,22 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 	"telegram-summarizer/internal/db"
+	"telegram-summarizer/internal/familycode"
+	"telegram-summarizer/internal/filter"
 	"telegram-summarizer/internal/logger"
+	"telegram-summarizer/internal/ocr"
 	"time"
 
 	"github.com/gotd/td/session"
 	"github.com/gotd/td/telegram"
 	"github.com/gotd/td/telegram/auth"
+	"github.com/gotd/td/telegram/updates"
+	"github.com/gotd/td/telegram/updates/hook"
 	"github.com/gotd/td/tg"
 )
 
@@ -18,11 +25,19 @@ import (
 type Client struct {
 	client    *telegram.Client
 	api       *tg.Client
-	db        *db.DB
+	db        db.Store
 	phone     string
 	appID     int
 	appHash   string
 	sessionDir string
+	dialogSyncInterval time.Duration
+	authProvider AuthProvider
+	hadSession   bool // a session file existed before this run
+	updates      *updates.Manager
+	selfID       int64
+	ocr          ocr.Engine
+	filters      *filter.Manager
+	codes        *familycode.Registry
 }
 
 // Config holds client configuration
@@ -31,17 +46,49 @@ type Config struct {
 	AppHash    string
 	Phone      string
 	SessionDir string
-	Database   *db.DB
+	Database   db.Store
+	
+	// DialogSyncInterval controls how often the group list is re-synced (default: 1 hour)
+	DialogSyncInterval time.Duration
+	
+	// AuthProvider supplies the login code and 2FA password (default: stdin)
+	AuthProvider AuthProvider
+	
+	// OCR extracts text from image attachments (optional, nil disables OCR)
+	OCR ocr.Engine
+	
+	// Filters decides which messages are saved (default: a new manager on Database)
+	Filters *filter.Manager
 }
 
 // NewClient creates a new Telegram client
 func NewClient(cfg Config) *Client {
+	syncInterval := cfg.DialogSyncInterval
+	if syncInterval <= 0 {
+		syncInterval = 1 * time.Hour
+	}
+	
+	authProvider := cfg.AuthProvider
+	if authProvider == nil {
+		authProvider = TerminalAuthProvider{}
+	}
+	
+	filters := cfg.Filters
+	if filters == nil {
+		filters = filter.NewManager(cfg.Database)
+	}
+	
 	return &Client{
-		phone:      cfg.Phone,
-		appID:      cfg.AppID,
-		appHash:    cfg.AppHash,
-		sessionDir: cfg.SessionDir,
-		db:         cfg.Database,
+		phone:              cfg.Phone,
+		appID:              cfg.AppID,
+		appHash:            cfg.AppHash,
+		sessionDir:         cfg.SessionDir,
+		db:                 cfg.Database,
+		dialogSyncInterval: syncInterval,
+		authProvider:       authProvider,
+		ocr:                cfg.OCR,
+		filters:            filters,
+		codes:              familycode.NewRegistry(cfg.Database),
 	}
 }
 
@@ -52,16 +99,36 @@ func (c *Client) Start(ctx context.Context) error {
 	logger.Info("⏳ This may take 10-30 seconds on first connection...")
 	
 	// Session storage
+	sessionPath := filepath.Join(c.sessionDir, "session.json")
 	sessionStorage := &session.FileStorage{
-		Path: filepath.Join(c.sessionDir, "session.json"),
+		Path: sessionPath,
 	}
 	
+	// Remember whether we were logged in before, to tell an expired session from a first run
+	if info, err := os.Stat(sessionPath); err == nil && info.Size() > 0 {
+		c.hadSession = true
+	}
+	
+	// Updates manager tracks pts/qts/seq and fills gaps with getDifference/getChannelDifference.
+	// State is persisted so updates missed while offline are recovered on the next start.
+	stateStorage := updateStateStorage{db: c.db}
+	c.updates = updates.New(updates.Config{
+		Handler:      telegram.UpdateHandlerFunc(c.handleUpdate),
+		Storage:      stateStorage,
+		AccessHasher: stateStorage,
+		OnChannelTooLong: func(channelID int64) {
+			logger.Warn("⚠️  Too many missed updates in channel %d, some messages could not be recovered", channelID)
+		},
+	})
+	
 	// Create client with update handler and options
 	client := telegram.NewClient(c.appID, c.appHash, telegram.Options{
 		SessionStorage: sessionStorage,
-		UpdateHandler: telegram.UpdateHandlerFunc(func(ctx context.Context, u tg.UpdatesClass) error {
-			return c.handleUpdate(ctx, u)
-		}),
+		UpdateHandler:  c.updates,
+		// Feed updates returned by API calls to the manager as well
+		Middlewares: []telegram.Middleware{
+			hook.UpdateHook(c.updates.Handle),
+		},
 		// Use DC2 (Singapore) - closer to Indonesia
 		DC: 2,
 	})
@@ -92,11 +159,20 @@ func (c *Client) Start(ctx context.Context) error {
 			logger.Error("Failed to fetch dialogs: %v", err)
 		}
 		
-		logger.Info("📱 Client is ready to receive messages!")
+		// Keep the group list in sync (left, renamed and migrated groups)
+		go c.runDialogSync(ctx)
 		
-		// Keep running
-		<-ctx.Done()
-		return ctx.Err()
+		// Recover missed updates, then keep running until the context is done
+		logger.Info("🔄 Recovering missed updates...")
+		err := c.updates.Run(ctx, c.api, c.selfID, updates.AuthOptions{
+			OnStart: func(ctx context.Context) {
+				logger.Info("📱 Client is ready to receive messages!")
+			},
+		})
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
 	})
 }
 
@@ -104,24 +180,21 @@ func (c *Client) Start(ctx context.Context) error {
 func (c *Client) authenticate(ctx context.Context) error {
 	logger.Info("🔐 Authenticating...")
 	
-	flow := auth.NewFlow(
-		auth.Constant(c.phone, "", auth.CodeAuthenticatorFunc(
-			func(ctx context.Context, sentCode *tg.AuthSentCode) (string, error) {
-				logger.Info("📱 Verification code sent to your Telegram app")
-				logger.Info("Please enter the code:")
-				
-				var code string
-				fmt.Print("> ")
-				fmt.Scanln(&code)
-				return code, nil
-			},
-		)),
-		auth.SendCodeOptions{},
-	)
-	
 	client := c.client.Auth()
-	if err := client.IfNecessary(ctx, flow); err != nil {
-		return err
+	status, err := client.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get auth status: %w", err)
+	}
+	
+	if !status.Authorized {
+		if c.hadSession {
+			c.alertSessionExpired(nil)
+		}
+		
+		flow := auth.NewFlow(userAuthenticator{phone: c.phone, provider: c.authProvider}, auth.SendCodeOptions{})
+		if err := flow.Run(ctx, client); err != nil {
+			return err
+		}
 	}
 	
 	// Get self info
@@ -131,6 +204,7 @@ func (c *Client) authenticate(ctx context.Context) error {
 	}
 	
 	user := self.Users[0].(*tg.User)
+	c.selfID = user.ID
 	logger.Info("✅ Logged in as: %s %s (@%s)", user.FirstName, user.LastName, user.Username)
 	logger.Info("   Phone: %s", user.Phone)
 	logger.Info("   User ID: %d", user.ID)
@@ -151,15 +225,28 @@ func (c *Client) handleUpdate(ctx context.Context, u tg.UpdatesClass) error {
 		// Short update (no users/chats)
 		return c.processSingleUpdate(ctx, updates.Update, nil, nil)
 	case *tg.UpdateShortMessage:
-		// Short message update
+		// Private message (not tracked)
 		logger.Debug("Short message update received")
 	case *tg.UpdateShortChatMessage:
-		// Short chat message update
-		logger.Debug("Short chat message update received")
+		// Basic group message without entities. Normally converted by the updates
+		// manager, but delivered as-is while it is still starting.
+		return c.handleMessage(ctx, shortChatMessage(updates), nil, nil)
 	}
 	return nil
 }
 
+// shortChatMessage expands a short basic group message into a regular message
+func shortChatMessage(u *tg.UpdateShortChatMessage) *tg.Message {
+	msg := &tg.Message{
+		ID:      u.ID,
+		PeerID:  &tg.PeerChat{ChatID: u.ChatID},
+		Message: u.Message,
+		Date:    u.Date,
+	}
+	msg.SetFromID(&tg.PeerUser{UserID: u.FromID})
+	return msg
+}
+
 // processUpdates processes multiple updates with entities
 func (c *Client) processUpdates(ctx context.Context, updates []tg.UpdateClass, users []tg.UserClass, chats []tg.ChatClass) error {
 	// Build entities map
@@ -201,21 +288,22 @@ func (c *Client) processSingleUpdate(ctx context.Context, update tg.UpdateClass,
 
 // handleMessage processes a message
 func (c *Client) handleMessage(ctx context.Context, messageClass tg.MessageClass, users map[int64]tg.UserClass, chats map[int64]tg.ChatClass) error {
+	// Service messages carry group renames and migrations
+	if service, ok := messageClass.(*tg.MessageService); ok {
+		return c.handleServiceMessage(service)
+	}
+	
 	// Extract message
 	msg, ok := messageClass.(*tg.Message)
 	if !ok {
 		return nil
 	}
 	
-	// Skip if no text
-	if msg.Message == "" {
-		logger.Debug("Skipping: no text")
-		return nil
-	}
+	media := extractMedia(msg.Media)
 	
-	// Filter: minimum length
-	if len(msg.Message) < 10 {
-		logger.Debug("Skipping: too short (%d chars)", len(msg.Message))
+	// Skip if no text and no media
+	if msg.Message == "" && media == nil {
+		logger.Debug("Skipping: no text or media")
 		return nil
 	}
 	
@@ -225,20 +313,27 @@ func (c *Client) handleMessage(ctx context.Context, messageClass tg.MessageClass
 	
 	switch peer := msg.PeerID.(type) {
 	case *tg.PeerChannel:
-		chatID = peer.ChannelID
-		// Try to get chat name from entities
+		chatID = db.ChannelChatID(peer.ChannelID).Int64()
+		// Try to get chat name and type from entities
+		broadcast := false
 		if chats != nil {
 			if chat, ok := chats[peer.ChannelID]; ok {
 				if channel, ok := chat.(*tg.Channel); ok {
 					chatName = channel.Title
+					broadcast = channel.Broadcast
 				}
 			}
 		}
+		if broadcast || c.isBroadcast(chatID) {
+			// Broadcast channels have no discussion to summarize
+			logger.Debug("⏭️  Skipping post of broadcast channel %d", chatID)
+			return nil
+		}
 		if chatName == "" {
-			chatName = fmt.Sprintf("Channel_%d", peer.ChannelID)
+			chatName = c.knownGroupName(chatID, fmt.Sprintf("Channel_%d", peer.ChannelID))
 		}
 	case *tg.PeerChat:
-		chatID = peer.ChatID
+		chatID = db.GroupChatID(peer.ChatID).Int64()
 		// Try to get chat name from entities
 		if chats != nil {
 			if chat, ok := chats[peer.ChatID]; ok {
@@ -248,7 +343,7 @@ func (c *Client) handleMessage(ctx context.Context, messageClass tg.MessageClass
 			}
 		}
 		if chatName == "" {
-			chatName = fmt.Sprintf("Chat_%d", peer.ChatID)
+			chatName = c.knownGroupName(chatID, fmt.Sprintf("Chat_%d", peer.ChatID))
 		}
 	default:
 		// Skip private messages
@@ -258,6 +353,8 @@ func (c *Client) handleMessage(ctx context.Context, messageClass tg.MessageClass
 	// Get sender info
 	var userID int64
 	var username string
+	var isBot bool
+	var sender *tg.User
 	
 	if msg.FromID != nil {
 		switch from := msg.FromID.(type) {
@@ -266,6 +363,8 @@ func (c *Client) handleMessage(ctx context.Context, messageClass tg.MessageClass
 			if users != nil {
 				if user, ok := users[from.UserID]; ok {
 					if u, ok := user.(*tg.User); ok {
+						sender = u
+						isBot = u.Bot
 						username = u.Username
 						if username == "" {
 							username = u.FirstName
@@ -279,6 +378,79 @@ func (c *Client) handleMessage(ctx context.Context, messageClass tg.MessageClass
 		}
 	}
 	
+	// FamilyCodes and their confirmations are recorded before filtering:
+	// short replies like "work" would not pass the filters
+	codeMsg := familycode.Message{
+		ChatID:    chatID,
+		MessageID: int64(msg.ID),
+		UserID:    userID,
+		Text:      msg.Message,
+		Timestamp: time.Unix(int64(msg.Date), 0),
+	}
+	if replyTo, ok := msg.GetReplyTo(); ok {
+		if header, ok := replyTo.(*tg.MessageReplyHeader); ok {
+			if replyToID, ok := header.GetReplyToMsgID(); ok {
+				codeMsg.ReplyToID = int64(replyToID)
+			}
+		}
+	}
+	c.codes.Observe(codeMsg)
+	
+	in := incoming{
+		msg:      msg,
+		media:    media,
+		chatID:   chatID,
+		chatName: chatName,
+		userID:   userID,
+		username: username,
+		isBot:    isBot,
+		sender:   sender,
+	}
+	
+	// An image without a caption is only content if it shows text, so it is recognized
+	// before filtering; in the background so slow engines don't block the update loop
+	if msg.Message == "" && c.shouldRecognize(media) {
+		go func() {
+			c.ingest(ctx, in, c.extractImageText(ctx, media))
+		}()
+		return nil
+	}
+	
+	return c.ingest(ctx, in, "")
+}
+
+// incoming is a message of a group with its sender, as handed from handleMessage to ingest
+type incoming struct {
+	msg      *tg.Message
+	media    *mediaInfo
+	chatID   int64
+	chatName string
+	userID   int64
+	username string
+	isBot    bool
+	sender   *tg.User // Sender's profile, if known
+}
+
+// ingest filters and saves a message; ocrText is the text recognized in its image
+// before filtering, if any
+func (c *Client) ingest(ctx context.Context, in incoming, ocrText string) error {
+	msg, media, chatID, chatName := in.msg, in.media, in.chatID, in.chatName
+	
+	// Ingest filters (length, emoji, bots, patterns, duplicates, flood, language)
+	if !c.filters.Check(&filter.Message{
+		ChatID:    chatID,
+		MessageID: int64(msg.ID),
+		UserID:    in.userID,
+		Text:      msg.Message,
+		OCRText:   ocrText,
+		HasMedia:  media != nil,
+		IsSticker: media != nil && media.Type == db.MediaTypeSticker,
+		IsBot:     in.isBot,
+		Timestamp: time.Unix(int64(msg.Date), 0),
+	}) {
+		return nil
+	}
+	
 	// Add to tracked groups (auto-track) - so it appears in /listgroups
 	c.db.AddTrackedGroup(chatID, chatName, "")
 	
@@ -295,23 +467,114 @@ func (c *Client) handleMessage(ctx context.Context, messageClass tg.MessageClass
 	// Save to database (only for ACTIVE groups)
 	dbMsg := &db.Message{
 		ChatID:        chatID,
-		UserID:        userID,
-		Username:      username,
+		MessageID:     int64(msg.ID),
+		UserID:        in.userID,
+		Username:      in.username,
 		MessageText:   msg.Message,
 		MessageLength: len(msg.Message),
 		Timestamp:     time.Unix(int64(msg.Date), 0),
+		OCRText:       ocrText,
+	}
+	if media != nil {
+		dbMsg.MediaType = media.Type
+		dbMsg.MediaFileID = media.FileID
+		dbMsg.MediaSize = media.Size
 	}
 	
-	if err := c.db.SaveMessage(dbMsg); err != nil {
+	// The bot may have saved it already
+	saved, err := c.db.SaveMessage(dbMsg)
+	if err != nil {
 		logger.Error("Failed to save message: %v", err)
 		return err
 	}
+	if !saved {
+		logger.Debug("⏭️  Skipping message already saved: %s (MessageID: %d)", chatName, msg.ID)
+		return nil
+	}
+	
+	logger.Info("✅ [%s] %s: %s", chatName, in.username, truncateText(msg.Message, 50))
+	
+	// The sender is tracked once per saved message, by whichever of the bot and the
+	// scraper saved it
+	c.trackSender(in)
+	
+	// Captioned images are recognized after saving, in the background so slow engines
+	// don't block the update loop
+	if msg.Message != "" && c.shouldRecognize(media) {
+		go c.recognizeImage(ctx, dbMsg.ID, media)
+	}
+	
+	return nil
+}
+
+// trackSender updates the profile and group activity of a message's sender
+func (c *Client) trackSender(in incoming) {
+	if in.userID == 0 {
+		return
+	}
+	
+	sighting := db.UserSighting{
+		UserID:    in.userID,
+		ChatID:    in.chatID,
+		IsBot:     in.isBot,
+		Timestamp: time.Unix(int64(in.msg.Date), 0),
+	}
+	if in.sender != nil {
+		sighting.Username = in.sender.Username
+		sighting.FirstName = in.sender.FirstName
+		sighting.LastName = in.sender.LastName
+	}
+	if err := c.db.TrackUser(sighting); err != nil {
+		logger.Error("Failed to track user %d: %v", in.userID, err)
+	}
+}
+
+// handleServiceMessage applies group renames and migrations announced in a chat
+func (c *Client) handleServiceMessage(msg *tg.MessageService) error {
+	var chatID int64
+	switch peer := msg.PeerID.(type) {
+	case *tg.PeerChannel:
+		chatID = db.ChannelChatID(peer.ChannelID).Int64()
+	case *tg.PeerChat:
+		chatID = db.GroupChatID(peer.ChatID).Int64()
+	default:
+		return nil
+	}
+	
+	group := c.db.GetTrackedGroup(chatID)
+	if group == nil {
+		return nil
+	}
 	
-	logger.Info("✅ [%s] %s: %s", chatName, username, truncateText(msg.Message, 50))
+	switch action := msg.Action.(type) {
+	case *tg.MessageActionChatEditTitle:
+		logger.Info("✏️  Group renamed: %s → %s (ID: %d)", group.GroupName, action.Title, chatID)
+		return c.db.AddTrackedGroup(chatID, action.Title, group.GroupUsername)
+	case *tg.MessageActionChatMigrateTo:
+		newChatID := db.ChannelChatID(action.ChannelID).Int64()
+		logger.Info("🔀 Group migrated: %s (ID: %d → %d)", group.GroupName, chatID, newChatID)
+		if err := c.db.AddTrackedGroup(newChatID, group.GroupName, ""); err != nil {
+			return err
+		}
+		return c.db.MarkGroupMigrated(chatID, newChatID)
+	}
 	
 	return nil
 }
 
+// knownGroupName returns the stored name of a group, for updates that come without entities
+func (c *Client) knownGroupName(chatID int64, fallback string) string {
+	if group := c.db.GetTrackedGroup(chatID); group != nil && group.GroupName != "" {
+		return group.GroupName
+	}
+	return fallback
+}
+
+// isBroadcast reports whether a tracked chat was classified as a broadcast channel
+func (c *Client) isBroadcast(chatID int64) bool {
+	group := c.db.GetTrackedGroup(chatID)
+	return group != nil && group.ChatType == db.ChatTypeChannel
+}
 
 // truncateText truncates text to max length
 func truncateText(text string, maxLen int) string {
@@ -321,51 +584,346 @@ func truncateText(text string, maxLen int) string {
 	return text[:maxLen] + "..."
 }
 
-// fetchAllDialogs fetches all groups at startup
+// Dialog folders synced by fetchAllDialogs
+const (
+	folderMain     = 0 // Main dialog list
+	folderArchived = 1 // Archived chats
+)
+
+// dialogsPageSize is the maximum number of dialogs Telegram returns per request
+const dialogsPageSize = 100
+
+// dialogsAPI gets pages of the dialog list; implemented by *tg.Client
+type dialogsAPI interface {
+	MessagesGetDialogs(ctx context.Context, request *tg.MessagesGetDialogsRequest) (tg.MessagesDialogsClass, error)
+}
+
+// fetchAllDialogs syncs all groups from the main and archived dialog lists.
+// Groups that disappeared from both lists are marked as left, renamed groups
+// are updated and basic groups upgraded to supergroups are recorded as migrated.
 func (c *Client) fetchAllDialogs(ctx context.Context) error {
+	return c.syncDialogs(ctx, c.api)
+}
+
+// syncDialogs syncs all groups from the dialog lists returned by api. Groups are only
+// marked as left when both lists were read completely.
+func (c *Client) syncDialogs(ctx context.Context, api dialogsAPI) error {
 	logger.Debug("Fetching all dialogs...")
 	
-	// Get all dialogs (chats, channels, groups)
-	dialogs, err := c.api.MessagesGetDialogs(ctx, &tg.MessagesGetDialogsRequest{
-		OffsetPeer: &tg.InputPeerEmpty{},
-		Limit:      100, // Get first 100
-	})
-	if err != nil {
-		return fmt.Errorf("failed to get dialogs: %w", err)
+	seen := make(map[int64]bool)
+	groupCount := 0
+	complete := true
+	
+	for _, folderID := range []int{folderMain, folderArchived} {
+		chats, err := fetchDialogChats(ctx, api, folderID)
+		if err != nil && len(chats) == 0 {
+			return fmt.Errorf("failed to get dialogs (folder %d): %w", folderID, err)
+		}
+		if err != nil {
+			// Sync the groups of the pages read, but do not take the rest for left
+			logger.Warn("⚠️  Dialog list of folder %d is incomplete after %d chats: %v", folderID, len(chats), err)
+			complete = false
+		}
+		
+		logger.Info("📊 Found %d chats in folder %d", len(chats), folderID)
+		
+		for _, chat := range chats {
+			group, ok := classifyChat(chat)
+			if !ok {
+				continue
+			}
+			group.FolderID = folderID
+			
+			if seen[group.ChatID] {
+				continue
+			}
+			seen[group.ChatID] = true
+			
+			c.syncGroup(group)
+			if group.IsLeft == 0 {
+				groupCount++
+			}
+		}
 	}
 	
-	var chats []tg.ChatClass
+	if !complete {
+		logger.Info("✅ Tracked %d groups, skipped checking for left groups", groupCount)
+		return nil
+	}
 	
-	switch d := dialogs.(type) {
-	case *tg.MessagesDialogs:
-		chats = d.Chats
-	case *tg.MessagesDialogsSlice:
-		chats = d.Chats
+	// Groups we were in before but are no longer in any folder
+	for _, group := range c.db.GetTrackedGroups() {
+		// Only groups discovered via dialogs have a chat type; others come from the bot
+		if group.ChatType == "" || group.IsLeft == 1 || seen[group.ChatID] {
+			continue
+		}
+		logger.Info("👋 Left group: %s (ID: %d)", group.GroupName, group.ChatID)
+		if err := c.db.MarkGroupLeft(group.ChatID); err != nil {
+			logger.Error("Failed to mark group as left: %v", err)
+		}
 	}
 	
-	logger.Info("📊 Found %d chats", len(chats))
+	logger.Info("✅ Tracked %d groups successfully", groupCount)
+	return nil
+}
+
+// errDialogsIncomplete is returned with the chats read so far when the dialog list
+// cannot be paged through to its end
+var errDialogsIncomplete = errors.New("dialog list incomplete")
+
+// fetchDialogChats pages through every dialog in a folder and returns the chats. If
+// a page fails or pagination stops before the folder's dialog count, the chats read
+// so far are returned with an error.
+func fetchDialogChats(ctx context.Context, api dialogsAPI, folderID int) ([]tg.ChatClass, error) {
+	var (
+		chats      []tg.ChatClass
+		fetched    int
+		offsetDate int
+		offsetID   int
+		offsetPeer tg.InputPeerClass = &tg.InputPeerEmpty{}
+	)
 	
-	// Track all groups
-	groupCount := 0
-	for _, chat := range chats {
-		switch ch := chat.(type) {
-		case *tg.Channel:
-			// Track channels/supergroups
-			if ch.Megagroup || ch.Broadcast {
-				c.db.AddTrackedGroup(ch.ID, ch.Title, ch.Username)
-				groupCount++
-				logger.Debug("  📂 %s (ID: %d)", ch.Title, ch.ID)
+	for page := 1; ; page++ {
+		req := &tg.MessagesGetDialogsRequest{
+			OffsetDate: offsetDate,
+			OffsetID:   offsetID,
+			OffsetPeer: offsetPeer,
+			Limit:      dialogsPageSize,
+		}
+		req.SetFolderID(folderID)
+		
+		result, err := api.MessagesGetDialogs(ctx, req)
+		if err != nil {
+			return chats, fmt.Errorf("page %d: %w", page, err)
+		}
+		
+		var (
+			dialogs  []tg.DialogClass
+			messages []tg.MessageClass
+			users    []tg.UserClass
+			last     bool
+		)
+		
+		switch d := result.(type) {
+		case *tg.MessagesDialogs:
+			// Complete list in a single response
+			dialogs, messages, users = d.Dialogs, d.Messages, d.Users
+			chats = append(chats, d.Chats...)
+			last = true
+		case *tg.MessagesDialogsSlice:
+			// Pages may be shorter than requested before the end, so the count tells the end
+			dialogs, messages, users = d.Dialogs, d.Messages, d.Users
+			chats = append(chats, d.Chats...)
+			fetched += len(d.Dialogs)
+			last = fetched >= d.Count
+		default:
+			return chats, fmt.Errorf("%w: unexpected %T on page %d", errDialogsIncomplete, result, page)
+		}
+		
+		logger.Debug("Dialogs page %d (folder %d): %d dialogs", page, folderID, len(dialogs))
+		
+		if last {
+			return chats, nil
+		}
+		if len(dialogs) == 0 {
+			return chats, fmt.Errorf("%w: empty page %d", errDialogsIncomplete, page)
+		}
+		
+		// Next page starts after the top message of the last dialog
+		nextDate, nextID, nextPeer, ok := dialogOffset(dialogs[len(dialogs)-1], messages, users, chats)
+		if !ok || (nextDate == offsetDate && nextID == offsetID) {
+			return chats, fmt.Errorf("%w: cannot continue after page %d", errDialogsIncomplete, page)
+		}
+		offsetDate, offsetID, offsetPeer = nextDate, nextID, nextPeer
+	}
+}
+
+// dialogOffset computes pagination offsets from the last dialog of a page
+func dialogOffset(dialog tg.DialogClass, messages []tg.MessageClass, users []tg.UserClass, chats []tg.ChatClass) (int, int, tg.InputPeerClass, bool) {
+	d, ok := dialog.(*tg.Dialog)
+	if !ok {
+		return 0, 0, nil, false
+	}
+	
+	var inputPeer tg.InputPeerClass
+	switch p := d.Peer.(type) {
+	case *tg.PeerUser:
+		for _, u := range users {
+			if user, ok := u.(*tg.User); ok && user.ID == p.UserID {
+				inputPeer = &tg.InputPeerUser{UserID: user.ID, AccessHash: user.AccessHash}
 			}
-		case *tg.Chat:
-			// Track regular groups
-			c.db.AddTrackedGroup(ch.ID, ch.Title, "")
-			groupCount++
-			logger.Debug("  📂 %s (ID: %d)", ch.Title, ch.ID)
 		}
+	case *tg.PeerChat:
+		inputPeer = &tg.InputPeerChat{ChatID: p.ChatID}
+	case *tg.PeerChannel:
+		for _, ch := range chats {
+			switch channel := ch.(type) {
+			case *tg.Channel:
+				if channel.ID == p.ChannelID {
+					inputPeer = &tg.InputPeerChannel{ChannelID: channel.ID, AccessHash: channel.AccessHash}
+				}
+			case *tg.ChannelForbidden:
+				if channel.ID == p.ChannelID {
+					inputPeer = &tg.InputPeerChannel{ChannelID: channel.ID, AccessHash: channel.AccessHash}
+				}
+			}
+		}
+	}
+	if inputPeer == nil {
+		return 0, 0, nil, false
 	}
 	
-	logger.Info("✅ Tracked %d groups successfully", groupCount)
-	return nil
+	// Find the date of the dialog's top message
+	for _, m := range messages {
+		msg, ok := m.AsNotEmpty()
+		if !ok || msg.GetID() != d.TopMessage || !samePeer(msg.GetPeerID(), d.Peer) {
+			continue
+		}
+		return msg.GetDate(), d.TopMessage, inputPeer, true
+	}
+	
+	return 0, 0, nil, false
+}
+
+// samePeer reports whether two peers refer to the same chat
+func samePeer(a, b tg.PeerClass) bool {
+	switch pa := a.(type) {
+	case *tg.PeerUser:
+		pb, ok := b.(*tg.PeerUser)
+		return ok && pa.UserID == pb.UserID
+	case *tg.PeerChat:
+		pb, ok := b.(*tg.PeerChat)
+		return ok && pa.ChatID == pb.ChatID
+	case *tg.PeerChannel:
+		pb, ok := b.(*tg.PeerChannel)
+		return ok && pa.ChannelID == pb.ChannelID
+	}
+	return false
+}
+
+// classifyChat converts a dialog chat into a tracked group.
+// Returns false for chats that are not groups or channels.
+func classifyChat(chat tg.ChatClass) (*db.TrackedGroup, bool) {
+	switch ch := chat.(type) {
+	case *tg.Channel:
+		group := &db.TrackedGroup{
+			ChatID:        db.ChannelChatID(ch.ID).Int64(),
+			GroupName:     ch.Title,
+			GroupUsername: ch.Username,
+			ChatType:      db.ChatTypeSupergroup,
+		}
+		if ch.Broadcast {
+			group.ChatType = db.ChatTypeChannel
+		}
+		if ch.Left {
+			group.IsLeft = 1
+		}
+		return group, true
+	case *tg.ChannelForbidden:
+		// Banned or kicked
+		group := &db.TrackedGroup{
+			ChatID:    db.ChannelChatID(ch.ID).Int64(),
+			GroupName: ch.Title,
+			ChatType:  db.ChatTypeSupergroup,
+			IsLeft:    1,
+		}
+		if ch.Broadcast {
+			group.ChatType = db.ChatTypeChannel
+		}
+		return group, true
+	case *tg.Chat:
+		group := &db.TrackedGroup{
+			ChatID:    db.GroupChatID(ch.ID).Int64(),
+			GroupName: ch.Title,
+			ChatType:  db.ChatTypeGroup,
+		}
+		if ch.Left || ch.Deactivated {
+			group.IsLeft = 1
+		}
+		if migratedTo, ok := ch.GetMigratedTo(); ok {
+			if channel, ok := migratedTo.(*tg.InputChannel); ok {
+				group.MigratedToChatID = db.ChannelChatID(channel.ChannelID).Int64()
+				group.IsLeft = 1
+			}
+		}
+		return group, true
+	case *tg.ChatForbidden:
+		return &db.TrackedGroup{
+			ChatID:    db.GroupChatID(ch.ID).Int64(),
+			GroupName: ch.Title,
+			ChatType:  db.ChatTypeGroup,
+			IsLeft:    1,
+		}, true
+	}
+	return nil, false
+}
+
+// syncGroup stores a group from the dialog list, logging renames and migrations
+func (c *Client) syncGroup(group *db.TrackedGroup) {
+	existing := c.db.GetTrackedGroup(group.ChatID)
+	
+	if existing != nil {
+		if existing.GroupName != "" && existing.GroupName != group.GroupName {
+			logger.Info("✏️  Group renamed: %s → %s (ID: %d)", existing.GroupName, group.GroupName, group.ChatID)
+		}
+		if existing.IsLeft == 0 && group.IsLeft == 1 {
+			logger.Info("👋 Left group: %s (ID: %d)", group.GroupName, group.ChatID)
+		}
+	}
+	
+	if err := c.db.SyncTrackedGroup(group); err != nil {
+		logger.Error("Failed to sync group %s: %v", group.GroupName, err)
+		return
+	}
+	
+	if group.IsLeft == 1 && (existing == nil || existing.IsActive == 1) {
+		c.db.MarkGroupLeft(group.ChatID)
+	}
+	
+	if group.MigratedToChatID != 0 && (existing == nil || existing.MigratedToChatID == 0) {
+		logger.Info("🔀 Group migrated: %s (ID: %d → %d)", group.GroupName, group.ChatID, group.MigratedToChatID)
+		if err := c.db.MarkGroupMigrated(group.ChatID, group.MigratedToChatID); err != nil {
+			logger.Error("Failed to record group migration: %v", err)
+		}
+	}
+	
+	logger.Debug("  📂 %s (ID: %d, Type: %s, Folder: %d)", group.GroupName, group.ChatID, group.ChatType, group.FolderID)
+}
+
+// runDialogSync periodically re-syncs the dialog list until the context is done
+func (c *Client) runDialogSync(ctx context.Context) {
+	ticker := time.NewTicker(c.dialogSyncInterval)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case <-ticker.C:
+			logger.Info("🔄 Re-syncing groups...")
+			if err := c.fetchAllDialogs(ctx); err != nil {
+				if auth.IsUnauthorized(err) {
+					c.alertSessionExpired(err)
+					return
+				}
+				logger.Error("Failed to re-sync dialogs: %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// alertSessionExpired notifies the admin that the scraper lost its session.
+// cause is nil when the expiry was detected at startup and a new login is starting.
+func (c *Client) alertSessionExpired(cause error) {
+	message := fmt.Sprintf("The scraper session for %s is no longer authorized.", c.phone)
+	if cause != nil {
+		message += fmt.Sprintf("\nError: %v\nRestart the scraper to log in again.", cause)
+	} else {
+		message += "\nStarting a new login, a verification code will be requested."
+	}
+	
+	logger.Error("⚠️  Session expired: %s", message)
+	logger.SendAlertNotification("Scraper session expired", message)
 }
 
 // Stop stops the client gracefully
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"path/filepath"
+	"telegram-summarizer/internal/db"
+	"testing"
+	"time"
+	
+	"github.com/gotd/td/tg"
+)
+
+func TestHandleMessageSkipsBroadcastChannels(t *testing.T) {
+	store, err := db.Open(db.DriverSQLite, filepath.Join(t.TempDir(), "client.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	c := NewClient(Config{Database: store})
+	
+	// Channel 1 is a tracked broadcast channel, channel 2 a tracked supergroup
+	for id, chatType := range map[int64]string{1: db.ChatTypeChannel, 2: db.ChatTypeSupergroup} {
+		chatID := db.ChannelChatID(id).Int64()
+		if err := store.SyncTrackedGroup(&db.TrackedGroup{ChatID: chatID, GroupName: "Chat", ChatType: chatType}); err != nil {
+			t.Fatalf("SyncTrackedGroup: %v", err)
+		}
+		if err := store.EnableGroupSummary(chatID); err != nil {
+			t.Fatalf("EnableGroupSummary: %v", err)
+		}
+	}
+	
+	date := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	post := func(channelID int64, msgID int) *tg.Message {
+		return &tg.Message{
+			ID:      msgID,
+			PeerID:  &tg.PeerChannel{ChannelID: channelID},
+			Message: "Paket internet Telkomsel lagi promo murah hari ini",
+			Date:    int(date.Unix()),
+		}
+	}
+	
+	tests := []struct {
+		name      string
+		channelID int64
+		chats     map[int64]tg.ChatClass
+		want      int
+	}{
+		{"tracked broadcast channel", 1, nil, 0},
+		{"untracked broadcast channel", 3, map[int64]tg.ChatClass{3: &tg.Channel{ID: 3, Title: "News", Broadcast: true}}, 0},
+		{"supergroup", 2, map[int64]tg.ChatClass{2: &tg.Channel{ID: 2, Title: "Chat", Megagroup: true}}, 1},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := c.handleMessage(context.Background(), post(tt.channelID, i+1), nil, tt.chats); err != nil {
+				t.Fatalf("handleMessage: %v", err)
+			}
+			chatID := db.ChannelChatID(tt.channelID).Int64()
+			messages, err := store.GetMessagesByTimeRange(chatID, date.Add(-time.Hour), date.Add(time.Hour))
+			if err != nil {
+				t.Fatalf("GetMessagesByTimeRange: %v", err)
+			}
+			if len(messages) != tt.want {
+				t.Errorf("got %d saved messages, want %d", len(messages), tt.want)
+			}
+			if tt.want == 0 && tt.chats != nil && store.GetTrackedGroup(chatID) != nil {
+				t.Errorf("broadcast channel %d was tracked", tt.channelID)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"telegram-summarizer/internal/db"
+	"testing"
+	
+	"github.com/gotd/td/tg"
+)
+
+// fakeDialogs serves a dialog list of supergroups in pages of the given sizes. A page
+// past the pages given fails.
+type fakeDialogs struct {
+	ids   []int64 // Channel IDs in dialog order
+	pages []int   // Dialogs per page
+	calls int
+}
+
+func (f *fakeDialogs) MessagesGetDialogs(ctx context.Context, request *tg.MessagesGetDialogsRequest) (tg.MessagesDialogsClass, error) {
+	if folderID, _ := request.GetFolderID(); folderID != folderMain {
+		return &tg.MessagesDialogs{}, nil
+	}
+	if f.calls >= len(f.pages) {
+		return nil, errors.New("FLOOD_WAIT_30")
+	}
+	start := 0
+	for _, size := range f.pages[:f.calls] {
+		start += size
+	}
+	f.calls++
+	
+	slice := &tg.MessagesDialogsSlice{Count: len(f.ids)}
+	for i, id := range f.ids[start : start+f.pages[f.calls-1]] {
+		peer := &tg.PeerChannel{ChannelID: id}
+		slice.Dialogs = append(slice.Dialogs, &tg.Dialog{Peer: peer, TopMessage: int(id)})
+		slice.Chats = append(slice.Chats, &tg.Channel{ID: id, Title: "Group", AccessHash: id, Megagroup: true})
+		slice.Messages = append(slice.Messages, &tg.Message{ID: int(id), PeerID: peer, Date: 1700000000 - start - i})
+	}
+	return slice, nil
+}
+
+// newDialogsClient creates a client on a temporary store tracking an active group for
+// each channel ID
+func newDialogsClient(t *testing.T, ids []int64) *Client {
+	t.Helper()
+	store, err := db.Open(db.DriverSQLite, filepath.Join(t.TempDir(), "client.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	
+	for _, id := range ids {
+		chatID := db.ChannelChatID(id).Int64()
+		if err := store.SyncTrackedGroup(&db.TrackedGroup{ChatID: chatID, GroupName: "Group", ChatType: db.ChatTypeSupergroup}); err != nil {
+			t.Fatalf("SyncTrackedGroup: %v", err)
+		}
+		if err := store.EnableGroupSummary(chatID); err != nil {
+			t.Fatalf("EnableGroupSummary: %v", err)
+		}
+	}
+	return &Client{db: store}
+}
+
+func channelIDs(n int) []int64 {
+	ids := make([]int64, n)
+	for i := range ids {
+		ids[i] = int64(1000 + i)
+	}
+	return ids
+}
+
+func TestDialogSyncFailingMidway(t *testing.T) {
+	ids := channelIDs(250)
+	c := newDialogsClient(t, ids)
+	
+	// The third page fails, so the last 50 groups are not seen
+	api := &fakeDialogs{ids: ids, pages: []int{100, 100}}
+	if err := c.syncDialogs(context.Background(), api); err != nil {
+		t.Fatalf("syncDialogs: %v", err)
+	}
+	if api.calls != 2 {
+		t.Errorf("got %d pages, want 2", api.calls)
+	}
+	for _, id := range ids {
+		group := c.db.GetTrackedGroup(db.ChannelChatID(id).Int64())
+		if group == nil || group.IsLeft != 0 || group.IsActive != 1 {
+			t.Fatalf("group %d after an incomplete sync: %+v, want it joined and active", id, group)
+		}
+	}
+}
+
+func TestDialogSyncShortPages(t *testing.T) {
+	ids := channelIDs(150)
+	c := newDialogsClient(t, ids)
+	
+	// Telegram may return short pages before the end of the list
+	api := &fakeDialogs{ids: ids, pages: []int{60, 40, 50}}
+	if err := c.syncDialogs(context.Background(), api); err != nil {
+		t.Fatalf("syncDialogs: %v", err)
+	}
+	if api.calls != 3 {
+		t.Errorf("got %d pages, want 3", api.calls)
+	}
+	for _, id := range ids {
+		if group := c.db.GetTrackedGroup(db.ChannelChatID(id).Int64()); group == nil || group.IsLeft != 0 {
+			t.Fatalf("group %d after a complete sync: %+v, want it joined", id, group)
+		}
+	}
+}
+
+func TestDialogSyncMarksLeftGroups(t *testing.T) {
+	ids := channelIDs(120)
+	c := newDialogsClient(t, ids)
+	
+	// The last group is gone from a complete list
+	api := &fakeDialogs{ids: ids[:119], pages: []int{100, 19}}
+	if err := c.syncDialogs(context.Background(), api); err != nil {
+		t.Fatalf("syncDialogs: %v", err)
+	}
+	left := db.ChannelChatID(ids[119]).Int64()
+	if group := c.db.GetTrackedGroup(left); group == nil || group.IsLeft != 1 || group.IsActive != 0 {
+		t.Fatalf("group missing from a complete list: %+v, want it left", group)
+	}
+	
+	// It shows up again with its summaries
+	api = &fakeDialogs{ids: ids, pages: []int{100, 20}}
+	if err := c.syncDialogs(context.Background(), api); err != nil {
+		t.Fatalf("syncDialogs: %v", err)
+	}
+	if group := c.db.GetTrackedGroup(left); group == nil || group.IsLeft != 0 || group.IsActive != 1 {
+		t.Errorf("group showing up again: %+v, want it joined and active", group)
+	}
+}
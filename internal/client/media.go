@@ -0,0 +1,144 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"telegram-summarizer/internal/db"
+	"telegram-summarizer/internal/logger"
+	"telegram-summarizer/internal/ocr"
+	
+	"github.com/gotd/td/telegram/downloader"
+	"github.com/gotd/td/tg"
+)
+
+// mediaInfo describes the attachment of a message
+type mediaInfo struct {
+	Type     string
+	FileID   string // "photo:<id>" or "document:<id>" (MTProto IDs, not Bot API file_ids)
+	Size     int64
+	IsImage  bool
+	Location tg.InputFileLocationClass // Used to download images for OCR
+}
+
+// extractMedia returns the attachment of a message, or nil if it has none
+func extractMedia(media tg.MessageMediaClass) *mediaInfo {
+	switch m := media.(type) {
+	case *tg.MessageMediaPhoto:
+		photo, ok := m.Photo.(*tg.Photo)
+		if !ok {
+			return nil
+		}
+	
+		thumbType, size := largestPhotoSize(photo.Sizes)
+		return &mediaInfo{
+			Type:    db.MediaTypePhoto,
+			FileID:  fmt.Sprintf("photo:%d", photo.ID),
+			Size:    size,
+			IsImage: true,
+			Location: &tg.InputPhotoFileLocation{
+				ID:            photo.ID,
+				AccessHash:    photo.AccessHash,
+				FileReference: photo.FileReference,
+				ThumbSize:     thumbType,
+			},
+		}
+	case *tg.MessageMediaDocument:
+		document, ok := m.Document.(*tg.Document)
+		if !ok {
+			return nil
+		}
+	
+		return &mediaInfo{
+			Type:    documentMediaType(document),
+			FileID:  fmt.Sprintf("document:%d", document.ID),
+			Size:    document.Size,
+			IsImage: strings.HasPrefix(document.MimeType, "image/"),
+			Location: &tg.InputDocumentFileLocation{
+				ID:            document.ID,
+				AccessHash:    document.AccessHash,
+				FileReference: document.FileReference,
+			},
+		}
+	}
+	return nil
+}
+
+// largestPhotoSize returns the type and byte size of the largest photo size
+func largestPhotoSize(sizes []tg.PhotoSizeClass) (string, int64) {
+	var bestType string
+	var bestSize int64
+	for _, sizeClass := range sizes {
+		switch s := sizeClass.(type) {
+		case *tg.PhotoSize:
+			if int64(s.Size) > bestSize {
+				bestType, bestSize = s.Type, int64(s.Size)
+			}
+		case *tg.PhotoSizeProgressive:
+			// Progressive sizes list the byte size of each scan, the last is the full image
+			if len(s.Sizes) > 0 && int64(s.Sizes[len(s.Sizes)-1]) > bestSize {
+				bestType, bestSize = s.Type, int64(s.Sizes[len(s.Sizes)-1])
+			}
+		}
+	}
+	return bestType, bestSize
+}
+
+// documentMediaType classifies a document by its attributes
+func documentMediaType(document *tg.Document) string {
+	mediaType := db.MediaTypeDocument
+	for _, attribute := range document.Attributes {
+		switch a := attribute.(type) {
+		case *tg.DocumentAttributeSticker:
+			return db.MediaTypeSticker
+		case *tg.DocumentAttributeAnimated:
+			return db.MediaTypeAnimation
+		case *tg.DocumentAttributeAudio:
+			if a.Voice {
+				return db.MediaTypeVoice
+			}
+			mediaType = db.MediaTypeAudio
+		case *tg.DocumentAttributeVideo:
+			// Animations carry both attributes, so keep looking for DocumentAttributeAnimated
+			mediaType = db.MediaTypeVideo
+		}
+	}
+	return mediaType
+}
+
+// extractImageText downloads an image and returns the text OCR finds in it ("" on failure)
+func (c *Client) extractImageText(ctx context.Context, media *mediaInfo) string {
+	var buf bytes.Buffer
+	if _, err := downloader.NewDownloader().Download(c.api, media.Location).Stream(ctx, &buf); err != nil {
+		logger.Warn("Failed to download image for OCR (%s): %v", media.FileID, err)
+		return ""
+	}
+	
+	text, err := c.ocr.ExtractText(ctx, buf.Bytes())
+	if err != nil {
+		logger.Warn("OCR failed (%s): %v", media.FileID, err)
+		return ""
+	}
+	return text
+}
+
+// recognizeImage downloads an image and stores the OCR text on the message
+func (c *Client) recognizeImage(ctx context.Context, messageID int64, media *mediaInfo) {
+	text := c.extractImageText(ctx, media)
+	if text == "" {
+		return
+	}
+	
+	if err := c.db.SetMessageOCRText(messageID, text); err != nil {
+		logger.Error("Failed to save OCR text: %v", err)
+		return
+	}
+	
+	logger.Info("🔍 OCR text saved (MessageID=%d): %q", messageID, truncateText(text, 50))
+}
+
+// shouldRecognize reports whether an attachment should be sent to the OCR engine
+func (c *Client) shouldRecognize(media *mediaInfo) bool {
+	return c.ocr != nil && media != nil && media.IsImage && media.Size <= ocr.MaxImageSize
+}
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"telegram-summarizer/internal/db"
+	
+	"github.com/gotd/td/telegram/updates"
+)
+
+// updateStateStorage persists the updates manager state in the database, so missed
+// updates are recovered with getDifference/getChannelDifference after a restart.
+// It implements updates.StateStorage and updates.ChannelAccessHasher.
+type updateStateStorage struct {
+	db db.Store
+}
+
+// GetState implements updates.StateStorage
+func (s updateStateStorage) GetState(ctx context.Context, userID int64) (updates.State, bool, error) {
+	state, found, err := s.db.GetUpdateState(userID)
+	if err != nil || !found {
+		return updates.State{}, found, err
+	}
+	return updates.State{Pts: state.Pts, Qts: state.Qts, Date: state.Date, Seq: state.Seq}, true, nil
+}
+
+// SetState implements updates.StateStorage
+func (s updateStateStorage) SetState(ctx context.Context, userID int64, state updates.State) error {
+	return s.db.SetUpdateState(userID, db.UpdateState{Pts: state.Pts, Qts: state.Qts, Date: state.Date, Seq: state.Seq})
+}
+
+// SetPts implements updates.StateStorage
+func (s updateStateStorage) SetPts(ctx context.Context, userID int64, pts int) error {
+	return s.db.SetUpdateStateFields(userID, map[string]int{"pts": pts})
+}
+
+// SetQts implements updates.StateStorage
+func (s updateStateStorage) SetQts(ctx context.Context, userID int64, qts int) error {
+	return s.db.SetUpdateStateFields(userID, map[string]int{"qts": qts})
+}
+
+// SetDate implements updates.StateStorage
+func (s updateStateStorage) SetDate(ctx context.Context, userID int64, date int) error {
+	return s.db.SetUpdateStateFields(userID, map[string]int{"date": date})
+}
+
+// SetSeq implements updates.StateStorage
+func (s updateStateStorage) SetSeq(ctx context.Context, userID int64, seq int) error {
+	return s.db.SetUpdateStateFields(userID, map[string]int{"seq": seq})
+}
+
+// SetDateSeq implements updates.StateStorage
+func (s updateStateStorage) SetDateSeq(ctx context.Context, userID int64, date, seq int) error {
+	return s.db.SetUpdateStateFields(userID, map[string]int{"date": date, "seq": seq})
+}
+
+// GetChannelPts implements updates.StateStorage
+func (s updateStateStorage) GetChannelPts(ctx context.Context, userID, channelID int64) (int, bool, error) {
+	return s.db.GetChannelPts(userID, channelID)
+}
+
+// SetChannelPts implements updates.StateStorage
+func (s updateStateStorage) SetChannelPts(ctx context.Context, userID, channelID int64, pts int) error {
+	return s.db.SetChannelPts(userID, channelID, pts)
+}
+
+// ForEachChannels implements updates.StateStorage
+func (s updateStateStorage) ForEachChannels(ctx context.Context, userID int64, f func(ctx context.Context, channelID int64, pts int) error) error {
+	channels, err := s.db.GetAllChannelPts(userID)
+	if err != nil {
+		return err
+	}
+	for channelID, pts := range channels {
+		if err := f(ctx, channelID, pts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetChannelAccessHash implements updates.ChannelAccessHasher
+func (s updateStateStorage) GetChannelAccessHash(ctx context.Context, userID, channelID int64) (int64, bool, error) {
+	return s.db.GetChannelAccessHash(userID, channelID)
+}
+
+// SetChannelAccessHash implements updates.ChannelAccessHasher
+func (s updateStateStorage) SetChannelAccessHash(ctx context.Context, userID, channelID, accessHash int64) error {
+	return s.db.SetChannelAccessHash(userID, channelID, accessHash)
+}
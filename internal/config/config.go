@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"strconv"
 )
 
 // Config holds all configuration for the bot
@@ -10,9 +11,31 @@ type Config struct {
 	GeminiAPIKey     string
 	GeminiModel      string
 	DatabasePath     string
+	DatabaseDriver   string // sqlite or postgres
+	DatabaseURL      string // PostgreSQL connection URL
 	DebugMode        bool
 	SummaryInterval  int // in hours
 	DailySummaryTime string
+	Timezone         string // IANA timezone of summary windows and dates (empty = server local time)
+	ReportChatID     int64 // Chat receiving the daily run report (0 = none)
+	TargetChatID     int64 // Chat that received all daily summaries before subscriptions (subscribed once on upgrade, 0 = none)
+	OwnerID          int64 // User who may subscribe to any group and the digest (0 = none)
+	SummaryWorkers   int   // Summary jobs generated at the same time
+	
+	// Leader election between instances sharing the database
+	LeaderElection bool   // Only the leader runs the scheduler and polls bot updates
+	InstanceID     string // ID of this instance in the lease (empty = host name and PID)
+	LeaderLease    int    // Seconds a lease lasts without a heartbeat
+	
+	// Scraper authentication
+	ScraperAuthMode string // terminal, env, file or bot
+	AuthAdminChatID int64  // Chat that receives login prompts in bot mode (required by bot mode)
+	
+	// Message retention (defaults, can be overridden per group)
+	RetentionDays int    // Days of messages to keep after the daily summary
+	ArchiveMode   string // none, jsonl or db
+	ArchiveDir    string // Directory of compressed JSONL archives
+	ArchiveDBPath string // SQLite database used by the db archive mode
 }
 
 // Load loads configuration from environment variables with fallbacks
@@ -26,7 +49,9 @@ func Load() *Config {
 		GeminiModel:  getEnv("GEMINI_MODEL", "gemini-2.0-flash-exp"),
 		
 		// Database
-		DatabasePath: getEnv("DATABASE_PATH", "telegram_bot.db"),
+		DatabasePath:   getEnv("DATABASE_PATH", "telegram_bot.db"),
+		DatabaseDriver: getEnv("DATABASE_DRIVER", "sqlite"),
+		DatabaseURL:    getEnv("DATABASE_URL", ""),
 		
 		// Debug Mode
 		DebugMode: getEnv("DEBUG_MODE", "true") == "true",
@@ -34,11 +59,39 @@ func Load() *Config {
 		// Summary Configuration
 		SummaryInterval:  4,      // Every 4 hours
 		DailySummaryTime: "23:59", // Daily summary time
+		Timezone:         getEnv("TIMEZONE", ""),
+		ReportChatID:     getEnvInt64("REPORT_CHAT_ID", 0),
+		TargetChatID:     getEnvInt64("TARGET_CHAT_ID", 0),
+		OwnerID:          getEnvInt64("OWNER_USER_ID", 0),
+		SummaryWorkers:   int(getEnvInt64("SUMMARY_WORKERS", 4)),
+		
+		// Leader Election
+		LeaderElection: getEnv("LEADER_ELECTION", "false") == "true",
+		InstanceID:     getEnv("INSTANCE_ID", ""),
+		LeaderLease:    int(getEnvInt64("LEADER_LEASE_SECONDS", 30)),
+		
+		// Scraper Authentication
+		ScraperAuthMode: getEnv("SCRAPER_AUTH_MODE", "terminal"),
+		AuthAdminChatID: getEnvInt64("AUTH_ADMIN_CHAT_ID", 0),
+		
+		// Message Retention
+		RetentionDays: int(getEnvInt64("MESSAGE_RETENTION_DAYS", 1)),
+		ArchiveMode:   getEnv("ARCHIVE_MODE", "jsonl"),
+		ArchiveDir:    getEnv("ARCHIVE_DIR", "message_archive"),
+		ArchiveDBPath: getEnv("ARCHIVE_DB_PATH", "archive.db"),
 	}
 	
 	return cfg
 }
 
+// DatabaseSource returns the data source of the configured database driver
+func (c *Config) DatabaseSource() string {
+	if c.DatabaseDriver == "postgres" {
+		return c.DatabaseURL
+	}
+	return c.DatabasePath
+}
+
 // getEnv gets an environment variable or returns a default value
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
@@ -48,6 +101,15 @@ func getEnv(key, defaultValue string) string {
 	return value
 }
 
+// getEnvInt64 gets an integer environment variable or returns a default value
+func getEnvInt64(key string, defaultValue int64) int64 {
+	value, err := strconv.ParseInt(os.Getenv(key), 10, 64)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
 // Validate validates the configuration
 func (c *Config) Validate() error {
 	if c.TelegramToken == "" {
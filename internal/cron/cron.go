@@ -0,0 +1,284 @@
+// Package cron parses standard 5-field cron expressions
+// ("minute hour day-of-month month day-of-week") and computes their run times.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// descriptors are the supported shorthand expressions
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Names accepted in the month and day-of-week fields
+var (
+	monthNames = map[string]int{"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12}
+	dayNames = map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}
+)
+
+// field describes the values allowed in one field of an expression
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+// fields are the fields of an expression, in order
+var fields = []field{
+	{"minute", 0, 59, nil},
+	{"hour", 0, 23, nil},
+	{"day of month", 1, 31, nil},
+	{"month", 1, 12, monthNames},
+	{"day of week", 0, 7, dayNames}, // 7 is Sunday too
+}
+
+// bits is a set of field values
+type bits uint64
+
+// allHours is the hour set of schedules that run every hour
+const allHours bits = 1<<24 - 1
+
+// has reports whether a value is in the set
+func (b bits) has(v int) bool {
+	return b&(1<<uint(v)) != 0
+}
+
+// Schedule is a parsed cron expression. Times are evaluated in the location of
+// the time passed to Next and Prev. As in Vixie cron, run times skipped by a daylight
+// saving gap run right after it, and run times in an hour repeated when clocks go back
+// run once, unless the schedule runs every hour.
+type Schedule struct {
+	expr    string
+	minute  bits
+	hour    bits
+	dom     bits
+	month   bits
+	dow     bits
+	domStar bool // Day of month starts with "*": only day of week restricts days
+	dowStar bool // Day of week starts with "*": only day of month restricts days
+}
+
+// Parse parses a 5-field cron expression or a descriptor such as "@daily".
+// Fields accept "*", values, ranges "a-b", steps "*/n" or "a-b/n" and lists "a,b".
+func Parse(expr string) (*Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	spec := expr
+	if strings.HasPrefix(spec, "@") {
+		var ok bool
+		if spec, ok = descriptors[strings.ToLower(spec)]; !ok {
+			return nil, fmt.Errorf("unknown cron descriptor %q", expr)
+		}
+	}
+	
+	parts := strings.Fields(spec)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("cron expression %q must have %d fields (minute hour day month weekday)", expr, len(fields))
+	}
+	
+	sets := make([]bits, len(fields))
+	for i, part := range parts {
+		set, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+		}
+		sets[i] = set
+	}
+	
+	// Sunday may be written as 0 or 7
+	dow := sets[4]
+	if dow.has(7) {
+		dow |= 1
+	}
+	
+	return &Schedule{
+		expr:    expr,
+		minute:  sets[0],
+		hour:    sets[1],
+		dom:     sets[2],
+		month:   sets[3],
+		dow:     dow,
+		domStar: strings.HasPrefix(parts[2], "*"),
+		dowStar: strings.HasPrefix(parts[4], "*"),
+	}, nil
+}
+
+// parseField parses one field of an expression into the set of its values
+func parseField(part string, f field) (bits, error) {
+	var set bits
+	for _, item := range strings.Split(part, ",") {
+		rangePart, step := item, 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			n, err := strconv.Atoi(item[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step in %s field %q", f.name, item)
+			}
+			rangePart, step = item[:i], n
+		}
+	
+		low, high := f.min, f.max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if low, err = parseValue(bounds[0], f); err != nil {
+				return 0, err
+			}
+			if high, err = parseValue(bounds[1], f); err != nil {
+				return 0, err
+			}
+			if low > high {
+				return 0, fmt.Errorf("invalid range in %s field %q", f.name, item)
+			}
+		default:
+			v, err := parseValue(rangePart, f)
+			if err != nil {
+				return 0, err
+			}
+			low = v
+			if step == 1 {
+				high = v
+			}
+		}
+	
+		for v := low; v <= high; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+// parseValue parses a number or name of a field
+func parseValue(s string, f field) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s must be between %d and %d, got %q", f.name, f.min, f.max, s)
+	}
+	return v, nil
+}
+
+// String returns the expression the schedule was parsed from
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// dayMatches reports whether the schedule runs on the day of t. As in cron, when both
+// day of month and day of week are restricted, either may match. A field starting with
+// "*", such as "*/2", does not restrict days.
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom.has(t.Day())
+	dowMatch := s.dow.has(int(t.Weekday()))
+	switch {
+	case s.domStar && s.dowStar:
+		return true
+	case s.domStar:
+		return dowMatch
+	case s.dowStar:
+		return domMatch
+	}
+	return domMatch || dowMatch
+}
+
+// skippedRun reports whether t is the first minute after a daylight saving gap that
+// skipped a run time of its day, e.g. 03:00 for "30 2 * * *" when clocks jump from 02:00
+func (s *Schedule) skippedRun(t time.Time) bool {
+	before := t.Add(-time.Minute)
+	from, to := before.Hour()*60+before.Minute()+1, t.Hour()*60+t.Minute()
+	if before.Day() != t.Day() {
+		from = 0
+	}
+	for w := from; w < to; w++ {
+		if s.hour.has(w/60) && s.minute.has(w%60) {
+			return true
+		}
+	}
+	return false
+}
+
+// repeated reports whether t is in the second pass of an hour repeated when clocks go
+// back, which only schedules running every hour run in
+func (s *Schedule) repeated(t time.Time) bool {
+	if s.hour == allHours {
+		return false
+	}
+	hourAgo := t.Add(-time.Hour)
+	return hourAgo.Day() == t.Day() && hourAgo.Hour() == t.Hour()
+}
+
+// searchLimit bounds the search for run times of expressions that never match, e.g. "0 0 31 2 *"
+const searchLimit = 5 * 366 * 24 * time.Hour
+
+// Next returns the first run time after t, or the zero time if there is none
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(searchLimit)
+	
+	for t.Before(limit) {
+		y, m, d := t.Date()
+		switch {
+		case !s.month.has(int(m)):
+			t = time.Date(y, m+1, 1, 0, 0, 0, 0, loc)
+		case !s.dayMatches(t):
+			t = time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+		case s.skippedRun(t):
+			return t
+		case !s.hour.has(t.Hour()) || s.repeated(t):
+			// Elapsed minutes rather than time.Date, which picks the second pass of a
+			// repeated hour
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+		case !s.minute.has(t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// Prev returns the last run time before t, or the zero time if there is none
+func (s *Schedule) Prev(t time.Time) time.Time {
+	loc := t.Location()
+	p := t.Truncate(time.Minute)
+	if !p.Before(t) {
+		p = p.Add(-time.Minute)
+	}
+	limit := p.Add(-searchLimit)
+	
+	for p.After(limit) {
+		y, m, d := p.Date()
+		switch {
+		case !s.month.has(int(m)):
+			p = time.Date(y, m, 1, 0, 0, 0, 0, loc).Add(-time.Minute)
+		case !s.dayMatches(p):
+			p = time.Date(y, m, d, 0, 0, 0, 0, loc).Add(-time.Minute)
+		case s.skippedRun(p):
+			return p
+		case !s.hour.has(p.Hour()) || s.repeated(p):
+			hourStart := p.Add(-time.Duration(p.Minute()) * time.Minute)
+			if s.skippedRun(hourStart) {
+				return hourStart
+			}
+			p = hourStart.Add(-time.Minute)
+		case !s.minute.has(p.Minute()):
+			p = p.Add(-time.Minute)
+		default:
+			return p
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,138 @@
+package cron
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func utc(year int, month time.Month, day, hour, min int) time.Time {
+	return time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+}
+
+func mustParse(t *testing.T, expr string) *Schedule {
+	t.Helper()
+	s, err := Parse(expr)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", expr, err)
+	}
+	return s
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expr  string
+		valid bool
+	}{
+		{"0 */4 * * *", true},
+		{"30 8,20 * * 1-5", true},
+		{"@daily", true},
+		{"@Weekly", true},
+		{"0 9 * jan-mar mon,FRI", true},
+		{"0-30/10 0 1 1 7", true},
+		{"", false},
+		{"0 0 * *", false},
+		{"0 0 * * * *", false},
+		{"60 * * * *", false},
+		{"* 24 * * *", false},
+		{"* * 0 * *", false},
+		{"* * 32 * *", false},
+		{"* * * 13 *", false},
+		{"* * * * 8", false},
+		{"5-1 * * * *", false},
+		{"*/0 * * * *", false},
+		{"*/x * * * *", false},
+		{"* * * foo *", false},
+		{"@every", false},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		if valid := err == nil; valid != tt.valid {
+			t.Errorf("Parse(%q) = %v, %v; want valid %v", tt.expr, s, err, tt.valid)
+		}
+	}
+}
+
+func TestNextAndPrev(t *testing.T) {
+	// 2026-01-05 is a Monday
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		next time.Time
+		prev time.Time
+	}{
+		{"every 4 hours", "0 */4 * * *", utc(2026, 1, 5, 1, 30), utc(2026, 1, 5, 4, 0), utc(2026, 1, 5, 0, 0)},
+		{"every 4 hours on a run time", "0 */4 * * *", utc(2026, 1, 5, 4, 0), utc(2026, 1, 5, 8, 0), utc(2026, 1, 5, 0, 0)},
+		{"every 4 hours past midnight", "0 */4 * * *", utc(2026, 1, 5, 23, 0), utc(2026, 1, 6, 0, 0), utc(2026, 1, 5, 20, 0)},
+		{"weekdays twice", "30 8,20 * * 1-5", utc(2026, 1, 9, 21, 0), utc(2026, 1, 12, 8, 30), utc(2026, 1, 9, 20, 30)},
+		{"weekdays twice on Monday", "30 8,20 * * 1-5", utc(2026, 1, 12, 8, 30), utc(2026, 1, 12, 20, 30), utc(2026, 1, 9, 20, 30)},
+		{"daily descriptor", "@daily", utc(2026, 1, 5, 12, 0), utc(2026, 1, 6, 0, 0), utc(2026, 1, 5, 0, 0)},
+		{"names", "0 9 * feb sun", utc(2026, 1, 5, 0, 0), utc(2026, 2, 1, 9, 0), utc(2025, 2, 23, 9, 0)},
+		{"Sunday as 7", "0 9 * * 7", utc(2026, 1, 5, 0, 0), utc(2026, 1, 11, 9, 0), utc(2026, 1, 4, 9, 0)},
+		{"day of month or day of week", "0 0 13 * 5", utc(2026, 1, 9, 0, 0), utc(2026, 1, 13, 0, 0), utc(2026, 1, 2, 0, 0)},
+		// As in Vixie cron, fields starting with "*" don't restrict days
+		{"stepped day of month", "0 0 */2 * 1", utc(2026, 1, 6, 0, 0), utc(2026, 1, 12, 0, 0), utc(2026, 1, 5, 0, 0)},
+		{"stepped day of week", "0 0 13 * */5", utc(2026, 1, 6, 0, 0), utc(2026, 1, 13, 0, 0), utc(2025, 12, 13, 0, 0)},
+		{"never", "0 0 31 2 *", utc(2026, 1, 5, 0, 0), time.Time{}, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := mustParse(t, tt.expr)
+			if got := s.Next(tt.from); !got.Equal(tt.next) {
+				t.Errorf("Next(%s) = %s, want %s", tt.from, got, tt.next)
+			}
+			if got := s.Prev(tt.from); !got.Equal(tt.prev) {
+				t.Errorf("Prev(%s) = %s, want %s", tt.from, got, tt.prev)
+			}
+		})
+	}
+}
+
+func TestRunTimesAcrossDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+	
+	// Clocks jump from 02:00 CET to 03:00 CEST on 2026-03-29, and go back from
+	// 03:00 CEST to 02:00 CET on 2026-10-25
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want []time.Time // Following run times, by Next
+	}{
+		{"spring forward skips a daily time", "30 2 * * *", utc(2026, 3, 29, 0, 0), // 01:00 CET
+			[]time.Time{utc(2026, 3, 29, 1, 0), utc(2026, 3, 30, 0, 30)}}, // 03:00 CEST, 02:30 CEST
+		{"spring forward skips an hour", "0 * * * *", utc(2026, 3, 29, 0, 30), // 01:30 CET
+			[]time.Time{utc(2026, 3, 29, 1, 0), utc(2026, 3, 29, 2, 0)}}, // 03:00 CEST, 04:00 CEST
+		{"fall back repeats a daily time", "30 2 * * *", utc(2026, 10, 24, 23, 0), // 01:00 CEST
+			[]time.Time{utc(2026, 10, 25, 0, 30), utc(2026, 10, 26, 1, 30)}}, // 02:30 CEST, 02:30 CET
+		{"fall back repeats an hour", "0 * * * *", utc(2026, 10, 24, 23, 30), // 01:30 CEST
+			[]time.Time{utc(2026, 10, 25, 0, 0), utc(2026, 10, 25, 1, 0), utc(2026, 10, 25, 2, 0)}}, // 02:00 CEST, 02:00 CET, 03:00 CET
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := mustParse(t, tt.expr)
+			from := tt.from.In(berlin)
+			for _, want := range tt.want {
+				got := s.Next(from)
+				if !got.Equal(want) {
+					t.Fatalf("Next(%s) = %s, want %s", from, got, want.In(berlin))
+				}
+				if prev := s.Prev(got.Add(time.Minute)); !prev.Equal(got) {
+					t.Errorf("Prev(%s) = %s, want %s", got.Add(time.Minute), prev, got)
+				}
+				from = got
+			}
+		})
+	}
+	
+	// In the repeated hour, the last run time is that of the first pass
+	s := mustParse(t, "0,30 2 * * *")
+	from := utc(2026, 10, 25, 1, 15).In(berlin) // 02:15 CET
+	if got, want := s.Prev(from), utc(2026, 10, 25, 0, 30); !got.Equal(want) {
+		t.Errorf("Prev(%s) = %s, want %s", from, got, want.In(berlin))
+	}
+}
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ChatID is a chat identifier in canonical (Bot API) form.
+// Supergroups and channels are stored as -100<id>, basic groups as -<id>
+// and private chats as the positive user ID. The scraper receives raw
+// MTProto IDs and must convert them with ChannelChatID or GroupChatID.
+type ChatID int64
+
+// channelIDOffset is the Bot API offset applied to MTProto channel IDs
+const channelIDOffset = 1000000000000
+
+// ChannelChatID converts a raw MTProto channel/supergroup ID to canonical form
+func ChannelChatID(channelID int64) ChatID {
+	return ChatID(-channelIDOffset - channelID)
+}
+
+// GroupChatID converts a raw MTProto basic group ID to canonical form
+func GroupChatID(chatID int64) ChatID {
+	return ChatID(-chatID)
+}
+
+// UserChatID converts a user ID to the canonical form of its private chat
+func UserChatID(userID int64) ChatID {
+	return ChatID(userID)
+}
+
+// Int64 returns the chat ID as stored in the database
+func (id ChatID) Int64() int64 {
+	return int64(id)
+}
+
+// IsChannel reports whether the ID refers to a supergroup or channel
+func (id ChatID) IsChannel() bool {
+	return id < -channelIDOffset
+}
+
+// IsGroup reports whether the ID refers to a basic group
+func (id ChatID) IsGroup() bool {
+	return id < 0 && !id.IsChannel()
+}
+
+// IsUser reports whether the ID refers to a private chat
+func (id ChatID) IsUser() bool {
+	return id > 0
+}
+
+// Raw returns the MTProto ID (channel, chat or user ID) without the Bot API prefix
+func (id ChatID) Raw() int64 {
+	switch {
+	case id.IsChannel():
+		return -channelIDOffset - int64(id)
+	case id.IsGroup():
+		return -int64(id)
+	default:
+		return int64(id)
+	}
+}
+
+// String returns the canonical chat ID
+func (id ChatID) String() string {
+	return strconv.FormatInt(int64(id), 10)
+}
+
+// ParseChatID parses a chat ID from user input.
+// Negative values are already in canonical form and are returned as is; positive
+// values are ambiguous raw MTProto IDs and must be resolved with DB.ResolveChatID.
+func ParseChatID(s string) (ChatID, error) {
+	value, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid chat ID %q: %w", s, err)
+	}
+	if value == 0 {
+		return 0, fmt.Errorf("invalid chat ID %q", s)
+	}
+	return ChatID(value), nil
+}
+
+// ResolveChatID parses a chat ID from user input and maps it to a tracked group.
+// Raw MTProto IDs (as shown by the scraper or other clients) are matched against the
+// supergroup/channel form first and then the basic group form. The parsed ID is
+// returned unchanged when no tracked group matches.
+func (db *DB) ResolveChatID(s string) (ChatID, error) {
+	id, err := ParseChatID(s)
+	if err != nil {
+		return 0, err
+	}
+	
+	if !id.IsUser() || db.GetTrackedGroup(id.Int64()) != nil {
+		return id, nil
+	}
+	
+	for _, candidate := range []ChatID{ChannelChatID(int64(id)), GroupChatID(int64(id))} {
+		if db.GetTrackedGroup(candidate.Int64()) != nil {
+			return candidate, nil
+		}
+	}
+	
+	return id, nil
+}
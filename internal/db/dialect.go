@@ -0,0 +1,209 @@
+package db
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Supported database drivers
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+)
+
+// sqlConn runs queries written in SQLite syntax against the configured driver.
+// Queries use ? placeholders and SQLite column types; for PostgreSQL they are
+// rewritten before execution. Time arguments are converted to UTC, see utcArgs.
+//
+// The embedded pool is used for writes. SQLite additionally has a separate
+// reader pool, so reads never wait behind the single writer connection.
+type sqlConn struct {
+	*sql.DB
+	reader *sql.DB
+	driver string
+}
+
+// queryExecer is implemented by connections and transactions
+type queryExecer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// inserter is implemented by connections and transactions
+type inserter interface {
+	insert(query string, args ...interface{}) (int64, error)
+}
+
+// readDB returns the pool used for reads
+func (c *sqlConn) readDB() *sql.DB {
+	if c.reader != nil {
+		return c.reader
+	}
+	return c.DB
+}
+
+// Exec executes a query without returning rows
+func (c *sqlConn) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return c.DB.Exec(c.rebind(query), utcArgs(args)...)
+}
+
+// Query executes a query that returns rows
+func (c *sqlConn) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return c.readDB().Query(c.rebind(query), utcArgs(args)...)
+}
+
+// QueryRow executes a query that returns at most one row
+func (c *sqlConn) QueryRow(query string, args ...interface{}) *sql.Row {
+	return c.readDB().QueryRow(c.rebind(query), utcArgs(args)...)
+}
+
+// Close closes the writer and reader pools
+func (c *sqlConn) Close() error {
+	err := c.DB.Close()
+	if c.reader != nil {
+		if rerr := c.reader.Close(); err == nil {
+			err = rerr
+		}
+	}
+	return err
+}
+
+// insert executes an INSERT statement and returns the ID of the new row
+func (c *sqlConn) insert(query string, args ...interface{}) (int64, error) {
+	return insertRow(c, c.driver, query, args...)
+}
+
+// insertIfAbsent executes an INSERT ... ON CONFLICT DO NOTHING statement and returns
+// the ID of the new row, or 0 if a conflicting row exists
+func (c *sqlConn) insertIfAbsent(query string, args ...interface{}) (int64, error) {
+	if c.driver == DriverPostgres {
+		id, err := insertRow(c, c.driver, query, args...)
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return id, err
+	}
+	
+	result, err := c.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// begin starts a write transaction
+func (c *sqlConn) begin() (*sqlTx, error) {
+	tx, err := c.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &sqlTx{Tx: tx, conn: c}, nil
+}
+
+// sqlTx is a transaction that rewrites queries like sqlConn
+type sqlTx struct {
+	*sql.Tx
+	conn *sqlConn
+}
+
+// Exec executes a query without returning rows
+func (t *sqlTx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return t.Tx.Exec(t.conn.rebind(query), utcArgs(args)...)
+}
+
+// QueryRow executes a query that returns at most one row
+func (t *sqlTx) QueryRow(query string, args ...interface{}) *sql.Row {
+	return t.Tx.QueryRow(t.conn.rebind(query), utcArgs(args)...)
+}
+
+// insert executes an INSERT statement and returns the ID of the new row
+func (t *sqlTx) insert(query string, args ...interface{}) (int64, error) {
+	return insertRow(t, t.conn.driver, query, args...)
+}
+
+// insertRow executes an INSERT statement and returns the ID of the new row
+func insertRow(q queryExecer, driver string, query string, args ...interface{}) (int64, error) {
+	if driver == DriverPostgres {
+		var id int64
+		err := q.QueryRow(query+" RETURNING id", args...).Scan(&id)
+		return id, err
+	}
+	
+	result, err := q.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// utcArgs returns query arguments with times converted to UTC. SQLite stores a time
+// as text with its offset and compares times as text, so a time written or queried
+// in another zone would not match the stored ones. Timezones only apply to
+// scheduling and display.
+func utcArgs(args []interface{}) []interface{} {
+	var converted []interface{}
+	for i, arg := range args {
+		var utc interface{}
+		switch v := arg.(type) {
+		case time.Time:
+			utc = v.UTC()
+		case sql.NullTime:
+			v.Time = v.Time.UTC()
+			utc = v
+		default:
+			continue
+		}
+		if converted == nil {
+			converted = append([]interface{}(nil), args...)
+		}
+		converted[i] = utc
+	}
+	if converted == nil {
+		return args
+	}
+	return converted
+}
+
+// rebind replaces ? placeholders with $1, $2, ... for PostgreSQL.
+// Question marks inside string literals are left alone.
+func (c *sqlConn) rebind(query string) string {
+	if c.driver != DriverPostgres || !strings.Contains(query, "?") {
+		return query
+	}
+	
+	var b strings.Builder
+	b.Grow(len(query) + 16)
+	n := 0
+	inString := false
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			inString = !inString
+		case r == '?' && !inString:
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// ddl translates SQLite column types of a schema statement to the driver's types
+func (c *sqlConn) ddl(stmt string) string {
+	if c.driver != DriverPostgres {
+		return stmt
+	}
+	
+	return strings.NewReplacer(
+		"INTEGER PRIMARY KEY AUTOINCREMENT", "BIGSERIAL PRIMARY KEY",
+		"INTEGER", "BIGINT",
+		"DATETIME", "TIMESTAMPTZ",
+	).Replace(stmt)
+}
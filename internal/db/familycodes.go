@@ -0,0 +1,203 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// familyCodeColumns are the columns read by scanFamilyCode (table alias f)
+const familyCodeColumns = `f.code, COALESCE(f.product_text, ''), f.first_seen, f.last_seen, f.mention_count,
+	(SELECT COUNT(*) FROM family_code_groups fg WHERE fg.code = f.code),
+	(SELECT COUNT(*) FROM family_code_votes v WHERE v.code = f.code AND v.works = 1),
+	(SELECT COUNT(*) FROM family_code_votes v WHERE v.code = f.code AND v.works = 0)`
+
+// scanFamilyCode scans a row selected with familyCodeColumns
+func scanFamilyCode(row interface{ Scan(...interface{}) error }) (*FamilyCode, error) {
+	var fc FamilyCode
+	var firstSeen, lastSeen sql.NullTime
+	if err := row.Scan(&fc.Code, &fc.ProductText, &firstSeen, &lastSeen, &fc.MentionCount,
+		&fc.Groups, &fc.WorksCount, &fc.FailsCount); err != nil {
+		return nil, err
+	}
+	fc.FirstSeen = firstSeen.Time
+	fc.LastSeen = lastSeen.Time
+	return &fc, nil
+}
+
+// RecordFamilyCode records a message sharing a FamilyCode: the code, its group and the message
+func (db *DB) RecordFamilyCode(s FamilyCodeSighting) error {
+	tx, err := db.conn.begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	
+	// The same message may be seen twice (bot and scraper); count it once
+	result, err := tx.Exec(`
+		INSERT INTO family_code_messages (chat_id, message_id, code, created_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(chat_id, message_id, code) DO NOTHING`, s.ChatID, s.MessageID, s.Code, s.Timestamp)
+	if err != nil {
+		return fmt.Errorf("failed to save family code message: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return nil
+	}
+	
+	codeQuery := `
+		INSERT INTO family_codes (code, product_text, first_seen, last_seen, mention_count)
+		VALUES (?, ?, ?, ?, 1)
+		ON CONFLICT(code) DO UPDATE SET
+			product_text = CASE WHEN excluded.product_text != '' AND excluded.last_seen >= family_codes.last_seen
+				THEN excluded.product_text ELSE family_codes.product_text END,
+			first_seen = CASE WHEN excluded.first_seen < family_codes.first_seen THEN excluded.first_seen ELSE family_codes.first_seen END,
+			last_seen = CASE WHEN excluded.last_seen > family_codes.last_seen THEN excluded.last_seen ELSE family_codes.last_seen END,
+			mention_count = family_codes.mention_count + 1`
+	if _, err := tx.Exec(codeQuery, s.Code, s.ProductText, s.Timestamp, s.Timestamp); err != nil {
+		return fmt.Errorf("failed to save family code: %w", err)
+	}
+	
+	groupQuery := `
+		INSERT INTO family_code_groups (code, chat_id, mention_count, first_seen, last_seen)
+		VALUES (?, ?, 1, ?, ?)
+		ON CONFLICT(code, chat_id) DO UPDATE SET
+			mention_count = family_code_groups.mention_count + 1,
+			first_seen = CASE WHEN excluded.first_seen < family_code_groups.first_seen THEN excluded.first_seen ELSE family_code_groups.first_seen END,
+			last_seen = CASE WHEN excluded.last_seen > family_code_groups.last_seen THEN excluded.last_seen ELSE family_code_groups.last_seen END`
+	if _, err := tx.Exec(groupQuery, s.Code, s.ChatID, s.Timestamp, s.Timestamp); err != nil {
+		return fmt.Errorf("failed to save family code group: %w", err)
+	}
+	
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit family code: %w", err)
+	}
+	return nil
+}
+
+// GetFamilyCodesByMessage gets the FamilyCodes shared by a message
+func (db *DB) GetFamilyCodesByMessage(chatID, messageID int64) ([]string, error) {
+	rows, err := db.conn.Query(`
+		SELECT code FROM family_code_messages
+		WHERE chat_id = ? AND message_id = ?
+		ORDER BY code`, chatID, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get family codes of message: %w", err)
+	}
+	defer rows.Close()
+	
+	var codes []string
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			return nil, fmt.Errorf("failed to scan family code: %w", err)
+		}
+		codes = append(codes, code)
+	}
+	return codes, rows.Err()
+}
+
+// RecordFamilyCodeVote records whether a user reported a FamilyCode to work.
+// Only the latest vote of each user counts.
+func (db *DB) RecordFamilyCodeVote(code string, userID int64, works bool, at time.Time) error {
+	query := `
+		INSERT INTO family_code_votes (code, user_id, works, voted_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(code, user_id) DO UPDATE SET
+			works = CASE WHEN excluded.voted_at >= family_code_votes.voted_at THEN excluded.works ELSE family_code_votes.works END,
+			voted_at = CASE WHEN excluded.voted_at >= family_code_votes.voted_at THEN excluded.voted_at ELSE family_code_votes.voted_at END`
+	
+	if _, err := db.conn.Exec(query, code, userID, boolInt(works), at); err != nil {
+		return fmt.Errorf("failed to save family code vote: %w", err)
+	}
+	return nil
+}
+
+// GetFamilyCode gets a FamilyCode; returns nil if it was never seen
+func (db *DB) GetFamilyCode(code string) (*FamilyCode, error) {
+	query := `SELECT ` + familyCodeColumns + ` FROM family_codes f WHERE f.code = ?`
+	
+	fc, err := scanFamilyCode(db.conn.QueryRow(query, strings.ToLower(code)))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get family code: %w", err)
+	}
+	return fc, nil
+}
+
+// GetRecentFamilyCodes gets the FamilyCodes seen since a time, most recently seen first
+func (db *DB) GetRecentFamilyCodes(since time.Time, limit int) ([]FamilyCode, error) {
+	query := `
+		SELECT ` + familyCodeColumns + `
+		FROM family_codes f
+		WHERE f.last_seen >= ?
+		ORDER BY f.last_seen DESC
+		LIMIT ?`
+	
+	return db.queryFamilyCodes(query, since, limit)
+}
+
+// SearchFamilyCodes finds FamilyCodes by code prefix or product text (case-insensitive),
+// most recently seen first
+func (db *DB) SearchFamilyCodes(text string, limit int) ([]FamilyCode, error) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	query := `
+		SELECT ` + familyCodeColumns + `
+		FROM family_codes f
+		WHERE f.code LIKE ? OR LOWER(f.product_text) LIKE ?
+		ORDER BY f.last_seen DESC
+		LIMIT ?`
+	
+	return db.queryFamilyCodes(query, text+"%", "%"+text+"%", limit)
+}
+
+// queryFamilyCodes runs a query selecting familyCodeColumns
+func (db *DB) queryFamilyCodes(query string, args ...interface{}) ([]FamilyCode, error) {
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get family codes: %w", err)
+	}
+	defer rows.Close()
+	
+	var codes []FamilyCode
+	for rows.Next() {
+		fc, err := scanFamilyCode(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan family code: %w", err)
+		}
+		codes = append(codes, *fc)
+	}
+	return codes, rows.Err()
+}
+
+// GetFamilyCodeGroups gets the groups a FamilyCode was shared in, most mentions first
+func (db *DB) GetFamilyCodeGroups(code string) ([]FamilyCodeGroup, error) {
+	query := `
+		SELECT fg.chat_id, COALESCE(g.group_name, ''), fg.mention_count, fg.first_seen, fg.last_seen
+		FROM family_code_groups fg
+		LEFT JOIN tracked_groups g ON g.chat_id = fg.chat_id
+		WHERE fg.code = ?
+		ORDER BY fg.mention_count DESC, fg.last_seen DESC`
+	
+	rows, err := db.conn.Query(query, strings.ToLower(code))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get family code groups: %w", err)
+	}
+	defer rows.Close()
+	
+	var groups []FamilyCodeGroup
+	for rows.Next() {
+		var g FamilyCodeGroup
+		var firstSeen, lastSeen sql.NullTime
+		if err := rows.Scan(&g.ChatID, &g.GroupName, &g.MentionCount, &firstSeen, &lastSeen); err != nil {
+			return nil, fmt.Errorf("failed to scan family code group: %w", err)
+		}
+		g.FirstSeen = firstSeen.Time
+		g.LastSeen = lastSeen.Time
+		groups = append(groups, g)
+	}
+	return groups, rows.Err()
+}
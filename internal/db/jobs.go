@@ -0,0 +1,254 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// jobColumns are the columns selected for a SummaryJob, in scan order
+const jobColumns = `j.id, j.kind, j.chat_id, COALESCE(g.group_name, ''), j.summary_type, COALESCE(j.prompt_type, ''),
+	j.window_start, j.window_end, COALESCE(j.target_chat_id, 0), COALESCE(j.min_messages, 0), j.status, j.attempts, j.max_attempts,
+	j.next_run_at, COALESCE(j.last_error, ''), j.created_at, j.updated_at`
+
+// scanJob scans a row selected with jobColumns
+func scanJob(row rowScanner) (*SummaryJob, error) {
+	var job SummaryJob
+	var nextRunAt, createdAt, updatedAt sql.NullTime
+	err := row.Scan(&job.ID, &job.Kind, &job.ChatID, &job.GroupName, &job.SummaryType, &job.PromptType,
+		&job.WindowStart, &job.WindowEnd, &job.TargetChatID, &job.MinMessages, &job.Status, &job.Attempts, &job.MaxAttempts,
+		&nextRunAt, &job.LastError, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	job.NextRunAt = nextRunAt.Time
+	job.CreatedAt = createdAt.Time
+	job.UpdatedAt = updatedAt.Time
+	return &job, nil
+}
+
+// EnqueueJob adds a pending job and sets its ID. Returns false without adding it if a
+// pending or running job of the same window and summary type exists.
+func (db *DB) EnqueueJob(job *SummaryJob) (bool, error) {
+	if job.MaxAttempts <= 0 {
+		job.MaxAttempts = DefaultJobAttempts
+	}
+	if job.CreatedAt.IsZero() {
+		job.CreatedAt = time.Now()
+	}
+	if job.NextRunAt.IsZero() {
+		job.NextRunAt = job.CreatedAt
+	}
+	job.Status = JobPending
+	job.UpdatedAt = job.CreatedAt
+	
+	tx, err := db.conn.begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	
+	existsQuery := `
+		SELECT COUNT(*) FROM summary_jobs
+		WHERE chat_id = ? AND summary_type = ? AND window_start = ? AND status IN (?, ?)`
+	var existing int
+	if err := tx.QueryRow(existsQuery, job.ChatID, job.SummaryType, job.WindowStart, JobPending, JobRunning).Scan(&existing); err != nil {
+		return false, fmt.Errorf("failed to check queued jobs: %w", err)
+	}
+	if existing > 0 {
+		return false, nil
+	}
+	
+	query := `
+		INSERT INTO summary_jobs (kind, chat_id, summary_type, prompt_type, window_start, window_end, target_chat_id,
+			min_messages, status, attempts, max_attempts, next_run_at, last_error, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, 0, ?, ?, '', ?, ?)`
+	id, err := tx.insert(query, job.Kind, job.ChatID, job.SummaryType, job.PromptType, job.WindowStart, job.WindowEnd,
+		job.TargetChatID, job.MinMessages, job.Status, job.MaxAttempts, job.NextRunAt, job.CreatedAt, job.UpdatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit job: %w", err)
+	}
+	job.ID = id
+	return true, nil
+}
+
+// ClaimJob marks the pending job that is due longest as running and counts the attempt.
+// Returns nil if no job is due.
+func (db *DB) ClaimJob(now time.Time) (*SummaryJob, error) {
+	tx, err := db.conn.begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	
+	dueQuery := `
+		SELECT id FROM summary_jobs
+		WHERE status = ? AND next_run_at <= ?
+		ORDER BY next_run_at, id
+		LIMIT 1`
+	var jobID int64
+	err = tx.QueryRow(dueQuery, JobPending, now).Scan(&jobID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find due job: %w", err)
+	}
+	
+	// Another worker may have claimed the job in the meantime
+	claimQuery := `
+		UPDATE summary_jobs SET status = ?, attempts = attempts + 1, updated_at = ?
+		WHERE id = ? AND status = ?`
+	result, err := tx.Exec(claimQuery, JobRunning, now, jobID, JobPending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return nil, nil
+	}
+	
+	job, err := scanJob(tx.QueryRow(`
+		SELECT `+jobColumns+`
+		FROM summary_jobs j
+		LEFT JOIN tracked_groups g ON g.chat_id = j.chat_id
+		WHERE j.id = ?`, jobID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read claimed job: %w", err)
+	}
+	
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit job claim: %w", err)
+	}
+	return job, nil
+}
+
+// CompleteJob marks a job as succeeded
+func (db *DB) CompleteJob(jobID int64) error {
+	query := `UPDATE summary_jobs SET status = ?, last_error = '', updated_at = ? WHERE id = ?`
+	if _, err := db.conn.Exec(query, JobSucceeded, time.Now(), jobID); err != nil {
+		return fmt.Errorf("failed to complete job: %w", err)
+	}
+	return nil
+}
+
+// FailJob records a failed attempt of a job. The job runs again at retryAt, or moves
+// to the failed (dead-letter) state if retryAt is zero.
+func (db *DB) FailJob(jobID int64, lastError string, retryAt time.Time) error {
+	status := JobPending
+	var nextRunAt interface{} = retryAt
+	if retryAt.IsZero() {
+		status = JobFailed
+		nextRunAt = nil
+	}
+	
+	query := `UPDATE summary_jobs SET status = ?, last_error = ?, next_run_at = ?, updated_at = ? WHERE id = ?`
+	if _, err := db.conn.Exec(query, status, lastError, nextRunAt, time.Now(), jobID); err != nil {
+		return fmt.Errorf("failed to record job failure: %w", err)
+	}
+	return nil
+}
+
+// RetryJob queues a failed job again with a fresh set of attempts; returns false if
+// the job does not exist or has not failed
+func (db *DB) RetryJob(jobID int64) (bool, error) {
+	now := time.Now()
+	query := `
+		UPDATE summary_jobs SET status = ?, attempts = 0, next_run_at = ?, updated_at = ?
+		WHERE id = ? AND status = ?`
+	
+	result, err := db.conn.Exec(query, JobPending, now, now, jobID, JobFailed)
+	if err != nil {
+		return false, fmt.Errorf("failed to retry job: %w", err)
+	}
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+// ResetRunningJobs returns jobs left running by a stopped process to the queue;
+// returns the number of jobs reset
+func (db *DB) ResetRunningJobs() (int64, error) {
+	now := time.Now()
+	query := `UPDATE summary_jobs SET status = ?, next_run_at = ?, updated_at = ? WHERE status = ?`
+	
+	result, err := db.conn.Exec(query, JobPending, now, now, JobRunning)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reset running jobs: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// GetJob gets a job by ID (nil if it does not exist)
+func (db *DB) GetJob(jobID int64) (*SummaryJob, error) {
+	job, err := scanJob(db.conn.QueryRow(`
+		SELECT `+jobColumns+`
+		FROM summary_jobs j
+		LEFT JOIN tracked_groups g ON g.chat_id = j.chat_id
+		WHERE j.id = ?`, jobID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	return job, nil
+}
+
+// GetJobs gets up to limit jobs of a status (all statuses if empty), most recently updated first
+func (db *DB) GetJobs(status string, limit int) ([]SummaryJob, error) {
+	query := `
+		SELECT ` + jobColumns + `
+		FROM summary_jobs j
+		LEFT JOIN tracked_groups g ON g.chat_id = j.chat_id
+		WHERE ? = '' OR j.status = ?
+		ORDER BY j.updated_at DESC, j.id DESC
+		LIMIT ?`
+	
+	rows, err := db.conn.Query(query, status, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get jobs: %w", err)
+	}
+	defer rows.Close()
+	
+	var jobs []SummaryJob
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs, rows.Err()
+}
+
+// CountJobs counts the jobs of each status
+func (db *DB) CountJobs() (map[string]int, error) {
+	rows, err := db.conn.Query(`SELECT status, COUNT(*) FROM summary_jobs GROUP BY status`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count jobs: %w", err)
+	}
+	defer rows.Close()
+	
+	counts := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan job count: %w", err)
+		}
+		counts[status] = count
+	}
+	return counts, rows.Err()
+}
+
+// PruneJobs deletes succeeded jobs last updated before a time; returns the number deleted
+func (db *DB) PruneJobs(before time.Time) (int64, error) {
+	result, err := db.conn.Exec(`DELETE FROM summary_jobs WHERE status = ? AND updated_at < ?`, JobSucceeded, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune jobs: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,68 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// AcquireLease takes or renews the lease of a role for a holder until now+ttl. A lease
+// is taken over once it expired or was released. Returns false if another holder has it.
+// Times are stored in UTC, so instances in other timezones compare them alike.
+func (db *DB) AcquireLease(name, holder string, now time.Time, ttl time.Duration) (bool, error) {
+	now = now.UTC()
+	
+	query := `
+		UPDATE leader_leases
+		SET acquired_at = CASE WHEN holder = ? THEN acquired_at ELSE ? END,
+			holder = ?, renewed_at = ?, expires_at = ?
+		WHERE name = ? AND (holder = ? OR expires_at <= ?)`
+	
+	result, err := db.conn.Exec(query, holder, now, holder, now, now.Add(ttl), name, holder, now)
+	if err != nil {
+		return false, fmt.Errorf("failed to renew lease: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected > 0 {
+		return true, nil
+	}
+	
+	// The first holder creates the lease
+	query = `
+		INSERT INTO leader_leases (name, holder, acquired_at, renewed_at, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(name) DO NOTHING`
+	
+	result, err = db.conn.Exec(query, name, holder, now, now, now.Add(ttl))
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lease: %w", err)
+	}
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+// ReleaseLease gives up a holder's lease, so another instance can take it over at once
+func (db *DB) ReleaseLease(name, holder string) error {
+	query := `UPDATE leader_leases SET expires_at = ? WHERE name = ? AND holder = ?`
+	if _, err := db.conn.Exec(query, time.Unix(0, 0).UTC(), name, holder); err != nil {
+		return fmt.Errorf("failed to release lease: %w", err)
+	}
+	return nil
+}
+
+// GetLease gets the lease of a role (nil if it was never taken)
+func (db *DB) GetLease(name string) (*Lease, error) {
+	query := `
+		SELECT name, holder, acquired_at, renewed_at, expires_at
+		FROM leader_leases
+		WHERE name = ?`
+	
+	var lease Lease
+	err := db.conn.QueryRow(query, name).Scan(&lease.Name, &lease.Holder, &lease.AcquiredAt, &lease.RenewedAt, &lease.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lease: %w", err)
+	}
+	return &lease, nil
+}
@@ -1,19 +1,40 @@
 package db
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 // Message represents a chat message
 type Message struct {
 	ID            int64
 	ChatID        int64
+	MessageID     int64 // Telegram message ID (0 if unknown)
 	UserID        int64
 	Username      string
-	MessageText   string
+	MessageText   string // Text or media caption
 	MessageLength int
 	Timestamp     time.Time
 	CreatedAt     time.Time
+	MediaType     string // One of the MediaType* constants (empty if no media)
+	MediaFileID   string // Bot API file_id or MTProto "photo:<id>"/"document:<id>"
+	MediaSize     int64  // Size in bytes (0 if unknown)
+	OCRText       string // Text recognized in an attached image (empty if none)
+	Restored      bool   // Restored from the archive (not archived again on cleanup)
 }
 
+// Media types stored on Message
+const (
+	MediaTypePhoto     = "photo"
+	MediaTypeVideo     = "video"
+	MediaTypeAnimation = "animation"
+	MediaTypeDocument  = "document"
+	MediaTypeAudio     = "audio"
+	MediaTypeVoice     = "voice"
+	MediaTypeSticker   = "sticker"
+)
+
 // Summary represents a generated summary
 type Summary struct {
 	ID               int64
@@ -31,14 +52,75 @@ type Summary struct {
 	CreatedAt        time.Time
 }
 
+// Chat types stored on TrackedGroup
+const (
+	ChatTypeGroup      = "group"      // Basic group (legacy chat)
+	ChatTypeSupergroup = "supergroup" // Megagroup channel
+	ChatTypeChannel    = "channel"    // Broadcast channel
+)
+
 // TrackedGroup represents a tracked Telegram group
 type TrackedGroup struct {
-	ChatID          int64
-	GroupName       string
-	GroupUsername   string
-	JoinDate        time.Time
-	IsActive        int // 0=scrape only, 1=summarize
-	LastMessageDate time.Time
+	ChatID           int64
+	GroupName        string
+	GroupUsername    string
+	JoinDate         time.Time
+	IsActive         int // 0=scrape only, 1=summarize
+	LastMessageDate  time.Time
+	ChatType         string // 'group', 'supergroup', 'channel' (empty if unknown)
+	FolderID         int    // 0=main dialog list, 1=archived
+	IsLeft           int    // 1 if we are no longer a member
+	MigratedToChatID int64  // Supergroup a basic group was migrated to (0 if none)
+	RetentionDays    int    // Days of messages to keep (0 = default, -1 = keep forever)
+	ArchiveMode      string // 'none', 'jsonl', 'db' (empty = default)
+	Timezone         string // IANA timezone, e.g. 'Asia/Jakarta' (empty = default)
+	Windows          WindowPolicy // Adaptive summary windows (zero fields = default)
+}
+
+// Default adaptive window policy of groups
+const (
+	DefaultMinMessages       = 3  // Messages a window needs to be summarized
+	DefaultMaxWindowHours    = 1  // Hours a window may accumulate messages
+	DefaultBusyWindowMinutes = 15 // Window length of busy groups
+)
+
+// WindowPolicy decides the windows of a group's hourly summaries. Quiet groups
+// accumulate messages until a window has MinMessages or is MaxWindowHours old; busy
+// groups, with at least BusyMessages in the last hour, get windows of BusyWindowMinutes.
+type WindowPolicy struct {
+	MinMessages       int // Messages a window needs to be summarized (0 = default)
+	MaxWindowHours    int // Hours a window may accumulate messages, 1-24 (0 = default, 1 = hourly)
+	BusyMessages      int // Messages per hour from which a group is busy (0 = never busy)
+	BusyWindowMinutes int // Window length of a busy group: 10, 15, 20 or 30 (0 = default)
+}
+
+// Effective returns the policy with defaults for unset fields
+func (p WindowPolicy) Effective() WindowPolicy {
+	if p.MinMessages <= 0 {
+		p.MinMessages = DefaultMinMessages
+	}
+	if p.MaxWindowHours <= 0 {
+		p.MaxWindowHours = DefaultMaxWindowHours
+	}
+	if p.BusyWindowMinutes <= 0 {
+		p.BusyWindowMinutes = DefaultBusyWindowMinutes
+	}
+	return p
+}
+
+// Validate checks that set fields are in range
+func (p WindowPolicy) Validate() error {
+	switch {
+	case p.MinMessages < 0 || p.MinMessages > 1000:
+		return fmt.Errorf("minimum messages must be between 1 and 1000")
+	case p.MaxWindowHours < 0 || p.MaxWindowHours > 24:
+		return fmt.Errorf("max window age must be between 1 and 24 hours")
+	case p.BusyMessages < 0:
+		return fmt.Errorf("busy threshold must not be negative")
+	case p.BusyWindowMinutes != 0 && (p.BusyWindowMinutes < 10 || 60%p.BusyWindowMinutes != 0 || p.BusyWindowMinutes == 60):
+		return fmt.Errorf("busy window must be 10, 15, 20 or 30 minutes")
+	}
+	return nil
 }
 
 // ProductMention represents a product mentioned in a summary
@@ -52,4 +134,261 @@ type ProductMention struct {
 	ValidationStatus string // 'valid', 'suspicious'
 	PriceMentioned   string
 	CreatedAt        time.Time
+	ProductID        int64   // Catalog product (0 if not linked)
+	Prices           []int64 // Prices in Rupiah parsed from PriceMentioned
+	FamilyCodes      string  // Comma-separated FC UUIDs mentioned with the product
+}
+
+// UpdateState is the persisted MTProto update sequence state of an account
+type UpdateState struct {
+	Pts  int
+	Qts  int
+	Date int
+	Seq  int
+}
+
+// User is a Telegram user seen in tracked groups
+type User struct {
+	UserID       int64
+	Username     string // Current @username without "@" (empty if none)
+	FirstName    string
+	LastName     string
+	IsBot        bool
+	IsAdmin      bool // Admin of at least one tracked group (as far as known)
+	FirstSeen    time.Time
+	LastSeen     time.Time
+	MessageCount int // Messages seen across all groups
+}
+
+// DisplayName returns the @username, or the full name if the user has none
+func (u *User) DisplayName() string {
+	if u.Username != "" {
+		return "@" + u.Username
+	}
+	name := strings.TrimSpace(u.FirstName + " " + u.LastName)
+	if name == "" {
+		return fmt.Sprintf("User_%d", u.UserID)
+	}
+	return name
+}
+
+// UsernameRecord is a username a user had during a period
+type UsernameRecord struct {
+	Username  string
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// UserGroupActivity is the activity of a user in one group
+type UserGroupActivity struct {
+	ChatID       int64
+	GroupName    string
+	MessageCount int
+	IsAdmin      bool
+	FirstSeen    time.Time
+	LastSeen     time.Time
+}
+
+// ActiveUser is a user with the number of messages sent in a group during a period
+type ActiveUser struct {
+	User
+	PeriodMessages int
+}
+
+// UserSighting is a message of a user seen in a group, used to update the users table
+type UserSighting struct {
+	UserID     int64
+	ChatID     int64
+	Username   string // Without "@" (empty if none)
+	FirstName  string
+	LastName   string
+	IsBot      bool
+	IsAdmin    bool
+	AdminKnown bool // Whether IsAdmin was looked up (the scraper doesn't know admins)
+	Timestamp  time.Time
+}
+
+// Product is a catalog product with a canonical name
+type Product struct {
+	ID        int64
+	Name      string // Canonical display name
+	NameKey   string // Normalized name used for matching
+	CreatedAt time.Time
+}
+
+// ProductAlias maps a normalized product name to a catalog product
+type ProductAlias struct {
+	AliasKey    string // Normalized name
+	Alias       string // Name as written
+	ProductID   int64
+	ProductName string
+}
+
+// ProductPrice is a price of a product mentioned in a summary
+type ProductPrice struct {
+	ID         int64
+	ProductID  int64
+	SummaryID  int64
+	ChatID     int64
+	Price      int64 // Rupiah
+	PriceText  string
+	ObservedAt time.Time
+}
+
+// UnlinkedProductMention is a stored product mention not yet linked to the catalog
+type UnlinkedProductMention struct {
+	Mention   ProductMention
+	ChatID    int64     // Chat of the summary
+	PeriodEnd time.Time // End of the summary period
+}
+
+// ProductActivity is a linked product mention with the context of its summary
+type ProductActivity struct {
+	ProductMention
+	ProductTitle string // Canonical name of the catalog product
+	ChatID       int64
+	GroupName    string
+	PeriodEnd    time.Time // End of the summary period
+	RedFlags     int       // Red flags of the summary
+}
+
+// CrossPost is a message text posted in several groups, e.g. a cross-posted promo
+type CrossPost struct {
+	Text     string
+	Groups   int // Distinct groups the text was posted in
+	Messages int // Times the text was posted
+}
+
+// FamilyCode is a MyXL FamilyCode (FC) shared in tracked groups
+type FamilyCode struct {
+	Code         string // Lowercase UUID
+	ProductText  string // Text of the latest message sharing the code, without the code
+	FirstSeen    time.Time
+	LastSeen     time.Time
+	MentionCount int
+	Groups       int
+	WorksCount   int // Users who replied that the code works
+	FailsCount   int // Users who replied that the code doesn't work
+}
+
+// FamilyCodeSighting is a message sharing a FamilyCode
+type FamilyCodeSighting struct {
+	Code        string
+	ProductText string
+	ChatID      int64
+	MessageID   int64 // Telegram message ID within the chat
+	Timestamp   time.Time
+}
+
+// FamilyCodeGroup is the activity of a FamilyCode in a group
+type FamilyCodeGroup struct {
+	ChatID       int64
+	GroupName    string
+	MentionCount int
+	FirstSeen    time.Time
+	LastSeen     time.Time
+}
+
+// Summary cadences a chat can subscribe to
+const (
+	CadenceHourly = "hourly"
+	CadenceDaily  = "daily"
+)
+
+// DigestChatID is the group chat ID of the cross-group digest in subscriptions and
+// stored summaries
+const DigestChatID int64 = 0
+
+// Subscription is a chat or user receiving the summaries of a group
+type Subscription struct {
+	GroupChatID  int64
+	GroupName    string // Name of the tracked group (empty if unknown)
+	TargetChatID int64  // Chat or user the summaries are sent to
+	Cadence      string // CadenceHourly or CadenceDaily
+	CreatedBy    int64  // User who subscribed
+	CreatedAt    time.Time
+}
+
+// SummarySchedule is a cron schedule of a group's summaries
+type SummarySchedule struct {
+	ID         int64
+	ChatID     int64
+	GroupName  string    // Name of the tracked group (empty if unknown)
+	CronExpr   string    // 5-field cron expression or descriptor, e.g. "0 */4 * * *"
+	PromptType string    // Prompt of the summaries, e.g. "4h" or "daily"
+	LastRunAt  time.Time // Run time of the last run (zero if it never ran)
+	CreatedBy  int64
+	CreatedAt  time.Time
+}
+
+// Summary window statuses
+const (
+	WindowSummarized = "summarized" // A summary was stored for the window
+	WindowSkipped    = "skipped"    // Too few messages to summarize
+	WindowDeferred   = "deferred"   // Still accumulating messages (see WindowPolicy)
+)
+
+// SummaryWindow is a completed window of a group's scheduled summaries
+type SummaryWindow struct {
+	ChatID       int64
+	SummaryType  string // e.g. "1h" or "4h"
+	WindowStart  time.Time
+	WindowEnd    time.Time
+	Status       string // WindowSummarized, WindowSkipped or WindowDeferred
+	MessageCount int
+	CompletedAt  time.Time
+}
+
+// Summary job kinds
+const (
+	JobHourly    = "hourly"    // Hourly summary of a group
+	JobDaily     = "daily"     // Daily summary of a group
+	JobScheduled = "scheduled" // Run of a cron schedule
+	JobBackfill  = "backfill"  // Window missed during downtime
+	JobManual    = "manual"    // Requested through the bot
+	JobRollup    = "rollup"    // Weekly or monthly rollup of daily summaries
+	JobDigest    = "digest"    // Cross-group digest of the groups' daily summaries
+)
+
+// Summary job statuses. Failed jobs ran out of attempts and wait in the dead-letter
+// state until retried by hand.
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// DefaultJobAttempts is the number of attempts of jobs enqueued without MaxAttempts
+const DefaultJobAttempts = 5
+
+// SummaryJob is a queued summary generation
+type SummaryJob struct {
+	ID           int64
+	Kind         string // JobHourly, JobDaily, JobScheduled, JobBackfill, JobManual, JobRollup or JobDigest
+	ChatID       int64
+	GroupName    string // Name of the tracked group (empty if unknown)
+	SummaryType  string // Type of the stored summary, e.g. "1h"
+	PromptType   string // Prompt of the summary (empty = default of the summary type)
+	WindowStart  time.Time
+	WindowEnd    time.Time
+	TargetChatID int64  // Chat receiving a manual job's summary
+	MinMessages  int    // Messages the window needs to be summarized (0 = the group's minimum)
+	Status       string // JobPending, JobRunning, JobSucceeded or JobFailed
+	Attempts     int
+	MaxAttempts  int
+	NextRunAt    time.Time // When a pending job may run
+	LastError    string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// Lease is a role only one instance may hold at a time, e.g. the leader running the
+// scheduler. The holder renews it before it expires; others take over after that.
+type Lease struct {
+	Name       string
+	Holder     string    // Instance ID of the holder
+	AcquiredAt time.Time // When the holder took the lease
+	RenewedAt  time.Time // Last heartbeat of the holder
+	ExpiresAt  time.Time
 }
@@ -0,0 +1,35 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"telegram-summarizer/internal/logger"
+	
+	_ "github.com/lib/pq"
+)
+
+// InitPostgres connects to a PostgreSQL database and creates the schema
+func InitPostgres(dsn string) (*DB, error) {
+	logger.Info("Initializing PostgreSQL database")
+	
+	conn, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	
+	// Test connection
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+	
+	db := &DB{conn: &sqlConn{DB: conn, driver: DriverPostgres}}
+	
+	if err := db.setup(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	
+	logger.Info("✅ Database initialized successfully")
+	return db, nil
+}
@@ -0,0 +1,307 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"telegram-summarizer/internal/logger"
+	"time"
+)
+
+// insertProductPrices stores the parsed prices of a linked product mention
+func insertProductPrices(q inserter, pm *ProductMention, chatID int64, observedAt time.Time) error {
+	if pm.ProductID == 0 {
+		return nil
+	}
+	
+	query := `
+		INSERT INTO product_prices (product_id, summary_id, chat_id, price, price_text, observed_at)
+		VALUES (?, ?, ?, ?, ?, ?)`
+	
+	for _, price := range pm.Prices {
+		if _, err := q.insert(query, pm.ProductID, pm.SummaryID, chatID, price, pm.PriceMentioned, observedAt); err != nil {
+			return fmt.Errorf("failed to save product price: %w", err)
+		}
+	}
+	return nil
+}
+
+// CreateProduct creates a catalog product together with the alias of its own name.
+// If a product with the same name key exists, that product is returned.
+func (db *DB) CreateProduct(name, nameKey string) (*Product, error) {
+	if _, err := db.conn.Exec(`
+		INSERT INTO products (name, name_key) VALUES (?, ?)
+		ON CONFLICT(name_key) DO NOTHING`, name, nameKey); err != nil {
+		return nil, fmt.Errorf("failed to create product: %w", err)
+	}
+	
+	product, err := db.getProductBy("name_key", nameKey)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, fmt.Errorf("failed to create product: %s not found after insert", nameKey)
+	}
+	
+	// The canonical name is an alias of its own product unless it already points elsewhere
+	if _, err := db.conn.Exec(`
+		INSERT INTO product_aliases (alias_key, alias, product_id) VALUES (?, ?, ?)
+		ON CONFLICT(alias_key) DO NOTHING`, nameKey, name, product.ID); err != nil {
+		return nil, fmt.Errorf("failed to save product alias: %w", err)
+	}
+	
+	logger.Debug("Product in catalog: %s (ID=%d)", product.Name, product.ID)
+	return product, nil
+}
+
+// GetProduct gets a catalog product by ID; returns nil if it does not exist
+func (db *DB) GetProduct(productID int64) (*Product, error) {
+	return db.getProductBy("id", productID)
+}
+
+// getProductBy gets a product by a unique column
+func (db *DB) getProductBy(column string, value interface{}) (*Product, error) {
+	query := fmt.Sprintf(`SELECT id, name, name_key, created_at FROM products WHERE %s = ?`, column)
+	
+	var p Product
+	var createdAt sql.NullTime
+	err := db.conn.QueryRow(query, value).Scan(&p.ID, &p.Name, &p.NameKey, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	p.CreatedAt = createdAt.Time
+	return &p, nil
+}
+
+// GetProductAliases gets all aliases of the catalog (productID 0) or of one product
+func (db *DB) GetProductAliases(productID int64) ([]ProductAlias, error) {
+	query := `
+		SELECT a.alias_key, a.alias, a.product_id, p.name
+		FROM product_aliases a
+		JOIN products p ON p.id = a.product_id
+		WHERE ? = 0 OR a.product_id = ?
+		ORDER BY p.name, a.alias`
+	
+	rows, err := db.conn.Query(query, productID, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product aliases: %w", err)
+	}
+	defer rows.Close()
+	
+	var aliases []ProductAlias
+	for rows.Next() {
+		var a ProductAlias
+		if err := rows.Scan(&a.AliasKey, &a.Alias, &a.ProductID, &a.ProductName); err != nil {
+			return nil, fmt.Errorf("failed to scan product alias: %w", err)
+		}
+		aliases = append(aliases, a)
+	}
+	return aliases, rows.Err()
+}
+
+// SetProductAlias points an alias to a product, replacing its previous product
+func (db *DB) SetProductAlias(productID int64, alias, aliasKey string) error {
+	query := `
+		INSERT INTO product_aliases (alias_key, alias, product_id) VALUES (?, ?, ?)
+		ON CONFLICT(alias_key) DO UPDATE SET
+			alias = excluded.alias,
+			product_id = excluded.product_id`
+	
+	if _, err := db.conn.Exec(query, aliasKey, alias, productID); err != nil {
+		return fmt.Errorf("failed to save product alias: %w", err)
+	}
+	return nil
+}
+
+// DeleteProductAlias removes an alias; returns false if it did not exist
+func (db *DB) DeleteProductAlias(aliasKey string) (bool, error) {
+	result, err := db.conn.Exec(`DELETE FROM product_aliases WHERE alias_key = ?`, aliasKey)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete product alias: %w", err)
+	}
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+// MergeProducts moves mentions, prices and aliases of a product to another and deletes it
+func (db *DB) MergeProducts(fromID, intoID int64) error {
+	if fromID == intoID {
+		return nil
+	}
+	
+	tx, err := db.conn.begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	
+	for _, table := range []string{"product_mentions", "product_prices", "product_aliases"} {
+		query := fmt.Sprintf("UPDATE %s SET product_id = ? WHERE product_id = ?", table)
+		if _, err := tx.Exec(query, intoID, fromID); err != nil {
+			return fmt.Errorf("failed to merge %s: %w", table, err)
+		}
+	}
+	if _, err := tx.Exec(`DELETE FROM products WHERE id = ?`, fromID); err != nil {
+		return fmt.Errorf("failed to delete merged product: %w", err)
+	}
+	
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit product merge: %w", err)
+	}
+	
+	logger.Info("🔗 Merged product %d into %d", fromID, intoID)
+	return nil
+}
+
+// GetUnlinkedProductMentions gets up to limit product mentions after afterID not yet linked to the catalog
+func (db *DB) GetUnlinkedProductMentions(afterID int64, limit int) ([]UnlinkedProductMention, error) {
+	query := `
+		SELECT pm.id, pm.summary_id, pm.product_name, COALESCE(pm.price_mentioned, ''),
+		       COALESCE(s.chat_id, 0), s.period_end
+		FROM product_mentions pm
+		LEFT JOIN summaries s ON s.id = pm.summary_id
+		WHERE COALESCE(pm.product_id, 0) = 0
+		AND pm.id > ?
+		ORDER BY pm.id
+		LIMIT ?`
+	
+	rows, err := db.conn.Query(query, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unlinked product mentions: %w", err)
+	}
+	defer rows.Close()
+	
+	var mentions []UnlinkedProductMention
+	for rows.Next() {
+		var u UnlinkedProductMention
+		var periodEnd sql.NullTime
+		if err := rows.Scan(&u.Mention.ID, &u.Mention.SummaryID, &u.Mention.ProductName,
+			&u.Mention.PriceMentioned, &u.ChatID, &periodEnd); err != nil {
+			return nil, fmt.Errorf("failed to scan product mention: %w", err)
+		}
+		u.PeriodEnd = periodEnd.Time
+		mentions = append(mentions, u)
+	}
+	return mentions, rows.Err()
+}
+
+// LinkProductMention links a stored mention to a catalog product and stores its prices
+func (db *DB) LinkProductMention(pm *ProductMention, chatID int64, observedAt time.Time) error {
+	tx, err := db.conn.begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	
+	if _, err := tx.Exec(`UPDATE product_mentions SET product_id = ? WHERE id = ?`, pm.ProductID, pm.ID); err != nil {
+		return fmt.Errorf("failed to link product mention: %w", err)
+	}
+	if err := insertProductPrices(tx, pm, chatID, observedAt); err != nil {
+		return err
+	}
+	
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit product link: %w", err)
+	}
+	return nil
+}
+
+// GetProductMentions gets the mentions of a catalog product over the last days, newest first
+func (db *DB) GetProductMentions(productID int64, days int) ([]ProductMention, error) {
+	query := `
+		SELECT id, summary_id, product_name, mention_count,
+		       credibility_score, COALESCE(sentiment, ''), COALESCE(validation_status, ''),
+		       COALESCE(price_mentioned, ''), created_at, product_id
+		FROM product_mentions
+		WHERE product_id = ?
+		AND created_at >= ?
+		ORDER BY created_at DESC`
+	
+	rows, err := db.conn.Query(query, productID, time.Now().UTC().AddDate(0, 0, -days))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product mentions: %w", err)
+	}
+	defer rows.Close()
+	
+	var mentions []ProductMention
+	for rows.Next() {
+		var pm ProductMention
+		if err := rows.Scan(&pm.ID, &pm.SummaryID, &pm.ProductName, &pm.MentionCount,
+			&pm.CredibilityScore, &pm.Sentiment, &pm.ValidationStatus,
+			&pm.PriceMentioned, &pm.CreatedAt, &pm.ProductID); err != nil {
+			return nil, fmt.Errorf("failed to scan product mention: %w", err)
+		}
+		mentions = append(mentions, pm)
+	}
+	return mentions, rows.Err()
+}
+
+// GetProductPrices gets the price history of a product since a time, oldest first
+func (db *DB) GetProductPrices(productID int64, since time.Time) ([]ProductPrice, error) {
+	query := `
+		SELECT id, product_id, summary_id, chat_id, price, COALESCE(price_text, ''), observed_at
+		FROM product_prices
+		WHERE product_id = ? AND observed_at >= ?
+		ORDER BY observed_at, id`
+	
+	rows, err := db.conn.Query(query, productID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product prices: %w", err)
+	}
+	defer rows.Close()
+	
+	var prices []ProductPrice
+	for rows.Next() {
+		var p ProductPrice
+		var observedAt sql.NullTime
+		if err := rows.Scan(&p.ID, &p.ProductID, &p.SummaryID, &p.ChatID, &p.Price, &p.PriceText, &observedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan product price: %w", err)
+		}
+		p.ObservedAt = observedAt.Time
+		prices = append(prices, p)
+	}
+	return prices, rows.Err()
+}
+
+// GetProductActivity gets the linked mentions of one product (or all products for
+// productID 0) in summaries of a type ending within [start, end), oldest first. Each
+// summary level (e.g. "1h" and "daily") mentions the products again, so only one is read.
+func (db *DB) GetProductActivity(productID int64, summaryType string, start, end time.Time) ([]ProductActivity, error) {
+	query := `
+		SELECT pm.id, pm.summary_id, pm.product_name, COALESCE(pm.mention_count, 0),
+		       COALESCE(pm.credibility_score, 0), COALESCE(pm.sentiment, ''), COALESCE(pm.validation_status, ''),
+		       COALESCE(pm.price_mentioned, ''), pm.created_at, pm.product_id, COALESCE(pm.family_codes, ''),
+		       p.name, s.chat_id, COALESCE(g.group_name, ''), s.period_end, COALESCE(s.red_flags_count, 0)
+		FROM product_mentions pm
+		JOIN products p ON p.id = pm.product_id
+		JOIN summaries s ON s.id = pm.summary_id
+		LEFT JOIN tracked_groups g ON g.chat_id = s.chat_id
+		WHERE (? = 0 OR pm.product_id = ?)
+		AND s.summary_type = ?
+		AND s.period_end >= ? AND s.period_end < ?
+		ORDER BY s.period_end, pm.id`
+	
+	rows, err := db.conn.Query(query, productID, productID, summaryType, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product activity: %w", err)
+	}
+	defer rows.Close()
+	
+	var activity []ProductActivity
+	for rows.Next() {
+		var a ProductActivity
+		var createdAt, periodEnd sql.NullTime
+		if err := rows.Scan(&a.ID, &a.SummaryID, &a.ProductName, &a.MentionCount,
+			&a.CredibilityScore, &a.Sentiment, &a.ValidationStatus,
+			&a.PriceMentioned, &createdAt, &a.ProductID, &a.FamilyCodes,
+			&a.ProductTitle, &a.ChatID, &a.GroupName, &periodEnd, &a.RedFlags); err != nil {
+			return nil, fmt.Errorf("failed to scan product activity: %w", err)
+		}
+		a.CreatedAt = createdAt.Time
+		a.PeriodEnd = periodEnd.Time
+		activity = append(activity, a)
+	}
+	return activity, rows.Err()
+}
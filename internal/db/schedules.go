@@ -0,0 +1,68 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// AddSummarySchedule saves a new summary schedule and sets its ID
+func (db *DB) AddSummarySchedule(schedule *SummarySchedule) error {
+	query := `
+		INSERT INTO summary_schedules (chat_id, cron_expr, prompt_type, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?)`
+	
+	id, err := db.conn.insert(query, schedule.ChatID, schedule.CronExpr, schedule.PromptType, schedule.CreatedBy, schedule.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save summary schedule: %w", err)
+	}
+	schedule.ID = id
+	return nil
+}
+
+// DeleteSummarySchedule deletes a schedule of a group; returns false if it does not exist
+func (db *DB) DeleteSummarySchedule(chatID, scheduleID int64) (bool, error) {
+	result, err := db.conn.Exec(`DELETE FROM summary_schedules WHERE id = ? AND chat_id = ?`, scheduleID, chatID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete summary schedule: %w", err)
+	}
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+// GetSummarySchedules gets the schedules of a group (all groups if chatID is 0), oldest first
+func (db *DB) GetSummarySchedules(chatID int64) ([]SummarySchedule, error) {
+	query := `
+		SELECT s.id, s.chat_id, COALESCE(g.group_name, ''), s.cron_expr, s.prompt_type, s.last_run_at, s.created_by, s.created_at
+		FROM summary_schedules s
+		LEFT JOIN tracked_groups g ON g.chat_id = s.chat_id
+		WHERE ? = 0 OR s.chat_id = ?
+		ORDER BY s.chat_id, s.id`
+	
+	rows, err := db.conn.Query(query, chatID, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get summary schedules: %w", err)
+	}
+	defer rows.Close()
+	
+	var schedules []SummarySchedule
+	for rows.Next() {
+		var s SummarySchedule
+		var lastRunAt, createdAt sql.NullTime
+		if err := rows.Scan(&s.ID, &s.ChatID, &s.GroupName, &s.CronExpr, &s.PromptType, &lastRunAt, &s.CreatedBy, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan summary schedule: %w", err)
+		}
+		s.LastRunAt = lastRunAt.Time
+		s.CreatedAt = createdAt.Time
+		schedules = append(schedules, s)
+	}
+	return schedules, rows.Err()
+}
+
+// SetSummaryScheduleLastRun records the run time of a schedule's last run
+func (db *DB) SetSummaryScheduleLastRun(scheduleID int64, at time.Time) error {
+	if _, err := db.conn.Exec(`UPDATE summary_schedules SET last_run_at = ? WHERE id = ?`, at, scheduleID); err != nil {
+		return fmt.Errorf("failed to update summary schedule: %w", err)
+	}
+	return nil
+}
@@ -1,42 +1,93 @@
 package db
 
 import (
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"fmt"
+	"strings"
 	"telegram-summarizer/internal/logger"
 	"time"
-
+	
 	_ "github.com/mattn/go-sqlite3"
 )
 
-// DB wraps the database connection
+// DB wraps the database connection. It implements Store for SQLite and PostgreSQL.
 type DB struct {
-	conn *sql.DB
+	conn *sqlConn
 }
 
+// SQLite connection settings. WAL lets readers run while the writer commits, and
+// busy_timeout makes connections wait for locks instead of failing with
+// "database is locked". Write transactions take the lock up front (_txlock=immediate)
+// so they never fail halfway when upgrading a read lock.
+const (
+	sqliteBusyTimeoutMs = 5000
+	sqliteWriterParams  = "_journal_mode=WAL&_synchronous=NORMAL&_txlock=immediate"
+	sqliteReaderParams  = "_query_only=true"
+	sqliteMaxReaders    = 8
+)
+
 // InitDB initializes the SQLite database
 func InitDB(dbPath string) (*DB, error) {
 	logger.Info("Initializing database: %s", dbPath)
 	
-	conn, err := sql.Open("sqlite3", dbPath)
+	// A single writer connection serializes writes inside the process
+	writer, err := openSQLite(dbPath, sqliteWriterParams, 1)
+	if err != nil {
+		return nil, err
+	}
+	
+	reader, err := openSQLite(dbPath, sqliteReaderParams, sqliteMaxReaders)
+	if err != nil {
+		writer.Close()
+		return nil, err
+	}
+	
+	db := &DB{conn: &sqlConn{DB: writer, reader: reader, driver: DriverSQLite}}
+	
+	if err := db.setup(); err != nil {
+		db.conn.Close()
+		return nil, err
+	}
+	
+	logger.Info("✅ Database initialized successfully")
+	return db, nil
+}
+
+// openSQLite opens a connection pool to a SQLite database file
+func openSQLite(dbPath, params string, maxConns int) (*sql.DB, error) {
+	dsn := fmt.Sprintf("%s?_busy_timeout=%d&%s", dbPath, sqliteBusyTimeoutMs, params)
+	
+	conn, err := sql.Open("sqlite3", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
+	conn.SetMaxOpenConns(maxConns)
+	conn.SetMaxIdleConns(maxConns)
 	
 	// Test connection
 	if err := conn.Ping(); err != nil {
+		conn.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 	
-	db := &DB{conn: conn}
-	
+	return conn, nil
+}
+
+// setup creates the schema and upgrades tables created by older versions
+func (db *DB) setup() error {
 	// Create tables
 	if err := db.createTables(); err != nil {
-		return nil, fmt.Errorf("failed to create tables: %w", err)
+		return fmt.Errorf("failed to create tables: %w", err)
 	}
 	
-	logger.Info("✅ Database initialized successfully")
-	return db, nil
+	// Upgrade tables created by older versions
+	if err := db.migrateTables(); err != nil {
+		return fmt.Errorf("failed to migrate tables: %w", err)
+	}
+	
+	return nil
 }
 
 // createTables creates the necessary database tables
@@ -53,7 +104,14 @@ func (db *DB) createTables() error {
 		message_text TEXT NOT NULL,
 		message_length INTEGER,
 		timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		media_type TEXT DEFAULT '',
+		media_file_id TEXT DEFAULT '',
+		media_size INTEGER DEFAULT 0,
+		ocr_text TEXT DEFAULT '',
+		restored INTEGER DEFAULT 0,
+		message_id INTEGER DEFAULT 0,
+		dedupe_key TEXT DEFAULT ''
 	);`
 	
 	// Summaries table
@@ -86,9 +144,39 @@ func (db *DB) createTables() error {
 		validation_status TEXT,
 		price_mentioned TEXT,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		product_id INTEGER DEFAULT 0,
+		family_codes TEXT DEFAULT '',
 		FOREIGN KEY (summary_id) REFERENCES summaries(id)
 	);`
 	
+	// Product catalog: canonical names, aliases and price history
+	productsTable := `
+	CREATE TABLE IF NOT EXISTS products (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		name_key TEXT UNIQUE NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+	
+	productAliasesTable := `
+	CREATE TABLE IF NOT EXISTS product_aliases (
+		alias_key TEXT PRIMARY KEY,
+		alias TEXT NOT NULL,
+		product_id INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+	
+	productPricesTable := `
+	CREATE TABLE IF NOT EXISTS product_prices (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		product_id INTEGER NOT NULL,
+		summary_id INTEGER NOT NULL,
+		chat_id INTEGER NOT NULL,
+		price INTEGER NOT NULL,
+		price_text TEXT,
+		observed_at DATETIME
+	);`
+	
 	// Tracked groups table
 	trackedGroupsTable := `
 	CREATE TABLE IF NOT EXISTS tracked_groups (
@@ -100,9 +188,218 @@ func (db *DB) createTables() error {
 		is_active INTEGER DEFAULT 0,
 		last_message_date DATETIME,
 		summary_enabled_date DATETIME,
+		chat_type TEXT DEFAULT '',
+		folder_id INTEGER DEFAULT 0,
+		is_left INTEGER DEFAULT 0,
+		migrated_to_chat_id INTEGER DEFAULT 0,
+		retention_days INTEGER DEFAULT 0,
+		archive_mode TEXT DEFAULT '',
+		timezone TEXT DEFAULT '',
+		min_messages INTEGER DEFAULT 0,
+		max_window_hours INTEGER DEFAULT 0,
+		busy_messages INTEGER DEFAULT 0,
+		busy_window_minutes INTEGER DEFAULT 0,
+		active_when_left INTEGER DEFAULT 0,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);`
 	
+	// MTProto update state tables (used to recover missed updates after downtime)
+	updateStateTable := `
+	CREATE TABLE IF NOT EXISTS update_state (
+		user_id INTEGER PRIMARY KEY,
+		pts INTEGER NOT NULL DEFAULT 0,
+		qts INTEGER NOT NULL DEFAULT 0,
+		date INTEGER NOT NULL DEFAULT 0,
+		seq INTEGER NOT NULL DEFAULT 0
+	);`
+	
+	channelStateTable := `
+	CREATE TABLE IF NOT EXISTS channel_update_state (
+		user_id INTEGER NOT NULL,
+		channel_id INTEGER NOT NULL,
+		pts INTEGER,
+		access_hash INTEGER,
+		PRIMARY KEY (user_id, channel_id)
+	);`
+	
+	// Ingest filter configuration per group (chat_id 0 = default for all groups)
+	filterConfigsTable := `
+	CREATE TABLE IF NOT EXISTS filter_configs (
+		chat_id INTEGER PRIMARY KEY,
+		config TEXT NOT NULL,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+	
+	// Messages dropped by each ingest filter rule, per group and day
+	filterDropsTable := `
+	CREATE TABLE IF NOT EXISTS filter_drops (
+		chat_id INTEGER NOT NULL,
+		rule TEXT NOT NULL,
+		date TEXT NOT NULL,
+		count INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (chat_id, rule, date)
+	);`
+	
+	// Users seen in tracked groups
+	usersTable := `
+	CREATE TABLE IF NOT EXISTS users (
+		user_id INTEGER PRIMARY KEY,
+		username TEXT DEFAULT '',
+		first_name TEXT DEFAULT '',
+		last_name TEXT DEFAULT '',
+		is_bot INTEGER DEFAULT 0,
+		is_admin INTEGER DEFAULT 0,
+		first_seen DATETIME,
+		last_seen DATETIME,
+		message_count INTEGER DEFAULT 0
+	);`
+	
+	// Every username a user had, so renamed sellers can still be found
+	usernameHistoryTable := `
+	CREATE TABLE IF NOT EXISTS username_history (
+		user_id INTEGER NOT NULL,
+		username TEXT NOT NULL,
+		first_seen DATETIME,
+		last_seen DATETIME,
+		PRIMARY KEY (user_id, username)
+	);`
+	
+	// Activity of each user per group
+	userGroupsTable := `
+	CREATE TABLE IF NOT EXISTS user_groups (
+		user_id INTEGER NOT NULL,
+		chat_id INTEGER NOT NULL,
+		message_count INTEGER DEFAULT 0,
+		is_admin INTEGER DEFAULT 0,
+		first_seen DATETIME,
+		last_seen DATETIME,
+		PRIMARY KEY (user_id, chat_id)
+	);`
+	
+	// Messages of each user per group and hour, kept after the messages expire
+	userActivityTable := `
+	CREATE TABLE IF NOT EXISTS user_activity (
+		user_id INTEGER NOT NULL,
+		chat_id INTEGER NOT NULL,
+		hour DATETIME NOT NULL,
+		message_count INTEGER DEFAULT 0,
+		PRIMARY KEY (user_id, chat_id, hour)
+	);`
+	
+	// FamilyCode registry: codes shared in groups and confirmations from replies
+	familyCodesTable := `
+	CREATE TABLE IF NOT EXISTS family_codes (
+		code TEXT PRIMARY KEY,
+		product_text TEXT DEFAULT '',
+		first_seen DATETIME,
+		last_seen DATETIME,
+		mention_count INTEGER DEFAULT 0
+	);`
+	
+	familyCodeGroupsTable := `
+	CREATE TABLE IF NOT EXISTS family_code_groups (
+		code TEXT NOT NULL,
+		chat_id INTEGER NOT NULL,
+		mention_count INTEGER DEFAULT 0,
+		first_seen DATETIME,
+		last_seen DATETIME,
+		PRIMARY KEY (code, chat_id)
+	);`
+	
+	// Messages that shared a code, to attribute replies to it
+	familyCodeMessagesTable := `
+	CREATE TABLE IF NOT EXISTS family_code_messages (
+		chat_id INTEGER NOT NULL,
+		message_id INTEGER NOT NULL,
+		code TEXT NOT NULL,
+		created_at DATETIME,
+		PRIMARY KEY (chat_id, message_id, code)
+	);`
+	
+	// Latest works/doesn't-work confirmation of each user per code
+	familyCodeVotesTable := `
+	CREATE TABLE IF NOT EXISTS family_code_votes (
+		code TEXT NOT NULL,
+		user_id INTEGER NOT NULL,
+		works INTEGER NOT NULL,
+		voted_at DATETIME,
+		PRIMARY KEY (code, user_id)
+	);`
+	
+	// Chats receiving the hourly/daily summaries of a group
+	subscriptionsTable := `
+	CREATE TABLE IF NOT EXISTS summary_subscriptions (
+		group_chat_id INTEGER NOT NULL,
+		target_chat_id INTEGER NOT NULL,
+		cadence TEXT NOT NULL,
+		created_by INTEGER DEFAULT 0,
+		created_at DATETIME,
+		PRIMARY KEY (group_chat_id, target_chat_id, cadence)
+	);`
+	
+	// Cron schedules of group summaries
+	schedulesTable := `
+	CREATE TABLE IF NOT EXISTS summary_schedules (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		chat_id INTEGER NOT NULL,
+		cron_expr TEXT NOT NULL,
+		prompt_type TEXT NOT NULL,
+		last_run_at DATETIME,
+		created_by INTEGER DEFAULT 0,
+		created_at DATETIME
+	);`
+	
+	// Windows the scheduler completed, used to find windows missed during downtime
+	windowsTable := `
+	CREATE TABLE IF NOT EXISTS summary_windows (
+		chat_id INTEGER NOT NULL,
+		summary_type TEXT NOT NULL,
+		window_start DATETIME NOT NULL,
+		window_end DATETIME NOT NULL,
+		status TEXT NOT NULL,
+		message_count INTEGER DEFAULT 0,
+		completed_at DATETIME,
+		PRIMARY KEY (chat_id, summary_type, window_start)
+	);`
+	
+	// Durable queue of summary generations, retried with backoff
+	jobsTable := `
+	CREATE TABLE IF NOT EXISTS summary_jobs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		kind TEXT NOT NULL,
+		chat_id INTEGER NOT NULL,
+		summary_type TEXT NOT NULL,
+		prompt_type TEXT DEFAULT '',
+		window_start DATETIME NOT NULL,
+		window_end DATETIME NOT NULL,
+		target_chat_id INTEGER DEFAULT 0,
+		min_messages INTEGER DEFAULT 0,
+		status TEXT NOT NULL,
+		attempts INTEGER DEFAULT 0,
+		max_attempts INTEGER DEFAULT 0,
+		next_run_at DATETIME,
+		last_error TEXT DEFAULT '',
+		created_at DATETIME,
+		updated_at DATETIME
+	);`
+	
+	// Leases on roles only one instance may hold, such as running the scheduler
+	leasesTable := `
+	CREATE TABLE IF NOT EXISTS leader_leases (
+		name TEXT PRIMARY KEY,
+		holder TEXT NOT NULL,
+		acquired_at DATETIME NOT NULL,
+		renewed_at DATETIME NOT NULL,
+		expires_at DATETIME NOT NULL
+	);`
+	
+	// One-off data migrations that ran, e.g. seeding subscriptions
+	migrationsTable := `
+	CREATE TABLE IF NOT EXISTS applied_migrations (
+		name TEXT PRIMARY KEY,
+		applied_at DATETIME NOT NULL
+	);`
+	
 	// Create indexes
 	messagesIndex := `
 	CREATE INDEX IF NOT EXISTS idx_messages_chat_time 
@@ -124,21 +421,67 @@ func (db *DB) createTables() error {
 	CREATE INDEX IF NOT EXISTS idx_product_mentions_name
 	ON product_mentions(product_name, created_at);`
 	
+	productPricesIndex := `
+	CREATE INDEX IF NOT EXISTS idx_product_prices_product
+	ON product_prices(product_id, observed_at);`
+	
+	usernameHistoryIndex := `
+	CREATE INDEX IF NOT EXISTS idx_username_history_name
+	ON username_history(username);`
+	
+	familyCodesIndex := `
+	CREATE INDEX IF NOT EXISTS idx_family_codes_last_seen
+	ON family_codes(last_seen);`
+	
+	jobsIndex := `
+	CREATE INDEX IF NOT EXISTS idx_summary_jobs_status
+	ON summary_jobs(status, next_run_at);`
+	
+	userActivityIndex := `
+	CREATE INDEX IF NOT EXISTS idx_user_activity_chat_hour
+	ON user_activity(chat_id, hour);`
+	
 	// Execute all statements
 	statements := []string{
 		messagesTable,
 		summariesTable,
 		trackedGroupsTable,
 		productMentionsTable,
+		updateStateTable,
+		channelStateTable,
+		filterConfigsTable,
+		filterDropsTable,
+		usersTable,
+		usernameHistoryTable,
+		userGroupsTable,
+		userActivityTable,
+		productsTable,
+		productAliasesTable,
+		productPricesTable,
+		familyCodesTable,
+		familyCodeGroupsTable,
+		familyCodeMessagesTable,
+		familyCodeVotesTable,
+		subscriptionsTable,
+		schedulesTable,
+		windowsTable,
+		jobsTable,
+		leasesTable,
+		migrationsTable,
 		messagesIndex,
 		summariesIndex,
 		trackedGroupsIndex,
 		productMentionsIndex1,
 		productMentionsIndex2,
+		productPricesIndex,
+		usernameHistoryIndex,
+		familyCodesIndex,
+		jobsIndex,
+		userActivityIndex,
 	}
 	
 	for _, stmt := range statements {
-		if _, err := db.conn.Exec(stmt); err != nil {
+		if _, err := db.conn.Exec(db.conn.ddl(stmt)); err != nil {
 			return fmt.Errorf("failed to execute statement: %w", err)
 		}
 	}
@@ -147,32 +490,435 @@ func (db *DB) createTables() error {
 	return nil
 }
 
-// SaveMessage saves a message to the database
-func (db *DB) SaveMessage(msg *Message) error {
+// migrateTables adds columns introduced after the initial schema to existing databases
+func (db *DB) migrateTables() error {
+	logger.Debug("Migrating database tables...")
+	
+	columns := []struct {
+		table      string
+		column     string
+		definition string
+	}{
+		{"tracked_groups", "chat_type", "TEXT DEFAULT ''"},
+		{"tracked_groups", "folder_id", "INTEGER DEFAULT 0"},
+		{"tracked_groups", "is_left", "INTEGER DEFAULT 0"},
+		{"tracked_groups", "migrated_to_chat_id", "INTEGER DEFAULT 0"},
+		{"messages", "media_type", "TEXT DEFAULT ''"},
+		{"messages", "media_file_id", "TEXT DEFAULT ''"},
+		{"messages", "media_size", "INTEGER DEFAULT 0"},
+		{"messages", "ocr_text", "TEXT DEFAULT ''"},
+		{"messages", "restored", "INTEGER DEFAULT 0"},
+		{"messages", "message_id", "INTEGER DEFAULT 0"},
+		{"messages", "dedupe_key", "TEXT DEFAULT ''"},
+		{"tracked_groups", "retention_days", "INTEGER DEFAULT 0"},
+		{"tracked_groups", "archive_mode", "TEXT DEFAULT ''"},
+		{"tracked_groups", "timezone", "TEXT DEFAULT ''"},
+		{"tracked_groups", "min_messages", "INTEGER DEFAULT 0"},
+		{"tracked_groups", "max_window_hours", "INTEGER DEFAULT 0"},
+		{"tracked_groups", "busy_messages", "INTEGER DEFAULT 0"},
+		{"tracked_groups", "busy_window_minutes", "INTEGER DEFAULT 0"},
+		{"tracked_groups", "active_when_left", "INTEGER DEFAULT 0"},
+		{"summary_jobs", "min_messages", "INTEGER DEFAULT 0"},
+		{"product_mentions", "product_id", "INTEGER DEFAULT 0"},
+		{"product_mentions", "family_codes", "TEXT DEFAULT ''"},
+	}
+	
+	for _, c := range columns {
+		if err := db.addColumnIfMissing(c.table, c.column, c.definition); err != nil {
+			return err
+		}
+	}
+	
+	// Indexes on added columns
+	indexes := []string{
+		`CREATE INDEX IF NOT EXISTS idx_product_mentions_product
+		ON product_mentions(product_id, created_at);`,
+		// The bot and the scraper may both receive a message
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_messages_telegram_id
+		ON messages(chat_id, message_id) WHERE message_id <> 0;`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_messages_dedupe_key
+		ON messages(chat_id, dedupe_key) WHERE dedupe_key <> '';`,
+	}
+	for _, stmt := range indexes {
+		if _, err := db.conn.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to create index: %w", err)
+		}
+	}
+	
+	// Timestamps first, so the messages of merged groups compare by time
+	if err := db.normalizeTimestamps(); err != nil {
+		return err
+	}
+	if err := db.normalizeChatIDs(); err != nil {
+		return err
+	}
+	
+	logger.Debug("✅ Tables migrated successfully")
+	return nil
+}
+
+// chatKeyedTables are the tables keyed by a group's chat ID besides tracked_groups.
+// unique matches a row o of the canonical chat a moved row would collide with (empty
+// if rows never collide). Messages saved without a message ID or dedupe key by older
+// versions match by sender, time and text, as messageDedupeKey does.
+var chatKeyedTables = []struct {
+	table, column, unique string
+}{
+	{"messages", "chat_id", "(o.message_id = messages.message_id AND o.message_id <> 0) OR " +
+		"(o.dedupe_key = messages.dedupe_key AND o.dedupe_key <> '') OR " +
+		"((o.message_id = 0 OR messages.message_id = 0) AND o.user_id = messages.user_id AND " +
+		"o.timestamp = messages.timestamp AND o.message_text = messages.message_text AND o.message_text <> '')"},
+	{"summaries", "chat_id", ""},
+	{"product_prices", "chat_id", ""},
+	{"filter_configs", "chat_id", "1 = 1"},
+	{"filter_drops", "chat_id", "o.rule = filter_drops.rule AND o.date = filter_drops.date"},
+	{"user_groups", "chat_id", "o.user_id = user_groups.user_id"},
+	{"user_activity", "chat_id", "o.user_id = user_activity.user_id AND o.hour = user_activity.hour"},
+	{"family_code_groups", "chat_id", "o.code = family_code_groups.code"},
+	{"family_code_messages", "chat_id", "o.message_id = family_code_messages.message_id AND o.code = family_code_messages.code"},
+	{"summary_subscriptions", "group_chat_id", "o.target_chat_id = summary_subscriptions.target_chat_id AND o.cadence = summary_subscriptions.cadence"},
+	{"summary_schedules", "chat_id", ""},
+	{"summary_windows", "chat_id", "o.summary_type = summary_windows.summary_type AND o.window_start = summary_windows.window_start"},
+	{"summary_jobs", "chat_id", ""},
+}
+
+// normalizeChatIDs converts raw MTProto chat IDs stored by older scraper versions to
+// canonical form and merges groups that were tracked twice (once by the bot, once by
+// the scraper) together with the rows of every chat-keyed table. Where both IDs have
+// a row with the same key, e.g. the same subscription, the canonical chat's row is kept.
+// Positive IDs without a group name are private bot chats and are left untouched.
+func (db *DB) normalizeChatIDs() error {
+	rows, err := db.conn.Query(`
+		SELECT chat_id, COALESCE(chat_type, '')
+		FROM tracked_groups
+		WHERE chat_id > 0 AND COALESCE(group_name, '') != ''`)
+	if err != nil {
+		return fmt.Errorf("failed to query raw chat IDs: %w", err)
+	}
+	
+	type rawGroup struct {
+		chatID   int64
+		chatType string
+	}
+	var raw []rawGroup
+	for rows.Next() {
+		var g rawGroup
+		if err := rows.Scan(&g.chatID, &g.chatType); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan raw chat ID: %w", err)
+		}
+		raw = append(raw, g)
+	}
+	rows.Close()
+	
+	if len(raw) == 0 {
+		return nil
+	}
+	
+	logger.Info("🔄 Normalizing %d raw chat IDs...", len(raw))
+	
+	tx, err := db.conn.begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	
+	merged := 0
+	for _, g := range raw {
+		canonical := canonicalChatID(tx, g.chatID, g.chatType).Int64()
+		
+		var exists bool
+		if err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM tracked_groups WHERE chat_id = ?)`, canonical).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to check group existence: %w", err)
+		}
+		
+		if exists {
+			// Keep the bot's row, folding in what the scraper knew about the group
+			if err := mergeTrackedGroup(tx, g.chatID, canonical); err != nil {
+				return err
+			}
+			if _, err := tx.Exec(`DELETE FROM tracked_groups WHERE chat_id = ?`, g.chatID); err != nil {
+				return fmt.Errorf("failed to delete duplicate group %d: %w", g.chatID, err)
+			}
+			merged++
+		} else {
+			if _, err := tx.Exec(`UPDATE tracked_groups SET chat_id = ? WHERE chat_id = ?`, canonical, g.chatID); err != nil {
+				return fmt.Errorf("failed to normalize tracked group %d: %w", g.chatID, err)
+			}
+		}
+		
+		for _, t := range chatKeyedTables {
+			if t.unique == "" {
+				query := fmt.Sprintf("UPDATE %s SET %s = ? WHERE %[2]s = ?", t.table, t.column)
+				if _, err := tx.Exec(query, canonical, g.chatID); err != nil {
+					return fmt.Errorf("failed to normalize %s of chat %d: %w", t.table, g.chatID, err)
+				}
+				continue
+			}
+			query := fmt.Sprintf(`
+				UPDATE %[1]s SET %[2]s = ? WHERE %[2]s = ?
+				AND NOT EXISTS (SELECT 1 FROM %[1]s o WHERE o.%[2]s = ? AND (%[3]s))`, t.table, t.column, t.unique)
+			if _, err := tx.Exec(query, canonical, g.chatID, canonical); err != nil {
+				return fmt.Errorf("failed to normalize %s of chat %d: %w", t.table, g.chatID, err)
+			}
+			// Rows left behind duplicate rows of the canonical chat
+			if _, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s = ?", t.table, t.column), g.chatID); err != nil {
+				return fmt.Errorf("failed to delete duplicate %s of chat %d: %w", t.table, g.chatID, err)
+			}
+		}
+		
+		logger.Debug("Normalized chat ID %d -> %d", g.chatID, canonical)
+	}
+	
+	// Migration targets are always supergroups
+	migratedQuery := `
+		UPDATE tracked_groups
+		SET migrated_to_chat_id = -? - migrated_to_chat_id
+		WHERE migrated_to_chat_id > 0`
+	if _, err := tx.Exec(migratedQuery, channelIDOffset); err != nil {
+		return fmt.Errorf("failed to normalize migrated chat IDs: %w", err)
+	}
+	
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit chat ID normalization: %w", err)
+	}
+	
+	logger.Info("✅ Normalized %d chat IDs (%d duplicate groups merged)", len(raw), merged)
+	return nil
+}
+
+// normalizeTimestamps converts SQLite timestamps that older versions stored with the
+// offset of the host's zone (e.g. "2025-01-01 07:00:00+07:00") to UTC, the zone all
+// times are written in now. Timestamps are compared as text, so mixed offsets break
+// time range queries.
+func (db *DB) normalizeTimestamps() error {
+	if db.conn.driver != DriverSQLite {
+		return nil
+	}
+	
+	rows, err := db.conn.Query(`
+		SELECT m.name, p.name
+		FROM sqlite_master m, pragma_table_info(m.name) p
+		WHERE m.type = 'table' AND p.type = 'DATETIME'`)
+	if err != nil {
+		return fmt.Errorf("failed to query timestamp columns: %w", err)
+	}
+	type column struct{ table, name string }
+	var columns []column
+	for rows.Next() {
+		var c column
+		if err := rows.Scan(&c.table, &c.name); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan timestamp column: %w", err)
+		}
+		columns = append(columns, c)
+	}
+	rows.Close()
+	
+	var converted int64
+	for _, c := range columns {
+		// datetime() applies the offset; fractional seconds are carried over as written
+		query := fmt.Sprintf(`
+			UPDATE %[1]s SET %[2]s = datetime(%[2]s) || substr(%[2]s, 20, length(%[2]s) - 25) || '+00:00'
+			WHERE typeof(%[2]s) = 'text' AND %[2]s LIKE '____-__-__ __:__:__%%'
+			AND substr(%[2]s, -6, 1) IN ('+', '-') AND substr(%[2]s, -6) != '+00:00'`, c.table, c.name)
+		result, err := db.conn.Exec(query)
+		if err != nil {
+			return fmt.Errorf("failed to convert %s.%s to UTC: %w", c.table, c.name, err)
+		}
+		n, _ := result.RowsAffected()
+		converted += n
+	}
+	
+	if converted > 0 {
+		logger.Info("✅ Converted %d timestamps to UTC", converted)
+	}
+	return nil
+}
+
+// mergeTrackedGroup folds the row of a group tracked under its raw ID into the row of
+// its canonical ID. Values are combined here rather than in SQL, as UPDATE ... FROM
+// needs SQLite 3.33.
+func mergeTrackedGroup(tx queryExecer, rawID, canonical int64) error {
+	type row struct {
+		isActive, folderID, isLeft int
+		lastMessage              sql.NullTime
+		chatType                 string
+		migratedTo               int64
+	}
+	query := `
+		SELECT COALESCE(is_active, 0), COALESCE(folder_id, 0), COALESCE(is_left, 0), last_message_date,
+		       COALESCE(chat_type, ''), COALESCE(migrated_to_chat_id, 0)
+		FROM tracked_groups WHERE chat_id = ?`
+	var t, s row
+	for _, r := range []struct {
+		chatID int64
+		row    *row
+	}{{canonical, &t}, {rawID, &s}} {
+		if err := tx.QueryRow(query, r.chatID).Scan(&r.row.isActive, &r.row.folderID, &r.row.isLeft, &r.row.lastMessage,
+			&r.row.chatType, &r.row.migratedTo); err != nil {
+			return fmt.Errorf("failed to read tracked group %d: %w", r.chatID, err)
+		}
+	}
+	
+	if s.isActive > t.isActive {
+		t.isActive = s.isActive
+	}
+	if s.lastMessage.Valid && (!t.lastMessage.Valid || s.lastMessage.Time.After(t.lastMessage.Time)) {
+		t.lastMessage = s.lastMessage
+	}
+	if t.chatType == "" {
+		t.chatType = s.chatType
+	}
+	
+	// Membership is what the scraper knew
+	mergeQuery := `
+		UPDATE tracked_groups SET
+			is_active = ?, last_message_date = ?, chat_type = ?, folder_id = ?, is_left = ?, migrated_to_chat_id = ?
+		WHERE chat_id = ?`
+	if _, err := tx.Exec(mergeQuery, t.isActive, t.lastMessage, t.chatType, s.folderID, s.isLeft, s.migratedTo, canonical); err != nil {
+		return fmt.Errorf("failed to merge tracked group %d: %w", rawID, err)
+	}
+	return nil
+}
+
+// canonicalChatID guesses the canonical form of a raw MTProto group ID.
+// The stored chat type decides when known; otherwise an existing bot row for
+// either form wins, falling back to supergroup (the common case).
+func canonicalChatID(tx queryExecer, rawID int64, chatType string) ChatID {
+	switch chatType {
+	case ChatTypeGroup:
+		return GroupChatID(rawID)
+	case ChatTypeSupergroup, ChatTypeChannel:
+		return ChannelChatID(rawID)
+	}
+	
+	var exists bool
+	err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM tracked_groups WHERE chat_id = ?)`, GroupChatID(rawID).Int64()).Scan(&exists)
+	if err == nil && exists {
+		return GroupChatID(rawID)
+	}
+	
+	return ChannelChatID(rawID)
+}
+
+// addColumnIfMissing adds a column to a table unless it already exists
+func (db *DB) addColumnIfMissing(table, column, definition string) error {
+	if db.conn.driver == DriverPostgres {
+		query := fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s", table, column, db.conn.ddl(definition))
+		if _, err := db.conn.Exec(query); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", table, column, err)
+		}
+		return nil
+	}
+	
+	rows, err := db.conn.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+	
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return fmt.Errorf("failed to scan column of %s: %w", table, err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	
+	query := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)
+	if _, err := db.conn.Exec(query); err != nil {
+		return fmt.Errorf("failed to add column %s.%s: %w", table, column, err)
+	}
+	
+	logger.Info("✅ Added column %s.%s", table, column)
+	return nil
+}
+
+// SaveMessage saves a message to the database.
+// Returns false if the message was already saved under its Telegram message ID.
+func (db *DB) SaveMessage(msg *Message) (bool, error) {
 	logger.Debug("Saving message: ChatID=%d, UserID=%d, Length=%d", 
 		msg.ChatID, msg.UserID, msg.MessageLength)
 	
+	// Basic groups number messages per account, so the bot's and the scraper's IDs of
+	// a message differ and only those of supergroups and channels identify it. Messages
+	// of basic groups are identified by sender, time and text instead.
+	var messageID int64
+	var dedupeKey string
+	if ChatID(msg.ChatID).IsChannel() {
+		messageID = msg.MessageID
+	} else {
+		dedupeKey = messageDedupeKey(msg)
+	}
+	
 	query := `
-		INSERT INTO messages (chat_id, user_id, username, message_text, message_length, timestamp)
-		VALUES (?, ?, ?, ?, ?, ?)`
+		INSERT INTO messages (chat_id, message_id, dedupe_key, user_id, username, message_text, message_length, timestamp,
+		                      media_type, media_file_id, media_size, ocr_text)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT DO NOTHING`
 	
-	result, err := db.conn.Exec(query, 
+	id, err := db.conn.insertIfAbsent(query, 
 		msg.ChatID, 
+		messageID, 
+		dedupeKey, 
 		msg.UserID, 
 		msg.Username, 
 		msg.MessageText, 
 		msg.MessageLength,
 		msg.Timestamp,
+		msg.MediaType,
+		msg.MediaFileID,
+		msg.MediaSize,
+		msg.OCRText,
 	)
 	
 	if err != nil {
-		return fmt.Errorf("failed to save message: %w", err)
+		return false, fmt.Errorf("failed to save message: %w", err)
+	}
+	if id == 0 {
+		logger.Debug("Message already saved: ChatID=%d, MessageID=%d", msg.ChatID, messageID)
+		return false, nil
 	}
 	
-	id, _ := result.LastInsertId()
 	msg.ID = id
 	
 	logger.Info("✅ Message saved: ID=%d, User=%s, ChatID=%d", id, msg.Username, msg.ChatID)
+	return true, nil
+}
+
+// messageDedupeKey identifies a message of a basic group by its sender, time and text.
+// Messages without text, e.g. photos of an album, have no key and are never deduplicated.
+func messageDedupeKey(msg *Message) string {
+	if msg.MessageText == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d\x00%d\x00%s", msg.UserID, msg.Timestamp.Unix(), msg.MessageText)))
+	return hex.EncodeToString(sum[:16])
+}
+
+// SetMessageOCRText stores the text recognized in a message's image
+func (db *DB) SetMessageOCRText(messageID int64, text string) error {
+	logger.Debug("Saving OCR text: MessageID=%d, Length=%d", messageID, len(text))
+	
+	query := `UPDATE messages SET ocr_text = ? WHERE id = ?`
+	
+	if _, err := db.conn.Exec(query, text, messageID); err != nil {
+		return fmt.Errorf("failed to save OCR text: %w", err)
+	}
+	
 	return nil
 }
 
@@ -182,12 +928,40 @@ func (db *DB) GetMessagesByTimeRange(chatID int64, startTime, endTime time.Time)
 		chatID, startTime.Format("15:04:05"), endTime.Format("15:04:05"))
 	
 	query := `
-		SELECT id, chat_id, user_id, username, message_text, message_length, timestamp, created_at
+		SELECT ` + messageColumns + `
 		FROM messages
 		WHERE chat_id = ? AND timestamp BETWEEN ? AND ?
 		ORDER BY timestamp ASC`
 	
-	rows, err := db.conn.Query(query, chatID, startTime, endTime)
+	messages, err := db.queryMessages(query, chatID, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	
+	logger.Info("📊 Found %d messages in time range", len(messages))
+	return messages, nil
+}
+
+// GetMessagesOlderThan retrieves messages older than a date that still need archiving.
+// Messages restored from an archive are skipped since they are archived already.
+func (db *DB) GetMessagesOlderThan(chatID int64, beforeDate time.Time) ([]Message, error) {
+	query := `
+		SELECT ` + messageColumns + `
+		FROM messages
+		WHERE chat_id = ? AND timestamp < ? AND COALESCE(restored, 0) = 0
+		ORDER BY timestamp ASC`
+	
+	return db.queryMessages(query, chatID, beforeDate)
+}
+
+// messageColumns lists the messages columns read by queryMessages
+const messageColumns = `id, chat_id, COALESCE(message_id, 0), user_id, username, message_text, message_length, timestamp, created_at,
+		       COALESCE(media_type, ''), COALESCE(media_file_id, ''), COALESCE(media_size, 0), COALESCE(ocr_text, ''),
+		       COALESCE(restored, 0)`
+
+// queryMessages runs a query selecting messageColumns and scans the rows
+func (db *DB) queryMessages(query string, args ...interface{}) ([]Message, error) {
+	rows, err := db.conn.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query messages: %w", err)
 	}
@@ -199,12 +973,18 @@ func (db *DB) GetMessagesByTimeRange(chatID int64, startTime, endTime time.Time)
 		err := rows.Scan(
 			&msg.ID,
 			&msg.ChatID,
+			&msg.MessageID,
 			&msg.UserID,
 			&msg.Username,
 			&msg.MessageText,
 			&msg.MessageLength,
 			&msg.Timestamp,
 			&msg.CreatedAt,
+			&msg.MediaType,
+			&msg.MediaFileID,
+			&msg.MediaSize,
+			&msg.OCRText,
+			&msg.Restored,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan message: %w", err)
@@ -212,8 +992,50 @@ func (db *DB) GetMessagesByTimeRange(chatID int64, startTime, endTime time.Time)
 		messages = append(messages, msg)
 	}
 	
-	logger.Info("📊 Found %d messages in time range", len(messages))
-	return messages, nil
+	return messages, rows.Err()
+}
+
+// RestoreMessage inserts an archived message back into the messages table, marked as restored.
+// Returns false if an identical message is already present.
+func (db *DB) RestoreMessage(msg *Message) (bool, error) {
+	existsQuery := `
+		SELECT EXISTS(
+			SELECT 1 FROM messages
+			WHERE chat_id = ? AND user_id = ? AND timestamp = ? AND message_text = ?
+		)`
+	
+	var exists bool
+	if err := db.conn.QueryRow(existsQuery, msg.ChatID, msg.UserID, msg.Timestamp, msg.MessageText).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check message existence: %w", err)
+	}
+	if exists {
+		return false, nil
+	}
+	
+	query := `
+		INSERT INTO messages (chat_id, user_id, username, message_text, message_length, timestamp,
+		                      media_type, media_file_id, media_size, ocr_text, restored)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1)`
+	
+	id, err := db.conn.insert(query,
+		msg.ChatID,
+		msg.UserID,
+		msg.Username,
+		msg.MessageText,
+		msg.MessageLength,
+		msg.Timestamp,
+		msg.MediaType,
+		msg.MediaFileID,
+		msg.MediaSize,
+		msg.OCRText,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to restore message: %w", err)
+	}
+	
+	msg.ID = id
+	msg.Restored = true
+	return true, nil
 }
 
 // DeleteMessagesByTimeRange deletes messages within a time range for a chat
@@ -243,13 +1065,58 @@ func (db *DB) SaveSummary(summary *Summary) error {
 	logger.Debug("Saving summary: ChatID=%d, Type=%s, Messages=%d", 
 		summary.ChatID, summary.SummaryType, summary.MessageCount)
 	
+	if err := insertSummary(db.conn, summary); err != nil {
+		return err
+	}
+	
+	logger.Info("✅ Summary saved: ID=%d, Type=%s, ChatID=%d", summary.ID, summary.SummaryType, summary.ChatID)
+	return nil
+}
+
+// SaveSummaryWithProducts saves a summary and its product mentions in one transaction,
+// so either all of them are stored or none
+func (db *DB) SaveSummaryWithProducts(summary *Summary, products []ProductMention) error {
+	logger.Debug("Saving summary with %d products: ChatID=%d, Type=%s, Messages=%d", 
+		len(products), summary.ChatID, summary.SummaryType, summary.MessageCount)
+	
+	tx, err := db.conn.begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	
+	if err := insertSummary(tx, summary); err != nil {
+		return err
+	}
+	
+	for i := range products {
+		products[i].SummaryID = summary.ID
+		if err := insertProductMention(tx, &products[i]); err != nil {
+			return err
+		}
+		if err := insertProductPrices(tx, &products[i], summary.ChatID, summary.PeriodEnd); err != nil {
+			return err
+		}
+	}
+	
+	if err := tx.Commit(); err != nil {
+		summary.ID = 0
+		return fmt.Errorf("failed to commit summary: %w", err)
+	}
+	
+	logger.Info("✅ Summary saved: ID=%d, Type=%s, ChatID=%d, Products=%d", summary.ID, summary.SummaryType, summary.ChatID, len(products))
+	return nil
+}
+
+// insertSummary inserts a summary row and sets its ID
+func insertSummary(q inserter, summary *Summary) error {
 	query := `
 		INSERT INTO summaries (
 			chat_id, summary_type, period_start, period_end, summary_text, message_count,
 			sentiment, credibility_score, products_mentioned, red_flags_count, validation_status
 		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 	
-	result, err := db.conn.Exec(query,
+	id, err := q.insert(query,
 		summary.ChatID,
 		summary.SummaryType,
 		summary.PeriodStart,
@@ -267,10 +1134,7 @@ func (db *DB) SaveSummary(summary *Summary) error {
 		return fmt.Errorf("failed to save summary: %w", err)
 	}
 	
-	id, _ := result.LastInsertId()
 	summary.ID = id
-	
-	logger.Info("✅ Summary saved: ID=%d, Type=%s, ChatID=%d", id, summary.SummaryType, summary.ChatID)
 	return nil
 }
 
@@ -390,10 +1254,56 @@ func (db *DB) UpdateGroupActivity(chatID int64, lastMessageDate time.Time) error
 	
 	_, err := db.conn.Exec(query, lastMessageDate, chatID)
 	if err != nil {
-		return fmt.Errorf("failed to update group activity: %w", err)
+		return fmt.Errorf("failed to update group activity: %w", err)
+	}
+	
+	return nil
+}
+
+// trackedGroupColumns lists the tracked_groups columns read by scanTrackedGroup
+const trackedGroupColumns = `chat_id, group_name, group_username, join_date, is_active, last_message_date,
+		       COALESCE(chat_type, ''), COALESCE(folder_id, 0), COALESCE(is_left, 0), COALESCE(migrated_to_chat_id, 0),
+		       COALESCE(retention_days, 0), COALESCE(archive_mode, ''), COALESCE(timezone, ''),
+		       COALESCE(min_messages, 0), COALESCE(max_window_hours, 0), COALESCE(busy_messages, 0), COALESCE(busy_window_minutes, 0)`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTrackedGroup scans a row selected with trackedGroupColumns
+func scanTrackedGroup(row rowScanner) (TrackedGroup, error) {
+	var g TrackedGroup
+	var lastMessageDate sql.NullTime
+	
+	err := row.Scan(
+		&g.ChatID,
+		&g.GroupName,
+		&g.GroupUsername,
+		&g.JoinDate,
+		&g.IsActive,
+		&lastMessageDate,
+		&g.ChatType,
+		&g.FolderID,
+		&g.IsLeft,
+		&g.MigratedToChatID,
+		&g.RetentionDays,
+		&g.ArchiveMode,
+		&g.Timezone,
+		&g.Windows.MinMessages,
+		&g.Windows.MaxWindowHours,
+		&g.Windows.BusyMessages,
+		&g.Windows.BusyWindowMinutes,
+	)
+	if err != nil {
+		return g, err
 	}
 	
-	return nil
+	if lastMessageDate.Valid {
+		g.LastMessageDate = lastMessageDate.Time
+	}
+	
+	return g, nil
 }
 
 // GetTrackedGroup gets a single tracked group by chat ID
@@ -401,22 +1311,12 @@ func (db *DB) GetTrackedGroup(chatID int64) *TrackedGroup {
 	logger.Debug("Fetching tracked group: ChatID=%d", chatID)
 	
 	query := `
-		SELECT chat_id, group_name, group_username, join_date, is_active, last_message_date
+		SELECT ` + trackedGroupColumns + `
 		FROM tracked_groups
 		WHERE chat_id = ?
 		LIMIT 1`
 	
-	var g TrackedGroup
-	var lastMessageDate sql.NullTime
-	
-	err := db.conn.QueryRow(query, chatID).Scan(
-		&g.ChatID,
-		&g.GroupName,
-		&g.GroupUsername,
-		&g.JoinDate,
-		&g.IsActive,
-		&lastMessageDate,
-	)
+	g, err := scanTrackedGroup(db.conn.QueryRow(query, chatID))
 	
 	if err == sql.ErrNoRows {
 		logger.Debug("Group not found: ChatID=%d", chatID)
@@ -428,10 +1328,6 @@ func (db *DB) GetTrackedGroup(chatID int64) *TrackedGroup {
 		return nil
 	}
 	
-	if lastMessageDate.Valid {
-		g.LastMessageDate = lastMessageDate.Time
-	}
-	
 	return &g
 }
 
@@ -440,7 +1336,7 @@ func (db *DB) GetTrackedGroups() []TrackedGroup {
 	logger.Debug("Fetching tracked groups...")
 	
 	query := `
-		SELECT chat_id, group_name, group_username, join_date, is_active, last_message_date
+		SELECT ` + trackedGroupColumns + `
 		FROM tracked_groups
 		WHERE is_active >= 0
 		ORDER BY last_message_date DESC`
@@ -454,26 +1350,12 @@ func (db *DB) GetTrackedGroups() []TrackedGroup {
 	
 	var groups []TrackedGroup
 	for rows.Next() {
-		var g TrackedGroup
-		var lastMessageDate sql.NullTime
-		
-		err := rows.Scan(
-			&g.ChatID,
-			&g.GroupName,
-			&g.GroupUsername,
-			&g.JoinDate,
-			&g.IsActive,
-			&lastMessageDate,
-		)
+		g, err := scanTrackedGroup(rows)
 		if err != nil {
 			logger.Error("Failed to scan tracked group: %v", err)
 			continue
 		}
 		
-		if lastMessageDate.Valid {
-			g.LastMessageDate = lastMessageDate.Time
-		}
-		
 		groups = append(groups, g)
 	}
 	
@@ -481,6 +1363,177 @@ func (db *DB) GetTrackedGroups() []TrackedGroup {
 	return groups
 }
 
+// SyncTrackedGroup inserts or updates a group discovered from the dialog list.
+// Name, username, chat type and folder are refreshed and the group is marked as joined;
+// is_active and activity fields are preserved.
+func (db *DB) SyncTrackedGroup(group *TrackedGroup) error {
+	logger.Debug("Syncing tracked group: ChatID=%d, Name=%s, Type=%s", group.ChatID, group.GroupName, group.ChatType)
+	
+	query := `
+		INSERT INTO tracked_groups (chat_id, group_name, group_username, is_active, chat_type, folder_id, is_left, migrated_to_chat_id)
+		VALUES (?, ?, ?, 0, ?, ?, ?, ?)
+		ON CONFLICT(chat_id) DO UPDATE SET
+			group_name = excluded.group_name,
+			group_username = excluded.group_username,
+			chat_type = excluded.chat_type,
+			folder_id = excluded.folder_id,
+			is_left = excluded.is_left,
+			migrated_to_chat_id = excluded.migrated_to_chat_id,
+			is_active = CASE WHEN excluded.is_left = 0 AND tracked_groups.active_when_left = 1 THEN 1 ELSE tracked_groups.is_active END,
+			active_when_left = CASE WHEN excluded.is_left = 0 THEN 0 ELSE tracked_groups.active_when_left END`
+	
+	_, err := db.conn.Exec(query,
+		group.ChatID,
+		group.GroupName,
+		group.GroupUsername,
+		group.ChatType,
+		group.FolderID,
+		group.IsLeft,
+		group.MigratedToChatID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to sync tracked group: %w", err)
+	}
+	
+	return nil
+}
+
+// MarkGroupLeft marks a group as no longer joined and stops its summarization.
+// Summarization resumes if the group shows up in the dialogs again.
+func (db *DB) MarkGroupLeft(chatID int64) error {
+	logger.Info("Marking group as left: ChatID=%d", chatID)
+	
+	query := `
+		UPDATE tracked_groups 
+		SET is_left = 1, is_active = 0,
+			active_when_left = CASE WHEN is_active = 1 THEN 1 ELSE active_when_left END
+		WHERE chat_id = ?`
+	
+	_, err := db.conn.Exec(query, chatID)
+	if err != nil {
+		return fmt.Errorf("failed to mark group as left: %w", err)
+	}
+	
+	return nil
+}
+
+// MarkGroupMigrated records that a basic group was upgraded to a supergroup.
+// Summarization is carried over to the new chat when the old group had it enabled.
+// The group's settings move in one transaction, so a failed migration moves none.
+func (db *DB) MarkGroupMigrated(chatID, newChatID int64) error {
+	logger.Info("Marking group as migrated: ChatID=%d -> %d", chatID, newChatID)
+	
+	old := db.GetTrackedGroup(chatID)
+	
+	tx, err := db.conn.begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	
+	query := `
+		UPDATE tracked_groups 
+		SET migrated_to_chat_id = ?, is_left = 1, is_active = 0
+		WHERE chat_id = ?`
+	
+	if _, err := tx.Exec(query, newChatID, chatID); err != nil {
+		return fmt.Errorf("failed to mark group as migrated: %w", err)
+	}
+	
+	if old != nil && old.IsActive == 1 {
+		query := `UPDATE tracked_groups SET is_active = 1, summary_enabled_date = CURRENT_TIMESTAMP WHERE chat_id = ?`
+		if _, err := tx.Exec(query, newChatID); err != nil {
+			return fmt.Errorf("failed to enable group summary: %w", err)
+		}
+	}
+	
+	// The timezone, window policy, schedules and subscribers carry over to the supergroup
+	if old != nil && old.Timezone != "" {
+		query := `UPDATE tracked_groups SET timezone = ? WHERE chat_id = ? AND COALESCE(timezone, '') = ''`
+		if _, err := tx.Exec(query, old.Timezone, newChatID); err != nil {
+			return fmt.Errorf("failed to move group timezone: %w", err)
+		}
+	}
+	if old != nil && old.Windows != (WindowPolicy{}) {
+		query := `
+			UPDATE tracked_groups
+			SET min_messages = ?, max_window_hours = ?, busy_messages = ?, busy_window_minutes = ?
+			WHERE chat_id = ?`
+		p := old.Windows
+		if _, err := tx.Exec(query, p.MinMessages, p.MaxWindowHours, p.BusyMessages, p.BusyWindowMinutes, newChatID); err != nil {
+			return fmt.Errorf("failed to move group window policy: %w", err)
+		}
+	}
+	if _, err := tx.Exec(`UPDATE summary_schedules SET chat_id = ? WHERE chat_id = ?`, newChatID, chatID); err != nil {
+		return fmt.Errorf("failed to move summary schedules: %w", err)
+	}
+	if err := moveSubscriptions(tx, chatID, newChatID); err != nil {
+		return err
+	}
+	
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit group migration: %w", err)
+	}
+	return nil
+}
+
+// SetGroupRetention sets the retention policy of a group (0 days / empty mode = use default)
+func (db *DB) SetGroupRetention(chatID int64, retentionDays int, archiveMode string) error {
+	logger.Info("Setting retention for group %d: %d days, archive mode %q", chatID, retentionDays, archiveMode)
+	
+	query := `UPDATE tracked_groups SET retention_days = ?, archive_mode = ? WHERE chat_id = ?`
+	
+	result, err := db.conn.Exec(query, retentionDays, archiveMode, chatID)
+	if err != nil {
+		return fmt.Errorf("failed to set group retention: %w", err)
+	}
+	
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("group %d not found", chatID)
+	}
+	
+	return nil
+}
+
+// SetGroupTimezone sets the IANA timezone of a group (empty = deployment default)
+func (db *DB) SetGroupTimezone(chatID int64, timezone string) error {
+	logger.Info("Setting timezone for group %d: %q", chatID, timezone)
+	
+	query := `UPDATE tracked_groups SET timezone = ? WHERE chat_id = ?`
+	
+	result, err := db.conn.Exec(query, timezone, chatID)
+	if err != nil {
+		return fmt.Errorf("failed to set group timezone: %w", err)
+	}
+	
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("group %d not found", chatID)
+	}
+	
+	return nil
+}
+
+// SetGroupWindowPolicy sets the adaptive window policy of a group (zero fields = default)
+func (db *DB) SetGroupWindowPolicy(chatID int64, policy WindowPolicy) error {
+	logger.Info("Setting window policy for group %d: %+v", chatID, policy)
+	
+	query := `
+		UPDATE tracked_groups
+		SET min_messages = ?, max_window_hours = ?, busy_messages = ?, busy_window_minutes = ?
+		WHERE chat_id = ?`
+	
+	result, err := db.conn.Exec(query, policy.MinMessages, policy.MaxWindowHours, policy.BusyMessages, policy.BusyWindowMinutes, chatID)
+	if err != nil {
+		return fmt.Errorf("failed to set group window policy: %w", err)
+	}
+	
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("group %d not found", chatID)
+	}
+	
+	return nil
+}
+
 // EnableGroupSummary enables summarization for a group
 func (db *DB) EnableGroupSummary(chatID int64) error {
 	logger.Info("Enabling summary for ChatID=%d", chatID)
@@ -505,7 +1558,7 @@ func (db *DB) DisableGroupSummary(chatID int64) error {
 	
 	query := `
 		UPDATE tracked_groups 
-		SET is_active = 0
+		SET is_active = 0, active_when_left = 0
 		WHERE chat_id = ?`
 	
 	_, err := db.conn.Exec(query, chatID)
@@ -522,9 +1575,9 @@ func (db *DB) GetActiveGroups() []TrackedGroup {
 	logger.Debug("Fetching active groups...")
 	
 	query := `
-		SELECT chat_id, group_name, group_username, join_date, is_active, last_message_date
+		SELECT ` + trackedGroupColumns + `
 		FROM tracked_groups
-		WHERE is_active = 1
+		WHERE is_active = 1 AND COALESCE(is_left, 0) = 0
 		ORDER BY last_message_date DESC`
 	
 	rows, err := db.conn.Query(query)
@@ -536,26 +1589,12 @@ func (db *DB) GetActiveGroups() []TrackedGroup {
 	
 	var groups []TrackedGroup
 	for rows.Next() {
-		var g TrackedGroup
-		var lastMessageDate sql.NullTime
-		
-		err := rows.Scan(
-			&g.ChatID,
-			&g.GroupName,
-			&g.GroupUsername,
-			&g.JoinDate,
-			&g.IsActive,
-			&lastMessageDate,
-		)
+		g, err := scanTrackedGroup(rows)
 		if err != nil {
 			logger.Error("Failed to scan active group: %v", err)
 			continue
 		}
 		
-		if lastMessageDate.Valid {
-			g.LastMessageDate = lastMessageDate.Time
-		}
-		
 		groups = append(groups, g)
 	}
 	
@@ -591,10 +1630,10 @@ func (db *DB) GetGroupMessageCount24h(chatID int64) int {
 		SELECT COUNT(*) as count
 		FROM messages
 		WHERE chat_id = ? 
-		AND timestamp >= datetime('now', '-24 hours')`
+		AND timestamp >= ?`
 	
 	var count int
-	err := db.conn.QueryRow(query, chatID).Scan(&count)
+	err := db.conn.QueryRow(query, chatID, time.Now().Add(-24*time.Hour)).Scan(&count)
 	if err != nil {
 		logger.Error("Failed to get message count: %v", err)
 		return 0
@@ -603,6 +1642,20 @@ func (db *DB) GetGroupMessageCount24h(chatID int64) int {
 	return count
 }
 
+// CountMessages counts the messages of a group in [startTime, endTime)
+func (db *DB) CountMessages(chatID int64, startTime, endTime time.Time) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM messages
+		WHERE chat_id = ? AND timestamp >= ? AND timestamp < ?`
+	
+	var count int
+	if err := db.conn.QueryRow(query, chatID, startTime, endTime).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count messages: %w", err)
+	}
+	return count, nil
+}
+
 // Close closes the database connection
 func (db *DB) Close() error {
 	logger.Info("Closing database connection")
@@ -693,31 +1746,68 @@ func (db *DB) DeleteMessagesOlderThan(chatID int64, beforeDate time.Time) (int64
 	return rowsAffected, nil
 }
 
+// crossPostMinLength is the length of the shortest message text counted as a cross-post,
+// so greetings and short replies posted in several groups are ignored
+const crossPostMinLength = 40
+
+// GetCrossPosts gets the message texts posted in at least minGroups groups during a
+// period, posted in the most groups first
+func (db *DB) GetCrossPosts(startTime, endTime time.Time, minGroups, limit int) ([]CrossPost, error) {
+	query := `
+		SELECT message_text, COUNT(DISTINCT chat_id), COUNT(*)
+		FROM messages
+		WHERE timestamp >= ? AND timestamp < ? AND message_length >= ?
+		GROUP BY message_text
+		HAVING COUNT(DISTINCT chat_id) >= ?
+		ORDER BY COUNT(DISTINCT chat_id) DESC, COUNT(*) DESC
+		LIMIT ?`
+	
+	rows, err := db.conn.Query(query, startTime, endTime, crossPostMinLength, minGroups, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cross-posts: %w", err)
+	}
+	defer rows.Close()
+	
+	var posts []CrossPost
+	for rows.Next() {
+		var post CrossPost
+		if err := rows.Scan(&post.Text, &post.Groups, &post.Messages); err != nil {
+			return nil, fmt.Errorf("failed to scan cross-post: %w", err)
+		}
+		posts = append(posts, post)
+	}
+	return posts, rows.Err()
+}
+
 // SaveProductMention saves a product mention to database
 func (db *DB) SaveProductMention(pm *ProductMention) error {
 	logger.Debug("Saving product mention: %s (SummaryID=%d)", pm.ProductName, pm.SummaryID)
 	
+	if err := insertProductMention(db.conn, pm); err != nil {
+		return err
+	}
+	
+	logger.Debug("✅ Product mention saved: ID=%d, Product=%s", pm.ID, pm.ProductName)
+	return nil
+}
+
+// insertProductMention inserts a product mention row and sets its ID
+func insertProductMention(q inserter, pm *ProductMention) error {
 	query := `
 		INSERT INTO product_mentions (
 			summary_id, product_name, mention_count,
-			credibility_score, sentiment, validation_status, price_mentioned
-		) VALUES (?, ?, ?, ?, ?, ?, ?)`
+			credibility_score, sentiment, validation_status, price_mentioned, product_id, family_codes
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
 	
-	result, err := db.conn.Exec(query,
+	id, err := q.insert(query,
 		pm.SummaryID, pm.ProductName, pm.MentionCount,
-		pm.CredibilityScore, pm.Sentiment, pm.ValidationStatus, pm.PriceMentioned,
+		pm.CredibilityScore, pm.Sentiment, pm.ValidationStatus, pm.PriceMentioned, pm.ProductID, pm.FamilyCodes,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to save product mention: %w", err)
 	}
 	
-	id, err := result.LastInsertId()
-	if err != nil {
-		return fmt.Errorf("failed to get last insert ID: %w", err)
-	}
-	
 	pm.ID = id
-	logger.Debug("✅ Product mention saved: ID=%d, Product=%s", pm.ID, pm.ProductName)
 	return nil
 }
 
@@ -730,10 +1820,10 @@ func (db *DB) GetProductTrends(productName string, days int) []ProductMention {
 		       credibility_score, sentiment, validation_status, price_mentioned, created_at
 		FROM product_mentions
 		WHERE product_name = ?
-		AND created_at >= datetime('now', '-' || ? || ' days')
+		AND created_at >= ?
 		ORDER BY created_at DESC`
 	
-	rows, err := db.conn.Query(query, productName, days)
+	rows, err := db.conn.Query(query, productName, time.Now().UTC().AddDate(0, 0, -days))
 	if err != nil {
 		logger.Error("Failed to query product trends: %v", err)
 		return nil
@@ -765,3 +1855,251 @@ func (db *DB) GetProductTrends(productName string, days int) []ProductMention {
 	logger.Info("Found %d mentions of %s in last %d days", len(mentions), productName, days)
 	return mentions
 }
+
+// GetUpdateState gets the persisted update state of an account
+func (db *DB) GetUpdateState(userID int64) (UpdateState, bool, error) {
+	query := `
+		SELECT pts, qts, date, seq
+		FROM update_state
+		WHERE user_id = ?`
+	
+	var state UpdateState
+	err := db.conn.QueryRow(query, userID).Scan(&state.Pts, &state.Qts, &state.Date, &state.Seq)
+	if err == sql.ErrNoRows {
+		return UpdateState{}, false, nil
+	}
+	if err != nil {
+		return UpdateState{}, false, fmt.Errorf("failed to get update state: %w", err)
+	}
+	
+	return state, true, nil
+}
+
+// SetUpdateState replaces the update state of an account
+func (db *DB) SetUpdateState(userID int64, state UpdateState) error {
+	logger.Debug("Saving update state: UserID=%d, Pts=%d, Qts=%d, Seq=%d", userID, state.Pts, state.Qts, state.Seq)
+	
+	query := `
+		INSERT INTO update_state (user_id, pts, qts, date, seq)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			pts = excluded.pts,
+			qts = excluded.qts,
+			date = excluded.date,
+			seq = excluded.seq`
+	
+	if _, err := db.conn.Exec(query, userID, state.Pts, state.Qts, state.Date, state.Seq); err != nil {
+		return fmt.Errorf("failed to set update state: %w", err)
+	}
+	
+	return nil
+}
+
+// updateStateFields are the update_state columns that can be set individually
+var updateStateFields = map[string]bool{"pts": true, "qts": true, "date": true, "seq": true}
+
+// SetUpdateStateFields updates some fields of an existing update state.
+// Returns an error if the account has no state yet.
+func (db *DB) SetUpdateStateFields(userID int64, fields map[string]int) error {
+	var (
+		sets []string
+		args []interface{}
+	)
+	for field, value := range fields {
+		if !updateStateFields[field] {
+			return fmt.Errorf("unknown update state field: %s", field)
+		}
+		sets = append(sets, field+" = ?")
+		args = append(args, value)
+	}
+	args = append(args, userID)
+	
+	query := fmt.Sprintf("UPDATE update_state SET %s WHERE user_id = ?", strings.Join(sets, ", "))
+	
+	result, err := db.conn.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update update state: %w", err)
+	}
+	
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("update state not found for user %d", userID)
+	}
+	
+	return nil
+}
+
+// GetChannelPts gets the persisted pts of a channel
+func (db *DB) GetChannelPts(userID, channelID int64) (int, bool, error) {
+	query := `
+		SELECT pts
+		FROM channel_update_state
+		WHERE user_id = ? AND channel_id = ? AND pts IS NOT NULL`
+	
+	var pts int
+	err := db.conn.QueryRow(query, userID, channelID).Scan(&pts)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get channel pts: %w", err)
+	}
+	
+	return pts, true, nil
+}
+
+// SetChannelPts saves the pts of a channel
+func (db *DB) SetChannelPts(userID, channelID int64, pts int) error {
+	query := `
+		INSERT INTO channel_update_state (user_id, channel_id, pts)
+		VALUES (?, ?, ?)
+		ON CONFLICT(user_id, channel_id) DO UPDATE SET pts = excluded.pts`
+	
+	if _, err := db.conn.Exec(query, userID, channelID, pts); err != nil {
+		return fmt.Errorf("failed to set channel pts: %w", err)
+	}
+	
+	return nil
+}
+
+// GetAllChannelPts gets the persisted pts of every channel of an account
+func (db *DB) GetAllChannelPts(userID int64) (map[int64]int, error) {
+	query := `
+		SELECT channel_id, pts
+		FROM channel_update_state
+		WHERE user_id = ? AND pts IS NOT NULL`
+	
+	rows, err := db.conn.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query channel pts: %w", err)
+	}
+	defer rows.Close()
+	
+	channels := make(map[int64]int)
+	for rows.Next() {
+		var channelID int64
+		var pts int
+		if err := rows.Scan(&channelID, &pts); err != nil {
+			return nil, fmt.Errorf("failed to scan channel pts: %w", err)
+		}
+		channels[channelID] = pts
+	}
+	
+	return channels, rows.Err()
+}
+
+// GetChannelAccessHash gets the persisted access hash of a channel
+func (db *DB) GetChannelAccessHash(userID, channelID int64) (int64, bool, error) {
+	query := `
+		SELECT access_hash
+		FROM channel_update_state
+		WHERE user_id = ? AND channel_id = ? AND access_hash IS NOT NULL`
+	
+	var accessHash int64
+	err := db.conn.QueryRow(query, userID, channelID).Scan(&accessHash)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get channel access hash: %w", err)
+	}
+	
+	return accessHash, true, nil
+}
+
+// SetChannelAccessHash saves the access hash of a channel
+func (db *DB) SetChannelAccessHash(userID, channelID, accessHash int64) error {
+	query := `
+		INSERT INTO channel_update_state (user_id, channel_id, access_hash)
+		VALUES (?, ?, ?)
+		ON CONFLICT(user_id, channel_id) DO UPDATE SET access_hash = excluded.access_hash`
+	
+	if _, err := db.conn.Exec(query, userID, channelID, accessHash); err != nil {
+		return fmt.Errorf("failed to set channel access hash: %w", err)
+	}
+	
+	return nil
+}
+
+// GetFilterConfig gets the raw ingest filter configuration of a group (chat_id 0 = default)
+func (db *DB) GetFilterConfig(chatID int64) (string, bool, error) {
+	query := `SELECT config FROM filter_configs WHERE chat_id = ?`
+	
+	var config string
+	err := db.conn.QueryRow(query, chatID).Scan(&config)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get filter config: %w", err)
+	}
+	
+	return config, true, nil
+}
+
+// SetFilterConfig stores the raw ingest filter configuration of a group
+func (db *DB) SetFilterConfig(chatID int64, config string) error {
+	logger.Debug("Saving filter config: ChatID=%d", chatID)
+	
+	query := `
+		INSERT INTO filter_configs (chat_id, config, updated_at)
+		VALUES (?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(chat_id) DO UPDATE SET
+			config = excluded.config,
+			updated_at = excluded.updated_at`
+	
+	if _, err := db.conn.Exec(query, chatID, config); err != nil {
+		return fmt.Errorf("failed to set filter config: %w", err)
+	}
+	
+	return nil
+}
+
+// DeleteFilterConfig removes the filter configuration of a group, so the default applies again
+func (db *DB) DeleteFilterConfig(chatID int64) error {
+	if _, err := db.conn.Exec(`DELETE FROM filter_configs WHERE chat_id = ?`, chatID); err != nil {
+		return fmt.Errorf("failed to delete filter config: %w", err)
+	}
+	return nil
+}
+
+// IncrementFilterDrop counts a message dropped by an ingest filter rule
+func (db *DB) IncrementFilterDrop(chatID int64, rule string, at time.Time) error {
+	query := `
+		INSERT INTO filter_drops (chat_id, rule, date, count)
+		VALUES (?, ?, ?, 1)
+		ON CONFLICT(chat_id, rule, date) DO UPDATE SET
+			count = filter_drops.count + 1`
+	
+	if _, err := db.conn.Exec(query, chatID, rule, at.Format("2006-01-02")); err != nil {
+		return fmt.Errorf("failed to increment filter drop: %w", err)
+	}
+	
+	return nil
+}
+
+// GetFilterDropCounts gets the number of dropped messages per rule since a date
+func (db *DB) GetFilterDropCounts(chatID int64, since time.Time) (map[string]int, error) {
+	query := `
+		SELECT rule, SUM(count)
+		FROM filter_drops
+		WHERE chat_id = ? AND date >= ?
+		GROUP BY rule`
+	
+	rows, err := db.conn.Query(query, chatID, since.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get filter drops: %w", err)
+	}
+	defer rows.Close()
+	
+	counts := make(map[string]int)
+	for rows.Next() {
+		var rule string
+		var count int
+		if err := rows.Scan(&rule, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan filter drop: %w", err)
+		}
+		counts[rule] = count
+	}
+	
+	return counts, rows.Err()
+}
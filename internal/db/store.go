@@ -0,0 +1,146 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// Store is the storage backend used by the bot, scraper and scheduler.
+// DB implements it for SQLite and PostgreSQL.
+type Store interface {
+	// Messages
+	SaveMessage(msg *Message) (bool, error)
+	SetMessageOCRText(messageID int64, text string) error
+	GetMessagesByTimeRange(chatID int64, startTime, endTime time.Time) ([]Message, error)
+	GetMessagesOlderThan(chatID int64, beforeDate time.Time) ([]Message, error)
+	RestoreMessage(msg *Message) (bool, error)
+	DeleteMessagesByTimeRange(chatID int64, startTime, endTime time.Time) error
+	DeleteMessagesOlderThan(chatID int64, beforeDate time.Time) (int64, error)
+	GetGroupMessageCount24h(chatID int64) int
+	CountMessages(chatID int64, startTime, endTime time.Time) (int, error)
+	GetCrossPosts(startTime, endTime time.Time, minGroups, limit int) ([]CrossPost, error)
+	
+	// Summaries and product mentions
+	SaveSummary(summary *Summary) error
+	SaveSummaryWithProducts(summary *Summary, products []ProductMention) error
+	GetLastSummaryTime(chatID int64, summaryType string) (time.Time, error)
+	GetSummaries(chatID int64, summaryType string, limit int) ([]Summary, error)
+	GetSummariesByTimeRange(chatID int64, summaryType string, start, end time.Time) []Summary
+	SaveProductMention(pm *ProductMention) error
+	GetProductTrends(productName string, days int) []ProductMention
+	
+	// Product catalog
+	CreateProduct(name, nameKey string) (*Product, error)
+	GetProduct(productID int64) (*Product, error)
+	GetProductAliases(productID int64) ([]ProductAlias, error)
+	SetProductAlias(productID int64, alias, aliasKey string) error
+	DeleteProductAlias(aliasKey string) (bool, error)
+	MergeProducts(fromID, intoID int64) error
+	GetUnlinkedProductMentions(afterID int64, limit int) ([]UnlinkedProductMention, error)
+	LinkProductMention(pm *ProductMention, chatID int64, observedAt time.Time) error
+	GetProductMentions(productID int64, days int) ([]ProductMention, error)
+	GetProductPrices(productID int64, since time.Time) ([]ProductPrice, error)
+	GetProductActivity(productID int64, summaryType string, start, end time.Time) ([]ProductActivity, error)
+	
+	// Tracked groups
+	ResolveChatID(s string) (ChatID, error)
+	AddTrackedGroup(chatID int64, groupName string, groupUsername string) error
+	UpdateGroupActivity(chatID int64, lastMessageDate time.Time) error
+	GetTrackedGroup(chatID int64) *TrackedGroup
+	GetTrackedGroups() []TrackedGroup
+	SyncTrackedGroup(group *TrackedGroup) error
+	MarkGroupLeft(chatID int64) error
+	MarkGroupMigrated(chatID, newChatID int64) error
+	SetGroupRetention(chatID int64, retentionDays int, archiveMode string) error
+	SetGroupTimezone(chatID int64, timezone string) error
+	SetGroupWindowPolicy(chatID int64, policy WindowPolicy) error
+	EnableGroupSummary(chatID int64) error
+	DisableGroupSummary(chatID int64) error
+	GetActiveGroups() []TrackedGroup
+	DisableAllGroups() (int64, error)
+	
+	// Users
+	TrackUser(s UserSighting) error
+	GetUser(userID int64) (*User, error)
+	FindUsersByUsername(username string) ([]User, error)
+	GetUsernameHistory(userID int64) ([]UsernameRecord, error)
+	GetUserGroupActivity(userID int64) ([]UserGroupActivity, error)
+	GetTopUsers(chatID int64, start, end time.Time, limit int) ([]ActiveUser, error)
+	PruneUserActivity(before time.Time) (int64, error)
+	
+	// FamilyCode registry
+	RecordFamilyCode(s FamilyCodeSighting) error
+	GetFamilyCodesByMessage(chatID, messageID int64) ([]string, error)
+	RecordFamilyCodeVote(code string, userID int64, works bool, at time.Time) error
+	GetFamilyCode(code string) (*FamilyCode, error)
+	GetRecentFamilyCodes(since time.Time, limit int) ([]FamilyCode, error)
+	SearchFamilyCodes(text string, limit int) ([]FamilyCode, error)
+	GetFamilyCodeGroups(code string) ([]FamilyCodeGroup, error)
+	
+	// Summary subscriptions
+	AddSubscription(sub *Subscription) (bool, error)
+	RemoveSubscriptions(groupChatID, targetChatID int64, cadence string) (int64, error)
+	GetSubscriptions(groupChatID int64, cadence string) ([]Subscription, error)
+	GetSubscriptionsByTarget(targetChatID int64) ([]Subscription, error)
+	SubscribeLegacyTarget(targetChatID int64) (int64, error)
+	
+	// Summary schedules
+	AddSummarySchedule(schedule *SummarySchedule) error
+	DeleteSummarySchedule(chatID, scheduleID int64) (bool, error)
+	GetSummarySchedules(chatID int64) ([]SummarySchedule, error)
+	SetSummaryScheduleLastRun(scheduleID int64, at time.Time) error
+	
+	// Completed summary windows
+	RecordSummaryWindow(window *SummaryWindow) error
+	GetSummaryWindows(chatID int64, startTime, endTime time.Time) ([]SummaryWindow, error)
+	GetFirstSummaryWindow(chatID int64) (time.Time, error)
+	
+	// Summary job queue
+	EnqueueJob(job *SummaryJob) (bool, error)
+	ClaimJob(now time.Time) (*SummaryJob, error)
+	CompleteJob(jobID int64) error
+	FailJob(jobID int64, lastError string, retryAt time.Time) error
+	RetryJob(jobID int64) (bool, error)
+	ResetRunningJobs() (int64, error)
+	GetJob(jobID int64) (*SummaryJob, error)
+	GetJobs(status string, limit int) ([]SummaryJob, error)
+	CountJobs() (map[string]int, error)
+	PruneJobs(before time.Time) (int64, error)
+	
+	// Leader leases
+	AcquireLease(name, holder string, now time.Time, ttl time.Duration) (bool, error)
+	ReleaseLease(name, holder string) error
+	GetLease(name string) (*Lease, error)
+	
+	// MTProto update state
+	GetUpdateState(userID int64) (UpdateState, bool, error)
+	SetUpdateState(userID int64, state UpdateState) error
+	SetUpdateStateFields(userID int64, fields map[string]int) error
+	GetChannelPts(userID, channelID int64) (int, bool, error)
+	SetChannelPts(userID, channelID int64, pts int) error
+	GetAllChannelPts(userID int64) (map[int64]int, error)
+	GetChannelAccessHash(userID, channelID int64) (int64, bool, error)
+	SetChannelAccessHash(userID, channelID, accessHash int64) error
+	
+	// Ingest filters
+	GetFilterConfig(chatID int64) (string, bool, error)
+	SetFilterConfig(chatID int64, config string) error
+	DeleteFilterConfig(chatID int64) error
+	IncrementFilterDrop(chatID int64, rule string, at time.Time) error
+	GetFilterDropCounts(chatID int64, since time.Time) (map[string]int, error)
+	
+	Close() error
+}
+
+var _ Store = (*DB)(nil)
+
+// Open opens the store of a driver: a file path for sqlite, a connection URL for postgres
+func Open(driver, source string) (Store, error) {
+	switch driver {
+	case DriverSQLite, "sqlite3", "":
+		return InitDB(source)
+	case DriverPostgres, "postgresql":
+		return InitPostgres(source)
+	}
+	return nil, fmt.Errorf("unknown database driver %q (use %s or %s)", driver, DriverSQLite, DriverPostgres)
+}
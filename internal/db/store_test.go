@@ -0,0 +1,299 @@
+package db_test
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"telegram-summarizer/internal/db"
+	"telegram-summarizer/internal/db/storetest"
+	"testing"
+	"time"
+)
+
+func TestSQLiteStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storetest.db")
+	runSuite(t, db.DriverSQLite, path)
+	testNormalizeChatIDs(t, db.DriverSQLite, path)
+}
+
+func TestSQLiteTimestampsToUTC(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "zoned.db")
+	store, err := db.Open(db.DriverSQLite, path)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	store.Close()
+	
+	// Rows written by an older version on a host in WIB
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	chatID := db.ChannelChatID(1234).Int64()
+	for _, timestamp := range []string{"2025-03-01 07:30:00+07:00", "2025-03-01 07:45:00.5+07:00", "2025-03-01 00:50:00+00:00", "2025-03-01 08:10:00+07:00"} {
+		if _, err := conn.Exec(`INSERT INTO messages (chat_id, user_id, username, message_text, message_length, timestamp) VALUES (?, 1, 'tester', 'zoned', 5, ?)`, chatID, timestamp); err != nil {
+			t.Fatalf("failed to insert message: %v", err)
+		}
+	}
+	conn.Close()
+	
+	store, err = db.Open(db.DriverSQLite, path)
+	if err != nil {
+		t.Fatalf("failed to reopen store: %v", err)
+	}
+	defer store.Close()
+	
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	messages, err := store.GetMessagesByTimeRange(chatID, start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GetMessagesByTimeRange: %v", err)
+	}
+	want := []time.Time{start.Add(30 * time.Minute), start.Add(45*time.Minute + 500*time.Millisecond), start.Add(50 * time.Minute)}
+	if len(messages) != len(want) {
+		t.Fatalf("GetMessagesByTimeRange: got %d messages, want %d", len(messages), len(want))
+	}
+	for i, msg := range messages {
+		if !msg.Timestamp.Equal(want[i]) {
+			t.Errorf("message %d: timestamp %s, want %s", i, msg.Timestamp, want[i])
+		}
+	}
+}
+
+func TestSQLiteMergesLegacyDuplicateMessages(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy.db")
+	store, err := db.Open(db.DriverSQLite, path)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	store.Close()
+	
+	// A group tracked by the bot and, under its raw ID, by an older scraper, both
+	// saving messages without message IDs or dedupe keys
+	rawID := int64(1234)
+	chatID := db.ChannelChatID(rawID).Int64()
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	for _, id := range []int64{chatID, rawID} {
+		if _, err := conn.Exec(`INSERT INTO tracked_groups (chat_id, group_name) VALUES (?, 'Legacy group')`, id); err != nil {
+			t.Fatalf("failed to insert group: %v", err)
+		}
+	}
+	rows := []struct {
+		chatID, userID  int64
+		text, timestamp string
+	}{
+		{chatID, 1, "hello", "2025-03-01 00:30:00+00:00"},
+		{rawID, 1, "hello", "2025-03-01 07:30:00+07:00"}, // The same message, written in WIB
+		{chatID, 1, "again", "2025-03-01 00:31:00+00:00"},
+		{rawID, 2, "again", "2025-03-01 00:31:00+00:00"}, // Another sender
+		{rawID, 1, "only scraper", "2025-03-01 00:32:00+00:00"},
+		{chatID, 1, "", "2025-03-01 00:33:00+00:00"}, // Photos without text are never merged
+		{rawID, 1, "", "2025-03-01 00:33:00+00:00"},
+	}
+	for _, r := range rows {
+		if _, err := conn.Exec(`INSERT INTO messages (chat_id, user_id, username, message_text, message_length, timestamp)
+			VALUES (?, ?, 'tester', ?, ?, ?)`, r.chatID, r.userID, r.text, len(r.text), r.timestamp); err != nil {
+			t.Fatalf("failed to insert message: %v", err)
+		}
+	}
+	conn.Close()
+	
+	store, err = db.Open(db.DriverSQLite, path)
+	if err != nil {
+		t.Fatalf("failed to reopen store: %v", err)
+	}
+	defer store.Close()
+	
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	messages, err := store.GetMessagesByTimeRange(chatID, start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GetMessagesByTimeRange: %v", err)
+	}
+	var got []string
+	for _, msg := range messages {
+		got = append(got, fmt.Sprintf("%d:%s", msg.UserID, msg.MessageText))
+	}
+	want := []string{"1:hello", "1:again", "2:again", "1:only scraper", "1:", "1:"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("merged messages = %q, want %q", got, want)
+	}
+	if raw, _ := store.GetMessagesByTimeRange(rawID, start, start.Add(time.Hour)); len(raw) != 0 {
+		t.Errorf("%d messages left under the raw ID", len(raw))
+	}
+}
+
+func TestSQLiteFailedMigrationMovesNothing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "migration.db")
+	store, err := db.Open(db.DriverSQLite, path)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	chatID, newChatID := int64(-1234), db.ChannelChatID(1234).Int64()
+	for _, id := range []int64{chatID, newChatID} {
+		if err := store.AddTrackedGroup(id, "Migrating group", ""); err != nil {
+			t.Fatalf("AddTrackedGroup: %v", err)
+		}
+	}
+	if err := store.EnableGroupSummary(chatID); err != nil {
+		t.Fatalf("EnableGroupSummary: %v", err)
+	}
+	if err := store.SetGroupTimezone(chatID, "Asia/Jakarta"); err != nil {
+		t.Fatalf("SetGroupTimezone: %v", err)
+	}
+	if err := store.AddSummarySchedule(&db.SummarySchedule{ChatID: chatID, CronExpr: "0 */4 * * *", PromptType: "4h"}); err != nil {
+		t.Fatalf("AddSummarySchedule: %v", err)
+	}
+	if _, err := store.AddSubscription(&db.Subscription{GroupChatID: chatID, TargetChatID: 42, Cadence: db.CadenceDaily}); err != nil {
+		t.Fatalf("AddSubscription: %v", err)
+	}
+	store.Close()
+	
+	// Moving the subscriptions, the last step, fails
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if _, err := conn.Exec(`CREATE TRIGGER fail_subscriptions BEFORE INSERT ON summary_subscriptions
+		BEGIN SELECT RAISE(ABORT, 'disk full'); END`); err != nil {
+		t.Fatalf("failed to create trigger: %v", err)
+	}
+	conn.Close()
+	
+	store, err = db.Open(db.DriverSQLite, path)
+	if err != nil {
+		t.Fatalf("failed to reopen store: %v", err)
+	}
+	defer store.Close()
+	if err := store.MarkGroupMigrated(chatID, newChatID); err == nil {
+		t.Fatal("MarkGroupMigrated succeeded, want the subscriptions error")
+	}
+	
+	if old := store.GetTrackedGroup(chatID); old == nil || old.MigratedToChatID != 0 || old.IsActive != 1 {
+		t.Errorf("old group after a failed migration: %+v, want it active and not migrated", old)
+	}
+	if migrated := store.GetTrackedGroup(newChatID); migrated == nil || migrated.IsActive != 0 || migrated.Timezone != "" {
+		t.Errorf("new group after a failed migration: %+v, want it without the old group's settings", migrated)
+	}
+	if schedules, err := store.GetSummarySchedules(chatID); err != nil || len(schedules) != 1 {
+		t.Errorf("GetSummarySchedules: %d schedules of the old group (err=%v), want 1", len(schedules), err)
+	}
+	if subs, err := store.GetSubscriptions(chatID, ""); err != nil || len(subs) != 1 {
+		t.Errorf("GetSubscriptions: %d subscriptions of the old group (err=%v), want 1", len(subs), err)
+	}
+}
+
+func TestPostgresStore(t *testing.T) {
+	url := os.Getenv("STORETEST_POSTGRES_URL")
+	if url == "" {
+		t.Skip("STORETEST_POSTGRES_URL is not set")
+	}
+	runSuite(t, db.DriverPostgres, url)
+	testNormalizeChatIDs(t, db.DriverPostgres, url)
+}
+
+// runSuite runs the store conformance suite against one backend, one subtest per check
+func runSuite(t *testing.T, driver, source string) {
+	store, err := db.Open(driver, source)
+	if err != nil {
+		t.Fatalf("failed to open %s store: %v", driver, err)
+	}
+	defer store.Close()
+	
+	for _, c := range storetest.Cases() {
+		t.Run(c.Name, func(t *testing.T) {
+			c.Run(t, store)
+		})
+	}
+}
+
+// testNormalizeChatIDs checks that reopening a store converts groups tracked under a
+// raw MTProto ID, as older scraper versions stored them, to their canonical chat ID
+// with the rows of the other chat-keyed tables, and merges a group the bot tracked too
+func testNormalizeChatIDs(t *testing.T, driver, source string) {
+	rawID := 1000000000 + time.Now().UnixNano()%1000000000
+	canonical := db.ChannelChatID(rawID).Int64()
+	mergedRawID := rawID + 1
+	merged := db.ChannelChatID(mergedRawID).Int64()
+	windowStart := time.Now().Truncate(time.Hour).Add(-time.Hour)
+	
+	store, err := db.Open(driver, source)
+	if err != nil {
+		t.Fatalf("failed to open %s store: %v", driver, err)
+	}
+	if err := store.AddTrackedGroup(rawID, "Raw group", ""); err != nil {
+		t.Fatalf("AddTrackedGroup: %v", err)
+	}
+	if _, err := store.SaveMessage(&db.Message{ChatID: rawID, UserID: 1, Username: "user", MessageText: "hello",
+		Timestamp: time.Now().Truncate(time.Second)}); err != nil {
+		t.Fatalf("SaveMessage: %v", err)
+	}
+	if _, err := store.AddSubscription(&db.Subscription{GroupChatID: rawID, TargetChatID: 42, Cadence: db.CadenceDaily}); err != nil {
+		t.Fatalf("AddSubscription: %v", err)
+	}
+	if err := store.AddSummarySchedule(&db.SummarySchedule{ChatID: rawID, CronExpr: "0 */4 * * *", PromptType: "4h"}); err != nil {
+		t.Fatalf("AddSummarySchedule: %v", err)
+	}
+	if err := store.RecordSummaryWindow(&db.SummaryWindow{ChatID: rawID, SummaryType: "1h", WindowStart: windowStart,
+		WindowEnd: windowStart.Add(time.Hour), Status: db.WindowSummarized, CompletedAt: time.Now()}); err != nil {
+		t.Fatalf("RecordSummaryWindow: %v", err)
+	}
+	
+	// The same group tracked by the bot and, summarized, by the scraper
+	for _, chatID := range []int64{merged, mergedRawID} {
+		if err := store.AddTrackedGroup(chatID, "Merged group", ""); err != nil {
+			t.Fatalf("AddTrackedGroup: %v", err)
+		}
+		if _, err := store.AddSubscription(&db.Subscription{GroupChatID: chatID, TargetChatID: 42, Cadence: db.CadenceDaily}); err != nil {
+			t.Fatalf("AddSubscription: %v", err)
+		}
+		if err := store.SetFilterConfig(chatID, fmt.Sprintf(`{"chat":%d}`, chatID)); err != nil {
+			t.Fatalf("SetFilterConfig: %v", err)
+		}
+	}
+	if err := store.EnableGroupSummary(mergedRawID); err != nil {
+		t.Fatalf("EnableGroupSummary: %v", err)
+	}
+	store.Close()
+	
+	store, err = db.Open(driver, source)
+	if err != nil {
+		t.Fatalf("failed to reopen %s store: %v", driver, err)
+	}
+	defer store.Close()
+	
+	if store.GetTrackedGroup(rawID) != nil {
+		t.Errorf("group is still tracked under raw ID %d", rawID)
+	}
+	if group := store.GetTrackedGroup(canonical); group == nil || group.GroupName != "Raw group" {
+		t.Errorf("group is not tracked under canonical ID %d: %+v", canonical, group)
+	}
+	messages, err := store.GetMessagesOlderThan(canonical, time.Now().Add(time.Hour))
+	if err != nil || len(messages) != 1 {
+		t.Errorf("messages of canonical ID = %d (err %v), want 1", len(messages), err)
+	}
+	if subs, err := store.GetSubscriptions(canonical, db.CadenceDaily); err != nil || len(subs) != 1 {
+		t.Errorf("subscriptions of canonical ID = %d (err %v), want 1", len(subs), err)
+	}
+	if schedules, err := store.GetSummarySchedules(canonical); err != nil || len(schedules) != 1 {
+		t.Errorf("schedules of canonical ID = %d (err %v), want 1", len(schedules), err)
+	}
+	if windows, err := store.GetSummaryWindows(canonical, windowStart, windowStart.Add(time.Hour)); err != nil || len(windows) != 1 {
+		t.Errorf("summary windows of canonical ID = %d (err %v), want 1", len(windows), err)
+	}
+	
+	if store.GetTrackedGroup(mergedRawID) != nil {
+		t.Errorf("merged group is still tracked under raw ID %d", mergedRawID)
+	}
+	if group := store.GetTrackedGroup(merged); group == nil || group.IsActive != 1 {
+		t.Errorf("merged group did not keep the scraper's summaries: %+v", group)
+	}
+	if subs, err := store.GetSubscriptions(merged, db.CadenceDaily); err != nil || len(subs) != 1 {
+		t.Errorf("subscriptions of merged group = %d (err %v), want 1", len(subs), err)
+	}
+	if config, _, err := store.GetFilterConfig(merged); err != nil || config != fmt.Sprintf(`{"chat":%d}`, merged) {
+		t.Errorf("filter config of merged group = %q (err %v), want the bot's", config, err)
+	}
+}
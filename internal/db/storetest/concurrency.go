@@ -0,0 +1,96 @@
+package storetest
+
+import (
+	"sync"
+	"telegram-summarizer/internal/db"
+	"time"
+)
+
+// concurrentT collects failures from several goroutines
+type concurrentT struct {
+	mu sync.Mutex
+	t  T
+}
+
+// Errorf implements T
+func (c *concurrentT) Errorf(format string, args ...interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t.Errorf(format, args...)
+}
+
+func testConcurrency(t T, store db.Store, chatID int64) {
+	const (
+		writers     = 8
+		perWriter   = 50
+		summarizers = 4
+		perSummary  = 10
+	)
+	
+	ct := &concurrentT{t: t}
+	start := testTime(-time.Hour)
+	var wg sync.WaitGroup
+	
+	// Ingest: scraper and bot handlers saving messages
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				msg := &db.Message{
+					ChatID:        chatID,
+					UserID:        int64(w + 1),
+					Username:      "writer",
+					MessageText:   "concurrent message",
+					MessageLength: 18,
+					Timestamp:     start.Add(time.Duration(w*perWriter+i) * time.Second),
+				}
+				if _, err := store.SaveMessage(msg); err != nil {
+					ct.Errorf("SaveMessage (writer %d): %v", w, err)
+					return
+				}
+				if err := store.UpdateGroupActivity(chatID, msg.Timestamp); err != nil {
+					ct.Errorf("UpdateGroupActivity (writer %d): %v", w, err)
+					return
+				}
+			}
+		}(w)
+	}
+	
+	// Summarization: scheduler and commands reading messages and saving summaries
+	for s := 0; s < summarizers; s++ {
+		wg.Add(1)
+		go func(s int) {
+			defer wg.Done()
+			for i := 0; i < perSummary; i++ {
+				messages, err := store.GetMessagesByTimeRange(chatID, start, start.Add(time.Hour))
+				if err != nil {
+					ct.Errorf("GetMessagesByTimeRange (summarizer %d): %v", s, err)
+					return
+				}
+				summary := &db.Summary{
+					ChatID:       chatID,
+					SummaryType:  "check-concurrent",
+					PeriodStart:  start,
+					PeriodEnd:    start.Add(time.Hour),
+					MessageCount: len(messages),
+				}
+				products := []db.ProductMention{{ProductName: "concurrent product", MentionCount: 1}}
+				if err := store.SaveSummaryWithProducts(summary, products); err != nil {
+					ct.Errorf("SaveSummaryWithProducts (summarizer %d): %v", s, err)
+					return
+				}
+			}
+		}(s)
+	}
+	
+	wg.Wait()
+	
+	messages, err := store.GetMessagesByTimeRange(chatID, start, start.Add(time.Hour))
+	if err != nil || len(messages) != writers*perWriter {
+		t.Errorf("GetMessagesByTimeRange: got %d messages (err=%v), want %d", len(messages), err, writers*perWriter)
+	}
+	if summaries, err := store.GetSummaries(chatID, "check-concurrent", 100); err != nil || len(summaries) != summarizers*perSummary {
+		t.Errorf("GetSummaries: got %d summaries (err=%v), want %d", len(summaries), err, summarizers*perSummary)
+	}
+}
@@ -0,0 +1,80 @@
+package storetest
+
+import (
+	"fmt"
+	"strings"
+	"telegram-summarizer/internal/db"
+	"time"
+)
+
+func testFamilyCodes(t T, store db.Store, chatID int64) {
+	code := fmt.Sprintf("%08x-0000-4000-8000-%012x", -chatID%0xffffffff, time.Now().UnixNano()%0xffffffffffff)
+	otherChatID := chatID - 1000000
+	sightings := []db.FamilyCodeSighting{
+		{Code: code, ProductText: "Storetest Combo 10GB", ChatID: chatID, MessageID: 10, Timestamp: testTime(-2 * time.Hour)},
+		{Code: code, ProductText: "Storetest Combo 10GB 25rb", ChatID: otherChatID, MessageID: 20, Timestamp: testTime(-time.Hour)},
+		// The same message seen again is not counted twice
+		{Code: code, ProductText: "Storetest Combo 10GB 25rb", ChatID: otherChatID, MessageID: 20, Timestamp: testTime(-time.Hour)},
+	}
+	for _, s := range sightings {
+		if err := store.RecordFamilyCode(s); err != nil {
+			t.Errorf("RecordFamilyCode: %v", err)
+			return
+		}
+	}
+	
+	if codes, err := store.GetFamilyCodesByMessage(chatID, 10); err != nil || len(codes) != 1 || codes[0] != code {
+		t.Errorf("GetFamilyCodesByMessage: got %v (err=%v)", codes, err)
+	}
+	
+	// The latest vote of a user replaces the earlier one
+	votes := []struct {
+		userID int64
+		works  bool
+		at     time.Time
+	}{
+		{1, false, testTime(-50 * time.Minute)},
+		{1, true, testTime(-40 * time.Minute)},
+		{2, true, testTime(-30 * time.Minute)},
+		{3, false, testTime(-20 * time.Minute)},
+	}
+	for _, v := range votes {
+		if err := store.RecordFamilyCodeVote(code, v.userID, v.works, v.at); err != nil {
+			t.Errorf("RecordFamilyCodeVote: %v", err)
+		}
+	}
+	
+	fc, err := store.GetFamilyCode(strings.ToUpper(code))
+	if err != nil || fc == nil {
+		t.Errorf("GetFamilyCode: got %v (err=%v)", fc, err)
+		return
+	}
+	if fc.MentionCount != 2 || fc.Groups != 2 || fc.WorksCount != 2 || fc.FailsCount != 1 || fc.ProductText != "Storetest Combo 10GB 25rb" {
+		t.Errorf("GetFamilyCode: unexpected code %+v", *fc)
+	}
+	if !fc.FirstSeen.Equal(sightings[0].Timestamp) || !fc.LastSeen.Equal(sightings[1].Timestamp) {
+		t.Errorf("GetFamilyCode: first/last seen %s/%s", fc.FirstSeen, fc.LastSeen)
+	}
+	
+	if recent, err := store.GetRecentFamilyCodes(testTime(-90*time.Minute), 1000); err != nil || !containsFamilyCode(recent, code) {
+		t.Errorf("GetRecentFamilyCodes: code not found (err=%v)", err)
+	}
+	for _, text := range []string{code[:8], "STORETEST combo"} {
+		if found, err := store.SearchFamilyCodes(text, 1000); err != nil || !containsFamilyCode(found, code) {
+			t.Errorf("SearchFamilyCodes(%s): code not found (err=%v)", text, err)
+		}
+	}
+	if groups, err := store.GetFamilyCodeGroups(code); err != nil || len(groups) != 2 {
+		t.Errorf("GetFamilyCodeGroups: got %d groups (err=%v), want 2", len(groups), err)
+	}
+}
+
+// containsFamilyCode reports whether a list of codes contains a code
+func containsFamilyCode(codes []db.FamilyCode, code string) bool {
+	for _, fc := range codes {
+		if fc.Code == code {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package storetest
+
+import (
+	"telegram-summarizer/internal/db"
+	"time"
+)
+
+func testFilters(t T, store db.Store, chatID int64) {
+	if _, found, err := store.GetFilterConfig(chatID); err != nil || found {
+		t.Errorf("GetFilterConfig: found=%v err=%v for unknown group", found, err)
+	}
+	
+	if err := store.SetFilterConfig(chatID, `{"min_runes":5}`); err != nil {
+		t.Errorf("SetFilterConfig: %v", err)
+		return
+	}
+	if err := store.SetFilterConfig(chatID, `{"min_runes":7}`); err != nil {
+		t.Errorf("SetFilterConfig: overwrite: %v", err)
+	}
+	if config, found, err := store.GetFilterConfig(chatID); err != nil || !found || config != `{"min_runes":7}` {
+		t.Errorf("GetFilterConfig: got %q (found=%v, err=%v)", config, found, err)
+	}
+	if err := store.DeleteFilterConfig(chatID); err != nil {
+		t.Errorf("DeleteFilterConfig: %v", err)
+	}
+	if _, found, _ := store.GetFilterConfig(chatID); found {
+		t.Errorf("DeleteFilterConfig: config still present")
+	}
+	
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := store.IncrementFilterDrop(chatID, "emoji_only", now); err != nil {
+			t.Errorf("IncrementFilterDrop: %v", err)
+			return
+		}
+	}
+	if err := store.IncrementFilterDrop(chatID, "bots", now); err != nil {
+		t.Errorf("IncrementFilterDrop: %v", err)
+	}
+	counts, err := store.GetFilterDropCounts(chatID, now.AddDate(0, 0, -1))
+	if err != nil || counts["emoji_only"] != 3 || counts["bots"] != 1 {
+		t.Errorf("GetFilterDropCounts: got %v (err=%v), want emoji_only=3 bots=1", counts, err)
+	}
+}
@@ -0,0 +1,222 @@
+package storetest
+
+import (
+	"strconv"
+	"telegram-summarizer/internal/db"
+	"time"
+)
+
+func testTrackedGroups(t T, store db.Store, chatID int64) {
+	if group := store.GetTrackedGroup(chatID); group != nil {
+		t.Errorf("GetTrackedGroup: unknown group found")
+	}
+	
+	if err := store.AddTrackedGroup(chatID, "Check Group", "checkgroup"); err != nil {
+		t.Errorf("AddTrackedGroup: %v", err)
+		return
+	}
+	group := store.GetTrackedGroup(chatID)
+	if group == nil {
+		t.Errorf("GetTrackedGroup: added group not found")
+		return
+	}
+	if group.GroupName != "Check Group" || group.IsActive != 0 {
+		t.Errorf("GetTrackedGroup: unexpected group %+v", *group)
+	}
+	
+	// Raw MTProto IDs resolve to the tracked supergroup
+	resolved, err := store.ResolveChatID(strconv.FormatInt(db.ChatID(chatID).Raw(), 10))
+	if err != nil || resolved.Int64() != chatID {
+		t.Errorf("ResolveChatID: got %d (err=%v), want %d", resolved, err, chatID)
+	}
+	
+	activity := testTime(-time.Minute)
+	if err := store.UpdateGroupActivity(chatID, activity); err != nil {
+		t.Errorf("UpdateGroupActivity: %v", err)
+	}
+	if group := store.GetTrackedGroup(chatID); group == nil || !group.LastMessageDate.Equal(activity) {
+		t.Errorf("UpdateGroupActivity: last message date not stored")
+	}
+	
+	if err := store.EnableGroupSummary(chatID); err != nil {
+		t.Errorf("EnableGroupSummary: %v", err)
+	}
+	if !containsGroup(store.GetActiveGroups(), chatID) {
+		t.Errorf("GetActiveGroups: enabled group missing")
+	}
+	if err := store.DisableGroupSummary(chatID); err != nil {
+		t.Errorf("DisableGroupSummary: %v", err)
+	}
+	if containsGroup(store.GetActiveGroups(), chatID) {
+		t.Errorf("GetActiveGroups: disabled group listed")
+	}
+	
+	if err := store.SetGroupRetention(chatID, 30, "db"); err != nil {
+		t.Errorf("SetGroupRetention: %v", err)
+	}
+	if group := store.GetTrackedGroup(chatID); group == nil || group.RetentionDays != 30 || group.ArchiveMode != "db" {
+		t.Errorf("SetGroupRetention: retention not stored")
+	}
+	
+	if err := store.SetGroupTimezone(chatID, "Asia/Jakarta"); err != nil {
+		t.Errorf("SetGroupTimezone: %v", err)
+	}
+	if group := store.GetTrackedGroup(chatID); group == nil || group.Timezone != "Asia/Jakarta" {
+		t.Errorf("SetGroupTimezone: timezone not stored")
+	}
+	
+	policy := db.WindowPolicy{MinMessages: 10, MaxWindowHours: 6, BusyMessages: 200, BusyWindowMinutes: 15}
+	if err := store.SetGroupWindowPolicy(chatID, policy); err != nil {
+		t.Errorf("SetGroupWindowPolicy: %v", err)
+	}
+	if group := store.GetTrackedGroup(chatID); group == nil || group.Windows != policy {
+		t.Errorf("SetGroupWindowPolicy: policy not stored")
+	}
+	
+	synced := &db.TrackedGroup{ChatID: chatID, GroupName: "Renamed", ChatType: db.ChatTypeSupergroup, FolderID: 1}
+	if err := store.SyncTrackedGroup(synced); err != nil {
+		t.Errorf("SyncTrackedGroup: %v", err)
+	}
+	if group := store.GetTrackedGroup(chatID); group == nil || group.GroupName != "Renamed" || group.FolderID != 1 {
+		t.Errorf("SyncTrackedGroup: group not updated")
+	}
+	if !containsGroup(store.GetTrackedGroups(), chatID) {
+		t.Errorf("GetTrackedGroups: group missing")
+	}
+	
+	// A group taken for left resumes its summaries when it shows up again
+	store.EnableGroupSummary(chatID)
+	if err := store.MarkGroupLeft(chatID); err != nil {
+		t.Errorf("MarkGroupLeft: %v", err)
+	}
+	if containsGroup(store.GetActiveGroups(), chatID) {
+		t.Errorf("MarkGroupLeft: left group still active")
+	}
+	if err := store.SyncTrackedGroup(synced); err != nil {
+		t.Errorf("SyncTrackedGroup: %v", err)
+	}
+	if group := store.GetTrackedGroup(chatID); group == nil || group.IsLeft != 0 || group.IsActive != 1 {
+		t.Errorf("SyncTrackedGroup: summaries of a group showing up again not resumed: %+v", group)
+	}
+	// Unless they were disabled meanwhile
+	store.MarkGroupLeft(chatID)
+	store.DisableGroupSummary(chatID)
+	store.SyncTrackedGroup(synced)
+	if group := store.GetTrackedGroup(chatID); group == nil || group.IsActive != 0 {
+		t.Errorf("SyncTrackedGroup: summaries disabled while left were resumed")
+	}
+	
+	if err := store.MarkGroupMigrated(chatID, chatID-1); err != nil {
+		t.Errorf("MarkGroupMigrated: %v", err)
+	}
+	if group := store.GetTrackedGroup(chatID); group == nil || group.IsLeft != 1 || group.MigratedToChatID != chatID-1 {
+		t.Errorf("MarkGroupMigrated: migration not stored")
+	}
+	if err := store.MarkGroupLeft(chatID); err != nil {
+		t.Errorf("MarkGroupLeft: %v", err)
+	}
+}
+
+func containsGroup(groups []db.TrackedGroup, chatID int64) bool {
+	for _, g := range groups {
+		if g.ChatID == chatID {
+			return true
+		}
+	}
+	return false
+}
+
+func testUpdateState(t T, store db.Store, chatID int64) {
+	userID := -chatID
+	channelID := userID + 1
+	
+	if _, found, err := store.GetUpdateState(userID); err != nil || found {
+		t.Errorf("GetUpdateState: found=%v err=%v for unknown account", found, err)
+	}
+	
+	state := db.UpdateState{Pts: 10, Qts: 2, Date: 1700000000, Seq: 5}
+	if err := store.SetUpdateState(userID, state); err != nil {
+		t.Errorf("SetUpdateState: %v", err)
+		return
+	}
+	if err := store.SetUpdateStateFields(userID, map[string]int{"pts": 11, "seq": 6}); err != nil {
+		t.Errorf("SetUpdateStateFields: %v", err)
+	}
+	want := db.UpdateState{Pts: 11, Qts: 2, Date: 1700000000, Seq: 6}
+	if got, found, err := store.GetUpdateState(userID); err != nil || !found || got != want {
+		t.Errorf("GetUpdateState: got %+v (found=%v, err=%v), want %+v", got, found, err, want)
+	}
+	
+	if err := store.SetChannelPts(userID, channelID, 100); err != nil {
+		t.Errorf("SetChannelPts: %v", err)
+	}
+	if err := store.SetChannelAccessHash(userID, channelID, 123456789); err != nil {
+		t.Errorf("SetChannelAccessHash: %v", err)
+	}
+	if pts, found, err := store.GetChannelPts(userID, channelID); err != nil || !found || pts != 100 {
+		t.Errorf("GetChannelPts: got %d (found=%v, err=%v), want 100", pts, found, err)
+	}
+	if hash, found, err := store.GetChannelAccessHash(userID, channelID); err != nil || !found || hash != 123456789 {
+		t.Errorf("GetChannelAccessHash: got %d (found=%v, err=%v), want 123456789", hash, found, err)
+	}
+	if all, err := store.GetAllChannelPts(userID); err != nil || all[channelID] != 100 {
+		t.Errorf("GetAllChannelPts: got %v (err=%v)", all, err)
+	}
+}
+
+func testTimeZones(t T, store db.Store, chatID int64) {
+	wib := time.FixedZone("WIB", 7*60*60)
+	est := time.FixedZone("EST", -5*60*60)
+	start := testTime(-3 * time.Hour).Truncate(time.Hour)
+	
+	// Messages written with times in different zones
+	for _, timestamp := range []time.Time{start.Add(10 * time.Minute), start.Add(20 * time.Minute).In(wib), start.Add(70 * time.Minute).In(est)} {
+		msg := &db.Message{ChatID: chatID, UserID: 42, Username: "tester", MessageText: "zoned message", MessageLength: 13, Timestamp: timestamp}
+		if _, err := store.SaveMessage(msg); err != nil {
+			t.Errorf("SaveMessage: %v", err)
+			return
+		}
+	}
+	
+	// A window queried in any zone holds the same messages
+	for _, loc := range []*time.Location{time.UTC, wib, est} {
+		if count, err := store.CountMessages(chatID, start.In(loc), start.Add(time.Hour).In(loc)); err != nil || count != 2 {
+			t.Errorf("CountMessages: got %d in %s (err=%v), want 2", count, loc, err)
+		}
+		if messages, err := store.GetMessagesByTimeRange(chatID, start.In(loc), start.Add(2*time.Hour).In(loc)); err != nil || len(messages) != 3 {
+			t.Errorf("GetMessagesByTimeRange: got %d in %s (err=%v), want 3", len(messages), loc, err)
+		}
+	}
+	
+	// A window recorded in two zones is one window
+	for _, window := range []db.SummaryWindow{
+		{ChatID: chatID, SummaryType: "1h", WindowStart: start.In(wib), WindowEnd: start.Add(time.Hour).In(wib), Status: db.WindowSummarized},
+		{ChatID: chatID, SummaryType: "1h", WindowStart: start.In(est), WindowEnd: start.Add(time.Hour).In(est), Status: db.WindowSkipped},
+	} {
+		window.CompletedAt = testTime(0)
+		if err := store.RecordSummaryWindow(&window); err != nil {
+			t.Errorf("RecordSummaryWindow: %v", err)
+		}
+	}
+	if windows, err := store.GetSummaryWindows(chatID, start, start.Add(time.Hour)); err != nil || len(windows) != 1 || windows[0].Status != db.WindowSkipped {
+		t.Errorf("GetSummaryWindows: got %+v (err=%v), want the window once", windows, err)
+	}
+	
+	// A job queued in two zones is one job; it is never due, so no scheduler claims it
+	never := time.Date(2999, 1, 1, 0, 0, 0, 0, time.UTC)
+	job := &db.SummaryJob{Kind: db.JobHourly, ChatID: chatID, SummaryType: "1h", WindowStart: start.In(wib), WindowEnd: start.Add(time.Hour).In(wib), NextRunAt: never}
+	if added, err := store.EnqueueJob(job); err != nil || !added {
+		t.Errorf("EnqueueJob: added=%v (err=%v)", added, err)
+	}
+	if added, err := store.EnqueueJob(&db.SummaryJob{Kind: db.JobHourly, ChatID: chatID, SummaryType: "1h",
+		WindowStart: start.In(est), WindowEnd: start.Add(time.Hour).In(est), NextRunAt: never}); err != nil || added {
+		t.Errorf("EnqueueJob: queued the same window twice in other zones (err=%v)", err)
+	}
+	if job.ID != 0 {
+		store.CompleteJob(job.ID)
+	}
+	
+	if err := store.DeleteMessagesByTimeRange(chatID, start, start.Add(2*time.Hour)); err != nil {
+		t.Errorf("DeleteMessagesByTimeRange: %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package storetest
+
+import (
+	"telegram-summarizer/internal/db"
+	"time"
+)
+
+func testJobQueue(t T, store db.Store, chatID int64) {
+	// The job is due long ago, so claiming at that time cannot pick up real jobs of the database
+	due := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+	start := testTime(-2 * time.Hour).Truncate(time.Hour)
+	job := &db.SummaryJob{Kind: db.JobHourly, ChatID: chatID, SummaryType: "1h", WindowStart: start, WindowEnd: start.Add(time.Hour),
+		MinMessages: 1, MaxAttempts: 2, NextRunAt: due}
+	if added, err := store.EnqueueJob(job); err != nil || !added || job.ID == 0 {
+		t.Errorf("EnqueueJob: added=%v id=%d (err=%v)", added, job.ID, err)
+		return
+	}
+	if added, err := store.EnqueueJob(&db.SummaryJob{Kind: db.JobBackfill, ChatID: chatID, SummaryType: "1h",
+		WindowStart: start, WindowEnd: start.Add(time.Hour)}); err != nil || added {
+		t.Errorf("EnqueueJob: queued the same window twice (err=%v)", err)
+	}
+	
+	claimed, err := store.ClaimJob(due)
+	if err != nil || claimed == nil || claimed.ID != job.ID || claimed.Status != db.JobRunning || claimed.Attempts != 1 || !claimed.WindowStart.Equal(start) || claimed.MinMessages != 1 {
+		t.Errorf("ClaimJob: got %+v (err=%v), want job %d running with 1 attempt", claimed, err, job.ID)
+		return
+	}
+	if claimed, err := store.ClaimJob(due); err != nil || claimed != nil {
+		t.Errorf("ClaimJob: claimed %+v (err=%v) twice", claimed, err)
+	}
+	
+	// A retry is not due before its time
+	if err := store.FailJob(job.ID, "first failure", due.Add(time.Hour)); err != nil {
+		t.Errorf("FailJob: %v", err)
+	}
+	if claimed, err := store.ClaimJob(due); err != nil || claimed != nil {
+		t.Errorf("ClaimJob: claimed %+v (err=%v) before its retry time", claimed, err)
+	}
+	if got, err := store.GetJob(job.ID); err != nil || got == nil || got.Status != db.JobPending || got.LastError != "first failure" {
+		t.Errorf("GetJob: got %+v (err=%v), want a pending job with its error", got, err)
+	}
+	
+	// Out of attempts: dead letter, until retried
+	if err := store.FailJob(job.ID, "second failure", time.Time{}); err != nil {
+		t.Errorf("FailJob: %v", err)
+	}
+	if jobs, err := store.GetJobs(db.JobFailed, 1000); err != nil || !containsJob(jobs, job.ID) {
+		t.Errorf("GetJobs: failed job missing (err=%v)", err)
+	}
+	if counts, err := store.CountJobs(); err != nil || counts[db.JobFailed] == 0 {
+		t.Errorf("CountJobs: got %v (err=%v)", counts, err)
+	}
+	if retried, err := store.RetryJob(job.ID); err != nil || !retried {
+		t.Errorf("RetryJob: retried=%v (err=%v)", retried, err)
+	}
+	if retried, err := store.RetryJob(job.ID); err != nil || retried {
+		t.Errorf("RetryJob: retried a pending job (err=%v)", err)
+	}
+	if got, err := store.GetJob(job.ID); err != nil || got == nil || got.Status != db.JobPending || got.Attempts != 0 {
+		t.Errorf("GetJob: got %+v (err=%v), want a pending job without attempts", got, err)
+	}
+	
+	if err := store.CompleteJob(job.ID); err != nil {
+		t.Errorf("CompleteJob: %v", err)
+	}
+	if got, err := store.GetJob(job.ID); err != nil || got == nil || got.Status != db.JobSucceeded {
+		t.Errorf("GetJob: got %+v (err=%v), want a succeeded job", got, err)
+	}
+	
+	// Only jobs finished before the cutoff are pruned
+	if _, err := store.PruneJobs(due); err != nil {
+		t.Errorf("PruneJobs: %v", err)
+	}
+	if got, err := store.GetJob(job.ID); err != nil || got == nil {
+		t.Errorf("GetJob: got %+v (err=%v), want the recent job kept", got, err)
+	}
+}
+
+// containsJob reports whether a job is in a list
+func containsJob(jobs []db.SummaryJob, jobID int64) bool {
+	for _, job := range jobs {
+		if job.ID == jobID {
+			return true
+		}
+	}
+	return false
+}
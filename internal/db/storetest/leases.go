@@ -0,0 +1,52 @@
+package storetest
+
+import (
+	"fmt"
+	"telegram-summarizer/internal/db"
+	"time"
+)
+
+func testLeaderLeases(t T, store db.Store, chatID int64) {
+	name := fmt.Sprintf("check-%d", chatID)
+	now := testTime(0)
+	if acquired, err := store.AcquireLease(name, "a", now, time.Minute); err != nil || !acquired {
+		t.Errorf("AcquireLease: first holder did not get the lease (err=%v)", err)
+		return
+	}
+	if acquired, err := store.AcquireLease(name, "b", now.Add(30*time.Second), time.Minute); err != nil || acquired {
+		t.Errorf("AcquireLease: took over a held lease (err=%v)", err)
+	}
+	if acquired, err := store.AcquireLease(name, "a", now.Add(30*time.Second), time.Minute); err != nil || !acquired {
+		t.Errorf("AcquireLease: holder could not renew its lease (err=%v)", err)
+	}
+	lease, err := store.GetLease(name)
+	if err != nil || lease == nil || lease.Holder != "a" || !lease.AcquiredAt.Equal(now) || !lease.ExpiresAt.Equal(now.Add(90*time.Second)) {
+		t.Errorf("GetLease: got %+v (err=%v), want held by a since %s until %s", lease, err, now, now.Add(90*time.Second))
+	}
+	
+	// An expired lease is taken over
+	takeover := now.Add(2 * time.Minute)
+	if acquired, err := store.AcquireLease(name, "b", takeover, time.Minute); err != nil || !acquired {
+		t.Errorf("AcquireLease: expired lease not taken over (err=%v)", err)
+	}
+	if lease, err := store.GetLease(name); err != nil || lease == nil || lease.Holder != "b" || !lease.AcquiredAt.Equal(takeover) {
+		t.Errorf("GetLease: got %+v (err=%v), want taken over by b", lease, err)
+	}
+	
+	// A released lease is taken over at once, but only its holder can release it
+	if err := store.ReleaseLease(name, "a"); err != nil {
+		t.Errorf("ReleaseLease: %v", err)
+	}
+	if acquired, err := store.AcquireLease(name, "a", takeover.Add(time.Second), time.Minute); err != nil || acquired {
+		t.Errorf("AcquireLease: lease released by another instance was taken over (err=%v)", err)
+	}
+	if err := store.ReleaseLease(name, "b"); err != nil {
+		t.Errorf("ReleaseLease: %v", err)
+	}
+	if acquired, err := store.AcquireLease(name, "a", takeover.Add(time.Second), time.Minute); err != nil || !acquired {
+		t.Errorf("AcquireLease: released lease not taken over (err=%v)", err)
+	}
+	if lease, err := store.GetLease(fmt.Sprintf("check-%d", chatID+1)); err != nil || lease != nil {
+		t.Errorf("GetLease: got %+v (err=%v) for a lease never taken", lease, err)
+	}
+}
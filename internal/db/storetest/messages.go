@@ -0,0 +1,227 @@
+package storetest
+
+import (
+	"fmt"
+	"telegram-summarizer/internal/db"
+	"time"
+)
+
+func testMessages(t T, store db.Store, chatID int64) {
+	now := testTime(0)
+	texts := []string{"first message", "second message", "third message"}
+	for i, text := range texts {
+		msg := &db.Message{
+			ChatID:        chatID,
+			UserID:        42,
+			Username:      "tester",
+			MessageText:   text,
+			MessageLength: len(text),
+			Timestamp:     now.Add(time.Duration(i-len(texts)) * time.Hour),
+		}
+		if i == 0 {
+			msg.MediaType = "photo"
+			msg.MediaFileID = "file-1"
+			msg.MediaSize = 1024
+		}
+		if _, err := store.SaveMessage(msg); err != nil {
+			t.Errorf("SaveMessage: %v", err)
+			return
+		}
+		if msg.ID == 0 {
+			t.Errorf("SaveMessage: ID not set")
+		}
+		if i == 0 {
+			if err := store.SetMessageOCRText(msg.ID, "ocr text"); err != nil {
+				t.Errorf("SetMessageOCRText: %v", err)
+			}
+		}
+	}
+	
+	messages, err := store.GetMessagesByTimeRange(chatID, now.Add(-24*time.Hour), now)
+	if err != nil {
+		t.Errorf("GetMessagesByTimeRange: %v", err)
+		return
+	}
+	if len(messages) != len(texts) {
+		t.Errorf("GetMessagesByTimeRange: got %d messages, want %d", len(messages), len(texts))
+		return
+	}
+	for i, msg := range messages {
+		if msg.MessageText != texts[i] {
+			t.Errorf("GetMessagesByTimeRange: message %d is %q, want %q (ordered by time)", i, msg.MessageText, texts[i])
+		}
+	}
+	if messages[0].MediaType != "photo" || messages[0].MediaSize != 1024 || messages[0].OCRText != "ocr text" {
+		t.Errorf("GetMessagesByTimeRange: media fields not stored: %+v", messages[0])
+	}
+	if !messages[0].Timestamp.Equal(now.Add(-3 * time.Hour)) {
+		t.Errorf("GetMessagesByTimeRange: timestamp %s, want %s", messages[0].Timestamp, now.Add(-3*time.Hour))
+	}
+	
+	if count := store.GetGroupMessageCount24h(chatID); count != len(texts) {
+		t.Errorf("GetGroupMessageCount24h: got %d, want %d", count, len(texts))
+	}
+	if count, err := store.CountMessages(chatID, now.Add(-3*time.Hour), now.Add(-3*time.Hour+time.Second)); err != nil || count != 1 {
+		t.Errorf("CountMessages: got %d (err=%v), want 1", count, err)
+	}
+	
+	// The same promo in this chat and in a chat of its own
+	promo := fmt.Sprintf("Promo %d: paket unlimited murah, hubungi admin sekarang juga", chatID)
+	otherChatID := chatID - 500000000
+	for _, id := range []int64{chatID, otherChatID, otherChatID} {
+		msg := &db.Message{ChatID: id, UserID: 42, MessageText: promo, MessageLength: len(promo), Timestamp: now.Add(-time.Minute)}
+		if _, err := store.SaveMessage(msg); err != nil {
+			t.Errorf("SaveMessage: %v", err)
+		}
+	}
+	posts, err := store.GetCrossPosts(now.Add(-time.Hour), now, 2, 1000)
+	if err != nil {
+		t.Errorf("GetCrossPosts: %v", err)
+	}
+	found := false
+	for _, post := range posts {
+		if post.Text == promo {
+			found = true
+			if post.Groups != 2 || post.Messages != 3 {
+				t.Errorf("GetCrossPosts: got %d groups and %d messages, want 2 and 3", post.Groups, post.Messages)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("GetCrossPosts: promo posted in 2 groups not found")
+	}
+	if posts, _ := store.GetCrossPosts(now.Add(-time.Hour), now, 3, 1000); len(posts) > 0 && posts[len(posts)-1].Groups < 3 {
+		t.Errorf("GetCrossPosts: got a text posted in %d groups, want at least 3", posts[len(posts)-1].Groups)
+	}
+	if _, err := store.DeleteMessagesOlderThan(otherChatID, now.Add(time.Hour)); err != nil {
+		t.Errorf("DeleteMessagesOlderThan: %v", err)
+	}
+	
+	older, err := store.GetMessagesOlderThan(chatID, now.Add(-90*time.Minute))
+	if err != nil {
+		t.Errorf("GetMessagesOlderThan: %v", err)
+	} else if len(older) != 2 {
+		t.Errorf("GetMessagesOlderThan: got %d messages, want 2", len(older))
+	}
+	
+	deleted, err := store.DeleteMessagesOlderThan(chatID, now.Add(-90*time.Minute))
+	if err != nil {
+		t.Errorf("DeleteMessagesOlderThan: %v", err)
+	} else if deleted != 2 {
+		t.Errorf("DeleteMessagesOlderThan: deleted %d, want 2", deleted)
+	}
+	
+	if err := store.DeleteMessagesByTimeRange(chatID, now.Add(-24*time.Hour), now); err != nil {
+		t.Errorf("DeleteMessagesByTimeRange: %v", err)
+	}
+	if messages, _ := store.GetMessagesByTimeRange(chatID, now.Add(-24*time.Hour), now); len(messages) != 0 {
+		t.Errorf("DeleteMessagesByTimeRange: %d messages left", len(messages))
+	}
+}
+
+func testDuplicateMessages(t T, store db.Store, chatID int64) {
+	now := testTime(0)
+	var saveText func(chatID, messageID int64, username, text string) bool
+	save := func(chatID, messageID int64, username string) bool {
+		return saveText(chatID, messageID, username, "same message")
+	}
+	saveText = func(chatID, messageID int64, username, text string) bool {
+		msg := &db.Message{ChatID: chatID, MessageID: messageID, UserID: 42, Username: username,
+			MessageText: text, MessageLength: len(text), Timestamp: now.Add(-time.Minute)}
+		saved, err := store.SaveMessage(msg)
+		if err != nil {
+			t.Errorf("SaveMessage: %v", err)
+		}
+		if saved && msg.ID == 0 {
+			t.Errorf("SaveMessage: ID not set")
+		}
+		return saved
+	}
+	
+	// The bot and the scraper both receive a message
+	if !save(chatID, 7, "bot") {
+		t.Errorf("SaveMessage: first copy not saved")
+	}
+	if save(chatID, 7, "scraper") {
+		t.Errorf("SaveMessage: second copy of message 7 saved")
+	}
+	if !save(chatID, 8, "bot") {
+		t.Errorf("SaveMessage: message 8 not saved")
+	}
+	// Without a Telegram message ID nothing identifies a duplicate
+	if !save(chatID, 0, "archive") || !save(chatID, 0, "archive") {
+		t.Errorf("SaveMessage: message without ID not saved")
+	}
+	messages, _ := store.GetMessagesByTimeRange(chatID, now.Add(-time.Hour), now)
+	if len(messages) != 4 {
+		t.Errorf("GetMessagesByTimeRange: got %d messages, want 4", len(messages))
+	}
+	for _, msg := range messages {
+		if msg.MessageID == 7 && msg.Username != "bot" {
+			t.Errorf("GetMessagesByTimeRange: message 7 saved by %s, want the first copy", msg.Username)
+		}
+	}
+	
+	// Basic groups number messages per account, so equal IDs are different messages
+	// and a message is identified by its sender, time and text
+	groupID := db.GroupChatID(db.ChatID(chatID).Raw() % 1000000000).Int64()
+	if !save(groupID, 7, "bot") || !saveText(groupID, 7, "bot", "other message") {
+		t.Errorf("SaveMessage: messages of a basic group with equal IDs not saved")
+	}
+	if save(groupID, 3, "scraper") {
+		t.Errorf("SaveMessage: scraper's copy of a basic group message saved")
+	}
+	if !saveText(groupID, 0, "bot", "") || !saveText(groupID, 0, "bot", "") {
+		t.Errorf("SaveMessage: messages of a basic group without text not saved")
+	}
+	if messages, _ := store.GetMessagesByTimeRange(groupID, now.Add(-time.Hour), now); len(messages) != 4 {
+		t.Errorf("GetMessagesByTimeRange: got %d basic group messages, want 4", len(messages))
+	}
+	
+	for _, id := range []int64{chatID, groupID} {
+		if err := store.DeleteMessagesByTimeRange(id, now.Add(-time.Hour), now); err != nil {
+			t.Errorf("DeleteMessagesByTimeRange: %v", err)
+		}
+	}
+}
+
+func testRestore(t T, store db.Store, chatID int64) {
+	timestamp := testTime(-48 * time.Hour)
+	msg := db.Message{
+		ChatID:        chatID,
+		UserID:        7,
+		Username:      "archived",
+		MessageText:   "archived message",
+		MessageLength: 16,
+		Timestamp:     timestamp,
+	}
+	
+	restored := msg
+	ok, err := store.RestoreMessage(&restored)
+	if err != nil {
+		t.Errorf("RestoreMessage: %v", err)
+		return
+	}
+	if !ok || restored.ID == 0 || !restored.Restored {
+		t.Errorf("RestoreMessage: got ok=%v id=%d restored=%v, want a new restored row", ok, restored.ID, restored.Restored)
+	}
+	
+	again := msg
+	if ok, err := store.RestoreMessage(&again); err != nil || ok {
+		t.Errorf("RestoreMessage: duplicate restored (ok=%v, err=%v)", ok, err)
+	}
+	
+	messages, err := store.GetMessagesByTimeRange(chatID, timestamp.Add(-time.Minute), timestamp.Add(time.Minute))
+	if err != nil || len(messages) != 1 || !messages[0].Restored {
+		t.Errorf("GetMessagesByTimeRange: got %d messages (err=%v), want 1 restored", len(messages), err)
+	}
+	
+	// Restored messages are not archived again
+	if older, err := store.GetMessagesOlderThan(chatID, timestamp.Add(time.Hour)); err != nil || len(older) != 0 {
+		t.Errorf("GetMessagesOlderThan: got %d restored messages (err=%v), want 0", len(older), err)
+	}
+	
+	if _, err := store.DeleteMessagesOlderThan(chatID, timestamp.Add(time.Hour)); err != nil {
+		t.Errorf("DeleteMessagesOlderThan: %v", err)
+	}
+}
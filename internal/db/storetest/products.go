@@ -0,0 +1,96 @@
+package storetest
+
+import (
+	"strconv"
+	"telegram-summarizer/internal/db"
+	"time"
+)
+
+func testProductCatalog(t T, store db.Store, chatID int64) {
+	suffix := strconv.FormatInt(-chatID%1000000000, 10)
+	product, err := store.CreateProduct("Storetest Product "+suffix, "product storetest "+suffix)
+	if err != nil || product == nil || product.ID == 0 {
+		t.Errorf("CreateProduct: got %+v (err=%v)", product, err)
+		return
+	}
+	if again, err := store.CreateProduct("storetest product "+suffix, product.NameKey); err != nil || again == nil || again.ID != product.ID {
+		t.Errorf("CreateProduct: same key created another product %+v (err=%v)", again, err)
+	}
+	other, err := store.CreateProduct("Storetest Other "+suffix, "other storetest "+suffix)
+	if err != nil || other == nil {
+		t.Errorf("CreateProduct: %v", err)
+		return
+	}
+	
+	aliasKey := "alias storetest " + suffix
+	if err := store.SetProductAlias(other.ID, "Storetest Alias "+suffix, aliasKey); err != nil {
+		t.Errorf("SetProductAlias: %v", err)
+	}
+	
+	// Linked mentions store their prices in the same transaction
+	summary := &db.Summary{ChatID: chatID, SummaryType: "check-catalog", PeriodStart: testTime(-time.Hour), PeriodEnd: testTime(0)}
+	products := []db.ProductMention{
+		{ProductName: product.Name, MentionCount: 1, PriceMentioned: "Rp 150.000 - 200rb", ProductID: product.ID, Prices: []int64{150000, 200000},
+			FamilyCodes: "23b71540-8785-4abe-816d-e9b4efa48f95"},
+		{ProductName: "Storetest Alias " + suffix, MentionCount: 1, PriceMentioned: "75k", ProductID: other.ID, Prices: []int64{75000}},
+		{ProductName: "storetest unlinked " + suffix, MentionCount: 1, PriceMentioned: "Rp 10.000"},
+	}
+	if err := store.SaveSummaryWithProducts(summary, products); err != nil {
+		t.Errorf("SaveSummaryWithProducts: %v", err)
+		return
+	}
+	if prices, err := store.GetProductPrices(product.ID, testTime(-2*time.Hour)); err != nil || len(prices) != 2 || prices[0].ChatID != chatID {
+		t.Errorf("GetProductPrices: got %+v (err=%v)", prices, err)
+	}
+	
+	unlinked, err := store.GetUnlinkedProductMentions(products[1].ID, 1000)
+	if err != nil || len(unlinked) == 0 || unlinked[0].Mention.ID != products[2].ID || unlinked[0].ChatID != chatID {
+		t.Errorf("GetUnlinkedProductMentions: got %+v (err=%v)", unlinked, err)
+	} else {
+		mention := unlinked[0].Mention
+		mention.ProductID = other.ID
+		mention.Prices = []int64{10000}
+		if err := store.LinkProductMention(&mention, unlinked[0].ChatID, unlinked[0].PeriodEnd); err != nil {
+			t.Errorf("LinkProductMention: %v", err)
+		}
+	}
+	
+	// Merging moves mentions, prices and aliases
+	if err := store.MergeProducts(other.ID, product.ID); err != nil {
+		t.Errorf("MergeProducts: %v", err)
+		return
+	}
+	if merged, err := store.GetProduct(other.ID); err != nil || merged != nil {
+		t.Errorf("GetProduct: merged product still exists (err=%v)", err)
+	}
+	if mentions, err := store.GetProductMentions(product.ID, 1); err != nil || len(mentions) != 3 {
+		t.Errorf("GetProductMentions: got %d mentions (err=%v), want 3", len(mentions), err)
+	}
+	if prices, err := store.GetProductPrices(product.ID, testTime(-2*time.Hour)); err != nil || len(prices) != 4 {
+		t.Errorf("GetProductPrices: got %d prices after merge (err=%v), want 4", len(prices), err)
+	}
+	
+	// Mentions of other summary levels are not counted again
+	hourly := &db.Summary{ChatID: chatID, SummaryType: "check-catalog-1h", PeriodStart: testTime(-time.Hour), PeriodEnd: testTime(0)}
+	if err := store.SaveSummaryWithProducts(hourly, []db.ProductMention{{ProductName: product.Name, MentionCount: 1, ProductID: product.ID}}); err != nil {
+		t.Errorf("SaveSummaryWithProducts: %v", err)
+	}
+	activity, err := store.GetProductActivity(product.ID, summary.SummaryType, testTime(-2*time.Hour), testTime(time.Hour))
+	if err != nil || len(activity) != 3 {
+		t.Errorf("GetProductActivity: got %d mentions (err=%v), want 3", len(activity), err)
+	} else if activity[0].ChatID != chatID || activity[0].ProductTitle != product.Name || !activity[0].PeriodEnd.Equal(summary.PeriodEnd) ||
+		activity[0].FamilyCodes != products[0].FamilyCodes {
+		t.Errorf("GetProductActivity: unexpected activity %+v", activity[0])
+	}
+	aliases, err := store.GetProductAliases(product.ID)
+	if err != nil || len(aliases) != 3 {
+		t.Errorf("GetProductAliases: got %+v (err=%v), want 3 aliases", aliases, err)
+	}
+	
+	if removed, err := store.DeleteProductAlias(aliasKey); err != nil || !removed {
+		t.Errorf("DeleteProductAlias: removed=%v (err=%v)", removed, err)
+	}
+	if removed, err := store.DeleteProductAlias(aliasKey); err != nil || removed {
+		t.Errorf("DeleteProductAlias: removed a missing alias (err=%v)", err)
+	}
+}
@@ -0,0 +1,45 @@
+package storetest
+
+import (
+	"telegram-summarizer/internal/db"
+	"time"
+)
+
+func testSummarySchedules(t T, store db.Store, chatID int64) {
+	migratedChatID := chatID - 2000000
+	schedule := &db.SummarySchedule{ChatID: chatID, CronExpr: "0 */4 * * *", PromptType: "4h", CreatedBy: 1, CreatedAt: testTime(0)}
+	if err := store.AddSummarySchedule(schedule); err != nil {
+		t.Errorf("AddSummarySchedule: %v", err)
+		return
+	}
+	if schedule.ID == 0 {
+		t.Errorf("AddSummarySchedule: ID not set")
+	}
+	
+	runAt := testTime(time.Hour)
+	if err := store.SetSummaryScheduleLastRun(schedule.ID, runAt); err != nil {
+		t.Errorf("SetSummaryScheduleLastRun: %v", err)
+	}
+	schedules, err := store.GetSummarySchedules(chatID)
+	if err != nil || len(schedules) != 1 {
+		t.Errorf("GetSummarySchedules: got %d schedules (err=%v), want 1", len(schedules), err)
+		return
+	}
+	if got := schedules[0]; got.CronExpr != schedule.CronExpr || got.PromptType != "4h" || !got.LastRunAt.Equal(runAt) {
+		t.Errorf("GetSummarySchedules: got %+v", got)
+	}
+	
+	// Schedules follow a group to the supergroup it migrated to
+	if err := store.AddTrackedGroup(chatID, "Scheduled Group", ""); err != nil {
+		t.Errorf("AddTrackedGroup: %v", err)
+	}
+	if err := store.MarkGroupMigrated(chatID, migratedChatID); err != nil {
+		t.Errorf("MarkGroupMigrated: %v", err)
+	}
+	if removed, err := store.DeleteSummarySchedule(chatID, schedule.ID); err != nil || removed {
+		t.Errorf("DeleteSummarySchedule: removed schedule of the old chat (err=%v)", err)
+	}
+	if removed, err := store.DeleteSummarySchedule(migratedChatID, schedule.ID); err != nil || !removed {
+		t.Errorf("DeleteSummarySchedule: schedule of the migrated group not removed (err=%v)", err)
+	}
+}
@@ -0,0 +1,67 @@
+// Package storetest is a conformance suite for db.Store implementations.
+// It is run against every backend by cmd/storecheck and can be called from
+// go tests with a *testing.T.
+package storetest
+
+import (
+	"sync/atomic"
+	"telegram-summarizer/internal/db"
+	"time"
+)
+
+// T is the part of *testing.T used by the suite
+type T interface {
+	Errorf(format string, args ...interface{})
+}
+
+// Case is a named check of the suite
+type Case struct {
+	Name string
+	run  func(t T, store db.Store, chatID int64)
+}
+
+// Cases returns all checks of the suite
+func Cases() []Case {
+	return []Case{
+		{"messages", testMessages},
+		{"duplicate messages", testDuplicateMessages},
+		{"restore", testRestore},
+		{"summaries", testSummaries},
+		{"product mentions", testProductMentions},
+		{"tracked groups", testTrackedGroups},
+		{"update state", testUpdateState},
+		{"filters", testFilters},
+		{"users", testUsers},
+		{"product catalog", testProductCatalog},
+		{"family codes", testFamilyCodes},
+		{"subscriptions", testSubscriptions},
+		{"summary schedules", testSummarySchedules},
+		{"summary windows", testSummaryWindows},
+		{"job queue", testJobQueue},
+		{"time zones", testTimeZones},
+		{"leader leases", testLeaderLeases},
+		{"summary transaction", testSummaryTransaction},
+		{"concurrent ingest and summaries", testConcurrency},
+	}
+}
+
+// nextChatID is the chat ID handed to the next check. It is derived from the start
+// time, so checks can run against a database that already holds data.
+var nextChatID = db.ChannelChatID(1000000000 + time.Now().UnixNano()%1000000000).Int64()
+
+// Run runs the check against a store using a supergroup chat ID of its own
+func (c Case) Run(t T, store db.Store) {
+	c.run(t, store, atomic.AddInt64(&nextChatID, -1))
+}
+
+// Run runs all checks against a store
+func Run(t T, store db.Store) {
+	for _, c := range Cases() {
+		c.Run(t, store)
+	}
+}
+
+// testTime returns a timestamp without sub-second precision, which not every backend keeps
+func testTime(offset time.Duration) time.Time {
+	return time.Now().Add(offset).Truncate(time.Second)
+}
@@ -0,0 +1,70 @@
+package storetest
+
+import (
+	"telegram-summarizer/internal/db"
+)
+
+func testSubscriptions(t T, store db.Store, chatID int64) {
+	target := -chatID // A private chat
+	migratedChatID := chatID - 2000000
+	for _, cadence := range []string{db.CadenceDaily, db.CadenceHourly, db.CadenceDaily} {
+		if _, err := store.AddSubscription(&db.Subscription{GroupChatID: chatID, TargetChatID: target, Cadence: cadence, CreatedBy: 1, CreatedAt: testTime(0)}); err != nil {
+			t.Errorf("AddSubscription: %v", err)
+			return
+		}
+	}
+	if added, err := store.AddSubscription(&db.Subscription{GroupChatID: chatID, TargetChatID: target, Cadence: db.CadenceDaily, CreatedAt: testTime(0)}); err != nil || added {
+		t.Errorf("AddSubscription: duplicate added (err=%v)", err)
+	}
+	
+	if subs, err := store.GetSubscriptions(chatID, db.CadenceDaily); err != nil || len(subs) != 1 || subs[0].TargetChatID != target {
+		t.Errorf("GetSubscriptions: got %+v (err=%v), want 1 daily subscription", subs, err)
+	}
+	if subs, err := store.GetSubscriptions(chatID, ""); err != nil || len(subs) != 2 {
+		t.Errorf("GetSubscriptions: got %d subscriptions (err=%v), want 2", len(subs), err)
+	}
+	if subs, err := store.GetSubscriptionsByTarget(target); err != nil || len(subs) != 2 {
+		t.Errorf("GetSubscriptionsByTarget: got %d subscriptions (err=%v), want 2", len(subs), err)
+	}
+	
+	if removed, err := store.RemoveSubscriptions(chatID, target, db.CadenceHourly); err != nil || removed != 1 {
+		t.Errorf("RemoveSubscriptions: removed %d (err=%v), want 1", removed, err)
+	}
+	
+	// Subscriptions follow a group to the supergroup it migrated to
+	if err := store.AddTrackedGroup(chatID, "Subscribed Group", ""); err != nil {
+		t.Errorf("AddTrackedGroup: %v", err)
+	}
+	if err := store.MarkGroupMigrated(chatID, migratedChatID); err != nil {
+		t.Errorf("MarkGroupMigrated: %v", err)
+	}
+	if subs, err := store.GetSubscriptions(migratedChatID, db.CadenceDaily); err != nil || len(subs) != 1 {
+		t.Errorf("GetSubscriptions: got %d subscriptions of the migrated group (err=%v), want 1", len(subs), err)
+	}
+	if removed, err := store.RemoveSubscriptions(migratedChatID, target, ""); err != nil || removed != 1 {
+		t.Errorf("RemoveSubscriptions: removed %d (err=%v), want 1", removed, err)
+	}
+	
+	// Without a target chat nothing is subscribed, and a target set later still is
+	if added, err := store.SubscribeLegacyTarget(0); err != nil || added != 0 {
+		t.Errorf("SubscribeLegacyTarget(0): added %d (err=%v), want 0", added, err)
+	}
+	
+	// The former target chat is subscribed to the daily summaries of all groups once
+	legacyTarget := target - 1
+	if added, err := store.SubscribeLegacyTarget(legacyTarget); err != nil {
+		t.Errorf("SubscribeLegacyTarget: %v", err)
+	} else if added > 0 {
+		// Not yet applied to this database
+		if subs, err := store.GetSubscriptions(chatID, db.CadenceDaily); err != nil || len(subs) != 1 || subs[0].TargetChatID != legacyTarget {
+			t.Errorf("SubscribeLegacyTarget: got %+v (err=%v), want a daily subscription of the target", subs, err)
+		}
+	}
+	if added, err := store.SubscribeLegacyTarget(legacyTarget); err != nil || added != 0 {
+		t.Errorf("SubscribeLegacyTarget: added %d again (err=%v), want 0", added, err)
+	}
+	subs, _ := store.GetSubscriptionsByTarget(legacyTarget)
+	for _, sub := range subs {
+		store.RemoveSubscriptions(sub.GroupChatID, legacyTarget, "")
+	}
+}
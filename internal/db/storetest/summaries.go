@@ -0,0 +1,101 @@
+package storetest
+
+import (
+	"telegram-summarizer/internal/db"
+	"time"
+)
+
+func testSummaries(t T, store db.Store, chatID int64) {
+	end := testTime(0)
+	start := end.Add(-4 * time.Hour)
+	
+	if last, err := store.GetLastSummaryTime(chatID, "check"); err != nil || !last.IsZero() {
+		t.Errorf("GetLastSummaryTime: got %s (err=%v), want zero time", last, err)
+	}
+	
+	summary := &db.Summary{
+		ChatID:           chatID,
+		SummaryType:      "check",
+		PeriodStart:      start,
+		PeriodEnd:        end,
+		SummaryText:      "summary text",
+		MessageCount:     12,
+		Sentiment:        "positive",
+		CredibilityScore: 4,
+		ValidationStatus: "valid",
+	}
+	if err := store.SaveSummary(summary); err != nil {
+		t.Errorf("SaveSummary: %v", err)
+		return
+	}
+	if summary.ID == 0 {
+		t.Errorf("SaveSummary: ID not set")
+	}
+	
+	if last, err := store.GetLastSummaryTime(chatID, "check"); err != nil || !last.Equal(end) {
+		t.Errorf("GetLastSummaryTime: got %s (err=%v), want %s", last, err, end)
+	}
+	
+	summaries, err := store.GetSummaries(chatID, "check", 10)
+	if err != nil || len(summaries) != 1 {
+		t.Errorf("GetSummaries: got %d summaries (err=%v), want 1", len(summaries), err)
+	} else if summaries[0].SummaryText != "summary text" || summaries[0].MessageCount != 12 {
+		t.Errorf("GetSummaries: unexpected summary %+v", summaries[0])
+	}
+	
+	if byRange := store.GetSummariesByTimeRange(chatID, "check", start.Add(-time.Hour), end); len(byRange) != 1 {
+		t.Errorf("GetSummariesByTimeRange: got %d summaries, want 1", len(byRange))
+	}
+}
+
+func testProductMentions(t T, store db.Store, chatID int64) {
+	summary := &db.Summary{ChatID: chatID, SummaryType: "check", PeriodStart: testTime(-time.Hour), PeriodEnd: testTime(0)}
+	if err := store.SaveSummary(summary); err != nil {
+		t.Errorf("SaveSummary: %v", err)
+		return
+	}
+	
+	product := "storetest product " + time.Now().Format("150405.000000000")
+	pm := &db.ProductMention{
+		SummaryID:        summary.ID,
+		ProductName:      product,
+		MentionCount:     3,
+		CredibilityScore: 5,
+		Sentiment:        "positive",
+		ValidationStatus: "valid",
+		PriceMentioned:   "Rp 100.000",
+	}
+	if err := store.SaveProductMention(pm); err != nil {
+		t.Errorf("SaveProductMention: %v", err)
+		return
+	}
+	if pm.ID == 0 {
+		t.Errorf("SaveProductMention: ID not set")
+	}
+	
+	trends := store.GetProductTrends(product, 7)
+	if len(trends) != 1 {
+		t.Errorf("GetProductTrends: got %d mentions, want 1", len(trends))
+	} else if trends[0].MentionCount != 3 || trends[0].PriceMentioned != "Rp 100.000" {
+		t.Errorf("GetProductTrends: unexpected mention %+v", trends[0])
+	}
+}
+
+func testSummaryTransaction(t T, store db.Store, chatID int64) {
+	product := "storetest tx product " + time.Now().Format("150405.000000000")
+	summary := &db.Summary{ChatID: chatID, SummaryType: "check-tx", PeriodStart: testTime(-time.Hour), PeriodEnd: testTime(0)}
+	products := []db.ProductMention{
+		{ProductName: product, MentionCount: 1},
+		{ProductName: product, MentionCount: 2},
+	}
+	if err := store.SaveSummaryWithProducts(summary, products); err != nil {
+		t.Errorf("SaveSummaryWithProducts: %v", err)
+		return
+	}
+	if summary.ID == 0 || products[0].SummaryID != summary.ID || products[1].ID == 0 {
+		t.Errorf("SaveSummaryWithProducts: IDs not set (summary=%d, products=%+v)", summary.ID, products)
+	}
+	if trends := store.GetProductTrends(product, 1); len(trends) != 2 {
+		t.Errorf("GetProductTrends: got %d mentions, want 2", len(trends))
+	}
+}
@@ -0,0 +1,74 @@
+package storetest
+
+import (
+	"strconv"
+	"strings"
+	"telegram-summarizer/internal/db"
+	"time"
+)
+
+func testUsers(t T, store db.Store, chatID int64) {
+	userID := -chatID
+	otherChatID := chatID - 1000000
+	name := "storetest_" + strconv.FormatInt(userID%1000000000, 10)
+	renamed := name + "_new"
+	
+	sightings := []db.UserSighting{
+		{UserID: userID, ChatID: chatID, Username: name, FirstName: "Store", Timestamp: testTime(-3 * time.Hour)},
+		{UserID: userID, ChatID: chatID, Username: name, FirstName: "Store", Timestamp: testTime(-2 * time.Hour), IsAdmin: true, AdminKnown: true},
+		{UserID: userID, ChatID: otherChatID, Username: strings.ToUpper(renamed), FirstName: "Store", LastName: "Test", Timestamp: testTime(-time.Hour)},
+	}
+	for _, s := range sightings {
+		if err := store.TrackUser(s); err != nil {
+			t.Errorf("TrackUser: %v", err)
+			return
+		}
+	}
+	
+	user, err := store.GetUser(userID)
+	if err != nil || user == nil {
+		t.Errorf("GetUser: got %v (err=%v)", user, err)
+		return
+	}
+	if user.Username != strings.ToUpper(renamed) || user.LastName != "Test" || user.MessageCount != 3 || !user.IsAdmin {
+		t.Errorf("GetUser: unexpected user %+v", *user)
+	}
+	if !user.FirstSeen.Equal(sightings[0].Timestamp) || !user.LastSeen.Equal(sightings[2].Timestamp) {
+		t.Errorf("GetUser: first/last seen %s/%s, want %s/%s", user.FirstSeen, user.LastSeen, sightings[0].Timestamp, sightings[2].Timestamp)
+	}
+	
+	// Old usernames still find the user
+	for _, lookup := range []string{"@" + name, renamed} {
+		if users, err := store.FindUsersByUsername(lookup); err != nil || len(users) != 1 || users[0].UserID != userID {
+			t.Errorf("FindUsersByUsername(%s): got %d users (err=%v)", lookup, len(users), err)
+		}
+	}
+	
+	if history, err := store.GetUsernameHistory(userID); err != nil || len(history) != 2 || history[0].Username != renamed {
+		t.Errorf("GetUsernameHistory: got %+v (err=%v)", history, err)
+	}
+	
+	activity, err := store.GetUserGroupActivity(userID)
+	if err != nil || len(activity) != 2 {
+		t.Errorf("GetUserGroupActivity: got %d groups (err=%v), want 2", len(activity), err)
+	} else if activity[0].ChatID != chatID || activity[0].MessageCount != 2 || !activity[0].IsAdmin || activity[1].IsAdmin {
+		t.Errorf("GetUserGroupActivity: unexpected activity %+v", activity)
+	}
+	
+	top, err := store.GetTopUsers(chatID, testTime(-4*time.Hour), testTime(time.Hour), 10)
+	if err != nil || len(top) != 1 || top[0].UserID != userID || top[0].PeriodMessages != 2 {
+		t.Errorf("GetTopUsers: got %+v (err=%v), want 1 user with 2 messages", top, err)
+	}
+	if top, err := store.GetTopUsers(otherChatID, testTime(-4*time.Hour), testTime(-2*time.Hour), 10); err != nil || len(top) != 0 {
+		t.Errorf("GetTopUsers: got %d users outside the period (err=%v)", len(top), err)
+	}
+	
+	// Nothing is that old, so this only checks the query
+	if _, err := store.PruneUserActivity(time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Errorf("PruneUserActivity: %v", err)
+	}
+	
+	if user, err := store.GetUser(userID + 1); err != nil || user != nil {
+		t.Errorf("GetUser: unknown user found (err=%v)", err)
+	}
+}
@@ -0,0 +1,43 @@
+package storetest
+
+import (
+	"telegram-summarizer/internal/db"
+	"time"
+)
+
+func testSummaryWindows(t T, store db.Store, chatID int64) {
+	if first, err := store.GetFirstSummaryWindow(chatID); err != nil || !first.IsZero() {
+		t.Errorf("GetFirstSummaryWindow: got %v (err=%v), want zero time", first, err)
+	}
+	
+	start := testTime(-5 * time.Hour).Truncate(time.Hour)
+	for _, hour := range []int{0, 2} { // Hour 1 is missed
+		window := &db.SummaryWindow{ChatID: chatID, SummaryType: "1h", WindowStart: start.Add(time.Duration(hour) * time.Hour),
+			WindowEnd: start.Add(time.Duration(hour+1) * time.Hour), Status: db.WindowSummarized, MessageCount: 10, CompletedAt: testTime(0)}
+		if err := store.RecordSummaryWindow(window); err != nil {
+			t.Errorf("RecordSummaryWindow: %v", err)
+			return
+		}
+	}
+	
+	// Recording a window again replaces it
+	if err := store.RecordSummaryWindow(&db.SummaryWindow{ChatID: chatID, SummaryType: "1h", WindowStart: start,
+		WindowEnd: start.Add(time.Hour), Status: db.WindowSkipped, MessageCount: 2, CompletedAt: testTime(0)}); err != nil {
+		t.Errorf("RecordSummaryWindow: %v", err)
+	}
+	
+	windows, err := store.GetSummaryWindows(chatID, start, start.Add(3*time.Hour))
+	if err != nil || len(windows) != 2 {
+		t.Errorf("GetSummaryWindows: got %d windows (err=%v), want 2", len(windows), err)
+		return
+	}
+	if windows[0].Status != db.WindowSkipped || windows[0].MessageCount != 2 || !windows[1].WindowStart.Equal(start.Add(2*time.Hour)) {
+		t.Errorf("GetSummaryWindows: got %+v", windows)
+	}
+	if windows, err := store.GetSummaryWindows(chatID, start.Add(time.Hour), start.Add(2*time.Hour)); err != nil || len(windows) != 0 {
+		t.Errorf("GetSummaryWindows: got %d windows in the missed hour (err=%v), want 0", len(windows), err)
+	}
+	if first, err := store.GetFirstSummaryWindow(chatID); err != nil || !first.Equal(start) {
+		t.Errorf("GetFirstSummaryWindow: got %v (err=%v), want %v", first, err, start)
+	}
+}
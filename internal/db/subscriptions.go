@@ -0,0 +1,147 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// legacyTargetMigration names the migration subscribing the legacy target chat
+const legacyTargetMigration = "subscribe_legacy_target"
+
+// AddSubscription subscribes a chat to the summaries of a group; returns false if it
+// was already subscribed
+func (db *DB) AddSubscription(sub *Subscription) (bool, error) {
+	query := `
+		INSERT INTO summary_subscriptions (group_chat_id, target_chat_id, cadence, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(group_chat_id, target_chat_id, cadence) DO NOTHING`
+	
+	result, err := db.conn.Exec(query, sub.GroupChatID, sub.TargetChatID, sub.Cadence, sub.CreatedBy, sub.CreatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to save subscription: %w", err)
+	}
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+// RemoveSubscriptions unsubscribes a chat from the summaries of a group (all cadences if
+// cadence is empty); returns the number of subscriptions removed
+func (db *DB) RemoveSubscriptions(groupChatID, targetChatID int64, cadence string) (int64, error) {
+	query := `
+		DELETE FROM summary_subscriptions
+		WHERE group_chat_id = ? AND target_chat_id = ? AND (? = '' OR cadence = ?)`
+	
+	result, err := db.conn.Exec(query, groupChatID, targetChatID, cadence, cadence)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete subscriptions: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// GetSubscriptions gets the subscriptions to a group's summaries of a cadence (all
+// cadences if empty), oldest first
+func (db *DB) GetSubscriptions(groupChatID int64, cadence string) ([]Subscription, error) {
+	query := `
+		SELECT s.group_chat_id, COALESCE(g.group_name, ''), s.target_chat_id, s.cadence, s.created_by, s.created_at
+		FROM summary_subscriptions s
+		LEFT JOIN tracked_groups g ON g.chat_id = s.group_chat_id
+		WHERE s.group_chat_id = ? AND (? = '' OR s.cadence = ?)
+		ORDER BY s.created_at, s.target_chat_id`
+	
+	return db.querySubscriptions(query, groupChatID, cadence, cadence)
+}
+
+// GetSubscriptionsByTarget gets the subscriptions of a chat, by group
+func (db *DB) GetSubscriptionsByTarget(targetChatID int64) ([]Subscription, error) {
+	query := `
+		SELECT s.group_chat_id, COALESCE(g.group_name, ''), s.target_chat_id, s.cadence, s.created_by, s.created_at
+		FROM summary_subscriptions s
+		LEFT JOIN tracked_groups g ON g.chat_id = s.group_chat_id
+		WHERE s.target_chat_id = ?
+		ORDER BY COALESCE(g.group_name, ''), s.group_chat_id, s.cadence`
+	
+	return db.querySubscriptions(query, targetChatID)
+}
+
+// SubscribeLegacyTarget subscribes the chat that received every daily summary before
+// subscriptions existed to the daily summaries of all tracked groups, so upgrading
+// keeps its delivery. Runs once per database, the first time a target is configured;
+// returns the subscriptions added.
+func (db *DB) SubscribeLegacyTarget(targetChatID int64) (int64, error) {
+	if targetChatID == 0 {
+		return 0, nil
+	}
+	
+	tx, err := db.conn.begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	
+	now := time.Now()
+	markQuery := `
+		INSERT INTO applied_migrations (name, applied_at) VALUES (?, ?)
+		ON CONFLICT(name) DO NOTHING`
+	result, err := tx.Exec(markQuery, legacyTargetMigration, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record migration: %w", err)
+	}
+	if applied, _ := result.RowsAffected(); applied == 0 {
+		return 0, nil
+	}
+	
+	subscribeQuery := `
+		INSERT INTO summary_subscriptions (group_chat_id, target_chat_id, cadence, created_by, created_at)
+		SELECT chat_id, ?, ?, 0, ?
+		FROM tracked_groups WHERE chat_id < 0
+		ON CONFLICT(group_chat_id, target_chat_id, cadence) DO NOTHING`
+	result, err = tx.Exec(subscribeQuery, targetChatID, CadenceDaily, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to subscribe legacy target: %w", err)
+	}
+	added, _ := result.RowsAffected()
+	
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit legacy target subscriptions: %w", err)
+	}
+	return added, nil
+}
+
+// querySubscriptions runs a query selecting subscriptions with their group name
+func (db *DB) querySubscriptions(query string, args ...interface{}) ([]Subscription, error) {
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscriptions: %w", err)
+	}
+	defer rows.Close()
+	
+	var subs []Subscription
+	for rows.Next() {
+		var sub Subscription
+		var createdAt sql.NullTime
+		if err := rows.Scan(&sub.GroupChatID, &sub.GroupName, &sub.TargetChatID, &sub.Cadence, &sub.CreatedBy, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan subscription: %w", err)
+		}
+		sub.CreatedAt = createdAt.Time
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+// moveSubscriptions moves the subscriptions of a group to the group it migrated to,
+// in the migration's transaction
+func moveSubscriptions(tx *sqlTx, fromChatID, toChatID int64) error {
+	copyQuery := `
+		INSERT INTO summary_subscriptions (group_chat_id, target_chat_id, cadence, created_by, created_at)
+		SELECT ?, target_chat_id, cadence, created_by, created_at
+		FROM summary_subscriptions WHERE group_chat_id = ?
+		ON CONFLICT(group_chat_id, target_chat_id, cadence) DO NOTHING`
+	if _, err := tx.Exec(copyQuery, toChatID, fromChatID); err != nil {
+		return fmt.Errorf("failed to copy subscriptions: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM summary_subscriptions WHERE group_chat_id = ?`, fromChatID); err != nil {
+		return fmt.Errorf("failed to delete old subscriptions: %w", err)
+	}
+	return nil
+}
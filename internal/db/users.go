@@ -0,0 +1,256 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// userColumns are the columns read by scanUser
+const userColumns = `user_id, COALESCE(username, ''), COALESCE(first_name, ''), COALESCE(last_name, ''),
+	is_bot, is_admin, first_seen, last_seen, message_count`
+
+// scanUser scans a row selected with userColumns
+func scanUser(row interface{ Scan(...interface{}) error }) (*User, error) {
+	var u User
+	var firstSeen, lastSeen sql.NullTime
+	if err := row.Scan(&u.UserID, &u.Username, &u.FirstName, &u.LastName,
+		&u.IsBot, &u.IsAdmin, &firstSeen, &lastSeen, &u.MessageCount); err != nil {
+		return nil, err
+	}
+	u.FirstSeen = firstSeen.Time
+	u.LastSeen = lastSeen.Time
+	return &u, nil
+}
+
+// boolInt converts a flag to the INTEGER stored in the database
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// TrackUser records a message of a user: profile, username history and group activity
+func (db *DB) TrackUser(s UserSighting) error {
+	if s.UserID == 0 {
+		return nil
+	}
+	
+	tx, err := db.conn.begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	
+	userQuery := `
+		INSERT INTO users (user_id, username, first_name, last_name, is_bot, first_seen, last_seen, message_count)
+		VALUES (?, ?, ?, ?, ?, ?, ?, 1)
+		ON CONFLICT(user_id) DO UPDATE SET
+			username = CASE WHEN excluded.last_seen >= users.last_seen THEN excluded.username ELSE users.username END,
+			first_name = CASE WHEN excluded.first_name != '' THEN excluded.first_name ELSE users.first_name END,
+			last_name = CASE WHEN excluded.first_name != '' THEN excluded.last_name ELSE users.last_name END,
+			is_bot = excluded.is_bot,
+			first_seen = CASE WHEN excluded.first_seen < users.first_seen THEN excluded.first_seen ELSE users.first_seen END,
+			last_seen = CASE WHEN excluded.last_seen > users.last_seen THEN excluded.last_seen ELSE users.last_seen END,
+			message_count = users.message_count + 1`
+	if _, err := tx.Exec(userQuery, s.UserID, s.Username, s.FirstName, s.LastName, boolInt(s.IsBot), s.Timestamp, s.Timestamp); err != nil {
+		return fmt.Errorf("failed to save user: %w", err)
+	}
+	
+	if s.Username != "" {
+		historyQuery := `
+			INSERT INTO username_history (user_id, username, first_seen, last_seen)
+			VALUES (?, ?, ?, ?)
+			ON CONFLICT(user_id, username) DO UPDATE SET
+				first_seen = CASE WHEN excluded.first_seen < username_history.first_seen THEN excluded.first_seen ELSE username_history.first_seen END,
+				last_seen = CASE WHEN excluded.last_seen > username_history.last_seen THEN excluded.last_seen ELSE username_history.last_seen END`
+		if _, err := tx.Exec(historyQuery, s.UserID, strings.ToLower(s.Username), s.Timestamp, s.Timestamp); err != nil {
+			return fmt.Errorf("failed to save username history: %w", err)
+		}
+	}
+	
+	if s.ChatID != 0 {
+		groupQuery := `
+			INSERT INTO user_groups (user_id, chat_id, message_count, is_admin, first_seen, last_seen)
+			VALUES (?, ?, 1, ?, ?, ?)
+			ON CONFLICT(user_id, chat_id) DO UPDATE SET
+				message_count = user_groups.message_count + 1,
+				is_admin = CASE WHEN ? = 1 THEN excluded.is_admin ELSE user_groups.is_admin END,
+				first_seen = CASE WHEN excluded.first_seen < user_groups.first_seen THEN excluded.first_seen ELSE user_groups.first_seen END,
+				last_seen = CASE WHEN excluded.last_seen > user_groups.last_seen THEN excluded.last_seen ELSE user_groups.last_seen END`
+		if _, err := tx.Exec(groupQuery, s.UserID, s.ChatID, boolInt(s.IsAdmin), s.Timestamp, s.Timestamp, boolInt(s.AdminKnown)); err != nil {
+			return fmt.Errorf("failed to save user group activity: %w", err)
+		}
+	
+		activityQuery := `
+			INSERT INTO user_activity (user_id, chat_id, hour, message_count)
+			VALUES (?, ?, ?, 1)
+			ON CONFLICT(user_id, chat_id, hour) DO UPDATE SET
+				message_count = user_activity.message_count + 1`
+		if _, err := tx.Exec(activityQuery, s.UserID, s.ChatID, s.Timestamp.Truncate(time.Hour)); err != nil {
+			return fmt.Errorf("failed to save user activity: %w", err)
+		}
+	
+		if s.AdminKnown {
+			adminQuery := `
+				UPDATE users SET is_admin = (
+					SELECT COALESCE(MAX(is_admin), 0) FROM user_groups WHERE user_id = ?
+				)
+				WHERE user_id = ?`
+			if _, err := tx.Exec(adminQuery, s.UserID, s.UserID); err != nil {
+				return fmt.Errorf("failed to update admin flag: %w", err)
+			}
+		}
+	}
+	
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit user: %w", err)
+	}
+	return nil
+}
+
+// GetUser gets a user by ID; returns nil if the user was never seen
+func (db *DB) GetUser(userID int64) (*User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE user_id = ?`
+	
+	user, err := scanUser(db.conn.QueryRow(query, userID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+// FindUsersByUsername finds users who have or had a username (case-insensitive,
+// with or without "@"), most recently seen first
+func (db *DB) FindUsersByUsername(username string) ([]User, error) {
+	username = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(username), "@"))
+	
+	query := `
+		SELECT ` + userColumns + `
+		FROM users
+		WHERE user_id IN (SELECT user_id FROM username_history WHERE username = ?)
+		ORDER BY last_seen DESC`
+	
+	rows, err := db.conn.Query(query, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find users: %w", err)
+	}
+	defer rows.Close()
+	
+	var users []User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, *user)
+	}
+	return users, rows.Err()
+}
+
+// GetUsernameHistory gets all usernames of a user, most recent first
+func (db *DB) GetUsernameHistory(userID int64) ([]UsernameRecord, error) {
+	query := `
+		SELECT username, first_seen, last_seen
+		FROM username_history
+		WHERE user_id = ?
+		ORDER BY last_seen DESC`
+	
+	rows, err := db.conn.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get username history: %w", err)
+	}
+	defer rows.Close()
+	
+	var history []UsernameRecord
+	for rows.Next() {
+		var r UsernameRecord
+		var firstSeen, lastSeen sql.NullTime
+		if err := rows.Scan(&r.Username, &firstSeen, &lastSeen); err != nil {
+			return nil, fmt.Errorf("failed to scan username: %w", err)
+		}
+		r.FirstSeen = firstSeen.Time
+		r.LastSeen = lastSeen.Time
+		history = append(history, r)
+	}
+	return history, rows.Err()
+}
+
+// GetUserGroupActivity gets the activity of a user per group, most active first
+func (db *DB) GetUserGroupActivity(userID int64) ([]UserGroupActivity, error) {
+	query := `
+		SELECT ug.chat_id, COALESCE(g.group_name, ''), ug.message_count, ug.is_admin, ug.first_seen, ug.last_seen
+		FROM user_groups ug
+		LEFT JOIN tracked_groups g ON g.chat_id = ug.chat_id
+		WHERE ug.user_id = ?
+		ORDER BY ug.message_count DESC, ug.last_seen DESC`
+	
+	rows, err := db.conn.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user activity: %w", err)
+	}
+	defer rows.Close()
+	
+	var activity []UserGroupActivity
+	for rows.Next() {
+		var a UserGroupActivity
+		var firstSeen, lastSeen sql.NullTime
+		if err := rows.Scan(&a.ChatID, &a.GroupName, &a.MessageCount, &a.IsAdmin, &firstSeen, &lastSeen); err != nil {
+			return nil, fmt.Errorf("failed to scan user activity: %w", err)
+		}
+		a.FirstSeen = firstSeen.Time
+		a.LastSeen = lastSeen.Time
+		activity = append(activity, a)
+	}
+	return activity, rows.Err()
+}
+
+// GetTopUsers gets the users who sent the most messages in a group during a period,
+// most active first
+func (db *DB) GetTopUsers(chatID int64, start, end time.Time, limit int) ([]ActiveUser, error) {
+	query := `
+		SELECT ` + userColumns + `, a.period_messages
+		FROM (
+			SELECT user_id, SUM(message_count) AS period_messages
+			FROM user_activity
+			WHERE chat_id = ? AND hour >= ? AND hour < ?
+			GROUP BY user_id
+		) a
+		JOIN users USING (user_id)
+		ORDER BY a.period_messages DESC, user_id
+		LIMIT ?`
+	
+	rows, err := db.conn.Query(query, chatID, start, end, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top users: %w", err)
+	}
+	defer rows.Close()
+	
+	var users []ActiveUser
+	for rows.Next() {
+		var u ActiveUser
+		var firstSeen, lastSeen sql.NullTime
+		if err := rows.Scan(&u.UserID, &u.Username, &u.FirstName, &u.LastName,
+			&u.IsBot, &u.IsAdmin, &firstSeen, &lastSeen, &u.MessageCount, &u.PeriodMessages); err != nil {
+			return nil, fmt.Errorf("failed to scan top user: %w", err)
+		}
+		u.FirstSeen = firstSeen.Time
+		u.LastSeen = lastSeen.Time
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+// PruneUserActivity deletes the hourly user activity before a time; returns the number of rows deleted
+func (db *DB) PruneUserActivity(before time.Time) (int64, error) {
+	result, err := db.conn.Exec(`DELETE FROM user_activity WHERE hour < ?`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune user activity: %w", err)
+	}
+	return result.RowsAffected()
+}
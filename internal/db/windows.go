@@ -0,0 +1,72 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// RecordSummaryWindow records a completed summary window, replacing an earlier record
+// of the same window
+func (db *DB) RecordSummaryWindow(window *SummaryWindow) error {
+	query := `
+		INSERT INTO summary_windows (chat_id, summary_type, window_start, window_end, status, message_count, completed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(chat_id, summary_type, window_start) DO UPDATE SET
+			window_end = excluded.window_end,
+			status = excluded.status,
+			message_count = excluded.message_count,
+			completed_at = excluded.completed_at`
+	
+	if _, err := db.conn.Exec(query, window.ChatID, window.SummaryType, window.WindowStart, window.WindowEnd,
+		window.Status, window.MessageCount, window.CompletedAt); err != nil {
+		return fmt.Errorf("failed to record summary window: %w", err)
+	}
+	return nil
+}
+
+// GetSummaryWindows gets the completed windows of a group overlapping a time range, oldest first
+func (db *DB) GetSummaryWindows(chatID int64, startTime, endTime time.Time) ([]SummaryWindow, error) {
+	query := `
+		SELECT chat_id, summary_type, window_start, window_end, status, message_count, completed_at
+		FROM summary_windows
+		WHERE chat_id = ? AND window_start < ? AND window_end > ?
+		ORDER BY window_start, summary_type`
+	
+	rows, err := db.conn.Query(query, chatID, endTime, startTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get summary windows: %w", err)
+	}
+	defer rows.Close()
+	
+	var windows []SummaryWindow
+	for rows.Next() {
+		var w SummaryWindow
+		var completedAt sql.NullTime
+		if err := rows.Scan(&w.ChatID, &w.SummaryType, &w.WindowStart, &w.WindowEnd, &w.Status, &w.MessageCount, &completedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan summary window: %w", err)
+		}
+		w.CompletedAt = completedAt.Time
+		windows = append(windows, w)
+	}
+	return windows, rows.Err()
+}
+
+// GetFirstSummaryWindow gets the start of a group's first completed window (zero if none)
+func (db *DB) GetFirstSummaryWindow(chatID int64) (time.Time, error) {
+	query := `
+		SELECT window_start FROM summary_windows
+		WHERE chat_id = ?
+		ORDER BY window_start
+		LIMIT 1`
+	
+	var first time.Time
+	err := db.conn.QueryRow(query, chatID).Scan(&first)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get first summary window: %w", err)
+	}
+	return first, nil
+}
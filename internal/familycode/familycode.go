@@ -0,0 +1,129 @@
+package familycode
+
+import (
+	"regexp"
+	"strings"
+	"telegram-summarizer/internal/db"
+	"telegram-summarizer/internal/logger"
+	"time"
+)
+
+// Pattern matches a FamilyCode: a MyXL package UUID
+var Pattern = regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`)
+
+// labelPattern matches the labels codes are shared with, e.g. "FC:" or "Family Code ="
+var labelPattern = regexp.MustCompile(`(?i)\b(?:fc|family\s*code|kode)\b\s*[:=]?`)
+
+// maxProductText is the maximum length in runes of the product text stored with a code
+const maxProductText = 200
+
+// Extract returns the distinct FamilyCodes in a text, lowercased, in order of appearance
+func Extract(text string) []string {
+	var codes []string
+	seen := make(map[string]bool)
+	for _, code := range Pattern.FindAllString(text, -1) {
+		code = strings.ToLower(code)
+		if !seen[code] {
+			seen[code] = true
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+// ProductText returns the text of a message sharing codes without the codes, e.g. the
+// package name and price, collapsed to one line
+func ProductText(text string) string {
+	text = Pattern.ReplaceAllString(text, " ")
+	text = labelPattern.ReplaceAllString(text, " ")
+	text = strings.Join(strings.Fields(text), " ")
+	text = strings.TrimRight(text, " :-=")
+	
+	if runes := []rune(text); len(runes) > maxProductText {
+		text = string(runes[:maxProductText]) + "…"
+	}
+	return text
+}
+
+// Negative phrases are checked before positive words, so "gak work" is not read as "work"
+var (
+	failsPattern = regexp.MustCompile(`(?i)\b(?:(?:gak|ga|gk|nggak|ngga|enggak|tidak|tdk|not|no|belum|blm)\s*(?:work|jalan|bisa|masuk|berhasil|aktif)|gabisa|gagal|error|failed|fail|zonk|hangus|expired|mati)\b`)
+	worksPattern = regexp.MustCompile(`(?i)\b(?:work|works|worked|working|berhasil|sukses|success|masuk|aman|jalan|aktif|mantap|done)\b`)
+)
+
+// Verdict classifies a reply about a code: 1 if it reports the code works,
+// -1 if it reports the code doesn't work, 0 if it says neither
+func Verdict(text string) int {
+	switch {
+	case failsPattern.MatchString(text):
+		return -1
+	case worksPattern.MatchString(text):
+		return 1
+	}
+	return 0
+}
+
+// Message is what the registry sees of an incoming group message
+type Message struct {
+	ChatID    int64
+	MessageID int64 // Telegram message ID within the chat
+	ReplyToID int64 // Message replied to (0 if none)
+	UserID    int64
+	Text      string // Message text or media caption
+	Timestamp time.Time
+}
+
+// Registry records the FamilyCodes shared in groups and the confirmations in replies to them
+type Registry struct {
+	database db.Store
+}
+
+// NewRegistry creates a registry backed by a store
+func NewRegistry(database db.Store) *Registry {
+	return &Registry{database: database}
+}
+
+// Observe records the codes shared by a message and, for replies, whether the
+// sender reports the codes of the replied message to work
+func (r *Registry) Observe(msg Message) {
+	if msg.Text == "" {
+		return
+	}
+	
+	codes := Extract(msg.Text)
+	if len(codes) > 0 {
+		productText := ProductText(msg.Text)
+		for _, code := range codes {
+			err := r.database.RecordFamilyCode(db.FamilyCodeSighting{
+				Code:        code,
+				ProductText: productText,
+				ChatID:      msg.ChatID,
+				MessageID:   msg.MessageID,
+				Timestamp:   msg.Timestamp,
+			})
+			if err != nil {
+				logger.Error("Failed to record family code %s: %v", code, err)
+			}
+		}
+		logger.Debug("Recorded %d family codes from message %d in chat %d", len(codes), msg.MessageID, msg.ChatID)
+	}
+	
+	if msg.ReplyToID == 0 || msg.UserID == 0 {
+		return
+	}
+	verdict := Verdict(msg.Text)
+	if verdict == 0 {
+		return
+	}
+	
+	replied, err := r.database.GetFamilyCodesByMessage(msg.ChatID, msg.ReplyToID)
+	if err != nil {
+		logger.Error("Failed to get family codes of message %d: %v", msg.ReplyToID, err)
+		return
+	}
+	for _, code := range replied {
+		if err := r.database.RecordFamilyCodeVote(code, msg.UserID, verdict > 0, msg.Timestamp); err != nil {
+			logger.Error("Failed to record family code vote: %v", err)
+		}
+	}
+}
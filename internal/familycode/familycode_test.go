@@ -0,0 +1,107 @@
+package familycode
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"telegram-summarizer/internal/db"
+	"testing"
+	"time"
+)
+
+const (
+	codeA = "0a1b2c3d-4e5f-6a7b-8c9d-0e1f2a3b4c5d"
+	codeB = "ffffffff-0000-1111-2222-333333333333"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"FC: " + codeA, []string{codeA}},
+		{"Kuota 50GB\nFC " + "0A1B2C3D-4E5F-6A7B-8C9D-0E1F2A3B4C5D", []string{codeA}},
+		{codeB + " dan " + codeA + " lagi " + codeB, []string{codeB, codeA}},
+		{"x" + codeA, nil},                            // Inside a word
+		{"0a1b2c3d-4e5f-6a7b-8c9d-0e1f2a3b4c", nil},   // Last group too short
+		{"0a1b2c3d4e5f6a7b8c9d0e1f2a3b4c5d", nil},     // Without dashes
+		{"g0a1b2c3-4e5f-6a7b-8c9d-0e1f2a3b4c5d", nil}, // Not hexadecimal
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := Extract(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Extract(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestProductText(t *testing.T) {
+	tests := []struct {
+		text, want string
+	}{
+		{"Xtra Combo 50GB 25rb\nFC: " + codeA, "Xtra Combo 50GB 25rb"},
+		{"Family Code = " + codeA + "\n  Kuota   Harian 5GB ", "Kuota Harian 5GB"},
+		{"Paket murah kode: " + codeA + " " + codeB, "Paket murah"},
+		{"fc " + codeA, ""},
+	}
+	for _, tt := range tests {
+		if got := ProductText(tt.text); got != tt.want {
+			t.Errorf("ProductText(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+	
+	long := ProductText(strings.Repeat("a", maxProductText+10))
+	if runes := []rune(long); len(runes) != maxProductText+1 || runes[maxProductText] != '…' {
+		t.Errorf("ProductText of a long text has %d runes, want %d with an ellipsis", len(runes), maxProductText+1)
+	}
+}
+
+func TestVerdict(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"work gan", 1},
+		{"Sudah masuk, mantap", 1},
+		{"berhasil", 1},
+		{"gak work", -1},
+		{"ga masuk bang", -1},
+		{"tidak bisa", -1},
+		{"gagal terus", -1},
+		{"zonk", -1},
+		{"fc nya expired", -1},
+		{"ini buat kartu apa?", 0},
+		{"networking", 0},
+	}
+	for _, tt := range tests {
+		if got := Verdict(tt.text); got != tt.want {
+			t.Errorf("Verdict(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestObserveRecordsCodesAndReplies(t *testing.T) {
+	store, err := db.Open(db.DriverSQLite, filepath.Join(t.TempDir(), "codes.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer store.Close()
+	registry := NewRegistry(store)
+	
+	chatID := db.ChannelChatID(1234).Int64()
+	now := time.Now().UTC().Truncate(time.Second)
+	registry.Observe(Message{ChatID: chatID, MessageID: 10, UserID: 1, Text: "Xtra Combo 25rb\nFC: " + codeA, Timestamp: now})
+	registry.Observe(Message{ChatID: chatID, MessageID: 11, ReplyToID: 10, UserID: 2, Text: "work gan", Timestamp: now})
+	registry.Observe(Message{ChatID: chatID, MessageID: 12, ReplyToID: 10, UserID: 3, Text: "gak masuk", Timestamp: now})
+	registry.Observe(Message{ChatID: chatID, MessageID: 13, ReplyToID: 10, UserID: 4, Text: "buat kartu apa?", Timestamp: now})
+	// Replies to messages without codes are no votes
+	registry.Observe(Message{ChatID: chatID, MessageID: 14, ReplyToID: 13, UserID: 5, Text: "work", Timestamp: now})
+	
+	code, err := store.GetFamilyCode(codeA)
+	if err != nil || code == nil {
+		t.Fatalf("GetFamilyCode: %+v, err %v", code, err)
+	}
+	if code.ProductText != "Xtra Combo 25rb" || code.MentionCount != 1 || code.WorksCount != 1 || code.FailsCount != 1 {
+		t.Errorf("got %+v, want the product text, one mention and one vote each way", code)
+	}
+}
@@ -0,0 +1,208 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds the filter settings of a group. It is stored as JSON per group.
+type Config struct {
+	MinRunes         int      `json:"min_runes"`               // 0 disables the length check
+	DropEmojiOnly    bool     `json:"drop_emoji_only"`
+	DropBots         bool     `json:"drop_bots"`
+	DenyPatterns     []string `json:"deny_patterns,omitempty"`
+	AllowPatterns    []string `json:"allow_patterns,omitempty"`
+	DuplicateWindow  int      `json:"duplicate_window_sec"`    // 0 disables duplicate detection
+	FloodMaxMessages int      `json:"flood_max_messages"`      // 0 disables flood detection
+	FloodWindow      int      `json:"flood_window_sec"`
+	Languages        []string `json:"languages,omitempty"`     // Empty allows all languages
+}
+
+// DefaultConfig returns the filters applied when a group has no configuration
+func DefaultConfig() Config {
+	return Config{
+		MinRunes:      10,
+		DropEmojiOnly: true,
+		DropBots:      true,
+	}
+}
+
+// Build creates the pipeline for the configuration
+func (c Config) Build() (*Pipeline, error) {
+	var rules []Rule
+	
+	if c.DropBots {
+		rules = append(rules, BotSenderRule{})
+	}
+	if c.MinRunes > 0 {
+		rules = append(rules, MinRunesRule{Min: c.MinRunes})
+	}
+	if c.DropEmojiOnly {
+		rules = append(rules, EmojiOnlyRule{})
+	}
+	if len(c.DenyPatterns) > 0 {
+		patterns, err := compilePatterns(c.DenyPatterns)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, DenyRule{Patterns: patterns})
+	}
+	if len(c.AllowPatterns) > 0 {
+		patterns, err := compilePatterns(c.AllowPatterns)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, AllowRule{Patterns: patterns})
+	}
+	if len(c.Languages) > 0 {
+		rules = append(rules, LanguageRule{Languages: c.Languages})
+	}
+	
+	// Stateful rules last, so dropped messages don't count as seen
+	if c.DuplicateWindow > 0 {
+		rules = append(rules, NewDuplicateRule(time.Duration(c.DuplicateWindow)*time.Second))
+	}
+	if c.FloodMaxMessages > 0 && c.FloodWindow > 0 {
+		rules = append(rules, NewFloodRule(c.FloodMaxMessages, time.Duration(c.FloodWindow)*time.Second))
+	}
+	
+	return NewPipeline(rules...), nil
+}
+
+// compilePatterns compiles regex patterns (case-insensitive)
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// SettingKeys lists the keys accepted by Set
+var SettingKeys = []string{"min_runes", "emoji_only", "bots", "deny", "allow", "duplicate", "flood", "languages"}
+
+// Set changes one setting from a bot command argument, e.g.
+//   min_runes 15 | emoji_only off | bots on | deny <regex> | deny clear | allow <regex> |
+//   duplicate 600 | flood 5/60 | languages id,en | languages any
+func (c *Config) Set(key, value string) error {
+	value = strings.TrimSpace(value)
+	
+	switch key {
+	case "min_runes":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("min_runes must be a number >= 0")
+		}
+		c.MinRunes = n
+	case "emoji_only":
+		on, err := parseSwitch(value)
+		if err != nil {
+			return err
+		}
+		c.DropEmojiOnly = on
+	case "bots":
+		on, err := parseSwitch(value)
+		if err != nil {
+			return err
+		}
+		c.DropBots = on
+	case "deny", "allow":
+		patterns := &c.DenyPatterns
+		if key == "allow" {
+			patterns = &c.AllowPatterns
+		}
+		if value == "clear" {
+			*patterns = nil
+			return nil
+		}
+		if _, err := compilePatterns([]string{value}); err != nil {
+			return err
+		}
+		*patterns = append(*patterns, value)
+	case "duplicate":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("duplicate must be a window in seconds (0 = off)")
+		}
+		c.DuplicateWindow = n
+	case "flood":
+		if value == "off" || value == "0" {
+			c.FloodMaxMessages, c.FloodWindow = 0, 0
+			return nil
+		}
+		parts := strings.SplitN(value, "/", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("flood must be <messages>/<seconds>, e.g. 5/60")
+		}
+		max, err1 := strconv.Atoi(parts[0])
+		window, err2 := strconv.Atoi(parts[1])
+		if err1 != nil || err2 != nil || max <= 0 || window <= 0 {
+			return fmt.Errorf("flood must be <messages>/<seconds>, e.g. 5/60")
+		}
+		c.FloodMaxMessages, c.FloodWindow = max, window
+	case "languages":
+		if value == "any" || value == "" {
+			c.Languages = nil
+			return nil
+		}
+		var languages []string
+		for _, language := range strings.Split(value, ",") {
+			if language = strings.ToLower(strings.TrimSpace(language)); language != "" {
+				languages = append(languages, language)
+			}
+		}
+		c.Languages = languages
+	default:
+		return fmt.Errorf("unknown setting %q (use %s)", key, strings.Join(SettingKeys, ", "))
+	}
+	
+	return nil
+}
+
+// parseSwitch parses on/off values
+func parseSwitch(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "on", "true", "yes", "1":
+		return true, nil
+	case "off", "false", "no", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("value must be on or off")
+}
+
+// Describe returns a human-readable list of the active rules
+func (c Config) Describe() []string {
+	var lines []string
+	if c.DropBots {
+		lines = append(lines, "Drop bot senders")
+	}
+	if c.MinRunes > 0 {
+		lines = append(lines, fmt.Sprintf("Min length: %d characters (text-only)", c.MinRunes))
+	}
+	if c.DropEmojiOnly {
+		lines = append(lines, "Drop emoji-only messages")
+	}
+	for _, pattern := range c.DenyPatterns {
+		lines = append(lines, fmt.Sprintf("Deny: %s", pattern))
+	}
+	for _, pattern := range c.AllowPatterns {
+		lines = append(lines, fmt.Sprintf("Allow only: %s", pattern))
+	}
+	if len(c.Languages) > 0 {
+		lines = append(lines, fmt.Sprintf("Languages: %s", strings.Join(c.Languages, ", ")))
+	}
+	if c.DuplicateWindow > 0 {
+		lines = append(lines, fmt.Sprintf("Drop duplicates within %ds", c.DuplicateWindow))
+	}
+	if c.FloodMaxMessages > 0 && c.FloodWindow > 0 {
+		lines = append(lines, fmt.Sprintf("Flood limit: %d messages / %ds per user", c.FloodMaxMessages, c.FloodWindow))
+	}
+	return lines
+}
@@ -0,0 +1,57 @@
+package filter
+
+import (
+	"time"
+)
+
+// Message is what the ingest filters see of an incoming message.
+// Both the bot and the scraper build one before saving a message.
+type Message struct {
+	ChatID    int64
+	MessageID int64 // Telegram message ID (0 = unknown)
+	UserID    int64
+	Text      string // Message text or media caption
+	OCRText   string // Text recognized in an attached image, if it was recognized before filtering
+	HasMedia  bool
+	IsSticker bool
+	IsBot     bool
+	Timestamp time.Time
+}
+
+// Rule decides whether a message is kept
+type Rule interface {
+	// Name identifies the rule in drop counters and logs
+	Name() string
+	
+	// Allow returns false if the message must be dropped
+	Allow(msg *Message) bool
+}
+
+// Pipeline runs rules in order and stops at the first rule that drops the message
+type Pipeline struct {
+	rules []Rule
+}
+
+// NewPipeline creates a pipeline from rules
+func NewPipeline(rules ...Rule) *Pipeline {
+	return &Pipeline{rules: rules}
+}
+
+// Check returns true if the message passes all rules, otherwise false and the name of the rule that dropped it
+func (p *Pipeline) Check(msg *Message) (bool, string) {
+	for _, rule := range p.rules {
+		if !rule.Allow(msg) {
+			return false, rule.Name()
+		}
+	}
+	return true, ""
+}
+
+// Rules returns the names of the rules in the pipeline
+func (p *Pipeline) Rules() []string {
+	names := make([]string, len(p.rules))
+	for i, rule := range p.rules {
+		names[i] = rule.Name()
+	}
+	return names
+}
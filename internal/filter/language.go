@@ -0,0 +1,101 @@
+package filter
+
+import (
+	"strings"
+	"unicode"
+)
+
+// minLanguageLetters is the number of letters needed before a language is guessed
+const minLanguageLetters = 12
+
+// scriptLanguages maps non-Latin scripts to the language reported for them
+var scriptLanguages = []struct {
+	script   *unicode.RangeTable
+	language string
+}{
+	{unicode.Cyrillic, "ru"},
+	{unicode.Arabic, "ar"},
+	{unicode.Han, "zh"},
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Hangul, "ko"},
+	{unicode.Thai, "th"},
+}
+
+// Common words used to tell Indonesian and English apart (incl. chat slang)
+var (
+	indonesianWords = toSet("yang dan di ini itu ada tidak gak ga nggak bisa aja sudah udah " +
+		"untuk dengan kalau kalo apa mau juga lagi sama saya aku kak bang min gan dong " +
+		"sih kok ya belum masih pakai pake buat dari ke berapa harga paket kuota")
+	englishWords = toSet("the and is are to of it this that for with you not can have " +
+		"what how does do will would should there they we my your was were be just")
+)
+
+// DetectLanguage guesses the language of a text.
+// Returns an ISO 639-1 code ("id", "en", "ru", ...) or "" if unsure.
+func DetectLanguage(text string) string {
+	var letters, latin int
+	scriptCounts := make(map[string]int)
+	
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if unicode.Is(unicode.Latin, r) {
+			latin++
+			continue
+		}
+		for _, s := range scriptLanguages {
+			if unicode.Is(s.script, r) {
+				scriptCounts[s.language]++
+				break
+			}
+		}
+	}
+	
+	if letters < minLanguageLetters {
+		return ""
+	}
+	
+	// Mostly non-Latin: report the dominant script
+	if latin*2 < letters {
+		best, bestCount := "", 0
+		for language, count := range scriptCounts {
+			if count > bestCount {
+				best, bestCount = language, count
+			}
+		}
+		return best
+	}
+	
+	// Latin: count common words of each language
+	var id, en int
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		if indonesianWords[word] {
+			id++
+		}
+		if englishWords[word] {
+			en++
+		}
+	}
+	
+	switch {
+	case id > en:
+		return "id"
+	case en > id:
+		return "en"
+	}
+	return ""
+}
+
+// toSet splits words into a lookup set
+func toSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
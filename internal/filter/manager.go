@@ -0,0 +1,222 @@
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"telegram-summarizer/internal/db"
+	"telegram-summarizer/internal/logger"
+	"time"
+)
+
+// DefaultChatID is the chat ID under which the default configuration of all groups is stored
+const DefaultChatID = 0
+
+// configRefreshInterval is how often stored configs are re-read, so changes made by
+// another process (e.g. the bot while the scraper runs separately) are picked up
+const configRefreshInterval = 1 * time.Minute
+
+// verdictKeep is how long the outcome of a message is remembered, so the bot and the
+// scraper of one process receiving the same message run the rules on it only once
+const verdictKeep = 10 * time.Minute
+
+// Manager keeps one pipeline per group and records drop counters.
+// The bot and scraper share a Manager when running in the same process.
+type Manager struct {
+	db db.Store
+	
+	mu        sync.Mutex
+	pipelines map[int64]*cachedPipeline
+	
+	verdictMu   sync.Mutex
+	verdicts    map[messageKey]verdict // Outcome of recently checked messages
+	lastVerdict time.Time              // When verdicts were last pruned
+}
+
+// messageKey identifies a message of a supergroup or channel. Basic groups number
+// messages per account, so the bot's and the scraper's IDs of a message differ.
+type messageKey struct {
+	chatID    int64
+	messageID int64
+}
+
+// verdict is the outcome of checking a message
+type verdict struct {
+	kept      bool
+	checkedAt time.Time
+}
+
+// cachedPipeline is a built pipeline and the raw config it was built from
+type cachedPipeline struct {
+	raw       string
+	pipeline  *Pipeline
+	checkedAt time.Time
+}
+
+// NewManager creates a filter manager
+func NewManager(database db.Store) *Manager {
+	return &Manager{
+		db:        database,
+		pipelines: make(map[int64]*cachedPipeline),
+		verdicts:  make(map[messageKey]verdict),
+	}
+}
+
+// Check runs the group's pipeline on a message and counts drops per rule.
+// Returns true if the message should be saved. A message of a supergroup or channel
+// checked before gets the same outcome again, without running the duplicate and flood
+// rules or counting a drop a second time.
+func (m *Manager) Check(msg *Message) bool {
+	if msg.MessageID == 0 || !db.ChatID(msg.ChatID).IsChannel() {
+		return m.check(msg)
+	}
+	
+	key := messageKey{chatID: msg.ChatID, messageID: msg.MessageID}
+	m.verdictMu.Lock()
+	defer m.verdictMu.Unlock()
+	
+	now := time.Now()
+	m.pruneVerdicts(now)
+	if v, ok := m.verdicts[key]; ok {
+		logger.Debug("Message %d of chat %d already checked (kept: %v)", msg.MessageID, msg.ChatID, v.kept)
+		return v.kept
+	}
+	kept := m.check(msg)
+	m.verdicts[key] = verdict{kept: kept, checkedAt: now}
+	return kept
+}
+
+// pruneVerdicts forgets outcomes older than verdictKeep (at most once per verdictKeep).
+// The caller holds verdictMu.
+func (m *Manager) pruneVerdicts(now time.Time) {
+	if now.Sub(m.lastVerdict) < verdictKeep {
+		return
+	}
+	for key, v := range m.verdicts {
+		if now.Sub(v.checkedAt) >= verdictKeep {
+			delete(m.verdicts, key)
+		}
+	}
+	m.lastVerdict = now
+}
+
+// check runs the group's pipeline on a message and counts a drop
+func (m *Manager) check(msg *Message) bool {
+	kept, rule := m.pipeline(msg.ChatID).Check(msg)
+	if kept {
+		return true
+	}
+	
+	logger.Debug("Skipping message: dropped by %s filter (ChatID=%d, UserID=%d)", rule, msg.ChatID, msg.UserID)
+	
+	if err := m.db.IncrementFilterDrop(msg.ChatID, rule, msg.Timestamp); err != nil {
+		logger.Warn("Failed to count filter drop: %v", err)
+	}
+	return false
+}
+
+// pipeline returns the pipeline of a group, rebuilding it when its config changed.
+// Pipelines are kept while the config is unchanged so duplicate/flood state survives.
+func (m *Manager) pipeline(chatID int64) *Pipeline {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	
+	cached, ok := m.pipelines[chatID]
+	if ok && time.Since(cached.checkedAt) < configRefreshInterval {
+		return cached.pipeline
+	}
+	
+	raw, config := m.loadConfig(chatID)
+	if ok && cached.raw == raw {
+		cached.checkedAt = time.Now()
+		return cached.pipeline
+	}
+	
+	pipeline, err := config.Build()
+	if err != nil {
+		logger.Warn("Invalid filter config for chat %d, using defaults: %v", chatID, err)
+		pipeline, _ = DefaultConfig().Build()
+	}
+	
+	m.pipelines[chatID] = &cachedPipeline{raw: raw, pipeline: pipeline, checkedAt: time.Now()}
+	return pipeline
+}
+
+// loadConfig returns the raw stored config and the effective config of a group
+func (m *Manager) loadConfig(chatID int64) (string, Config) {
+	for _, id := range []int64{chatID, DefaultChatID} {
+		raw, found, err := m.db.GetFilterConfig(id)
+		if err != nil {
+			logger.Warn("Failed to load filter config: %v", err)
+			continue
+		}
+		if !found {
+			continue
+		}
+	
+		var config Config
+		if err := json.Unmarshal([]byte(raw), &config); err != nil {
+			logger.Warn("Invalid filter config JSON for chat %d: %v", id, err)
+			continue
+		}
+		return raw, config
+	}
+	return "", DefaultConfig()
+}
+
+// GetConfig returns the effective config of a group and whether it is group-specific
+func (m *Manager) GetConfig(chatID int64) (Config, bool) {
+	_, found, err := m.db.GetFilterConfig(chatID)
+	_, config := m.loadConfig(chatID)
+	return config, err == nil && found
+}
+
+// SetConfig validates and stores the config of a group (DefaultChatID for all groups)
+func (m *Manager) SetConfig(chatID int64, config Config) error {
+	if _, err := config.Build(); err != nil {
+		return err
+	}
+	
+	raw, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to encode filter config: %w", err)
+	}
+	
+	if err := m.db.SetFilterConfig(chatID, string(raw)); err != nil {
+		return err
+	}
+	
+	m.invalidate(chatID)
+	logger.Info("🧹 Filter config updated (ChatID=%d)", chatID)
+	return nil
+}
+
+// ResetConfig removes the config of a group so the default applies again
+func (m *Manager) ResetConfig(chatID int64) error {
+	if err := m.db.DeleteFilterConfig(chatID); err != nil {
+		return err
+	}
+	
+	m.invalidate(chatID)
+	logger.Info("🧹 Filter config reset (ChatID=%d)", chatID)
+	return nil
+}
+
+// invalidate drops cached pipelines affected by a config change
+func (m *Manager) invalidate(chatID int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	
+	if chatID == DefaultChatID {
+		// The default applies to every group without its own config
+		m.pipelines = make(map[int64]*cachedPipeline)
+		return
+	}
+	delete(m.pipelines, chatID)
+}
+
+// DropCounts returns the number of dropped messages per rule in the last days
+func (m *Manager) DropCounts(chatID int64, days int) (map[string]int, error) {
+	since := time.Now().AddDate(0, 0, -(days - 1))
+	return m.db.GetFilterDropCounts(chatID, since)
+}
@@ -0,0 +1,47 @@
+package filter
+
+import (
+	"path/filepath"
+	"telegram-summarizer/internal/db"
+	"testing"
+	"time"
+)
+
+func TestManagerChecksMessageOnce(t *testing.T) {
+	store, err := db.Open(db.DriverSQLite, filepath.Join(t.TempDir(), "filter.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer store.Close()
+	
+	manager := NewManager(store)
+	chatID := db.ChannelChatID(1234).Int64()
+	if err := manager.SetConfig(chatID, Config{DuplicateWindow: 60, FloodMaxMessages: 2, FloodWindow: 60}); err != nil {
+		t.Fatalf("SetConfig: %v", err)
+	}
+	
+	// The bot and the scraper both receive each message of a supergroup
+	now := time.Now()
+	message := func(messageID int64, text string) *Message {
+		return &Message{ChatID: chatID, MessageID: messageID, UserID: 1, Text: text, Timestamp: now}
+	}
+	for _, msg := range []*Message{message(1, "first"), message(1, "first"), message(2, "second"), message(2, "second")} {
+		if !manager.Check(msg) {
+			t.Fatalf("message %d dropped, want the same message counted once by the duplicate and flood rules", msg.MessageID)
+		}
+	}
+	
+	// A third message is flood, and stays dropped when seen again
+	for i := 0; i < 2; i++ {
+		if manager.Check(message(3, "third")) {
+			t.Fatal("third message within the flood window kept")
+		}
+	}
+	counts, err := manager.DropCounts(chatID, 1)
+	if err != nil {
+		t.Fatalf("DropCounts: %v", err)
+	}
+	if len(counts) != 1 || counts[RuleFlood] != 1 {
+		t.Errorf("drop counts %v, want one flood drop", counts)
+	}
+}
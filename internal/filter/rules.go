@@ -0,0 +1,259 @@
+package filter
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Rule names, used as keys of the drop counters
+const (
+	RuleMinRunes  = "min_runes"
+	RuleEmojiOnly = "emoji_only"
+	RuleBotSender = "bot_sender"
+	RuleDeny      = "deny_regex"
+	RuleAllow     = "allow_regex"
+	RuleDuplicate = "duplicate"
+	RuleFlood     = "flood"
+	RuleLanguage  = "language"
+)
+
+// MinRunesRule drops messages shorter than Min characters.
+// Media with a caption or OCR text is kept since a photo with a short caption is still
+// content; stickers and media without any text are not.
+type MinRunesRule struct {
+	Min int
+}
+
+// Name implements Rule
+func (r MinRunesRule) Name() string { return RuleMinRunes }
+
+// Allow implements Rule
+func (r MinRunesRule) Allow(msg *Message) bool {
+	if hasTextMedia(msg) {
+		return true
+	}
+	return utf8.RuneCountInString(strings.TrimSpace(msg.Text)) >= r.Min
+}
+
+// EmojiOnlyRule drops messages without any letter or digit, including stickers.
+// Media with a caption or OCR text is kept.
+type EmojiOnlyRule struct{}
+
+// Name implements Rule
+func (EmojiOnlyRule) Name() string { return RuleEmojiOnly }
+
+// Allow implements Rule
+func (EmojiOnlyRule) Allow(msg *Message) bool {
+	if hasTextMedia(msg) {
+		return true
+	}
+	return !IsOnlyEmoji(msg.Text)
+}
+
+// hasTextMedia reports whether a message has media other than a sticker that comes
+// with a caption or OCR text
+func hasTextMedia(msg *Message) bool {
+	if !msg.HasMedia || msg.IsSticker {
+		return false
+	}
+	return strings.TrimSpace(msg.Text) != "" || strings.TrimSpace(msg.OCRText) != ""
+}
+
+// IsOnlyEmoji checks if text contains only emoji, symbols and punctuation
+func IsOnlyEmoji(text string) bool {
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// BotSenderRule drops messages sent by bots
+type BotSenderRule struct{}
+
+// Name implements Rule
+func (BotSenderRule) Name() string { return RuleBotSender }
+
+// Allow implements Rule
+func (BotSenderRule) Allow(msg *Message) bool {
+	return !msg.IsBot
+}
+
+// DenyRule drops messages matching any of the patterns (e.g. spam templates)
+type DenyRule struct {
+	Patterns []*regexp.Regexp
+}
+
+// Name implements Rule
+func (r DenyRule) Name() string { return RuleDeny }
+
+// Allow implements Rule
+func (r DenyRule) Allow(msg *Message) bool {
+	for _, pattern := range r.Patterns {
+		if pattern.MatchString(msg.Text) {
+			return false
+		}
+	}
+	return true
+}
+
+// AllowRule keeps only messages matching at least one of the patterns
+type AllowRule struct {
+	Patterns []*regexp.Regexp
+}
+
+// Name implements Rule
+func (r AllowRule) Name() string { return RuleAllow }
+
+// Allow implements Rule
+func (r AllowRule) Allow(msg *Message) bool {
+	for _, pattern := range r.Patterns {
+		if pattern.MatchString(msg.Text) {
+			return true
+		}
+	}
+	return false
+}
+
+// DuplicateRule drops a text a user already sent to the same chat within Window
+type DuplicateRule struct {
+	Window time.Duration
+	
+	mu        sync.Mutex
+	seen      map[string]time.Time
+	lastPrune time.Time
+}
+
+// NewDuplicateRule creates a duplicate detector
+func NewDuplicateRule(window time.Duration) *DuplicateRule {
+	return &DuplicateRule{
+		Window: window,
+		seen:   make(map[string]time.Time),
+	}
+}
+
+// Name implements Rule
+func (r *DuplicateRule) Name() string { return RuleDuplicate }
+
+// Allow implements Rule
+func (r *DuplicateRule) Allow(msg *Message) bool {
+	text := strings.ToLower(strings.Join(strings.Fields(msg.Text), " "))
+	if text == "" {
+		return true
+	}
+	
+	key := strconv.FormatInt(msg.ChatID, 10) + ":" + strconv.FormatInt(msg.UserID, 10) + ":" + text
+	
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	
+	r.prune(msg.Timestamp)
+	
+	if last, ok := r.seen[key]; ok && msg.Timestamp.Sub(last) < r.Window {
+		return false
+	}
+	r.seen[key] = msg.Timestamp
+	return true
+}
+
+// prune forgets texts older than the window (at most once per window)
+func (r *DuplicateRule) prune(now time.Time) {
+	if now.Sub(r.lastPrune) < r.Window {
+		return
+	}
+	for key, last := range r.seen {
+		if now.Sub(last) >= r.Window {
+			delete(r.seen, key)
+		}
+	}
+	r.lastPrune = now
+}
+
+// FloodRule drops messages of a user beyond MaxMessages per Window in the same chat
+type FloodRule struct {
+	MaxMessages int
+	Window      time.Duration
+	
+	mu        sync.Mutex
+	recent    map[string][]time.Time
+	lastPrune time.Time
+}
+
+// NewFloodRule creates a flood detector
+func NewFloodRule(maxMessages int, window time.Duration) *FloodRule {
+	return &FloodRule{
+		MaxMessages: maxMessages,
+		Window:      window,
+		recent:      make(map[string][]time.Time),
+	}
+}
+
+// Name implements Rule
+func (r *FloodRule) Name() string { return RuleFlood }
+
+// Allow implements Rule
+func (r *FloodRule) Allow(msg *Message) bool {
+	key := strconv.FormatInt(msg.ChatID, 10) + ":" + strconv.FormatInt(msg.UserID, 10)
+	
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	
+	r.prune(msg.Timestamp)
+	
+	times := r.recent[key]
+	kept := times[:0]
+	for _, t := range times {
+		if msg.Timestamp.Sub(t) < r.Window {
+			kept = append(kept, t)
+		}
+	}
+	
+	if len(kept) >= r.MaxMessages {
+		r.recent[key] = kept
+		return false
+	}
+	r.recent[key] = append(kept, msg.Timestamp)
+	return true
+}
+
+// prune forgets users without messages in the window (at most once per window)
+func (r *FloodRule) prune(now time.Time) {
+	if now.Sub(r.lastPrune) < r.Window {
+		return
+	}
+	for key, times := range r.recent {
+		if len(times) == 0 || now.Sub(times[len(times)-1]) >= r.Window {
+			delete(r.recent, key)
+		}
+	}
+	r.lastPrune = now
+}
+
+// LanguageRule keeps only messages in one of the Languages.
+// Messages whose language can't be detected (short texts, media) are kept.
+type LanguageRule struct {
+	Languages []string
+}
+
+// Name implements Rule
+func (r LanguageRule) Name() string { return RuleLanguage }
+
+// Allow implements Rule
+func (r LanguageRule) Allow(msg *Message) bool {
+	language := DetectLanguage(msg.Text)
+	if language == "" {
+		return true
+	}
+	for _, allowed := range r.Languages {
+		if allowed == language {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package filter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMediaBypass(t *testing.T) {
+	tests := []struct {
+		name      string
+		msg       Message
+		minRunes  bool
+		emojiOnly bool
+	}{
+		{"short text", Message{Text: "ok"}, false, true},
+		{"emoji text", Message{Text: "🔥🔥🔥🔥🔥"}, true, false},
+		{"photo with short caption", Message{Text: "ok", HasMedia: true}, true, true},
+		{"photo with emoji caption", Message{Text: "🔥", HasMedia: true}, true, true},
+		{"photo without caption", Message{HasMedia: true}, false, false},
+		{"photo with blank caption", Message{Text: "  ", HasMedia: true}, false, false},
+		{"photo with OCR text", Message{OCRText: "Download 54.2 Mbps", HasMedia: true}, true, true},
+		{"sticker", Message{HasMedia: true, IsSticker: true}, false, false},
+		{"sticker with emoji", Message{Text: "😂", HasMedia: true, IsSticker: true}, false, false},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (MinRunesRule{Min: 5}).Allow(&tt.msg); got != tt.minRunes {
+				t.Errorf("MinRunesRule.Allow() = %v, want %v", got, tt.minRunes)
+			}
+			if got := (EmojiOnlyRule{}).Allow(&tt.msg); got != tt.emojiOnly {
+				t.Errorf("EmojiOnlyRule.Allow() = %v, want %v", got, tt.emojiOnly)
+			}
+		})
+	}
+}
+
+func TestDuplicateRule(t *testing.T) {
+	rule := NewDuplicateRule(time.Minute)
+	start := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		msg  Message
+		want bool
+	}{
+		{"first text", Message{ChatID: 1, UserID: 1, Text: "Promo kuota 50GB", Timestamp: start}, true},
+		{"same text", Message{ChatID: 1, UserID: 1, Text: "promo  KUOTA 50gb", Timestamp: start.Add(10 * time.Second)}, false},
+		{"other user", Message{ChatID: 1, UserID: 2, Text: "Promo kuota 50GB", Timestamp: start.Add(20 * time.Second)}, true},
+		{"other chat", Message{ChatID: 2, UserID: 1, Text: "Promo kuota 50GB", Timestamp: start.Add(30 * time.Second)}, true},
+		{"after the window", Message{ChatID: 1, UserID: 1, Text: "Promo kuota 50GB", Timestamp: start.Add(2 * time.Minute)}, true},
+		{"media without text", Message{ChatID: 1, UserID: 1, HasMedia: true, Timestamp: start.Add(2 * time.Minute)}, true},
+		{"media without text again", Message{ChatID: 1, UserID: 1, HasMedia: true, Timestamp: start.Add(2 * time.Minute)}, true},
+	}
+	for _, tt := range tests {
+		if got := rule.Allow(&tt.msg); got != tt.want {
+			t.Errorf("%s: DuplicateRule.Allow() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFloodRule(t *testing.T) {
+	rule := NewFloodRule(3, time.Minute)
+	start := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	at := func(userID int64, seconds int) *Message {
+		return &Message{ChatID: 1, UserID: userID, Text: "hello", Timestamp: start.Add(time.Duration(seconds) * time.Second)}
+	}
+	
+	for i, seconds := range []int{0, 10, 20} {
+		if !rule.Allow(at(1, seconds)) {
+			t.Errorf("message %d within the limit was dropped", i+1)
+		}
+	}
+	if rule.Allow(at(1, 30)) {
+		t.Error("fourth message within a minute was kept")
+	}
+	if !rule.Allow(at(2, 30)) {
+		t.Error("message of another user was dropped")
+	}
+	// The first message left the window, so there is room for one more
+	if !rule.Allow(at(1, 61)) {
+		t.Error("message after the first left the window was dropped")
+	}
+	if rule.Allow(at(1, 62)) {
+		t.Error("message beyond the limit was kept")
+	}
+}
+
+func TestPatternRules(t *testing.T) {
+	pipeline, err := Config{DenyPatterns: []string{`t\.me/joinchat`, `^free crypto`}, AllowPatterns: []string{`kuota|paket`}}.Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	tests := []struct {
+		text string
+		rule string // Rule dropping the message ("" = kept)
+	}{
+		{"Paket kuota 50GB murah", ""},
+		{"PAKET KUOTA promo", ""}, // Patterns are case-insensitive
+		{"Paket gratis t.me/joinchat/abc", RuleDeny},
+		{"Free crypto paket", RuleDeny},
+		{"Selamat pagi semua", RuleAllow},
+	}
+	for _, tt := range tests {
+		kept, rule := pipeline.Check(&Message{Text: tt.text})
+		if kept != (tt.rule == "") || rule != tt.rule {
+			t.Errorf("%q: kept %v by %q, want dropped by %q", tt.text, kept, rule, tt.rule)
+		}
+	}
+	
+	if _, err := (Config{DenyPatterns: []string{"("}}).Build(); err == nil {
+		t.Error("Build accepted an invalid pattern")
+	}
+}
+
+func TestLanguageRule(t *testing.T) {
+	rule := LanguageRule{Languages: []string{"id"}}
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"Kak ini paket kuota yang mana ya, harga berapa?", true},
+		{"What is the price of this package and how does it work?", false},
+		{"Привет, сколько стоит этот пакет интернета?", false},
+		{"ok", true}, // Too short to tell
+		{"", true},
+	}
+	for _, tt := range tests {
+		if got := rule.Allow(&Message{Text: tt.text}); got != tt.want {
+			t.Errorf("%q (detected %q): LanguageRule.Allow() = %v, want %v", tt.text, DetectLanguage(tt.text), got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// Package leader elects one of several instances sharing a database to run the work
+// only one of them may do, such as scheduling summaries and polling bot updates.
+// The leader holds a lease in the database and renews it as a heartbeat; standby
+// instances take over once it expires.
+package leader
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"telegram-summarizer/internal/db"
+	"telegram-summarizer/internal/logger"
+	"time"
+)
+
+// DefaultLease is how long a lease lasts without a heartbeat unless set otherwise
+const DefaultLease = 30 * time.Second
+
+// Elector campaigns for the lease of a role on behalf of one instance
+type Elector struct {
+	store  db.Store
+	name   string        // Role of the lease, e.g. "bot"
+	holder string        // Instance ID
+	ttl    time.Duration // Lease duration
+}
+
+// NewElector creates an elector for a role. The lease is renewed every third of ttl;
+// a leader that cannot renew it steps down before it expires.
+func NewElector(store db.Store, name, holder string, ttl time.Duration) *Elector {
+	if ttl <= 0 {
+		ttl = DefaultLease
+	}
+	return &Elector{
+		store:  store,
+		name:   name,
+		holder: holder,
+		ttl:    ttl,
+	}
+}
+
+// Lease returns how long a lease lasts without a heartbeat
+func (e *Elector) Lease() time.Duration {
+	return e.ttl
+}
+
+// InstanceID returns a default ID of this instance: its host name and process ID
+func InstanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// Run campaigns for the lease until ctx is done. While this instance holds the lease,
+// lead runs with a context that is cancelled when the lease is lost; once lead has
+// returned the instance stands by again. The lease is released when ctx is done, so
+// a standby takes over without waiting for it to expire.
+func (e *Elector) Run(ctx context.Context, lead func(ctx context.Context)) {
+	interval := e.ttl / 3
+	logger.Info("🗳️  Campaigning for %s lease as %s (lease %s)", e.name, e.holder, e.ttl)
+	
+	var standby string
+	for {
+		now := time.Now()
+		acquired, err := e.store.AcquireLease(e.name, e.holder, now, e.ttl)
+		if err != nil {
+			logger.Error("Failed to acquire %s lease: %v", e.name, err)
+		}
+		if acquired {
+			standby = ""
+			e.lead(ctx, now, lead)
+			if ctx.Err() != nil {
+				e.release()
+				return
+			}
+		} else if err == nil {
+			// Log the leader once per leader
+			if lease, err := e.store.GetLease(e.name); err == nil && lease != nil && lease.Holder != standby {
+				standby = lease.Holder
+				logger.Info("⏸️  Standing by: %s is the %s leader since %s", lease.Holder, e.name,
+					lease.AcquiredAt.Local().Format("2006-01-02 15:04:05"))
+			}
+		}
+	
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// lead runs lead while renewing the lease acquired at renewed, and stops it when the
+// lease is lost or ctx is done
+func (e *Elector) lead(ctx context.Context, renewed time.Time, lead func(ctx context.Context)) {
+	logger.Info("👑 %s became the %s leader", e.holder, e.name)
+	
+	leadCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		lead(leadCtx)
+	}()
+	
+	interval := e.ttl / 3
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case <-ticker.C:
+			now := time.Now()
+			acquired, err := e.store.AcquireLease(e.name, e.holder, now, e.ttl)
+			switch {
+			case acquired:
+				renewed = now
+				continue
+			case err == nil:
+				logger.Warn("⚠️  %s lease was taken over, stepping down", e.name)
+			case time.Since(renewed) < e.ttl-interval:
+				logger.Warn("⚠️  Failed to renew %s lease, retrying: %v", e.name, err)
+				continue
+			default:
+				// Step down before the lease expires, so two leaders never overlap
+				logger.Error("❌ Could not renew %s lease since %s, stepping down: %v", e.name,
+					renewed.Format("15:04:05"), err)
+			}
+		case <-done:
+			if ctx.Err() == nil {
+				logger.Warn("⚠️  %s leader work stopped, releasing lease", e.name)
+				e.release()
+			}
+			return
+		case <-ctx.Done():
+		}
+	
+		cancel()
+		<-done
+		logger.Info("⏸️  %s is no longer the %s leader", e.holder, e.name)
+		return
+	}
+}
+
+// release gives up the lease
+func (e *Elector) release() {
+	if err := e.store.ReleaseLease(e.name, e.holder); err != nil {
+		logger.Error("Failed to release %s lease: %v", e.name, err)
+	}
+}
@@ -0,0 +1,111 @@
+package leader
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"telegram-summarizer/internal/db"
+	"testing"
+	"time"
+)
+
+// unreachableStore is a store whose leases fail while down, like the database of an
+// instance cut off by a network partition
+type unreachableStore struct {
+	db.Store
+	down atomic.Bool
+}
+
+// AcquireLease fails while the store is down
+func (s *unreachableStore) AcquireLease(name, holder string, now time.Time, ttl time.Duration) (bool, error) {
+	if s.down.Load() {
+		return false, fmt.Errorf("database unreachable")
+	}
+	return s.Store.AcquireLease(name, holder, now, ttl)
+}
+
+func TestElectorFailover(t *testing.T) {
+	const ttl = 1500 * time.Millisecond
+	const name = "failover"
+	store, err := db.Open(db.DriverSQLite, filepath.Join(t.TempDir(), "leader.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer store.Close()
+	
+	// Two in-process instances record when they lead
+	var mu sync.Mutex
+	leading := make(map[string]bool)
+	overlap := false
+	lead := func(holder string) func(ctx context.Context) {
+		return func(ctx context.Context) {
+			mu.Lock()
+			overlap = overlap || len(leading) > 0
+			leading[holder] = true
+			mu.Unlock()
+	
+			<-ctx.Done()
+			mu.Lock()
+			delete(leading, holder)
+			mu.Unlock()
+		}
+	}
+	leaderIs := func(holder string, within time.Duration) bool {
+		for deadline := time.Now().Add(within); ; time.Sleep(20 * time.Millisecond) {
+			mu.Lock()
+			only := leading[holder] && len(leading) == 1
+			mu.Unlock()
+			if only || time.Now().After(deadline) {
+				return only
+			}
+		}
+	}
+	run := func(store db.Store, holder string) (stop func()) {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			NewElector(store, name, holder, ttl).Run(ctx, lead(holder))
+		}()
+		return func() {
+			cancel()
+			<-done
+		}
+	}
+	
+	partitioned := &unreachableStore{Store: store}
+	stopA := run(partitioned, "a")
+	if !leaderIs("a", ttl) {
+		t.Errorf("Elector: first instance did not become the leader")
+		stopA()
+		return
+	}
+	stopB := run(store, "b")
+	time.Sleep(ttl)
+	if !leaderIs("a", 0) {
+		t.Errorf("Elector: standby took over a renewed lease")
+	}
+	
+	// The leader loses the database: it steps down and the standby takes over
+	partitioned.down.Store(true)
+	if !leaderIs("b", 2*ttl) {
+		t.Errorf("Elector: standby did not take over an expired lease")
+	}
+	partitioned.down.Store(false)
+	
+	// The leader shuts down: its lease is released and taken over before it expires
+	stopB()
+	if !leaderIs("a", ttl/2) {
+		t.Errorf("Elector: released lease not taken over at once")
+	}
+	stopA()
+	
+	if overlap {
+		t.Errorf("Elector: two instances led at the same time")
+	}
+	if lease, err := store.GetLease(name); err != nil || lease == nil || lease.ExpiresAt.After(time.Now()) {
+		t.Errorf("Elector: lease %+v (err=%v) not released on shutdown", lease, err)
+	}
+}
@@ -2,6 +2,7 @@ package logger
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"time"
@@ -33,6 +34,13 @@ func Init(debugMode bool) {
 	}
 }
 
+// SetOutput sets where the default logger writes (stdout unless set)
+func SetOutput(w io.Writer) {
+	if defaultLogger != nil {
+		defaultLogger.logger.SetOutput(w)
+	}
+}
+
 // formatMessage formats a log message with timestamp and level
 func (l *Logger) formatMessage(level LogLevel, message string) string {
 	timestamp := time.Now().Format("2006-01-02 15:04:05")
@@ -131,6 +139,14 @@ func SendSummaryNotification(groupName, summary string) {
 	}
 }
 
+// SendAlertNotification sends an alert that needs admin attention to Telegram
+func SendAlertNotification(title, message string) {
+	notifier := GetTelegramNotifier()
+	if notifier != nil {
+		notifier.SendAlert(title, message)
+	}
+}
+
 // FlushTelegramLogs flushes any buffered Telegram logs
 func FlushTelegramLogs() {
 	notifier := GetTelegramNotifier()
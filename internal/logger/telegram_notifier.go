@@ -104,6 +104,18 @@ func (tn *TelegramNotifier) SendSummary(groupName, summary string) {
 	go tn.sendMessage(message)
 }
 
+// SendAlert sends an alert that needs admin attention (immediate, not buffered)
+func (tn *TelegramNotifier) SendAlert(title, message string) {
+	if tn == nil || !tn.enabled {
+		return
+	}
+
+	// Falls back to plain text in sendSingleMessage if the message breaks Markdown
+	text := fmt.Sprintf("🚨 *%s*\n\n%s", title, message)
+
+	go tn.sendMessage(text)
+}
+
 // flushLogs sends buffered logs to Telegram
 func (tn *TelegramNotifier) flushLogs() {
 	if len(tn.buffer) == 0 {
@@ -0,0 +1,142 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"telegram-summarizer/internal/logger"
+	"time"
+)
+
+const (
+	// MaxImageSize is the largest image (in bytes) sent to the OCR engine
+	MaxImageSize = 5 * 1024 * 1024
+	
+	// maxTextLength caps the OCR text stored per image
+	maxTextLength = 1000
+	
+	// defaultTimeout is the default time limit of a single OCR run
+	defaultTimeout = 30 * time.Second
+	
+	// fileArgPlaceholder is replaced by the image path in the command arguments
+	fileArgPlaceholder = "{file}"
+)
+
+// Engine extracts text from images (e.g. speed-test screenshots)
+type Engine interface {
+	// ExtractText returns the text found in an image
+	ExtractText(ctx context.Context, image []byte) (string, error)
+	
+	// GetName returns the name of the engine
+	GetName() string
+}
+
+// CommandEngine runs a local OCR command such as tesseract.
+// The image is written to a temp file whose path replaces {file} in Args, or is
+// appended to them if none has it; the recognized text is read from stdout.
+type CommandEngine struct {
+	Command string
+	Args    []string
+	Timeout time.Duration
+}
+
+// NewCommandEngine creates an engine from a command line like "tesseract {file} - -l ind+eng"
+func NewCommandEngine(commandLine string) (*CommandEngine, error) {
+	fields := strings.Fields(commandLine)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty OCR command")
+	}
+	
+	if _, err := exec.LookPath(fields[0]); err != nil {
+		return nil, fmt.Errorf("OCR command %q not found: %w", fields[0], err)
+	}
+	
+	return &CommandEngine{
+		Command: fields[0],
+		Args:    fields[1:],
+		Timeout: defaultTimeout,
+	}, nil
+}
+
+// NewFromEnv creates the engine configured by OCR_COMMAND.
+// Returns nil (OCR disabled) when the variable is unset or the command is unavailable.
+func NewFromEnv() Engine {
+	commandLine := os.Getenv("OCR_COMMAND")
+	if commandLine == "" {
+		logger.Debug("OCR disabled (OCR_COMMAND not set)")
+		return nil
+	}
+	
+	engine, err := NewCommandEngine(commandLine)
+	if err != nil {
+		logger.Warn("OCR disabled: %v", err)
+		return nil
+	}
+	
+	logger.Info("✅ OCR enabled: %s", engine.GetName())
+	return engine
+}
+
+// ExtractText implements Engine
+func (e *CommandEngine) ExtractText(ctx context.Context, image []byte) (string, error) {
+	if len(image) > MaxImageSize {
+		return "", fmt.Errorf("image too large for OCR (%d bytes)", len(image))
+	}
+	
+	file, err := os.CreateTemp("", "ocr-*.img")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	
+	if _, err := file.Write(image); err != nil {
+		file.Close()
+		return "", fmt.Errorf("failed to write temp file: %w", err)
+	}
+	file.Close()
+	
+	timeout := e.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	
+	args := make([]string, 0, len(e.Args)+1)
+	hasFile := false
+	for _, arg := range e.Args {
+		hasFile = hasFile || strings.Contains(arg, fileArgPlaceholder)
+		args = append(args, strings.ReplaceAll(arg, fileArgPlaceholder, file.Name()))
+	}
+	if !hasFile {
+		args = append(args, file.Name())
+	}
+	
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, e.Command, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("OCR command failed: %w (%s)", err, strings.TrimSpace(stderr.String()))
+	}
+	
+	return CleanText(stdout.String()), nil
+}
+
+// GetName implements Engine
+func (e *CommandEngine) GetName() string {
+	return e.Command
+}
+
+// CleanText collapses whitespace in OCR output and truncates it
+func CleanText(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > maxTextLength {
+		text = string(runes[:maxTextLength]) + "..."
+	}
+	return text
+}
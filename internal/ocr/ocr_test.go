@@ -0,0 +1,25 @@
+package ocr
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCommandEngineFileArgument(t *testing.T) {
+	for _, commandLine := range []string{"cat {file}", "cat"} {
+		t.Run(commandLine, func(t *testing.T) {
+			engine, err := NewCommandEngine(commandLine)
+			if err != nil {
+				t.Skipf("command unavailable: %v", err)
+			}
+			
+			text, err := engine.ExtractText(context.Background(), []byte("Download  54.2\nMbps"))
+			if err != nil {
+				t.Fatalf("ExtractText() error = %v", err)
+			}
+			if text != "Download 54.2 Mbps" {
+				t.Errorf("ExtractText() = %q, want the image passed to the command", text)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package scheduler
+
+import (
+	"telegram-summarizer/internal/db"
+	"telegram-summarizer/internal/logger"
+	"telegram-summarizer/internal/timezone"
+	"time"
+)
+
+// windowTick is how often the hourly scheduler plans the windows of groups. Busy
+// groups' windows end on ticks; all other windows end on full hours.
+const windowTick = 5 * time.Minute
+
+// windowPlan is the next summary window of a group
+type windowPlan struct {
+	start, end  time.Time
+	messages    int  // Messages in the window so far
+	busy        bool // The group is busy and gets a sub-hour window
+	ready       bool // The window is summarized now; otherwise it keeps accumulating
+	minMessages int  // Messages the job needs (0 = the group's minimum)
+}
+
+// planWindow decides the window a group summarizes at now according to its window
+// policy. Windows start where the last one ended. Quiet groups keep a window open
+// (recorded as deferred) until it has the minimum of messages, reaches the maximum
+// age or midnight; busy groups close a window every BusyWindowMinutes. Returns nil
+// if no window ends at now.
+func (s *Scheduler) planWindow(group db.TrackedGroup, now time.Time) (*windowPlan, error) {
+	policy := group.Windows.Effective()
+	loc := timezone.Of(group)
+	plan := &windowPlan{end: timezone.HourStart(now, loc)}
+	
+	if policy.BusyMessages > 0 {
+		step := time.Duration(policy.BusyWindowMinutes) * time.Minute
+		subEnd := plan.end.Add(now.Sub(plan.end) / step * step)
+		count, err := s.database.CountMessages(group.ChatID, subEnd.Add(-time.Hour), subEnd)
+		if err != nil {
+			return nil, err
+		}
+		if count >= policy.BusyMessages {
+			plan.end, plan.busy = subEnd, true
+		}
+	}
+	
+	// Windows last at most a day, so the last day shows where the next one starts
+	windows, err := s.database.GetSummaryWindows(group.ChatID, plan.end.Add(-25*time.Hour), plan.end.Add(time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	var last time.Time
+	var deferred *db.SummaryWindow
+	for i, window := range windows {
+		switch {
+		case window.SummaryType != "1h":
+		case window.Status == db.WindowDeferred:
+			deferred = &windows[i]
+		case window.WindowEnd.After(last):
+			last = window.WindowEnd
+		}
+	}
+	
+	plan.start = plan.end.Add(-time.Hour)
+	if last.After(plan.start) {
+		plan.start = last
+	}
+	accumulated := deferred != nil && !deferred.WindowStart.Before(last) && deferred.WindowStart.Before(plan.start)
+	if accumulated {
+		plan.start = deferred.WindowStart
+	}
+	if !plan.start.Before(plan.end) {
+		return nil, nil
+	}
+	
+	if plan.messages, err = s.database.CountMessages(group.ChatID, plan.start, plan.end); err != nil {
+		return nil, err
+	}
+	closing := plan.busy || !plan.end.Before(plan.start.Add(time.Duration(policy.MaxWindowHours)*time.Hour)) ||
+		plan.end.Equal(timezone.DayStart(plan.end, loc))
+	plan.ready = closing || plan.messages >= policy.MinMessages
+	if closing && accumulated && !plan.busy {
+		// A window that waited for messages is summarized with whatever it has
+		plan.minMessages = 1
+	}
+	return plan, nil
+}
+
+// closeDeferredWindow summarizes the open deferred window of a group up to endTime, so
+// the daily summary includes the messages it accumulated, and delivers it
+func (s *Scheduler) closeDeferredWindow(group db.TrackedGroup, endTime time.Time) {
+	windows, err := s.database.GetSummaryWindows(group.ChatID, endTime.Add(-25*time.Hour), endTime)
+	if err != nil {
+		logger.Error("Failed to get summary windows of %s: %v", group.GroupName, err)
+		return
+	}
+	var open *db.SummaryWindow
+	for i, window := range windows {
+		if window.SummaryType == "1h" {
+			open = &windows[i]
+		}
+	}
+	if open == nil || open.Status != db.WindowDeferred || !open.WindowStart.Before(endTime) {
+		return
+	}
+	
+	logger.Info("📥 Closing accumulated window of %s since %s (%d messages)", group.GroupName,
+		open.WindowStart.In(timezone.Of(group)).Format("15:04"), open.MessageCount)
+	text, err := s.summarizeWindow(group, "", "1h", open.WindowStart, endTime, 1)
+	if err != nil {
+		logger.Error("❌ Failed to close accumulated window of %s: %v", group.GroupName, err)
+		return
+	}
+	if text != "" {
+		s.deliver(group, db.CadenceHourly, text)
+	}
+}
@@ -0,0 +1,141 @@
+package scheduler
+
+import (
+	"path/filepath"
+	"telegram-summarizer/internal/db"
+	"testing"
+	"time"
+)
+
+// newTestScheduler creates a scheduler on a temporary SQLite store and a group tracked
+// in Asia/Jakarta with a window policy
+func newTestScheduler(t *testing.T, policy db.WindowPolicy) (*Scheduler, db.TrackedGroup) {
+	t.Helper()
+	store, err := db.Open(db.DriverSQLite, filepath.Join(t.TempDir(), "scheduler.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	
+	chatID := db.ChannelChatID(1234).Int64()
+	if err := store.AddTrackedGroup(chatID, "Test group", ""); err != nil {
+		t.Fatalf("AddTrackedGroup: %v", err)
+	}
+	group := store.GetTrackedGroup(chatID)
+	if group == nil {
+		t.Fatal("group is not tracked")
+	}
+	group.Timezone = "Asia/Jakarta"
+	group.Windows = policy
+	return NewScheduler(store, nil, nil), *group
+}
+
+// saveMessages saves a message of the group at each time, stored in UTC as the
+// scraper receives them
+func saveMessages(t *testing.T, s *Scheduler, group db.TrackedGroup, times ...time.Time) {
+	t.Helper()
+	for _, at := range times {
+		if _, err := s.database.SaveMessage(&db.Message{ChatID: group.ChatID, UserID: 1, Username: "user",
+			MessageText: "hello", Timestamp: at.UTC()}); err != nil {
+			t.Fatalf("SaveMessage: %v", err)
+		}
+	}
+}
+
+func TestQuietGroupIsDeferred(t *testing.T) {
+	s, group := newTestScheduler(t, db.WindowPolicy{MinMessages: 5, MaxWindowHours: 3})
+	wib := time.FixedZone("WIB", 7*3600)
+	at := func(hour, min int) time.Time {
+		return time.Date(2025, 3, 3, hour, min, 0, 0, wib)
+	}
+	saveMessages(t, s, group, at(9, 10), at(9, 40), at(10, 0), at(10, 20))
+	
+	steps := []struct {
+		now        time.Time
+		start      time.Time
+		messages   int
+		ready      bool
+		minMessage int
+	}{
+		{at(10, 0), at(9, 0), 2, false, 0},
+		{at(11, 0), at(9, 0), 4, false, 0},
+		{at(12, 0), at(9, 0), 4, true, 1}, // The window reached its maximum age
+	}
+	for _, step := range steps {
+		plan, err := s.planWindow(group, step.now)
+		if err != nil {
+			t.Fatalf("planWindow at %s: %v", step.now.Format("15:04"), err)
+		}
+		if plan == nil {
+			t.Fatalf("planWindow at %s: no window", step.now.Format("15:04"))
+		}
+		if !plan.start.Equal(step.start) || !plan.end.Equal(step.now) || plan.messages != step.messages ||
+			plan.ready != step.ready || plan.busy || plan.minMessages != step.minMessage {
+			t.Fatalf("planWindow at %s: %s-%s, %d messages, ready %v, busy %v, min %d; want %s-%s, %d messages, ready %v, min %d",
+				step.now.Format("15:04"), plan.start.In(wib).Format("15:04"), plan.end.In(wib).Format("15:04"), plan.messages,
+				plan.ready, plan.busy, plan.minMessages, step.start.Format("15:04"), step.now.Format("15:04"), step.messages,
+				step.ready, step.minMessage)
+		}
+		if !plan.ready {
+			s.recordWindow(group, "1h", plan.start, plan.end, db.WindowDeferred, plan.messages)
+		}
+	}
+}
+
+func TestBusyGroupIsSplit(t *testing.T) {
+	s, group := newTestScheduler(t, db.WindowPolicy{BusyMessages: 30, BusyWindowMinutes: 15})
+	wib := time.FixedZone("WIB", 7*3600)
+	at := func(hour, min int) time.Time {
+		return time.Date(2025, 3, 3, hour, min, 0, 0, wib)
+	}
+	var times []time.Time
+	for min := 20; min < 60; min++ {
+		times = append(times, at(9, min))
+	}
+	times = append(times, at(10, 0), at(10, 5), at(10, 15), at(10, 20))
+	saveMessages(t, s, group, times...)
+	
+	steps := []struct {
+		now        time.Time
+		start, end time.Time
+		messages   int
+	}{
+		{at(10, 17), at(9, 15), at(10, 15), 42},
+		{at(10, 32), at(10, 15), at(10, 30), 2}, // The message at 10:15 belongs to this window only
+	}
+	summarized := 0
+	for _, step := range steps {
+		plan, err := s.planWindow(group, step.now)
+		if err != nil {
+			t.Fatalf("planWindow at %s: %v", step.now.Format("15:04"), err)
+		}
+		if plan == nil || !plan.busy || !plan.ready {
+			t.Fatalf("planWindow at %s: %+v, want a busy window", step.now.Format("15:04"), plan)
+		}
+		if !plan.start.Equal(step.start) || !plan.end.Equal(step.end) || plan.messages != step.messages {
+			t.Fatalf("planWindow at %s: %s-%s with %d messages, want %s-%s with %d", step.now.Format("15:04"),
+				plan.start.In(wib).Format("15:04"), plan.end.In(wib).Format("15:04"), plan.messages,
+				step.start.Format("15:04"), step.end.Format("15:04"), step.messages)
+		}
+	
+		messages, err := s.windowMessages(group.ChatID, plan.start, plan.end)
+		if err != nil {
+			t.Fatalf("windowMessages: %v", err)
+		}
+		if len(messages) != plan.messages {
+			t.Errorf("window %s-%s: %d messages summarized, %d counted", plan.start.In(wib).Format("15:04"),
+				plan.end.In(wib).Format("15:04"), len(messages), plan.messages)
+		}
+		summarized += len(messages)
+		s.recordWindow(group, "1h", plan.start, plan.end, db.WindowSummarized, len(messages))
+	}
+	if summarized != len(times) {
+		t.Errorf("%d messages summarized, want each of %d once", summarized, len(times))
+	}
+	
+	// The group is no longer busy, so the rest of the hour is summarized at 11:00
+	plan, err := s.planWindow(group, at(10, 47))
+	if err != nil || plan != nil {
+		t.Errorf("planWindow at 10:47: %+v (err %v), want none", plan, err)
+	}
+}
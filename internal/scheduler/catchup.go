@@ -0,0 +1,135 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+	"telegram-summarizer/internal/db"
+	"telegram-summarizer/internal/logger"
+	"telegram-summarizer/internal/timezone"
+	"time"
+)
+
+// recordWindow records a window of a group as completed, so it is not backfilled
+func (s *Scheduler) recordWindow(group db.TrackedGroup, summaryType string, startTime, endTime time.Time, status string, messageCount int) {
+	err := s.database.RecordSummaryWindow(&db.SummaryWindow{
+		ChatID:       group.ChatID,
+		SummaryType:  summaryType,
+		WindowStart:  startTime,
+		WindowEnd:    endTime,
+		Status:       status,
+		MessageCount: messageCount,
+		CompletedAt:  time.Now(),
+	})
+	if err != nil {
+		logger.Error("Failed to record %s window of %s: %v", summaryType, group.GroupName, err)
+	}
+}
+
+// enqueueMissedWindows queues backfill jobs for the hourly windows before now that were
+// never completed, e.g. because the bot was down. Windows before a group's first
+// completed window are not missed, and windows older than the group's message
+// retention are not queued. Backfilled summaries are stored for the daily summary but
+// not sent to hourly subscribers. Hours are those of each group's timezone.
+func (s *Scheduler) enqueueMissedWindows(now time.Time) {
+	queued := 0
+	
+	for _, group := range s.withoutSchedules(s.activeGroups()) {
+		until := timezone.HourStart(now, timezone.Of(group))
+		missed, err := s.missedHours(group, time.Time{}, until)
+		if err != nil {
+			logger.Error("Failed to find missed windows of %s: %v", group.GroupName, err)
+			continue
+		}
+		if len(missed) == 0 {
+			continue
+		}
+	
+		logger.Info("⏪ %s: %d missed hourly window(s) since %s", group.GroupName, len(missed), missed[0].Format("2006-01-02 15:04"))
+		for _, start := range missed {
+			if s.enqueueJob(&db.SummaryJob{
+				Kind:        db.JobBackfill,
+				ChatID:      group.ChatID,
+				SummaryType: "1h",
+				WindowStart: start,
+				WindowEnd:   start.Add(time.Hour),
+			}) {
+				queued++
+			}
+		}
+	}
+	
+	if queued > 0 {
+		logger.Info("⏪ Queued %d missed windows for backfill", queued)
+	}
+}
+
+// missedHours returns the starts of the full hours in [from, until) that no completed
+// window, deferred window still accumulating messages, or stored summary covers. The range starts no earlier than the group's first
+// completed window and the oldest message its retention policy keeps. Hours are those
+// of the group's timezone.
+func (s *Scheduler) missedHours(group db.TrackedGroup, from, until time.Time, summaries ...db.Summary) ([]time.Time, error) {
+	first, err := s.database.GetFirstSummaryWindow(group.ChatID)
+	if err != nil {
+		return nil, err
+	}
+	if first.IsZero() {
+		return nil, nil // Nothing recorded yet, so nothing was missed
+	}
+	if first.After(from) {
+		from = first
+	}
+	loc := timezone.Of(group)
+	if policy := s.retention.PolicyFor(group); policy.RetentionDays >= 0 {
+		if cutoff := until.Add(-time.Duration(policy.RetentionDays) * 24 * time.Hour); cutoff.After(from) {
+			from = cutoff
+		}
+	}
+	if start := timezone.HourStart(from, loc); start.Before(from) {
+		from = start.Add(time.Hour)
+	} else {
+		from = start
+	}
+	if !from.Before(until) {
+		return nil, nil
+	}
+	
+	windows, err := s.database.GetSummaryWindows(group.ChatID, from, until)
+	if err != nil {
+		return nil, err
+	}
+	
+	covered := make(map[int64]bool)
+	cover := func(start, end time.Time) {
+		for h := timezone.HourStart(start, loc); h.Before(end); h = h.Add(time.Hour) {
+			covered[h.Unix()] = true
+		}
+	}
+	for _, window := range windows {
+		cover(window.WindowStart, window.WindowEnd)
+	}
+	for _, summary := range summaries {
+		cover(summary.PeriodStart, summary.PeriodEnd)
+	}
+	
+	var missed []time.Time
+	for h := from; !h.Add(time.Hour).After(until); h = h.Add(time.Hour) {
+		if !covered[h.Unix()] {
+			missed = append(missed, h)
+		}
+	}
+	return missed, nil
+}
+
+// formatHourRanges formats hour starts as ranges of consecutive hours, e.g. "02:00-09:00, 13:00-14:00"
+func formatHourRanges(hours []time.Time) string {
+	var ranges []string
+	for i := 0; i < len(hours); {
+		j := i
+		for j+1 < len(hours) && hours[j+1].Equal(hours[j].Add(time.Hour)) {
+			j++
+		}
+		ranges = append(ranges, fmt.Sprintf("%s-%s", hours[i].Format("15:04"), hours[j].Add(time.Hour).Format("15:04")))
+		i = j + 1
+	}
+	return strings.Join(ranges, ", ")
+}
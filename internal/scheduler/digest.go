@@ -0,0 +1,70 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"telegram-summarizer/internal/db"
+	"telegram-summarizer/internal/logger"
+	"telegram-summarizer/internal/summarizer"
+	"telegram-summarizer/internal/timezone"
+	"time"
+)
+
+// enqueueDigest queues the cross-group digest of the 24 hours before now
+func (s *Scheduler) enqueueDigest(now time.Time) {
+	s.enqueueJob(&db.SummaryJob{
+		Kind:        db.JobDigest,
+		ChatID:      db.DigestChatID,
+		SummaryType: summarizer.PromptTypeDigest,
+		PromptType:  summarizer.PromptTypeDigest,
+		WindowStart: now.Add(-24 * time.Hour),
+		WindowEnd:   now,
+	})
+}
+
+// runDigest generates the cross-group digest of the active groups' latest daily
+// summaries and delivers it once to the digest subscribers. Returns an error if the
+// digest could not be generated, so its job is retried.
+func (s *Scheduler) runDigest(now time.Time) error {
+	logger.Info("🌐 Generating cross-group digest...")
+	
+	result, facts, err := s.summarizer.SummarizeDigest(s.activeGroups(), now)
+	if errors.Is(err, summarizer.ErrNoGroupSummaries) {
+		logger.Info("ℹ️  No daily summaries for the cross-group digest, skipping")
+		return nil
+	}
+	if result == nil {
+		return fmt.Errorf("failed to generate cross-group digest: %w", err)
+	}
+	if err != nil {
+		logger.Error("Failed to save cross-group digest: %v", err)
+	}
+	
+	logger.Info("✅ Cross-group digest generated from %d groups (%d products in several groups, %d cross-posts)",
+		facts.Groups, len(facts.Products), len(facts.CrossPosts))
+	
+	var response strings.Builder
+	response.WriteString("🌐 Cross-Group Daily Digest\n\n")
+	response.WriteString(fmt.Sprintf("📅 Date: %s (%s)\n", now.In(timezone.Default()).Format("2006-01-02"), timezone.Label(now.In(timezone.Default()))))
+	response.WriteString(fmt.Sprintf("👥 Groups: %d\n", facts.Groups))
+	response.WriteString(fmt.Sprintf("💬 Total Messages: %d\n", facts.TotalMessages))
+	response.WriteString("\n━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+	response.WriteString(result.Text())
+	response.WriteString("\n\n━━━━━━━━━━━━━━━━━━━━━━━\n")
+	response.WriteString("Generated by AI ✨")
+	
+	s.deliver(db.TrackedGroup{ChatID: db.DigestChatID, GroupName: summarizer.DigestName}, db.CadenceDaily, response.String())
+	return nil
+}
+
+// digestDue reports whether the daily run of a set of timezones is followed by the
+// cross-group digest, which runs with the daily summaries of the default timezone
+func digestDue(zones []string) bool {
+	for _, zone := range zones {
+		if zone == timezone.Default().String() {
+			return true
+		}
+	}
+	return false
+}
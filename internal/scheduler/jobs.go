@@ -0,0 +1,214 @@
+package scheduler
+
+import (
+	"fmt"
+	"telegram-summarizer/internal/db"
+	"telegram-summarizer/internal/logger"
+	"telegram-summarizer/internal/summarizer"
+	"time"
+)
+
+// Job queue settings
+const (
+	defaultWorkers  = 4                  // Jobs run at the same time unless set with SetWorkers
+	jobPollInterval = 15 * time.Second   // How often the pool looks for due jobs when idle
+	jobRetryBase    = time.Minute        // Delay before the first retry, doubled for each further one
+	jobRetryMax     = time.Hour          // Longest delay between retries
+	jobKeep         = 7 * 24 * time.Hour // How long succeeded jobs are kept
+)
+
+// enqueueJob adds a job to the queue and wakes the worker. Returns false if the job
+// was not added, e.g. because the same window is already queued.
+func (s *Scheduler) enqueueJob(job *db.SummaryJob) bool {
+	added, err := s.database.EnqueueJob(job)
+	if err != nil {
+		logger.Error("Failed to queue %s job for %d: %v", job.Kind, job.ChatID, err)
+		return false
+	}
+	if !added {
+		logger.Debug("%s job for %d (%s) already queued", job.Kind, job.ChatID, job.WindowStart.Format("2006-01-02 15:04"))
+		return false
+	}
+	
+	select {
+	case s.jobsCh <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// runJobPool runs due jobs on up to s.workers goroutines until the scheduler stops
+func (s *Scheduler) runJobPool() {
+	// A previous leader may still be finishing its jobs until the takeover delay ends
+	if s.takeover > 0 {
+		logger.Info("🔁 Waiting %s for the previous leader's jobs before requeueing them", s.takeover)
+		select {
+		case <-time.After(s.takeover):
+		case <-s.ctx.Done():
+			logger.Info("🔁 Job pool stopped")
+			return
+		}
+	}
+	
+	// Jobs left running by a previous process were interrupted
+	if reset, err := s.database.ResetRunningJobs(); err != nil {
+		logger.Error("Failed to reset interrupted jobs: %v", err)
+	} else if reset > 0 {
+		logger.Info("🔁 Requeued %d interrupted job(s)", reset)
+	}
+	logger.Info("🔁 Running summary jobs with %d worker(s)", s.workers)
+	
+	slots := make(chan struct{}, s.workers)
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+	
+	for {
+		// Start due jobs while workers are free
+		for {
+			select {
+			case slots <- struct{}{}:
+			case <-s.ctx.Done():
+				logger.Info("🔁 Job pool stopped")
+				return
+			}
+	
+			job, err := s.database.ClaimJob(time.Now())
+			if err != nil {
+				logger.Error("Failed to claim job: %v", err)
+			}
+			if job == nil {
+				<-slots
+				break
+			}
+	
+			s.spawn(func() {
+				defer func() { <-slots }()
+				s.runJob(job)
+			})
+		}
+	
+		select {
+		case <-ticker.C:
+		case <-s.jobsCh:
+		case <-s.ctx.Done():
+			logger.Info("🔁 Job pool stopped")
+			return
+		}
+	}
+}
+
+// runJob runs a claimed job and records the outcome. Failed jobs are retried with
+// exponential backoff until they run out of attempts.
+func (s *Scheduler) runJob(job *db.SummaryJob) {
+	logger.Info("🔁 Job %d (%s %s) for %s: %s - %s, attempt %d/%d", job.ID, job.Kind, job.SummaryType, job.GroupName,
+		job.WindowStart.Format("2006-01-02 15:04"), job.WindowEnd.Format("15:04"), job.Attempts, job.MaxAttempts)
+	
+	started := time.Now()
+	err := s.executeJob(job)
+	s.finishJob(job, time.Since(started), err)
+	if err == nil {
+		if err := s.database.CompleteJob(job.ID); err != nil {
+			logger.Error("Failed to complete job %d: %v", job.ID, err)
+		}
+		return
+	}
+	
+	var retryAt time.Time
+	if job.Attempts < job.MaxAttempts {
+		retryAt = time.Now().Add(retryDelay(job.Attempts))
+		logger.Warn("⚠️  Job %d failed (attempt %d/%d), retrying at %s: %v", job.ID, job.Attempts, job.MaxAttempts,
+			retryAt.Format("15:04:05"), err)
+	} else {
+		logger.Error("❌ Job %d failed after %d attempts, moved to failed jobs: %v", job.ID, job.Attempts, err)
+	}
+	if err := s.database.FailJob(job.ID, err.Error(), retryAt); err != nil {
+		logger.Error("Failed to record failure of job %d: %v", job.ID, err)
+	}
+}
+
+// retryDelay returns the delay before retrying a job after its nth failed attempt
+func retryDelay(attempt int) time.Duration {
+	delay := jobRetryBase
+	for i := 1; i < attempt && delay < jobRetryMax; i++ {
+		delay *= 2
+	}
+	if delay > jobRetryMax {
+		delay = jobRetryMax
+	}
+	return delay
+}
+
+// executeJob generates the summary of a job and delivers it
+func (s *Scheduler) executeJob(job *db.SummaryJob) error {
+	// The cross-group digest belongs to no single group
+	if job.Kind == db.JobDigest {
+		return s.runDigest(job.WindowEnd)
+	}
+	
+	group := s.database.GetTrackedGroup(job.ChatID)
+	if group == nil {
+		return fmt.Errorf("group %d is not tracked", job.ChatID)
+	}
+	
+	// The window may have been completed since the job was queued, e.g. by a backfill
+	if job.Kind != db.JobManual && s.windowCompleted(job) {
+		logger.Info("⏭️  Job %d: %s window of %s already completed", job.ID, job.SummaryType, group.GroupName)
+		return nil
+	}
+	
+	// Scheduled daily summaries combine the period summaries of the window
+	if job.PromptType == summarizer.PromptTypeDaily {
+		if job.Kind != db.JobDaily {
+			return s.generateDailySummary(*group, job.WindowStart, job.WindowEnd)
+		}
+		// Messages still accumulating in a deferred window belong to the day's summary,
+		// and the day ends the group's weekly and monthly rollups
+		s.closeDeferredWindow(*group, job.WindowEnd)
+		if err := s.generateDailySummary(*group, job.WindowStart, job.WindowEnd); err != nil {
+			return err
+		}
+		s.enqueueRollups(*group, job.WindowEnd)
+		return nil
+	}
+	
+	// Weekly and monthly rollups combine the daily summaries of the window
+	var text string
+	var err error
+	if summarizer.IsRollup(job.PromptType) {
+		text, err = s.generateRollup(*group, job)
+	} else {
+		text, err = s.summarizeWindow(*group, job.PromptType, job.SummaryType, job.WindowStart, job.WindowEnd, job.MinMessages)
+	}
+	if err != nil || text == "" {
+		return err
+	}
+	
+	switch job.Kind {
+	case db.JobBackfill:
+		// Late summaries are only stored
+	case db.JobManual:
+		if err := s.sendMessageWithAutoSplit(job.TargetChatID, text); err != nil {
+			logger.Error("❌ Failed to send summary of job %d to %d: %v", job.ID, job.TargetChatID, err)
+		}
+	default:
+		s.deliver(*group, cadenceOf(job.SummaryType), text)
+	}
+	return nil
+}
+
+// windowCompleted reports whether the window of a job was recorded as completed.
+// Deferred windows are still open.
+func (s *Scheduler) windowCompleted(job *db.SummaryJob) bool {
+	windows, err := s.database.GetSummaryWindows(job.ChatID, job.WindowStart, job.WindowEnd)
+	if err != nil {
+		logger.Error("Failed to get summary windows of %d: %v", job.ChatID, err)
+		return false
+	}
+	for _, window := range windows {
+		if window.SummaryType == job.SummaryType && window.WindowStart.Equal(job.WindowStart) && window.WindowEnd.Equal(job.WindowEnd) &&
+			window.Status != db.WindowDeferred {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"telegram-summarizer/internal/db"
+	"telegram-summarizer/internal/logger"
+	"telegram-summarizer/internal/summarizer"
+	"telegram-summarizer/internal/timezone"
+	"time"
+)
+
+// userActivityKeep is how long hourly user activity is kept for the most active users
+// of monthly rollups
+const userActivityKeep = 62 * 24 * time.Hour
+
+// rollupTypes are the rollups built from daily summaries, shortest period first
+var rollupTypes = []string{summarizer.PromptTypeWeekly, summarizer.PromptTypeMonthly}
+
+// enqueueRollups queues the rollups of a group whose period ends with the group's
+// current day, i.e. the weekly rollup on Sundays and the monthly rollup on the last
+// day of the month. Runs after the group's daily summary, so that day is included.
+func (s *Scheduler) enqueueRollups(group db.TrackedGroup, now time.Time) {
+	loc := timezone.Of(group)
+	now = now.In(loc)
+	tomorrow := timezone.DayStart(now, loc).AddDate(0, 0, 1)
+	
+	for _, promptType := range rollupTypes {
+		start, end := summarizer.RollupWindow(promptType, now)
+		if end.After(tomorrow) {
+			continue
+		}
+		s.enqueueJob(&db.SummaryJob{
+			Kind:        db.JobRollup,
+			ChatID:      group.ChatID,
+			SummaryType: promptType,
+			PromptType:  promptType,
+			WindowStart: start,
+			WindowEnd:   end,
+		})
+	}
+}
+
+// generateRollup generates the weekly or monthly rollup of a job's window from the
+// group's daily summaries and delivers it to daily subscribers. Windows without daily
+// summaries are recorded as skipped.
+func (s *Scheduler) generateRollup(group db.TrackedGroup, job *db.SummaryJob) (string, error) {
+	loc := timezone.Of(group)
+	startTime, endTime := job.WindowStart.In(loc), job.WindowEnd.In(loc)
+	
+	result, facts, err := s.summarizer.SummarizeRollup(group, job.PromptType, startTime, endTime)
+	if errors.Is(err, summarizer.ErrNoDailySummaries) {
+		logger.Info("⏭️  Skipping %s rollup of %s: no daily summaries since %s", job.PromptType, group.GroupName, startTime.Format("2006-01-02"))
+		s.recordWindow(group, job.SummaryType, startTime, endTime, db.WindowSkipped, 0)
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to generate %s rollup of %s: %w", job.PromptType, group.GroupName, err)
+	}
+	
+	logger.Info("✅ %s rollup saved for %s (%d daily summaries, %d messages)",
+		job.PromptType, group.GroupName, facts.Days, facts.TotalMessages)
+	s.recordWindow(group, job.SummaryType, startTime, endTime, db.WindowSummarized, facts.TotalMessages)
+	
+	var response strings.Builder
+	response.WriteString(fmt.Sprintf("📆 %s for %s\n\n", summaryTitle(job.SummaryType), group.GroupName))
+	response.WriteString(fmt.Sprintf("📅 Period: %s - %s (%s)\n", startTime.Format("2006-01-02"), endTime.AddDate(0, 0, -1).Format("2006-01-02"), timezone.Name(loc)))
+	response.WriteString(fmt.Sprintf("💬 Messages: %d over %d days\n", facts.TotalMessages, facts.Days))
+	response.WriteString(fmt.Sprintf("📈 Sentiment: %s\n\n", facts.SentimentLine()))
+	response.WriteString(result.Text())
+	return response.String(), nil
+}
+
+// pruneUserActivity forgets user activity older than monthly rollups need
+func (s *Scheduler) pruneUserActivity() {
+	if pruned, err := s.database.PruneUserActivity(time.Now().Add(-userActivityKeep)); err != nil {
+		logger.Error("Failed to prune user activity: %v", err)
+	} else if pruned > 0 {
+		logger.Info("🧹 Pruned %d hourly user activity row(s)", pruned)
+	}
+}
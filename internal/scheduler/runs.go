@@ -0,0 +1,243 @@
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"telegram-summarizer/internal/db"
+	"telegram-summarizer/internal/logger"
+	"time"
+	
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// slowestShown is the number of slowest groups listed in run metrics
+const slowestShown = 5
+
+// dailyRunWindow is how long the daily summaries of a run may take before the groups
+// still waiting are reported as late
+const dailyRunWindow = time.Hour
+
+// windowRun tracks the jobs of one scheduled run, such as the hourly summaries of a window
+type windowRun struct {
+	name     string    // e.g. "1h 2025-01-10 09:00-10:00"
+	started  time.Time // When the run was queued
+	deadline time.Time // Jobs finishing later are late
+	
+	mu      sync.Mutex
+	pending map[string]string // Window key -> group name of unfinished jobs
+	timings []jobTiming
+	sealed  bool          // All jobs were added
+	done    chan struct{} // Closed when all jobs of a sealed run finished
+	closed  bool
+}
+
+// jobTiming is the outcome of a job of a run
+type jobTiming struct {
+	group    string
+	duration time.Duration // Time spent generating
+	finished time.Time
+	failed   bool
+}
+
+// windowKey identifies the window of a job
+func windowKey(job *db.SummaryJob) string {
+	return fmt.Sprintf("%d/%s/%d", job.ChatID, job.SummaryType, job.WindowStart.Unix())
+}
+
+// startRun starts tracking a run that should finish by deadline
+func (s *Scheduler) startRun(name string, deadline time.Time) *windowRun {
+	return &windowRun{
+		name:     name,
+		started:  time.Now(),
+		deadline: deadline,
+		pending:  make(map[string]string),
+		done:     make(chan struct{}),
+	}
+}
+
+// trackJob adds a job to a run. Jobs are tracked before they are queued, so a worker
+// cannot finish them unnoticed.
+func (s *Scheduler) trackJob(run *windowRun, job *db.SummaryJob, groupName string) {
+	key := windowKey(job)
+	run.mu.Lock()
+	run.pending[key] = groupName
+	run.mu.Unlock()
+	
+	s.runsMu.Lock()
+	s.runs[key] = run
+	s.runsMu.Unlock()
+}
+
+// untrackJob removes a job that was not queued from its run
+func (s *Scheduler) untrackJob(job *db.SummaryJob) {
+	key := windowKey(job)
+	s.runsMu.Lock()
+	run := s.runs[key]
+	delete(s.runs, key)
+	s.runsMu.Unlock()
+	
+	if run != nil {
+		run.mu.Lock()
+		delete(run.pending, key)
+		run.closeIfDone()
+		run.mu.Unlock()
+	}
+}
+
+// finishJob records the first attempt of a tracked job in its run. Later attempts of
+// a retried job are not part of the run.
+func (s *Scheduler) finishJob(job *db.SummaryJob, duration time.Duration, err error) {
+	key := windowKey(job)
+	s.runsMu.Lock()
+	run := s.runs[key]
+	delete(s.runs, key)
+	s.runsMu.Unlock()
+	if run == nil {
+		return
+	}
+	
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	group, ok := run.pending[key]
+	if !ok {
+		return
+	}
+	delete(run.pending, key)
+	run.timings = append(run.timings, jobTiming{group: group, duration: duration, finished: time.Now(), failed: err != nil})
+	run.closeIfDone()
+}
+
+// seal marks that all jobs were added to a run
+func (r *windowRun) seal() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sealed = true
+	r.closeIfDone()
+}
+
+// closeIfDone closes done once a sealed run has no unfinished jobs. The caller holds mu.
+func (r *windowRun) closeIfDone() {
+	if r.sealed && len(r.pending) == 0 && !r.closed {
+		close(r.done)
+		r.closed = true
+	}
+}
+
+// size returns the number of jobs of a run
+func (r *windowRun) size() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.pending) + len(r.timings)
+}
+
+// outcome returns the number of finished jobs of a run that succeeded and failed
+func (r *windowRun) outcome() (succeeded, failed int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, timing := range r.timings {
+		if timing.failed {
+			failed++
+		} else {
+			succeeded++
+		}
+	}
+	return succeeded, failed
+}
+
+// unfinished returns the groups of the jobs that have not finished, by name
+func (r *windowRun) unfinished() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	groups := make([]string, 0, len(r.pending))
+	for _, group := range r.pending {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	return groups
+}
+
+// monitorRun waits for the jobs of a run and logs its timing. If the run does not
+// finish by its deadline, the groups still waiting are reported as late. Returns
+// false if the scheduler stopped first.
+func (s *Scheduler) monitorRun(run *windowRun) bool {
+	run.seal()
+	if run.size() == 0 {
+		return true
+	}
+	
+	select {
+	case <-run.done:
+	case <-time.After(time.Until(run.deadline)):
+		late := run.unfinished()
+		if len(late) > 0 {
+			logger.Warn("⚠️  Run %s did not finish within its window: %d group(s) late: %s",
+				run.name, len(late), strings.Join(late, ", "))
+			s.sendReport(fmt.Sprintf("⚠️ Summary run %s did not finish within its window\n\n%d group(s) still waiting:\n%s",
+				run.name, len(late), strings.Join(late, "\n")))
+		}
+		select {
+		case <-run.done:
+		case <-s.ctx.Done():
+			return false
+		}
+	case <-s.ctx.Done():
+		return false
+	}
+	
+	s.logRunMetrics(run)
+	return true
+}
+
+// logRunMetrics logs how long a finished run and its slowest jobs took
+func (s *Scheduler) logRunMetrics(run *windowRun) {
+	run.mu.Lock()
+	timings := append([]jobTiming(nil), run.timings...)
+	run.mu.Unlock()
+	if len(timings) == 0 {
+		return
+	}
+	
+	var total time.Duration
+	var finished time.Time
+	var failed int
+	var late []string
+	for _, timing := range timings {
+		total += timing.duration
+		if timing.finished.After(finished) {
+			finished = timing.finished
+		}
+		if timing.failed {
+			failed++
+		}
+		if timing.finished.After(run.deadline) {
+			late = append(late, timing.group)
+		}
+	}
+	
+	sort.Slice(timings, func(i, j int) bool { return timings[i].duration > timings[j].duration })
+	var slowest []string
+	for i := 0; i < len(timings) && i < slowestShown; i++ {
+		slowest = append(slowest, fmt.Sprintf("%s %s", timings[i].group, timings[i].duration.Round(time.Second)))
+	}
+	
+	logger.Info("⏱️  Run %s: %d job(s) in %s (%d failed, %d workers), avg %s, max %s",
+		run.name, len(timings), finished.Sub(run.started).Round(time.Second), failed, s.workers,
+		(total / time.Duration(len(timings))).Round(time.Second), timings[0].duration.Round(time.Second))
+	logger.Info("⏱️  Slowest: %s", strings.Join(slowest, ", "))
+	if len(late) > 0 {
+		sort.Strings(late)
+		logger.Warn("⚠️  Run %s: %d group(s) finished after the window: %s", run.name, len(late), strings.Join(late, ", "))
+	}
+}
+
+// sendReport sends a message to the report chat, if one is set
+func (s *Scheduler) sendReport(text string) {
+	if s.reportChatID == 0 {
+		return
+	}
+	if _, err := s.bot.Send(tgbotapi.NewMessage(s.reportChatID, text)); err != nil {
+		logger.Error("Failed to send report: %v", err)
+	}
+}
@@ -1,294 +1,556 @@
 package scheduler
 
 import (
+	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
+	"telegram-summarizer/internal/archive"
 	"telegram-summarizer/internal/db"
 	"telegram-summarizer/internal/logger"
 	"telegram-summarizer/internal/summarizer"
-
+	"telegram-summarizer/internal/timezone"
+	
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
 // Scheduler handles scheduled summary generation
 type Scheduler struct {
-	database     *db.DB
+	database     db.Store
 	summarizer   *summarizer.Summarizer
 	bot          *tgbotapi.BotAPI
-	targetChatID int64  // Chat ID to send summaries to
-	stopCh       chan struct{}
-	ticker1h     *time.Ticker
-	tickerDaily  *time.Ticker
+	reportChatID int64 // Chat receiving the daily run report (0 = none)
+	retention    *archive.Service
+	workers      int             // Jobs run at the same time
+	jobsCh       chan struct{}   // Wakes the job pool when jobs are queued
+	takeover     time.Duration   // Wait before requeueing jobs left running by a previous leader
+	ctx          context.Context // Done when the scheduler stops
+	cancel       context.CancelFunc
+	wg           sync.WaitGroup // Loops, runs and jobs Stop waits for
+	
+	runsMu     sync.Mutex
+	runs       map[string]*windowRun // Tracked runs by window key of their jobs
+	lastWindow time.Time             // Tick of the last hourly run
+	planned    map[int64]time.Time   // End of the last window planned per group
 }
 
 // NewScheduler creates a new scheduler
-func NewScheduler(database *db.DB, summarizer *summarizer.Summarizer, bot *tgbotapi.BotAPI, targetChatID int64) *Scheduler {
+// Summaries are delivered to the subscribers of each group
+func NewScheduler(database db.Store, summarizer *summarizer.Summarizer, bot *tgbotapi.BotAPI) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Scheduler{
 		database:    database,
 		summarizer:  summarizer,
 		bot:         bot,
-		targetChatID: targetChatID,
-		stopCh:      make(chan struct{}),
+		retention:   archive.NewService(database, archive.Config{}),
+		workers:     defaultWorkers,
+		jobsCh:      make(chan struct{}, 1),
+		runs:        make(map[string]*windowRun),
+		planned:     make(map[int64]time.Time),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// SetRetention sets the service applying retention policies after daily summaries
+func (s *Scheduler) SetRetention(retention *archive.Service) {
+	s.retention = retention
+}
+
+// SetWorkers sets the number of summary jobs run at the same time
+func (s *Scheduler) SetWorkers(workers int) {
+	if workers < 1 {
+		workers = 1
 	}
+	s.workers = workers
+}
+
+// SetReportChatID sets the chat receiving the report of each daily run (0 = none)
+func (s *Scheduler) SetReportChatID(chatID int64) {
+	s.reportChatID = chatID
+}
+
+// SetTakeoverDelay sets how long the job pool waits before requeueing the jobs left
+// running by a previous process. A leader taking over sets it to the lease, so the
+// previous leader, which steps down before its lease expires, can finish its jobs.
+func (s *Scheduler) SetTakeoverDelay(delay time.Duration) {
+	s.takeover = delay
 }
 
-// Start starts the scheduler with both 1h and daily summaries
-func (s *Scheduler) Start(dailySummaryTime string) {
+// Start starts the scheduler with both 1h and daily summaries. The scheduler stops
+// when ctx is done or Stop is called.
+func (s *Scheduler) Start(ctx context.Context, dailySummaryTime string) {
+	// The scheduler's context is created by NewScheduler, so a Stop before Start holds;
+	// ctx being done stops it as well
+	context.AfterFunc(ctx, s.cancel)
+	
 	logger.Info("📅 Starting schedulers...")
 	logger.Info("  ⏰ 1-hour summaries: Every hour (00:00, 01:00, 02:00, ... 23:00)")
+	logger.Info("  📥 Quiet groups accumulate messages per their window policy, busy groups get windows every %s", windowTick)
 	logger.Info("  🌅 Daily summary: %s", dailySummaryTime)
+	logger.Info("  🗓️  Groups with cron schedules only use their schedules")
 	
 	// Start 1-hour scheduler
-	go s.run1HourScheduler()
+	s.spawn(s.run1HourScheduler)
 	
 	// Start daily scheduler
-	go s.runDailyScheduler(dailySummaryTime)
+	s.spawn(func() { s.runDailyScheduler(dailySummaryTime) })
+	
+	// Start per-group cron schedules
+	s.spawn(s.runCronScheduler)
+	
+	// Run queued summary jobs
+	s.spawn(s.runJobPool)
+	
+	// Queue the hourly windows missed while the bot was down
+	now := time.Now()
+	s.spawn(func() { s.enqueueMissedWindows(now) })
 }
 
-// Stop stops the scheduler
+// Stop stops the scheduler and waits until its loops, the daily run in progress and
+// the running jobs have finished, so no summary is delivered after it returns
 func (s *Scheduler) Stop() {
-	if s.ticker1h != nil {
-		s.ticker1h.Stop()
-	}
-	if s.tickerDaily != nil {
-		s.tickerDaily.Stop()
-	}
-	close(s.stopCh)
+	s.cancel()
+	s.wg.Wait()
 	logger.Info("🛑 Scheduler stopped")
 }
 
-// run1HourScheduler runs 1-hour summary generation
+// spawn runs f on a goroutine that Stop waits for
+func (s *Scheduler) spawn(f func()) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		f()
+	}()
+}
+
+// run1HourScheduler runs 1-hour summary generation. Windows are planned every
+// windowTick, so busy groups can be summarized within the hour.
 func (s *Scheduler) run1HourScheduler() {
 	logger.Info("⏰ Starting 1-hour summary scheduler")
 	
-	// Align to next hour mark (00:00, 01:00, 02:00, etc)
-	waitDuration := s.alignToNextHour()
-	logger.Info("⏰ Next 1h summary in: %s", formatDuration(waitDuration))
+	// Align to the next tick (00:00, 00:05, 00:10, etc)
+	waitDuration := s.alignToNextTick()
+	logger.Info("⏰ Next 1h summary check in: %s", formatDuration(waitDuration))
 	
 	// Wait until first aligned time
-	time.Sleep(waitDuration)
+	select {
+	case <-time.After(waitDuration):
+	case <-s.ctx.Done():
+		logger.Info("⏰ 1-hour scheduler stopped")
+		return
+	}
 	
-	// Generate first summary immediately
-	s.generate1HourSummaries()
+	// Plan the first windows immediately
+	s.generate1HourSummaries(time.Now())
 	
-	// Then run every 1 hour
-	s.ticker1h = time.NewTicker(1 * time.Hour)
-	defer s.ticker1h.Stop()
+	// Then run every tick
+	ticker := time.NewTicker(windowTick)
+	defer ticker.Stop()
 	
 	for {
 		select {
-		case <-s.ticker1h.C:
-			s.generate1HourSummaries()
-		case <-s.stopCh:
+		case now := <-ticker.C:
+			s.generate1HourSummaries(now)
+		case <-s.ctx.Done():
 			logger.Info("⏰ 1-hour scheduler stopped")
 			return
 		}
 	}
 }
 
-// alignToNextHour calculates time until next hour mark
-func (s *Scheduler) alignToNextHour() time.Duration {
-	now := time.Now()
-	
-	// Next hour mark (round up to next hour)
-	nextHour := now.Add(1 * time.Hour).Truncate(1 * time.Hour)
-	
-	return time.Until(nextHour)
+// alignToNextTick calculates time until the next tick of the hourly scheduler
+func (s *Scheduler) alignToNextTick() time.Duration {
+	return time.Until(time.Now().Truncate(windowTick).Add(windowTick))
 }
 
-// generate1HourSummaries generates summaries for all active groups
-func (s *Scheduler) generate1HourSummaries() {
-	logger.Info("🕐 Generating 1-hour summaries...")
+// generate1HourSummaries plans the windows of all active groups at a tick and queues
+// the summaries of those that are ready. Windows still accumulating messages are
+// recorded as deferred.
+func (s *Scheduler) generate1HourSummaries(now time.Time) {
+	logger.Debug("🕐 Planning 1-hour summaries...")
 	
-	// Get all active groups
-	groups := s.database.GetActiveGroups()
+	// Get all active groups without schedules of their own
+	groups := s.withoutSchedules(s.activeGroups())
 	
 	if len(groups) == 0 {
-		logger.Info("No active groups for 1h summary")
+		logger.Debug("No active groups for 1h summary")
 		return
 	}
 	
-	logger.Info("Processing %d active groups", len(groups))
+	// A tick firing early or twice must not plan windows again
+	tick := now.Truncate(windowTick)
+	if !tick.After(s.lastWindow) {
+		logger.Warn("⚠️  1h run for %s already started, skipping", tick.Format("2006-01-02 15:04"))
+		return
+	}
+	s.lastWindow = tick
 	
-	// Time range: last 1 hour
-	endTime := time.Now()
-	startTime := endTime.Add(-1 * time.Hour)
-	
-	successCount := 0
-	totalMessages := 0
+	// The run should finish before the next hour's windows are queued; it is named
+	// after the tick in the default timezone
+	run := s.startRun(fmt.Sprintf("1h %s", tick.In(timezone.Default()).Format("2006-01-02 15:04 MST")), tick.Add(time.Hour))
 	
+	// Hourly summaries keep the default prompt
+	deferred := 0
 	for _, group := range groups {
-		logger.Info("📝 1h summary for: %s (ID: %d)", group.GroupName, group.ChatID)
-		
-		// Get messages from last 1 hour
-		messages, err := s.database.GetMessagesByTimeRange(group.ChatID, startTime, endTime)
+		plan, err := s.planWindow(group, now)
 		if err != nil {
-			logger.Error("Failed to get messages: %v", err)
+			logger.Error("Failed to plan the window of %s: %v", group.GroupName, err)
 			continue
 		}
-		
-		if len(messages) < 3 {
-			logger.Info("⏭️  Skipping %s: only %d messages (need at least 3)", group.GroupName, len(messages))
+		if plan == nil || !plan.end.After(s.planned[group.ChatID]) {
 			continue
 		}
-		
-		// Use hierarchical streaming summarization (same as manual summary)
-		// This prevents "prompt too large" errors for active groups
-		progressCallback := func(progressMsg string) {
-			logger.Debug("1h summary progress: %s", progressMsg)
-		}
-		
-		summaryCallback := func(partialSummary string) {
-			logger.Debug("1h summary partial generated (%d chars)", len(partialSummary))
-		}
-		
-		// Generate summary with hierarchical chunking (handles any size)
-		summaryText, err := s.summarizer.GenerateSummaryHierarchical(messages, group.GroupName, startTime, endTime, progressCallback, summaryCallback)
-		if err != nil {
-			logger.Error("Failed to generate summary: %v", err)
+		s.planned[group.ChatID] = plan.end
+	
+		if !plan.ready {
+			logger.Debug("📥 %s: %d message(s) since %s, waiting for more", group.GroupName, plan.messages,
+				plan.start.In(timezone.Of(group)).Format("15:04"))
+			s.recordWindow(group, "1h", plan.start, plan.end, db.WindowDeferred, plan.messages)
+			deferred++
 			continue
 		}
-		
-		// Parse metadata
-		parser := s.summarizer.GetMetadataParser()
-		metadata := parser.Parse(summaryText)
-		
-		// Save summary with metadata
-		summary := &db.Summary{
-			ChatID:            group.ChatID,
-			SummaryType:       "1h",
-			PeriodStart:       startTime,
-			PeriodEnd:         endTime,
-			SummaryText:       summaryText,
-			MessageCount:      len(messages),
-			Sentiment:         metadata.Sentiment,
-			CredibilityScore:  metadata.CredibilityScore,
-			ProductsMentioned: metadata.ProductsJSON,
-			RedFlagsCount:     metadata.RedFlagsCount,
-			ValidationStatus:  metadata.ValidationStatus,
-		}
-		
-		if err := s.database.SaveSummary(summary); err != nil {
-			logger.Error("Failed to save summary: %v", err)
-			continue
+	
+		job := &db.SummaryJob{
+			Kind:        db.JobHourly,
+			ChatID:      group.ChatID,
+			SummaryType: "1h",
+			WindowStart: plan.start,
+			WindowEnd:   plan.end,
+			MinMessages: plan.minMessages,
 		}
-		
-		// Save product mentions
-		for i := range metadata.Products {
-			metadata.Products[i].SummaryID = summary.ID
-			if err := s.database.SaveProductMention(&metadata.Products[i]); err != nil {
-				logger.Error("Failed to save product mention: %v", err)
-			}
+		s.trackJob(run, job, group.GroupName)
+		if !s.enqueueJob(job) {
+			s.untrackJob(job)
 		}
-		
-		logger.Info("✅ 1h summary saved for %s (%d messages, %d products)", 
-			group.GroupName, len(messages), len(metadata.Products))
-		
-		successCount++
-		totalMessages += len(messages)
 	}
 	
-	logger.Info("✅ 1-hour summaries complete: %d/%d groups, %d messages processed", 
-		successCount, len(groups), totalMessages)
+	if run.size() > 0 || deferred > 0 {
+		logger.Info("✅ Queued 1-hour summaries of %d/%d groups (%d accumulating messages)", run.size(), len(groups), deferred)
+	}
+	s.spawn(func() { s.monitorRun(run) })
+}
+
+// summarizeWindow summarizes the messages of a group in a window with the prompt of a
+// prompt type and stores the summary as summaryType. The window is recorded as
+// completed unless summarizing fails. Returns the message to deliver, which is empty
+// if the window has fewer than minMessages (0 = the minimum of the group's window
+// policy) to summarize. Times are shown in the group's timezone.
+func (s *Scheduler) summarizeWindow(group db.TrackedGroup, promptType, summaryType string, startTime, endTime time.Time, minMessages int) (string, error) {
+	if minMessages <= 0 {
+		minMessages = group.Windows.Effective().MinMessages
+	}
+	loc := timezone.Of(group)
+	startTime, endTime = startTime.In(loc), endTime.In(loc)
+	
+	messages, err := s.windowMessages(group.ChatID, startTime, endTime)
+	if err != nil {
+		return "", fmt.Errorf("failed to get messages: %w", err)
+	}
+	
+	if len(messages) < minMessages {
+		logger.Info("⏭️  Skipping %s: only %d messages (need at least %d)", group.GroupName, len(messages), minMessages)
+		s.recordWindow(group, summaryType, startTime, endTime, db.WindowSkipped, len(messages))
+		return "", nil
+	}
+	
+	// Hierarchical streaming summarization (same as manual summary)
+	// prevents "prompt too large" errors for active groups
+	result, err := s.summarizer.SummarizeAndStore(summarizer.Request{
+		ChatID:      group.ChatID,
+		GroupName:   group.GroupName,
+		SummaryType: summaryType,
+		PromptType:  promptType,
+		StartTime:   startTime,
+		EndTime:     endTime,
+		Messages:    messages,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize %s: %w", group.GroupName, err)
+	}
+	
+	logger.Info("✅ %s summary saved for %s (%d messages, %d products)", 
+		summaryType, group.GroupName, len(messages), len(result.Products))
+	s.recordWindow(group, summaryType, startTime, endTime, db.WindowSummarized, len(messages))
+	
+	var response strings.Builder
+	response.WriteString(fmt.Sprintf("🕐 %s for %s\n\n", summaryTitle(summaryType), group.GroupName))
+	response.WriteString(fmt.Sprintf("📅 Period: %s - %s\n", startTime.Format("2006-01-02 15:04"), endTime.Format("15:04 MST")))
+	response.WriteString(fmt.Sprintf("💬 Messages: %d\n\n", len(messages)))
+	response.WriteString(result.Text())
+	return response.String(), nil
+}
+
+// windowMessages gets the messages of a window. Windows end before endTime, as
+// planWindow counts them, so a message on the boundary of two adjacent windows is
+// only summarized in the later one.
+func (s *Scheduler) windowMessages(chatID int64, startTime, endTime time.Time) ([]db.Message, error) {
+	messages, err := s.database.GetMessagesByTimeRange(chatID, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	n := len(messages)
+	for n > 0 && !messages[n-1].Timestamp.Before(endTime) {
+		n--
+	}
+	return messages[:n], nil
+}
+
+// summaryTitle returns the title of a scheduled summary type in delivered messages
+func summaryTitle(summaryType string) string {
+	switch summaryType {
+	case summarizer.PromptType1Hour:
+		return "Hourly Summary"
+	case summarizer.PromptType4Hour:
+		return "4-Hour Summary"
+	case summarizer.PromptType24Hour:
+		return "24-Hour Summary"
+	case summarizer.PromptTypeWeekly:
+		return "Weekly Summary"
+	case summarizer.PromptTypeMonthly:
+		return "Monthly Summary"
+	}
+	return "Summary"
 }
 
-// runDailyScheduler runs the daily summary job
+// cadenceOf returns the subscription cadence receiving a summary type: summaries of a
+// day or longer go to daily subscribers, shorter ones to hourly subscribers
+func cadenceOf(summaryType string) string {
+	switch summaryType {
+	case summarizer.PromptType24Hour, summarizer.PromptTypeDaily, summarizer.PromptTypeWeekly, summarizer.PromptTypeMonthly:
+		return db.CadenceDaily
+	}
+	return db.CadenceHourly
+}
+
+// runDailyScheduler runs the daily summary job when the clock of a group's timezone
+// reaches the target time. Groups in timezones reaching it together run together.
 func (s *Scheduler) runDailyScheduler(targetTime string) {
+	// Parse target time (format: "23:00")
+	targetHour, targetMin := parseTime(targetTime)
+	var announced time.Time
+	
 	for {
-		// Parse target time (format: "23:00")
+		// Calculate next run time: the first timezone to reach the target time
 		now := time.Now()
-		targetHour, targetMin := parseTime(targetTime)
-		
-		// Calculate next run time
-		nextRun := time.Date(now.Year(), now.Month(), now.Day(), targetHour, targetMin, 0, 0, now.Location())
-		if nextRun.Before(now) {
-			// If target time already passed today, schedule for tomorrow
-			nextRun = nextRun.Add(24 * time.Hour)
+		var nextRun time.Time
+		var zones []string
+		for _, loc := range s.dailyLocations() {
+			run := timezone.NextClock(now, loc, targetHour, targetMin)
+			switch {
+			case nextRun.IsZero() || run.Before(nextRun):
+				nextRun, zones = run, []string{loc.String()}
+			case run.Equal(nextRun):
+				zones = append(zones, loc.String())
+			}
 		}
 		
 		waitDuration := time.Until(nextRun)
-		logger.Info("⏰ Next daily summary scheduled at: %s (in %s)", nextRun.Format("2006-01-02 15:04:05"), formatDuration(waitDuration))
+		if !nextRun.Equal(announced) {
+			logger.Info("⏰ Next daily summary scheduled at: %s (in %s, %s)", nextRun.Format("2006-01-02 15:04:05 MST"),
+				formatDuration(waitDuration), strings.Join(zones, ", "))
+			announced = nextRun
+		}
+		
+		// Groups may change their timezone meanwhile, so long waits are checked again hourly
+		if waitDuration > time.Hour {
+			select {
+			case <-time.After(time.Hour):
+				continue
+			case <-s.ctx.Done():
+				return
+			}
+		}
 		
 		// Wait until next run or stop signal
 		select {
 		case <-time.After(waitDuration):
-			// Run daily summary
-			s.runDailySummaryForAllGroups()
-		case <-s.stopCh:
+			// Queue the daily summaries; the digest follows them
+			s.runDailySummaryForAllGroups(zones)
+		case <-s.ctx.Done():
 			return
 		}
 	}
 }
 
-// runDailySummaryForAllGroups generates summary for all active groups
-func (s *Scheduler) runDailySummaryForAllGroups() {
-	logger.Info("🌅 Starting daily summary generation for all active groups...")
-	
-	// Get all active groups
-	groups := s.database.GetTrackedGroups()
+// dailyLocations returns the timezones of the groups getting daily summaries, and the
+// default timezone
+func (s *Scheduler) dailyLocations() []*time.Location {
+	locations := []*time.Location{timezone.Default()}
+	seen := map[string]bool{timezone.Default().String(): true}
+	for _, group := range s.dailyGroups() {
+		if loc := timezone.Of(group); !seen[loc.String()] {
+			seen[loc.String()] = true
+			locations = append(locations, loc)
+		}
+	}
+	return locations
+}
+
+// dailyGroups returns the active groups without schedules of their own
+func (s *Scheduler) dailyGroups() []db.TrackedGroup {
 	activeGroups := make([]db.TrackedGroup, 0)
-	
-	for _, group := range groups {
-		if group.IsActive == 1 {
+	for _, group := range s.database.GetTrackedGroups() {
+		if group.IsActive == 1 && summarizable(group) {
+			activeGroups = append(activeGroups, group)
+		}
+	}
+	return s.withoutSchedules(activeGroups)
+}
+
+// activeGroups returns the active groups that are summarized
+func (s *Scheduler) activeGroups() []db.TrackedGroup {
+	var groups []db.TrackedGroup
+	for _, group := range s.database.GetActiveGroups() {
+		if summarizable(group) {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// summarizable reports whether a group is summarized; broadcast channels have no
+// discussion to summarize
+func summarizable(group db.TrackedGroup) bool {
+	return group.ChatType != db.ChatTypeChannel
+}
+
+// runDailySummaryForAllGroups queues the daily summaries of all active groups in the
+// given timezones and returns their run. Once the jobs of the run finished, the run is
+// reported and, with the default timezone, followed by the cross-group digest.
+func (s *Scheduler) runDailySummaryForAllGroups(zones []string) *windowRun {
+	logger.Info("🌅 Starting daily summary generation for all active groups in %s...", strings.Join(zones, ", "))
+	
+	// Get all active groups without schedules of their own in the timezones
+	inZone := make(map[string]bool, len(zones))
+	for _, zone := range zones {
+		inZone[zone] = true
+	}
+	activeGroups := make([]db.TrackedGroup, 0)
+	for _, group := range s.dailyGroups() {
+		if inZone[timezone.Of(group).String()] {
 			activeGroups = append(activeGroups, group)
 		}
 	}
 	
+	now := time.Now()
+	run := s.startRun(fmt.Sprintf("daily %s (%s)", now.In(timezone.Default()).Format("2006-01-02"), strings.Join(zones, ", ")),
+		now.Add(dailyRunWindow))
 	if len(activeGroups) == 0 {
 		logger.Info("ℹ️  No active groups to summarize")
-		return
+	} else {
+		logger.Info("📋 Found %d active group(s) to summarize", len(activeGroups))
 	}
 	
-	logger.Info("📋 Found %d active group(s) to summarize", len(activeGroups))
-	
-	successCount := 0
-	failCount := 0
-	
-	// Generate summary for each active group
+	// Each group's day, from the start of the day in its timezone to now, is a job
 	for _, group := range activeGroups {
-		logger.Info("📝 Processing group: %s (ID: %d)", group.GroupName, group.ChatID)
-		
-		if err := s.generateAndSendDailySummary(group); err != nil {
-			logger.Error("❌ Failed to generate summary for %s: %v", group.GroupName, err)
-			failCount++
-		} else {
-			successCount++
+		loc := timezone.Of(group)
+		endTime := now.In(loc)
+		job := &db.SummaryJob{
+			Kind:        db.JobDaily,
+			ChatID:      group.ChatID,
+			SummaryType: summarizer.PromptTypeDaily,
+			PromptType:  summarizer.PromptTypeDaily,
+			WindowStart: timezone.DayStart(endTime, loc),
+			WindowEnd:   endTime,
+		}
+		s.trackJob(run, job, group.GroupName)
+		if !s.enqueueJob(job) {
+			s.untrackJob(job)
 		}
-		
-		// Small delay between groups to avoid rate limiting
-		time.Sleep(2 * time.Second)
+	}
+	if len(activeGroups) > 0 {
+		logger.Info("✅ Queued daily summaries of %d/%d groups", run.size(), len(activeGroups))
+	}
+	
+	// Forget succeeded jobs after a week
+	if pruned, err := s.database.PruneJobs(now.Add(-jobKeep)); err != nil {
+		logger.Error("Failed to prune jobs: %v", err)
+	} else if pruned > 0 {
+		logger.Info("🧹 Pruned %d succeeded job(s)", pruned)
+	}
+	s.pruneUserActivity()
+	
+	s.spawn(func() { s.finishDailyRun(run, zones) })
+	return run
+}
+
+// finishDailyRun waits for the jobs of a daily run, logs its timing and late groups,
+// sends its report and queues the cross-group digest if it is due
+func (s *Scheduler) finishDailyRun(run *windowRun, zones []string) {
+	if !s.monitorRun(run) {
+		return
 	}
 	
-	logger.Info("✅ Daily summary complete: %d succeeded, %d failed", successCount, failCount)
+	if run.size() > 0 {
+		succeeded, failed := run.outcome()
+		logger.Info("✅ Daily summary complete: %d succeeded, %d failed", succeeded, failed)
+		s.sendDailyReport(succeeded, failed, zones)
+	}
+	if digestDue(zones) {
+		s.enqueueDigest(time.Now())
+	}
+}
+
+// sendDailyReport sends the report of a daily run to the report chat. Failed groups
+// are retried by the job queue.
+func (s *Scheduler) sendDailyReport(succeeded, failed int, zones []string) {
+	if s.reportChatID == 0 {
+		return
+	}
 	
-	// Send completion report to target chat
 	report := fmt.Sprintf("📊 Daily Summary Report\n\n"+
 		"✅ Successfully summarized: %d groups\n"+
-		"❌ Failed: %d groups\n"+
-		"📅 Date: %s",
-		successCount, failCount, time.Now().Format("2006-01-02"))
+		"❌ Failed: %d groups (retried by the job queue)\n"+
+		"📅 Date: %s\n"+
+		"🕐 Timezone: %s",
+		succeeded, failed, time.Now().In(timezone.Default()).Format("2006-01-02"), strings.Join(zones, ", "))
+	if counts, err := s.database.CountJobs(); err == nil && counts[db.JobFailed] > 0 {
+		report += fmt.Sprintf("\n\n🔁 Failed summary jobs: %d (see /jobs failed)", counts[db.JobFailed])
+	}
 	
-	msg := tgbotapi.NewMessage(s.targetChatID, report)
-	s.bot.Send(msg)
+	msg := tgbotapi.NewMessage(s.reportChatID, report)
+	if _, err := s.bot.Send(msg); err != nil {
+		logger.Error("Failed to send daily summary report: %v", err)
+	}
 }
 
-// generateAndSendDailySummary generates and sends summary for a single group
-// This now aggregates from 1h summaries instead of direct messages
-func (s *Scheduler) generateAndSendDailySummary(group db.TrackedGroup) error {
-	// Get time range for today (start of day to now)
-	endTime := time.Now()
-	startTime := time.Date(endTime.Year(), endTime.Month(), endTime.Day(), 0, 0, 0, 0, endTime.Location())
-	
-	// Get all 1h summaries from today
-	summaries := s.database.GetSummariesByTimeRange(group.ChatID, "1h", startTime, endTime)
+// periodSummaryTypes are the summary types a daily summary is built from, in order of preference
+var periodSummaryTypes = []string{"1h", summarizer.PromptType4Hour}
+
+// generateDailySummary generates and sends the summary of a group's period summaries in a
+// window, then applies the group's retention policy. If the summary cannot be stored it
+// is neither delivered nor followed by retention, and the error fails the job.
+func (s *Scheduler) generateDailySummary(group db.TrackedGroup, startTime, endTime time.Time) error {
+	// Times are shown in the group's timezone
+	loc := timezone.Of(group)
+	startTime, endTime = startTime.In(loc), endTime.In(loc)
+	
+	// Get the 1h summaries of the window (4h summaries for groups scheduled so)
+	var summaries []db.Summary
+	for _, summaryType := range periodSummaryTypes {
+		if summaries = s.database.GetSummariesByTimeRange(group.ChatID, summaryType, startTime, endTime); len(summaries) > 0 {
+			break
+		}
+	}
 	
 	if len(summaries) == 0 {
-		logger.Info("ℹ️  No 1h summaries for %s today, skipping", group.GroupName)
+		logger.Info("ℹ️  No period summaries for %s since %s, skipping", group.GroupName, startTime.Format("2006-01-02 15:04"))
 		return nil
 	}
 	
-	logger.Info("Found %d one-hour summaries for %s", len(summaries), group.GroupName)
+	logger.Info("Found %d period summaries for %s", len(summaries), group.GroupName)
+	
+	// Hours of the window that were never summarized (e.g. downtime not yet backfilled)
+	missed, err := s.missedHours(group, startTime, endTime, summaries...)
+	if err != nil {
+		logger.Error("Failed to find missed hours of %s: %v", group.GroupName, err)
+	}
+	missedText := formatHourRanges(missed)
+	if len(missed) > 0 {
+		logger.Warn("⚠️  %s: %d hour(s) without summary: %s", group.GroupName, len(missed), missedText)
+	}
 	
 	// Combine all 1h summaries into one text
 	var combinedText strings.Builder
@@ -297,8 +559,8 @@ func (s *Scheduler) generateAndSendDailySummary(group db.TrackedGroup) error {
 	for i, summary := range summaries {
 		combinedText.WriteString(fmt.Sprintf("=== Periode %d: %s - %s ===\n\n",
 			i+1,
-			summary.PeriodStart.Format("15:04"),
-			summary.PeriodEnd.Format("15:04")))
+			summary.PeriodStart.In(loc).Format("15:04"),
+			summary.PeriodEnd.In(loc).Format("15:04")))
 		combinedText.WriteString(summary.SummaryText)
 		combinedText.WriteString("\n\n")
 		totalMessages += summary.MessageCount
@@ -312,12 +574,15 @@ func (s *Scheduler) generateAndSendDailySummary(group db.TrackedGroup) error {
 	
 	// Build a comprehensive text from all 1h summaries
 	var aggregatedText strings.Builder
-	aggregatedText.WriteString(fmt.Sprintf("Berikut adalah ringkasan per jam untuk grup %s:\n\n", group.GroupName))
+	aggregatedText.WriteString(fmt.Sprintf("Berikut adalah ringkasan per jam untuk grup %s (waktu %s):\n\n", group.GroupName, timezone.Label(endTime)))
+	if len(missed) > 0 {
+		aggregatedText.WriteString(fmt.Sprintf("Catatan: jam %s tidak memiliki ringkasan (data tidak lengkap).\n\n", missedText))
+	}
 	
 	for _, summary := range summaries {
 		aggregatedText.WriteString(fmt.Sprintf("## Periode %s - %s (%d pesan)\n",
-			summary.PeriodStart.Format("15:04"),
-			summary.PeriodEnd.Format("15:04"),
+			summary.PeriodStart.In(loc).Format("15:04"),
+			summary.PeriodEnd.In(loc).Format("15:04"),
 			summary.MessageCount))
 		aggregatedText.WriteString(summary.SummaryText)
 		aggregatedText.WriteString("\n\n---\n\n")
@@ -335,75 +600,56 @@ func (s *Scheduler) generateAndSendDailySummary(group db.TrackedGroup) error {
 		},
 	}
 	
-	progressCallback := func(progressMsg string) {
-		logger.Debug("Daily summary progress: %s", progressMsg)
-	}
-	
-	summaryCallback := func(partialSummary string) {
-		logger.Debug("Daily summary partial generated (%d chars)", len(partialSummary))
+	// The counts stated in the summary are those of the day's messages, not of the
+	// pseudo-message
+	facts, err := s.summarizer.PeriodFacts(group.ChatID, startTime, endTime, totalMessages)
+	if err != nil {
+		return fmt.Errorf("failed to count the messages of %s: %w", group.GroupName, err)
 	}
 	
-	// Generate daily summary with hierarchical chunking
-	dailySummaryText, err := s.summarizer.GenerateSummaryHierarchical(pseudoMessages, group.GroupName, startTime, endTime, progressCallback, summaryCallback)
-	if err != nil {
+	// Generate and store daily summary with hierarchical chunking
+	result, err := s.summarizer.SummarizeAndStore(summarizer.Request{
+		ChatID:       group.ChatID,
+		GroupName:    group.GroupName,
+		SummaryType:  "daily",
+		StartTime:    startTime,
+		EndTime:      endTime,
+		Messages:     pseudoMessages,
+		MessageCount: totalMessages,
+		Facts:        facts,
+	})
+	if result == nil {
 		return fmt.Errorf("failed to generate daily summary: %w", err)
 	}
-	
-	// Parse metadata
-	parser := s.summarizer.GetMetadataParser()
-	metadata := parser.Parse(dailySummaryText)
-	
-	// Save daily summary with metadata
-	dbSummary := &db.Summary{
-		ChatID:            group.ChatID,
-		SummaryType:       "daily",
-		PeriodStart:       startTime,
-		PeriodEnd:         endTime,
-		SummaryText:       dailySummaryText,
-		MessageCount:      totalMessages,
-		Sentiment:         metadata.Sentiment,
-		CredibilityScore:  metadata.CredibilityScore,
-		ProductsMentioned: metadata.ProductsJSON,
-		RedFlagsCount:     metadata.RedFlagsCount,
-		ValidationStatus:  metadata.ValidationStatus,
-	}
-	
-	if err := s.database.SaveSummary(dbSummary); err != nil {
-		logger.Error("Failed to save daily summary: %v", err)
-		// Don't fail, just log
-	} else {
-		// Save product mentions
-		for i := range metadata.Products {
-			metadata.Products[i].SummaryID = dbSummary.ID
-			if err := s.database.SaveProductMention(&metadata.Products[i]); err != nil {
-				logger.Error("Failed to save product mention: %v", err)
-			}
-		}
+	if err != nil {
+		// The job is retried; the day's messages are kept until a summary is stored
+		return fmt.Errorf("failed to store daily summary of %s: %w", group.GroupName, err)
 	}
+	dailySummaryText := result.Text()
 	
 	// Format response
 	var response strings.Builder
 	response.WriteString(fmt.Sprintf("📝 Daily Summary for %s\n\n", group.GroupName))
-	response.WriteString(fmt.Sprintf("📅 Date: %s\n", endTime.Format("2006-01-02")))
+	response.WriteString(fmt.Sprintf("📅 Date: %s (%s)\n", endTime.Format("2006-01-02"), timezone.Label(endTime)))
 	response.WriteString(fmt.Sprintf("💬 Total Messages: %d\n", totalMessages))
-	response.WriteString(fmt.Sprintf("📊 Based on %d one-hour summaries\n\n", len(summaries)))
+	response.WriteString(fmt.Sprintf("📊 Based on %d %s summaries\n", len(summaries), summaries[0].SummaryType))
+	if len(missed) > 0 {
+		response.WriteString(fmt.Sprintf("⚠️ Missing hours: %s (not summarized)\n", missedText))
+	}
+	response.WriteString("\n")
 	response.WriteString("━━━━━━━━━━━━━━━━━━━━━━━\n\n")
 	response.WriteString(dailySummaryText)
 	response.WriteString("\n\n━━━━━━━━━━━━━━━━━━━━━━━\n")
 	response.WriteString("Generated by AI ✨")
 	
-	// Auto-split if message is too long
-	s.sendMessageWithAutoSplit(s.targetChatID, response.String())
+	s.deliver(group, db.CadenceDaily, response.String())
 	
-	logger.Info("✅ Daily summary sent for %s", group.GroupName)
-	
-	// Delete old messages (older than 24h) after successful daily summary
-	cleanupTime := endTime.Add(-24 * time.Hour)
-	deletedCount, err := s.database.DeleteMessagesOlderThan(group.ChatID, cleanupTime)
+	// Apply the group's retention policy (archive, then delete expired messages)
+	cleanup, err := s.retention.Apply(group, endTime)
 	if err != nil {
 		logger.Error("⚠️  Failed to cleanup old messages for %s: %v", group.GroupName, err)
-	} else if deletedCount > 0 {
-		logger.Info("🗑️  Cleaned up %d old messages for %s", deletedCount, group.GroupName)
+	} else if cleanup.Deleted > 0 {
+		logger.Info("🗑️  Cleaned up %d old messages for %s (%d archived)", cleanup.Deleted, group.GroupName, cleanup.Archived)
 	}
 	
 	return nil
@@ -426,17 +672,43 @@ func formatDuration(d time.Duration) string {
 	return fmt.Sprintf("%dm", minutes)
 }
 
-// sendMessageWithAutoSplit sends a message, automatically splitting if too long
-func (s *Scheduler) sendMessageWithAutoSplit(chatID int64, text string) {
+// deliver sends a summary to every subscriber of a group's cadence. A failing
+// destination is logged and does not stop delivery to the others.
+func (s *Scheduler) deliver(group db.TrackedGroup, cadence, text string) (sent, failed int) {
+	subs, err := s.database.GetSubscriptions(group.ChatID, cadence)
+	if err != nil {
+		logger.Error("Failed to get %s subscribers of %s: %v", cadence, group.GroupName, err)
+		return 0, 0
+	}
+	if len(subs) == 0 {
+		logger.Info("ℹ️  No %s subscribers for %s, summary stored only", cadence, group.GroupName)
+		return 0, 0
+	}
+	
+	for _, sub := range subs {
+		// Auto-split if message is too long
+		if err := s.sendMessageWithAutoSplit(sub.TargetChatID, text); err != nil {
+			logger.Error("❌ Failed to deliver %s summary of %s to %d: %v", cadence, group.GroupName, sub.TargetChatID, err)
+			failed++
+			continue
+		}
+		sent++
+	}
+	
+	logger.Info("✅ %s summary of %s delivered to %d/%d subscribers", cadence, group.GroupName, sent, len(subs))
+	return sent, failed
+}
+
+// sendMessageWithAutoSplit sends a message, automatically splitting if too long.
+// Returns the first error; the remaining parts are still sent.
+func (s *Scheduler) sendMessageWithAutoSplit(chatID int64, text string) error {
 	const maxLength = 4000 // Leave margin under 4096
 	
 	if len(text) <= maxLength {
 		// Send as single message
 		msg := tgbotapi.NewMessage(chatID, text)
-		if _, err := s.bot.Send(msg); err != nil {
-			logger.Error("Failed to send message: %v", err)
-		}
-		return
+		_, err := s.bot.Send(msg)
+		return err
 	}
 	
 	// Split into multiple messages
@@ -444,6 +716,7 @@ func (s *Scheduler) sendMessageWithAutoSplit(chatID int64, text string) {
 	
 	logger.Info("📄 Message too long (%d chars), splitting into %d parts", len(text), len(chunks))
 	
+	var firstErr error
 	for i, chunk := range chunks {
 		// Add part indicator
 		partHeader := fmt.Sprintf("📄 Part %d/%d\n\n", i+1, len(chunks))
@@ -452,6 +725,9 @@ func (s *Scheduler) sendMessageWithAutoSplit(chatID int64, text string) {
 		msg := tgbotapi.NewMessage(chatID, messageText)
 		if _, err := s.bot.Send(msg); err != nil {
 			logger.Error("Failed to send part %d/%d: %v", i+1, len(chunks), err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("part %d/%d: %w", i+1, len(chunks), err)
+			}
 			continue
 		}
 		
@@ -460,6 +736,7 @@ func (s *Scheduler) sendMessageWithAutoSplit(chatID int64, text string) {
 		// Small delay between messages
 		time.Sleep(500 * time.Millisecond)
 	}
+	return firstErr
 }
 
 // splitMessageAtSectionBreaks splits message at section headers
@@ -0,0 +1,306 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"telegram-summarizer/internal/archive"
+	"telegram-summarizer/internal/db"
+	"telegram-summarizer/internal/summarizer"
+	"telegram-summarizer/internal/timezone"
+	"testing"
+	"time"
+)
+
+// stopsWithin fails the test unless stop returns within a timeout
+func stopsWithin(t *testing.T, timeout time.Duration, stop func()) {
+	t.Helper()
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		stop()
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(timeout):
+		t.Fatalf("scheduler did not stop within %s", timeout)
+	}
+}
+
+// setOtherZone moves the group to a timezone other than the default, so daily runs of
+// it are not followed by the digest, and returns the zone
+func setOtherZone(t *testing.T, s *Scheduler, group db.TrackedGroup) string {
+	t.Helper()
+	zone := "Pacific/Chatham"
+	if timezone.Default().String() == zone {
+		zone = "Pacific/Kiritimati"
+	}
+	if err := s.database.SetGroupTimezone(group.ChatID, zone); err != nil {
+		t.Fatalf("SetGroupTimezone: %v", err)
+	}
+	return zone
+}
+
+func TestStopLeavesPreviousLeaderJobs(t *testing.T) {
+	s, group := newTestScheduler(t, db.WindowPolicy{})
+	windowStart := time.Now().Truncate(time.Hour).Add(-time.Hour)
+	job := &db.SummaryJob{Kind: db.JobHourly, ChatID: group.ChatID, SummaryType: "1h", WindowStart: windowStart,
+		WindowEnd: windowStart.Add(time.Hour)}
+	if added, err := s.database.EnqueueJob(job); err != nil || !added {
+		t.Fatalf("EnqueueJob: added %v, err %v", added, err)
+	}
+	// The previous leader is still running the job
+	claimed, err := s.database.ClaimJob(time.Now())
+	if err != nil || claimed == nil {
+		t.Fatalf("ClaimJob: %+v, err %v", claimed, err)
+	}
+	
+	s.SetTakeoverDelay(time.Hour)
+	s.Start(context.Background(), "23:59")
+	stopsWithin(t, 5*time.Second, s.Stop)
+	
+	running, err := s.database.GetJob(claimed.ID)
+	if err != nil || running == nil {
+		t.Fatalf("GetJob: %+v, err %v", running, err)
+	}
+	if running.Status != db.JobRunning || running.Attempts != 1 {
+		t.Errorf("job is %s after %d attempt(s), want it left running by the previous leader", running.Status, running.Attempts)
+	}
+}
+
+func TestStopsWhenContextIsDone(t *testing.T) {
+	s, _ := newTestScheduler(t, db.WindowPolicy{})
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Start(ctx, "23:59")
+	cancel()
+	// The loops are waiting for their first tick; they stop without Stop
+	stopsWithin(t, 5*time.Second, s.wg.Wait)
+	s.Stop()
+}
+
+func TestStopBeforeStart(t *testing.T) {
+	s, _ := newTestScheduler(t, db.WindowPolicy{})
+	s.Stop()
+	// The loops see the scheduler stopped and return at once
+	s.Start(context.Background(), "23:59")
+	stopsWithin(t, 5*time.Second, s.wg.Wait)
+}
+
+func TestDailyRunQueuesJobs(t *testing.T) {
+	s, group := newTestScheduler(t, db.WindowPolicy{})
+	if err := s.database.EnableGroupSummary(group.ChatID); err != nil {
+		t.Fatalf("EnableGroupSummary: %v", err)
+	}
+	zones := []string{setOtherZone(t, s, group)}
+	
+	// Running the day twice, e.g. after a restart, queues the group's day once
+	s.runDailySummaryForAllGroups(zones)
+	s.runDailySummaryForAllGroups(zones)
+	stopsWithin(t, 5*time.Second, s.Stop)
+	
+	jobs, err := s.database.GetJobs(db.JobPending, 10)
+	if err != nil {
+		t.Fatalf("GetJobs: %v", err)
+	}
+	if len(jobs) != 1 {
+		t.Fatalf("got %d pending jobs, want the daily summary of the group", len(jobs))
+	}
+	job := jobs[0]
+	loc, _ := timezone.Load(zones[0])
+	dayStart := timezone.DayStart(job.WindowEnd.In(loc), loc)
+	if job.Kind != db.JobDaily || job.ChatID != group.ChatID || job.PromptType != summarizer.PromptTypeDaily ||
+		!job.WindowStart.Equal(dayStart) {
+		t.Errorf("queued %+v, want a daily job of group %d from %s", job, group.ChatID, dayStart)
+	}
+}
+
+func TestDailyRunIsTimed(t *testing.T) {
+	s, group := newTestScheduler(t, db.WindowPolicy{})
+	if err := s.database.EnableGroupSummary(group.ChatID); err != nil {
+		t.Fatalf("EnableGroupSummary: %v", err)
+	}
+	zone := setOtherZone(t, s, group)
+	defer s.Stop()
+	
+	run := s.runDailySummaryForAllGroups([]string{zone})
+	job, err := s.database.ClaimJob(time.Now())
+	if err != nil || job == nil {
+		t.Fatalf("ClaimJob: %+v, err %v", job, err)
+	}
+	// Without period summaries the day is skipped, which succeeds
+	s.runJob(job)
+	
+	select {
+	case <-run.done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("daily run did not finish, waiting for %v", run.unfinished())
+	}
+	if succeeded, failed := run.outcome(); succeeded != 1 || failed != 0 {
+		t.Errorf("daily run: %d succeeded, %d failed; want the group's job timed", succeeded, failed)
+	}
+	if done, err := s.database.GetJob(job.ID); err != nil || done.Status != db.JobSucceeded {
+		t.Errorf("GetJob: %+v (err %v), want it succeeded", done, err)
+	}
+}
+
+// fakeProvider is an AI provider answering every prompt with the same summary
+type fakeProvider struct {
+	summary string
+	err     error // Returned instead of the summary if set
+	prompts []string
+}
+
+func (p *fakeProvider) GenerateSummary(prompt string) (string, error) {
+	p.prompts = append(p.prompts, prompt)
+	if p.err != nil {
+		return "", p.err
+	}
+	return p.summary, nil
+}
+
+func (p *fakeProvider) GetName() string   { return "fake" }
+func (p *fakeProvider) IsAvailable() bool { return true }
+
+func TestDailySummaryCountsMessages(t *testing.T) {
+	tests := []struct {
+		name    string
+		deleted bool // Messages were deleted after the hourly summaries
+	}{
+		{"stored messages", false},
+		{"deleted messages", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, group := newTestScheduler(t, db.WindowPolicy{})
+			s.SetRetention(archive.NewService(s.database, archive.Config{Mode: archive.ModeNone,
+				DBPath: filepath.Join(t.TempDir(), "archive.db")}))
+			ai := &fakeProvider{summary: "📊 STATISTIK\n- Total pesan: 1\n- User aktif: 1\n"}
+			s.summarizer = summarizer.NewSummarizer(s.database, nil)
+			s.summarizer.SetProviders(ai)
+			
+			loc, _ := timezone.Load(group.Timezone)
+			dayStart := time.Date(2026, 1, 5, 0, 0, 0, 0, loc)
+			
+			// Two hourly summaries of 4 messages by 3 users
+			for i, users := range [][]int64{{1, 2}, {2, 3}} {
+				hour := dayStart.Add(time.Duration(9+i) * time.Hour)
+				for j, userID := range append(users, users...) {
+					at := hour.Add(time.Duration(j) * time.Minute)
+					if _, err := s.database.SaveMessage(&db.Message{ChatID: group.ChatID, UserID: userID, Username: "user",
+						MessageText: "hello", Timestamp: at.UTC()}); err != nil {
+						t.Fatalf("SaveMessage: %v", err)
+					}
+					if err := s.database.TrackUser(db.UserSighting{UserID: userID, ChatID: group.ChatID, Username: "user", Timestamp: at}); err != nil {
+						t.Fatalf("TrackUser: %v", err)
+					}
+				}
+				if err := s.database.SaveSummary(&db.Summary{ChatID: group.ChatID, SummaryType: "1h", PeriodStart: hour,
+					PeriodEnd: hour.Add(time.Hour), SummaryText: "Ringkasan jam", MessageCount: 4}); err != nil {
+					t.Fatalf("SaveSummary: %v", err)
+				}
+			}
+			if tt.deleted {
+				if err := s.database.DeleteMessagesByTimeRange(group.ChatID, dayStart, dayStart.AddDate(0, 0, 1)); err != nil {
+					t.Fatalf("DeleteMessagesByTimeRange: %v", err)
+				}
+			}
+			
+			if err := s.generateDailySummary(group, dayStart, dayStart.AddDate(0, 0, 1)); err != nil {
+				t.Fatalf("generateDailySummary: %v", err)
+			}
+			
+			if len(ai.prompts) != 1 || !strings.Contains(ai.prompts[0], "- Total pesan: 8\n- User aktif: 3\n") {
+				t.Errorf("prompts %q do not state 8 messages by 3 users", ai.prompts)
+			}
+			daily := s.database.GetSummariesByTimeRange(group.ChatID, "daily", dayStart, dayStart.AddDate(0, 0, 1))
+			if len(daily) != 1 {
+				t.Fatalf("got %d daily summaries, want 1", len(daily))
+			}
+			if daily[0].MessageCount != 8 || !strings.Contains(daily[0].SummaryText, "- Total pesan: 8\n- User aktif: 3") {
+				t.Errorf("stored %d messages with text %q, want 8 messages by 3 users", daily[0].MessageCount, daily[0].SummaryText)
+			}
+		})
+	}
+}
+
+func TestChannelsAreNotSummarized(t *testing.T) {
+	s, group := newTestScheduler(t, db.WindowPolicy{})
+	channel := db.TrackedGroup{ChatID: db.ChannelChatID(5678).Int64(), GroupName: "News", ChatType: db.ChatTypeChannel}
+	if err := s.database.SyncTrackedGroup(&channel); err != nil {
+		t.Fatalf("SyncTrackedGroup: %v", err)
+	}
+	var zones []string
+	for _, g := range []db.TrackedGroup{group, channel} {
+		if err := s.database.EnableGroupSummary(g.ChatID); err != nil {
+			t.Fatalf("EnableGroupSummary: %v", err)
+		}
+		zones = append(zones, setOtherZone(t, s, g))
+	}
+	now := time.Now()
+	for _, g := range []db.TrackedGroup{group, channel} {
+		saveMessages(t, s, g, now.Add(-50*time.Minute), now.Add(-30*time.Minute), now.Add(-10*time.Minute))
+	}
+	
+	s.generate1HourSummaries(now)
+	s.runDailySummaryForAllGroups(zones[:1])
+	if err := s.database.AddSummarySchedule(&db.SummarySchedule{ChatID: channel.ChatID, CronExpr: "@hourly", PromptType: "1h"}); err != nil {
+		t.Fatalf("AddSummarySchedule: %v", err)
+	}
+	s.runDueSchedules(now.Add(2 * time.Hour))
+	stopsWithin(t, 5*time.Second, s.Stop)
+	
+	jobs, err := s.database.GetJobs(db.JobPending, 50)
+	if err != nil {
+		t.Fatalf("GetJobs: %v", err)
+	}
+	if len(jobs) == 0 {
+		t.Fatal("no jobs queued, want the group's")
+	}
+	for _, job := range jobs {
+		if job.ChatID == channel.ChatID {
+			t.Errorf("queued %s job of the broadcast channel", job.Kind)
+		}
+	}
+}
+
+func TestDigestJobIsRetried(t *testing.T) {
+	s, group := newTestScheduler(t, db.WindowPolicy{})
+	if err := s.database.EnableGroupSummary(group.ChatID); err != nil {
+		t.Fatalf("EnableGroupSummary: %v", err)
+	}
+	ai := &fakeProvider{err: errors.New("provider down")}
+	s.summarizer = summarizer.NewSummarizer(s.database, nil)
+	s.summarizer.SetProviders(ai)
+	defer s.Stop()
+	
+	now := time.Now()
+	if err := s.database.SaveSummary(&db.Summary{ChatID: group.ChatID, SummaryType: summarizer.PromptTypeDaily,
+		PeriodStart: now.Add(-20 * time.Hour), PeriodEnd: now.Add(-time.Hour), SummaryText: "Ringkasan harian", MessageCount: 12}); err != nil {
+		t.Fatalf("SaveSummary: %v", err)
+	}
+	
+	s.enqueueDigest(now)
+	job, err := s.database.ClaimJob(time.Now())
+	if err != nil || job == nil || job.Kind != db.JobDigest {
+		t.Fatalf("ClaimJob: %+v, err %v; want the digest job", job, err)
+	}
+	s.runJob(job)
+	failed, err := s.database.GetJob(job.ID)
+	if err != nil || failed.Status != db.JobPending || failed.LastError == "" {
+		t.Fatalf("GetJob: %+v (err %v), want the failed digest queued for a retry", failed, err)
+	}
+	
+	ai.err, ai.summary = nil, "Ringkasan lintas grup"
+	retry, err := s.database.ClaimJob(time.Now().Add(jobRetryMax))
+	if err != nil || retry == nil || retry.ID != job.ID {
+		t.Fatalf("ClaimJob: %+v, err %v; want the digest job retried", retry, err)
+	}
+	s.runJob(retry)
+	if done, err := s.database.GetJob(job.ID); err != nil || done.Status != db.JobSucceeded {
+		t.Errorf("GetJob: %+v (err %v), want it succeeded", done, err)
+	}
+	if digests := s.database.GetSummariesByTimeRange(db.DigestChatID, summarizer.PromptTypeDigest, now.Add(-24*time.Hour), now); len(digests) != 1 {
+		t.Errorf("got %d stored digests, want 1", len(digests))
+	}
+}
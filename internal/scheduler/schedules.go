@@ -0,0 +1,129 @@
+package scheduler
+
+import (
+	"telegram-summarizer/internal/cron"
+	"telegram-summarizer/internal/db"
+	"telegram-summarizer/internal/logger"
+	"telegram-summarizer/internal/timezone"
+	"time"
+)
+
+// scheduleCheckInterval is how often the cron schedules are checked for due runs
+const scheduleCheckInterval = time.Minute
+
+// runCronScheduler runs the per-group cron schedules. Schedules are read from the
+// database on every check, so changes made through the bot apply without a restart.
+func (s *Scheduler) runCronScheduler() {
+	logger.Info("🗓️  Starting cron schedule checker")
+	
+	// Align to the next minute so runs start on time
+	now := time.Now()
+	select {
+	case <-time.After(now.Truncate(time.Minute).Add(time.Minute).Sub(now)):
+	case <-s.ctx.Done():
+		return
+	}
+	
+	ticker := time.NewTicker(scheduleCheckInterval)
+	defer ticker.Stop()
+	
+	for {
+		s.runDueSchedules(time.Now())
+	
+		select {
+		case <-ticker.C:
+		case <-s.ctx.Done():
+			logger.Info("🗓️  Cron schedule checker stopped")
+			return
+		}
+	}
+}
+
+// runDueSchedules runs every schedule with a run time since its last run. Only the
+// latest missed run time of a schedule is run. Cron fields are matched against the
+// clock of the group's timezone.
+func (s *Scheduler) runDueSchedules(now time.Time) {
+	schedules, err := s.database.GetSummarySchedules(0)
+	if err != nil {
+		logger.Error("Failed to get summary schedules: %v", err)
+		return
+	}
+	
+	for _, schedule := range schedules {
+		if group := s.database.GetTrackedGroup(schedule.ChatID); group != nil && !summarizable(*group) {
+			continue
+		}
+		expr, err := cron.Parse(schedule.CronExpr)
+		if err != nil {
+			logger.Error("Invalid schedule %d of %s: %v", schedule.ID, schedule.GroupName, err)
+			continue
+		}
+	
+		loc := s.groupLocation(schedule.ChatID)
+		since := schedule.LastRunAt
+		if since.IsZero() {
+			since = schedule.CreatedAt
+		}
+		next := expr.Next(since.In(loc))
+		if next.IsZero() || next.After(now) {
+			continue
+		}
+	
+		// Latest run time up to now; the window ends there and starts at the run time before
+		runAt := expr.Prev(now.In(loc).Truncate(time.Minute).Add(time.Minute))
+		s.enqueueSchedule(schedule, expr.Prev(runAt), runAt)
+	
+		if err := s.database.SetSummaryScheduleLastRun(schedule.ID, runAt); err != nil {
+			logger.Error("Failed to record run of schedule %d: %v", schedule.ID, err)
+		}
+	}
+}
+
+// enqueueSchedule queues the summary of a schedule for a window
+func (s *Scheduler) enqueueSchedule(schedule db.SummarySchedule, startTime, endTime time.Time) {
+	logger.Info("🗓️  Schedule %d (%s, %s) for %s: %s - %s", schedule.ID, schedule.CronExpr, schedule.PromptType,
+		schedule.GroupName, startTime.Format("2006-01-02 15:04"), endTime.Format("2006-01-02 15:04"))
+	
+	s.enqueueJob(&db.SummaryJob{
+		Kind:        db.JobScheduled,
+		ChatID:      schedule.ChatID,
+		SummaryType: schedule.PromptType,
+		PromptType:  schedule.PromptType,
+		WindowStart: startTime,
+		WindowEnd:   endTime,
+	})
+}
+
+// groupLocation returns the timezone of a group by chat ID
+func (s *Scheduler) groupLocation(chatID int64) *time.Location {
+	if group := s.database.GetTrackedGroup(chatID); group != nil {
+		return timezone.Of(*group)
+	}
+	return timezone.Default()
+}
+
+// withoutSchedules filters out the groups that have cron schedules; the built-in
+// hourly and daily summaries only run for groups without schedules of their own
+func (s *Scheduler) withoutSchedules(groups []db.TrackedGroup) []db.TrackedGroup {
+	schedules, err := s.database.GetSummarySchedules(0)
+	if err != nil {
+		logger.Error("Failed to get summary schedules: %v", err)
+		return groups
+	}
+	if len(schedules) == 0 {
+		return groups
+	}
+	
+	scheduled := make(map[int64]bool, len(schedules))
+	for _, schedule := range schedules {
+		scheduled[schedule.ChatID] = true
+	}
+	
+	var filtered []db.TrackedGroup
+	for _, group := range groups {
+		if !scheduled[group.ChatID] {
+			filtered = append(filtered, group)
+		}
+	}
+	return filtered
+}
@@ -158,12 +158,48 @@ func (cm *ChunkManager) FormatMessagesForPrompt(messages []db.Message) string {
 	
 	for _, msg := range messages {
 		timestamp := msg.Timestamp.Format("15:04")
-		builder.WriteString(fmt.Sprintf("[%s] %s: %s\n", timestamp, msg.Username, msg.MessageText))
+		builder.WriteString(fmt.Sprintf("[%s] %s: %s\n", timestamp, msg.Username, formatMessageContent(msg)))
 	}
 	
 	return builder.String()
 }
 
+// mediaLabels are the markers shown to the AI for each attachment type
+var mediaLabels = map[string]string{
+	db.MediaTypePhoto:     "📷 FOTO",
+	db.MediaTypeVideo:     "🎥 VIDEO",
+	db.MediaTypeAnimation: "🎞️ GIF",
+	db.MediaTypeDocument:  "📎 FILE",
+	db.MediaTypeAudio:     "🎵 AUDIO",
+	db.MediaTypeVoice:     "🎤 VOICE NOTE",
+	db.MediaTypeSticker:   "STIKER",
+}
+
+// formatMessageContent returns the message text with markers for attached media and OCR text,
+// e.g. "mantap kencang [📷 FOTO] [OCR: Download 45.2 Mbps Upload 12.1 Mbps]"
+func formatMessageContent(msg db.Message) string {
+	if msg.MediaType == "" {
+		return msg.MessageText
+	}
+	
+	parts := []string{}
+	if msg.MessageText != "" {
+		parts = append(parts, msg.MessageText)
+	}
+	
+	label, ok := mediaLabels[msg.MediaType]
+	if !ok {
+		label = strings.ToUpper(msg.MediaType)
+	}
+	parts = append(parts, "["+label+"]")
+	
+	if msg.OCRText != "" {
+		parts = append(parts, "[OCR: "+msg.OCRText+"]")
+	}
+	
+	return strings.Join(parts, " ")
+}
+
 // EstimatePromptSize estimates the size of a prompt with given messages
 func (cm *ChunkManager) EstimatePromptSize(messages []db.Message) int {
 	const templateSize = 3500 // Prompt template is ~3500 chars
@@ -0,0 +1,179 @@
+package summarizer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"telegram-summarizer/internal/analytics"
+	"telegram-summarizer/internal/db"
+	"telegram-summarizer/internal/logger"
+	"time"
+)
+
+// Digest settings
+const (
+	DigestName       = "Cross-group digest" // Group name of the stored digest
+	digestMinGroups  = 2                    // Groups an item must appear in to count as cross-group
+	digestMaxItems   = 10                   // Items listed per fact
+	digestPostLength = 160                  // Characters of a cross-post shown in the prompt
+)
+
+// ErrNoGroupSummaries is returned when no group has a daily summary for the digest
+var ErrNoGroupSummaries = errors.New("no daily summaries of groups")
+
+// DigestFacts are the cross-group statistics of a digest period computed from the
+// groups' daily summaries, product mentions and messages
+type DigestFacts struct {
+	Groups          int // Groups with a daily summary in the digest
+	TotalMessages   int
+	Products        []analytics.ProductStats // Products mentioned in several groups
+	RedFlagProducts []analytics.ProductStats // Products with red flags in several groups
+	RedFlagActors   []EntityCount            // @usernames in the red flags of several groups, by groups
+	CrossPosts      []db.CrossPost           // Texts posted in several groups
+	FamilyCodes     []db.FamilyCode          // FamilyCodes seen in the period and shared in several groups
+}
+
+// SummarizeDigest merges the latest daily summary of each group ending in the 24 hours
+// before endTime into one cross-group digest and stores it under db.DigestChatID.
+// Returns ErrNoGroupSummaries if no group has one.
+func (s *Summarizer) SummarizeDigest(groups []db.TrackedGroup, endTime time.Time) (*Result, *DigestFacts, error) {
+	startTime := endTime.Add(-24 * time.Hour)
+	
+	var daily []db.Summary
+	var parts []string
+	for _, group := range groups {
+		// Daily summaries start at midnight of the group's timezone, up to a day earlier
+		summaries := s.database.GetSummariesByTimeRange(group.ChatID, PromptTypeDaily, startTime.Add(-24*time.Hour), endTime)
+		if len(summaries) == 0 || !summaries[len(summaries)-1].PeriodEnd.After(startTime) {
+			continue
+		}
+		summary := summaries[len(summaries)-1]
+		daily = append(daily, summary)
+		parts = append(parts, fmt.Sprintf("Grup: %s (%d pesan)\n%s", group.GroupName, summary.MessageCount, summary.SummaryText))
+	}
+	if len(daily) == 0 {
+		return nil, nil, ErrNoGroupSummaries
+	}
+	logger.Info("Generating cross-group digest from %d daily summaries", len(daily))
+	
+	facts, err := s.digestFacts(daily, startTime, endTime)
+	if err != nil {
+		return nil, nil, err
+	}
+	
+	hierarchical := NewHierarchicalSummarizer(s.fallbackManager, nil, nil)
+	hierarchical.SetPromptType(PromptTypeDigest)
+	text, err := hierarchical.MergeSummaries(parts, DigestName, startTime, endTime, facts.PromptBlock())
+	if err != nil {
+		return nil, nil, fmt.Errorf("hierarchical merge failed: %w", err)
+	}
+	
+	result, err := s.Store(Request{
+		ChatID:       db.DigestChatID,
+		GroupName:    DigestName,
+		SummaryType:  PromptTypeDigest,
+		StartTime:    startTime,
+		EndTime:      endTime,
+		MessageCount: facts.TotalMessages,
+		NoProducts:   true,
+	}, text)
+	return result, facts, err
+}
+
+// digestFacts computes the cross-group facts of the daily summaries of a digest
+func (s *Summarizer) digestFacts(daily []db.Summary, startTime, endTime time.Time) (*DigestFacts, error) {
+	facts := &DigestFacts{Groups: len(daily)}
+	
+	summaries := make(map[int64]bool, len(daily))
+	actors := newCounter()
+	for _, summary := range daily {
+		summaries[summary.ID] = true
+		facts.TotalMessages += summary.MessageCount
+	
+		var keys, values []string
+		for _, m := range usernamePattern.FindAllStringSubmatch(redFlagSectionPattern.FindString(summary.SummaryText), -1) {
+			keys = append(keys, strings.ToLower(m[1]))
+			values = append(values, "@"+m[1])
+		}
+		actors.add(keys, values)
+	}
+	for _, actor := range actors.byCount() {
+		if actor.Count >= digestMinGroups {
+			facts.RedFlagActors = append(facts.RedFlagActors, actor)
+		}
+	}
+	
+	// Daily summaries may end right at endTime, which the activity range excludes
+	activity, err := s.database.GetProductActivity(0, PromptTypeDaily, startTime, endTime.Add(time.Second))
+	if err != nil {
+		return nil, err
+	}
+	var inDigest []db.ProductActivity
+	for _, a := range activity {
+		if summaries[a.SummaryID] {
+			inDigest = append(inDigest, a)
+		}
+	}
+	facts.Products = analytics.CrossGroup(inDigest, digestMinGroups)
+	facts.RedFlagProducts = analytics.CrossGroup(analytics.RedFlags(inDigest), digestMinGroups)
+	
+	if facts.CrossPosts, err = s.database.GetCrossPosts(startTime, endTime, digestMinGroups, digestMaxItems); err != nil {
+		return nil, err
+	}
+	
+	codes, err := s.database.GetRecentFamilyCodes(startTime, 100)
+	if err != nil {
+		return nil, err
+	}
+	for _, code := range codes {
+		if code.Groups >= digestMinGroups {
+			facts.FamilyCodes = append(facts.FamilyCodes, code)
+		}
+	}
+	return facts, nil
+}
+
+// PromptBlock renders the facts as a ground-truth section for the AI prompt
+func (f *DigestFacts) PromptBlock() string {
+	var b strings.Builder
+	
+	b.WriteString("DATA FAKTUAL LINTAS GRUP (dihitung otomatis dari data tersimpan, PASTI BENAR):\n")
+	b.WriteString(fmt.Sprintf("- Grup: %d\n", f.Groups))
+	b.WriteString(fmt.Sprintf("- Total pesan: %d\n", f.TotalMessages))
+	
+	writeProductGroups(&b, "Produk di beberapa grup", f.Products)
+	writeProductGroups(&b, "Produk dengan red flag di beberapa grup", f.RedFlagProducts)
+	writeFactList(&b, "Aktor red flag di beberapa grup", f.RedFlagActors, " grup")
+	
+	if len(f.CrossPosts) > 0 {
+		b.WriteString("- Pesan cross-post:\n")
+		for _, post := range f.CrossPosts {
+			text := truncateRunes(strings.Join(strings.Fields(post.Text), " "), digestPostLength)
+			b.WriteString(fmt.Sprintf("  • %q (%d grup, %d kali)\n", text, post.Groups, post.Messages))
+		}
+	}
+	
+	if len(f.FamilyCodes) > 0 {
+		items := make([]string, 0, min(len(f.FamilyCodes), digestMaxItems))
+		for _, code := range f.FamilyCodes[:min(len(f.FamilyCodes), digestMaxItems)] {
+			items = append(items, fmt.Sprintf("%s (%d grup)", truncateRunes(code.ProductText, 40), code.Groups))
+		}
+		b.WriteString(fmt.Sprintf("- FC dibagikan di beberapa grup: %s\n", strings.Join(items, ", ")))
+	}
+	
+	b.WriteString("\nGunakan angka di atas PERSIS apa adanya - JANGAN hitung ulang.\n")
+	return b.String()
+}
+
+// writeProductGroups writes a list of products with the number of groups mentioning them
+func writeProductGroups(b *strings.Builder, label string, products []analytics.ProductStats) {
+	if len(products) == 0 {
+		return
+	}
+	
+	items := make([]string, 0, min(len(products), digestMaxItems))
+	for _, p := range products[:min(len(products), digestMaxItems)] {
+		items = append(items, fmt.Sprintf("%s (%d grup, %d sebutan)", p.Name, p.Groups, p.Mentions))
+	}
+	b.WriteString(fmt.Sprintf("- %s: %s\n", label, strings.Join(items, ", ")))
+}
@@ -0,0 +1,109 @@
+package summarizer
+
+import (
+	"path/filepath"
+	"strings"
+	"telegram-summarizer/internal/analytics"
+	"telegram-summarizer/internal/catalog"
+	"telegram-summarizer/internal/db"
+	"testing"
+	"time"
+)
+
+func TestDigestFacts(t *testing.T) {
+	store, err := db.Open(db.DriverSQLite, filepath.Join(t.TempDir(), "digest.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer store.Close()
+	s := &Summarizer{database: store, catalog: catalog.New(store)}
+	
+	endTime := time.Date(2026, 1, 7, 0, 0, 0, 0, time.UTC)
+	startTime := endTime.Add(-24 * time.Hour)
+	groupA, groupB, groupC := db.ChannelChatID(1).Int64(), db.ChannelChatID(2).Int64(), db.ChannelChatID(3).Int64()
+	
+	saveDaily := func(chatID int64, messages, redFlags int, text string, mentions []db.ProductMention) db.Summary {
+		t.Helper()
+		summary := &db.Summary{ChatID: chatID, SummaryType: PromptTypeDaily, PeriodStart: startTime, PeriodEnd: endTime,
+			SummaryText: text, MessageCount: messages, RedFlagsCount: redFlags}
+		if err := store.SaveSummaryWithProducts(summary, mentions); err != nil {
+			t.Fatalf("SaveSummaryWithProducts: %v", err)
+		}
+		if err := s.catalog.Link(mentions, chatID, endTime); err != nil {
+			t.Fatalf("Link: %v", err)
+		}
+		return *summary
+	}
+	daily := []db.Summary{
+		saveDaily(groupA, 10, 0, "## 🚩 RED FLAGS\n- @Scammer jual akun curian\n", []db.ProductMention{
+			{ProductName: "XL Akrab", MentionCount: 2, ValidationStatus: "suspicious"},
+			{ProductName: "Telkomsel Orbit", MentionCount: 1},
+		}),
+		saveDaily(groupB, 20, 1, "## 🚩 RED FLAGS\n- @scammer lagi, juga @lokal\n", []db.ProductMention{
+			{ProductName: "XL Akrab", MentionCount: 3},
+		}),
+		// Mentions outside the red flags don't count
+		saveDaily(groupC, 5, 0, "Aman. @lokal dan @Scammer cuma disebut", []db.ProductMention{
+			{ProductName: "Telkomsel Orbit", MentionCount: 1},
+		}),
+	}
+	
+	promo := "Promo kuota unlimited murah meriah, langsung hubungi admin sekarang"
+	local := "Pesan panjang yang cuma dikirim ke satu grup saja, dua kali berturut"
+	for _, m := range []struct {
+		chatID int64
+		text   string
+		at     time.Time
+	}{
+		{groupA, promo, startTime.Add(time.Hour)},
+		{groupB, promo, startTime.Add(2 * time.Hour)},
+		{groupB, promo, startTime.Add(3 * time.Hour)},
+		{groupA, local, startTime.Add(time.Hour)},
+		{groupA, local, startTime.Add(2 * time.Hour)},
+		{groupC, local, startTime.Add(-time.Hour)}, // Before the digest period
+	} {
+		msg := &db.Message{ChatID: m.chatID, UserID: 42, MessageText: m.text, MessageLength: len(m.text), Timestamp: m.at}
+		if _, err := store.SaveMessage(msg); err != nil {
+			t.Fatalf("SaveMessage: %v", err)
+		}
+	}
+	
+	facts, err := s.digestFacts(daily, startTime, endTime)
+	if err != nil {
+		t.Fatalf("digestFacts: %v", err)
+	}
+	
+	if facts.Groups != 3 || facts.TotalMessages != 35 {
+		t.Errorf("got %d groups with %d messages, want 3 with 35", facts.Groups, facts.TotalMessages)
+	}
+	if len(facts.RedFlagActors) != 1 || facts.RedFlagActors[0] != (EntityCount{"@Scammer", 2}) {
+		t.Errorf("got red flag actors %v, want @Scammer in 2 groups", facts.RedFlagActors)
+	}
+	if len(facts.CrossPosts) != 1 || facts.CrossPosts[0] != (db.CrossPost{Text: promo, Groups: 2, Messages: 3}) {
+		t.Errorf("got cross-posts %+v, want the promo in 2 groups, 3 times", facts.CrossPosts)
+	}
+	
+	tests := []struct {
+		name     string
+		stats    []analytics.ProductStats
+		want     []string
+		mentions int // Of the first product
+	}{
+		{"products", facts.Products, []string{"XL Akrab", "Telkomsel Orbit"}, 5},
+		{"red flag products", facts.RedFlagProducts, []string{"XL Akrab"}, 5},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, p := range tt.stats {
+			got = append(got, p.Name)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		if tt.stats[0].Groups != 2 || tt.stats[0].Mentions != tt.mentions {
+			t.Errorf("%s: got %s in %d groups with %d mentions, want 2 groups and %d",
+				tt.name, tt.stats[0].Name, tt.stats[0].Groups, tt.stats[0].Mentions, tt.mentions)
+		}
+	}
+}
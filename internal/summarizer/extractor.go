@@ -0,0 +1,233 @@
+package summarizer
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"telegram-summarizer/internal/catalog"
+	"telegram-summarizer/internal/db"
+	"telegram-summarizer/internal/familycode"
+)
+
+// Patterns of the entities counted before summarization
+var (
+	linkPattern     = regexp.MustCompile(`(?i)\b(?:https?://|t\.me/)[^\s<>"'\])]+`)
+	usernamePattern = regexp.MustCompile(`(?:^|[^\w@])@([A-Za-z][A-Za-z0-9_]{3,31})\b`)
+	phonePattern    = regexp.MustCompile(`(?:^|[^\d+])((?:\+62|62|0)8\d{1,3}[\s-]?\d{3,4}[\s-]?\d{3,5})\b`)
+	protocolPattern = regexp.MustCompile(`(?i)\b(vless|vmess|trojan|ssh)\b`)
+	// Only amounts with "Rp" or a Rupiah suffix: plain numbers in chat are rarely prices
+	chatPricePattern = regexp.MustCompile(`(?i)\brp\.?\s*\d[\d.,]*(?:\s*(?:rb|ribu|k|jt|juta)\b)?|\b\d[\d.,]*\s*(?:rb|ribu|k|jt|juta)\b`)
+)
+
+// maxFactItems is the maximum number of items of each list written to the prompt
+const maxFactItems = 10
+
+// EntityCount is an entity with the number of messages it appears in
+type EntityCount struct {
+	Value string
+	Count int
+}
+
+// Facts are the statistics and entities of a set of messages computed without the
+// AI, so the summary can state them exactly
+type Facts struct {
+	TotalMessages int
+	ActiveUsers   int
+	BusiestHour   int // Hour of day with the most messages (-1 if no messages)
+	TopPosters    []EntityCount
+	Prices        []EntityCount // Price texts, e.g. "Rp 25.000", ordered by amount
+	Links         []EntityCount
+	Usernames     []EntityCount // Mentioned @usernames
+	FamilyCodes   []EntityCount
+	Phones        []EntityCount
+	Protocols     []EntityCount // vless, vmess, trojan, ssh
+}
+
+// counter counts the messages an entity appears in, keeping the first spelling seen
+type counter struct {
+	counts map[string]*EntityCount
+	order  []string
+}
+
+// newCounter creates an empty counter
+func newCounter() *counter {
+	return &counter{counts: make(map[string]*EntityCount)}
+}
+
+// add counts the values of one message; each distinct key counts once
+func (c *counter) add(keys, values []string) {
+	seen := make(map[string]bool)
+	for i, key := range keys {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if entry, ok := c.counts[key]; ok {
+			entry.Count++
+			continue
+		}
+		c.counts[key] = &EntityCount{Value: values[i], Count: 1}
+		c.order = append(c.order, key)
+	}
+}
+
+// byCount returns the counts, most frequent first, then in order of appearance
+func (c *counter) byCount() []EntityCount {
+	counts := make([]EntityCount, len(c.order))
+	for i, key := range c.order {
+		counts[i] = *c.counts[key]
+	}
+	sort.SliceStable(counts, func(i, j int) bool { return counts[i].Count > counts[j].Count })
+	return counts
+}
+
+// ExtractFacts counts the messages, users and entities of a set of messages
+func ExtractFacts(messages []db.Message) *Facts {
+	facts := &Facts{TotalMessages: len(messages), BusiestHour: -1}
+	
+	users := make(map[string]bool)
+	posters := newCounter()
+	var hours [24]int
+	prices := newCounter()
+	priceValues := make(map[string]int64) // Price text to amount
+	links, usernames, codes, phones, protocols := newCounter(), newCounter(), newCounter(), newCounter(), newCounter()
+	
+	for _, msg := range messages {
+		user := strconv.FormatInt(msg.UserID, 10)
+		if msg.UserID == 0 {
+			user = "@" + msg.Username
+		}
+		users[user] = true
+		name := msg.Username
+		if name == "" {
+			name = user
+		}
+		posters.add([]string{user}, []string{name})
+		hours[msg.Timestamp.Hour()]++
+	
+		text := msg.MessageText
+		if msg.OCRText != "" {
+			text += "\n" + msg.OCRText
+		}
+		if text == "" {
+			continue
+		}
+	
+		found := linkPattern.FindAllString(text, -1)
+		links.add(found, found)
+	
+		// Codes and links are removed so their digits aren't read as prices or phones
+		found = familycode.Extract(text)
+		codes.add(found, found)
+		rest := familycode.Pattern.ReplaceAllString(linkPattern.ReplaceAllString(text, " "), " ")
+	
+		var keys, values []string
+		for _, m := range usernamePattern.FindAllStringSubmatch(rest, -1) {
+			keys = append(keys, strings.ToLower(m[1]))
+			values = append(values, "@"+m[1])
+		}
+		usernames.add(keys, values)
+	
+		keys, values = nil, nil
+		for _, m := range phonePattern.FindAllStringSubmatch(rest, -1) {
+			keys = append(keys, normalizePhone(m[1]))
+			values = append(values, m[1])
+		}
+		phones.add(keys, values)
+		rest = phonePattern.ReplaceAllString(rest, " ")
+	
+		keys = nil
+		for _, m := range protocolPattern.FindAllString(rest, -1) {
+			keys = append(keys, strings.ToLower(m))
+		}
+		protocols.add(keys, keys)
+	
+		keys, values = nil, nil
+		for _, m := range chatPricePattern.FindAllString(rest, -1) {
+			parsed := catalog.ParsePrices(m)
+			if len(parsed) == 0 {
+				continue
+			}
+			keys = append(keys, strconv.FormatInt(parsed[0], 10))
+			values = append(values, strings.TrimSpace(m))
+			priceValues[strings.TrimSpace(m)] = parsed[0]
+		}
+		prices.add(keys, values)
+	}
+	
+	facts.ActiveUsers = len(users)
+	for hour, count := range hours {
+		if count > 0 && (facts.BusiestHour < 0 || count > hours[facts.BusiestHour]) {
+			facts.BusiestHour = hour
+		}
+	}
+	facts.TopPosters = posters.byCount()
+	facts.Links = links.byCount()
+	facts.Usernames = usernames.byCount()
+	facts.FamilyCodes = codes.byCount()
+	facts.Phones = phones.byCount()
+	facts.Protocols = protocols.byCount()
+	
+	facts.Prices = prices.byCount()
+	sort.SliceStable(facts.Prices, func(i, j int) bool {
+		return priceValues[facts.Prices[i].Value] < priceValues[facts.Prices[j].Value]
+	})
+	return facts
+}
+
+// normalizePhone reduces a phone number to its digits with a leading 0, e.g. "+62 812-3456-789" gives "08123456789"
+func normalizePhone(phone string) string {
+	var digits strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	number := digits.String()
+	if strings.HasPrefix(number, "62") {
+		number = "0" + number[2:]
+	}
+	return number
+}
+
+// PromptBlock renders the facts as a ground-truth section for the AI prompt
+func (f *Facts) PromptBlock() string {
+	var b strings.Builder
+	
+	b.WriteString("DATA FAKTUAL (dihitung otomatis dari pesan, PASTI BENAR):\n")
+	b.WriteString(fmt.Sprintf("- Total pesan: %d\n", f.TotalMessages))
+	b.WriteString(fmt.Sprintf("- User aktif: %d\n", f.ActiveUsers))
+	if f.BusiestHour >= 0 {
+		b.WriteString(fmt.Sprintf("- Jam paling ramai: %02d:00-%02d:59\n", f.BusiestHour, f.BusiestHour))
+	}
+	writeFactList(&b, "User paling aktif", f.TopPosters, " pesan")
+	writeFactList(&b, "Protokol disebut", f.Protocols, " pesan")
+	writeFactList(&b, "Harga disebut", f.Prices, "x")
+	writeFactList(&b, "FC (FamilyCode)", f.FamilyCodes, "x")
+	writeFactList(&b, "Link", f.Links, "x")
+	writeFactList(&b, "Username disebut", f.Usernames, "x")
+	writeFactList(&b, "Nomor HP", f.Phones, "x")
+	
+	b.WriteString("\nGunakan angka Total pesan dan User aktif di atas PERSIS apa adanya - JANGAN hitung ulang. ")
+	b.WriteString("Harga, FC, link dan protokol hanya boleh ditulis jika ada di data ini atau di pesan.\n")
+	return b.String()
+}
+
+// writeFactList writes one list of counted entities, at most maxFactItems
+func writeFactList(b *strings.Builder, label string, counts []EntityCount, unit string) {
+	if len(counts) == 0 {
+		return
+	}
+	
+	items := make([]string, 0, min(len(counts), maxFactItems))
+	for _, c := range counts[:min(len(counts), maxFactItems)] {
+		items = append(items, fmt.Sprintf("%s (%d%s)", c.Value, c.Count, unit))
+	}
+	line := strings.Join(items, ", ")
+	if len(counts) > maxFactItems {
+		line += fmt.Sprintf(", +%d lainnya", len(counts)-maxFactItems)
+	}
+	b.WriteString(fmt.Sprintf("- %s: %s\n", label, line))
+}
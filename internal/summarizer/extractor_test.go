@@ -0,0 +1,82 @@
+package summarizer
+
+import (
+	"reflect"
+	"strings"
+	"telegram-summarizer/internal/db"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+const testCode = "0a1b2c3d-4e5f-6a7b-8c9d-0e1f2a3b4c5d"
+
+func TestExtractFacts(t *testing.T) {
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+	at := func(hour, min int) time.Time {
+		return time.Date(2026, 1, 5, hour, min, 0, 0, jakarta)
+	}
+	messages := []db.Message{
+		{UserID: 1, Username: "alice", Timestamp: at(10, 5),
+			MessageText: "Paket 50GB Rp 25.000 cek https://t.me/promo dan @SellerOne wa 0812-3456-7890"},
+		{UserID: 2, Username: "bob", Timestamp: at(10, 30),
+			MessageText: "harga 25rb aja, FC: " + testCode + " vless"},
+		{UserID: 1, Username: "alice", Timestamp: at(21, 0),
+			MessageText: "rp 25.000 lagi https://t.me/promo +6281234567890 @sellerone " + testCode},
+		// Without a user ID, e.g. an anonymous admin; the text is in the screenshot
+		{Username: "anon", Timestamp: at(10, 45), OCRText: "Rp 100.000 vmess"},
+	}
+	
+	facts := ExtractFacts(messages)
+	if facts.TotalMessages != 4 || facts.ActiveUsers != 3 || facts.BusiestHour != 10 {
+		t.Errorf("got %d messages, %d users, busiest hour %d; want 4, 3 and 10",
+			facts.TotalMessages, facts.ActiveUsers, facts.BusiestHour)
+	}
+	
+	lists := []struct {
+		name string
+		got  []EntityCount
+		want []EntityCount
+	}{
+		{"top posters", facts.TopPosters, []EntityCount{{"alice", 2}, {"bob", 1}, {"anon", 1}}},
+		{"prices", facts.Prices, []EntityCount{{"Rp 25.000", 3}, {"Rp 100.000", 1}}},
+		{"links", facts.Links, []EntityCount{{"https://t.me/promo", 2}}},
+		{"usernames", facts.Usernames, []EntityCount{{"@SellerOne", 2}}},
+		{"family codes", facts.FamilyCodes, []EntityCount{{testCode, 2}}},
+		{"phones", facts.Phones, []EntityCount{{"0812-3456-7890", 2}}},
+		{"protocols", facts.Protocols, []EntityCount{{"vless", 1}, {"vmess", 1}}},
+	}
+	for _, l := range lists {
+		if !reflect.DeepEqual(l.got, l.want) {
+			t.Errorf("%s: got %v, want %v", l.name, l.got, l.want)
+		}
+	}
+	
+	// The busiest hour is that of the messages' timezone
+	if hour := ExtractFacts(inLocation(messages, time.UTC)).BusiestHour; hour != 3 {
+		t.Errorf("busiest hour in UTC is %d, want 3", hour)
+	}
+	if facts := ExtractFacts(nil); facts.TotalMessages != 0 || facts.BusiestHour != -1 {
+		t.Errorf("got %+v for no messages, want no busiest hour", facts)
+	}
+}
+
+func TestPromptBlock(t *testing.T) {
+	facts := &Facts{TotalMessages: 120, ActiveUsers: 15, BusiestHour: 9}
+	for i := 0; i < maxFactItems+2; i++ {
+		facts.Links = append(facts.Links, EntityCount{Value: "https://t.me/l" + strings.Repeat("x", i), Count: 1})
+	}
+	
+	block := facts.PromptBlock()
+	for _, want := range []string{"Total pesan: 120\n", "User aktif: 15\n", "Jam paling ramai: 09:00-09:59\n", ", +2 lainnya\n"} {
+		if !strings.Contains(block, want) {
+			t.Errorf("prompt block %q lacks %q", block, want)
+		}
+	}
+	if strings.Contains(block, "Harga disebut") {
+		t.Errorf("prompt block %q lists prices without any", block)
+	}
+}
@@ -2,6 +2,7 @@ package summarizer
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 	"time"
 )
@@ -29,7 +30,7 @@ func (sf *SummaryFormatter) FormatPartialSummary(summary string, part, total int
 	output.WriteString(fmt.Sprintf("*Group:* `%s`\n", groupName))
 	output.WriteString(fmt.Sprintf("*Period:* `%s` - `%s`\n", 
 		startTime.Format("15:04"), 
-		endTime.Format("15:04")))
+		endTime.Format("15:04 MST")))
 	output.WriteString(fmt.Sprintf("*Messages:* `~%d messages`\n\n", messageCount))
 	
 	// Separator
@@ -49,6 +50,27 @@ func (sf *SummaryFormatter) FormatPartialSummary(summary string, part, total int
 	return output.String()
 }
 
+// factLines are the summary lines whose values are replaced by the extracted facts,
+// e.g. "- Total pesan: 120"
+var factLines = regexp.MustCompile(`(?im)^([\s\-•*]*(?:\*\*)?(total pesan|user aktif)(?:\*\*)?\s*:\s*(?:\*\*)?\s*).*$`)
+
+// ApplyFacts replaces the message and user counts written by the AI with the
+// counts computed from the messages
+func (sf *SummaryFormatter) ApplyFacts(summary string, facts *Facts) string {
+	if facts == nil {
+		return summary
+	}
+	
+	return factLines.ReplaceAllStringFunc(summary, func(line string) string {
+		m := factLines.FindStringSubmatch(line)
+		value := facts.TotalMessages
+		if strings.EqualFold(m[2], "user aktif") {
+			value = facts.ActiveUsers
+		}
+		return fmt.Sprintf("%s%d", m[1], value)
+	})
+}
+
 // FormatCompletionMessage formats the completion message
 func (sf *SummaryFormatter) FormatCompletionMessage(totalParts int) string {
 	var output strings.Builder
@@ -0,0 +1,27 @@
+package summarizer
+
+import "testing"
+
+func TestApplyFacts(t *testing.T) {
+	facts := &Facts{TotalMessages: 4, ActiveUsers: 3}
+	tests := []struct {
+		summary, want string
+	}{
+		{"- Total pesan: 120", "- Total pesan: 4"},
+		{"**Total Pesan:** 99 pesan", "**Total Pesan:** 4"},
+		{"• User aktif : sekitar 10", "• User aktif : 3"},
+		{"## STATISTIK\n- Total pesan: 7\n- User aktif: 2\nRamai", "## STATISTIK\n- Total pesan: 4\n- User aktif: 3\nRamai"},
+		{"Total pesan tidak dihitung", "Total pesan tidak dihitung"},
+		{"Ada user aktif: banyak sekali", "Ada user aktif: banyak sekali"},
+	}
+	formatter := NewSummaryFormatter()
+	for _, tt := range tests {
+		if got := formatter.ApplyFacts(tt.summary, facts); got != tt.want {
+			t.Errorf("ApplyFacts(%q) = %q, want %q", tt.summary, got, tt.want)
+		}
+	}
+	
+	if got := formatter.ApplyFacts("- Total pesan: 120", nil); got != "- Total pesan: 120" {
+		t.Errorf("ApplyFacts without facts = %q, want the summary unchanged", got)
+	}
+}
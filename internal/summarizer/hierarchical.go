@@ -16,6 +16,9 @@ type HierarchicalSummarizer struct {
 	chunkManager      *ChunkManager
 	formatter         *SummaryFormatter
 	maxRecursionDepth int
+	promptType        string       // Prompt of chunk summaries (see GetPrompt)
+	mergeFacts        string       // Ground truth added to merge prompts (see MergeSummaries)
+	facts             *Facts       // Facts used instead of those of the messages (see SetFacts)
 	progressCallback  func(string) // Callback to send progress updates
 	summaryCallback   func(string) // Callback to send partial summaries
 }
@@ -33,10 +36,43 @@ func NewHierarchicalSummarizer(fallbackManager *ai.FallbackManager, progressCall
 	}
 }
 
-// SummarizeMessages generates a summary from messages with automatic chunking
+// SetPromptType sets the prompt type of chunk summaries (default PromptType24Hour)
+func (hs *HierarchicalSummarizer) SetPromptType(promptType string) {
+	hs.promptType = promptType
+}
+
+// SetFacts sets the facts stated in the summary instead of those extracted from the
+// messages, e.g. when the messages are summaries of the period; nil extracts them
+func (hs *HierarchicalSummarizer) SetFacts(facts *Facts) {
+	hs.facts = facts
+}
+
+// factsOf returns the facts of messages, or those set by SetFacts
+func (hs *HierarchicalSummarizer) factsOf(messages []db.Message) *Facts {
+	if hs.facts != nil {
+		return hs.facts
+	}
+	return ExtractFacts(messages)
+}
+
+// MergeSummaries merges finished summaries into one with automatic chunking, e.g. the
+// daily summaries of several groups. factsBlock (may be empty) is added to every merge
+// prompt as ground truth. Times in prompts are shown in the timezone of startTime.
+func (hs *HierarchicalSummarizer) MergeSummaries(summaries []string, name string, startTime, endTime time.Time, factsBlock string) (string, error) {
+	logger.Info("Starting hierarchical merge of %d summaries for %s", len(summaries), name)
+	
+	hs.mergeFacts = factsBlock
+	return hs.mergeSummariesRecursive(summaries, name, startTime, endTime.In(startTime.Location()), 0, nil)
+}
+
+// SummarizeMessages generates a summary from messages with automatic chunking.
+// Times in prompts are shown in the timezone of startTime.
 func (hs *HierarchicalSummarizer) SummarizeMessages(messages []db.Message, groupName string, startTime, endTime time.Time) (string, error) {
 	logger.Info("Starting hierarchical summarization for %d messages from %s", len(messages), groupName)
 	
+	messages = inLocation(messages, startTime.Location())
+	endTime = endTime.In(startTime.Location())
+	
 	// Check if we need to split messages
 	if !hs.chunkManager.ShouldSplitMessages(messages) {
 		// Small enough - direct summarization
@@ -78,6 +114,12 @@ func (hs *HierarchicalSummarizer) summarizeMessagesStreaming(messages []db.Messa
 		
 		batchChunks := chunks[batchStart:batchEnd]
 		
+		var batchMessages []db.Message
+		for _, chunk := range batchChunks {
+			batchMessages = append(batchMessages, chunk...)
+		}
+		batchFacts := hs.factsOf(batchMessages)
+		
 		logger.Info("Processing batch %d/%d (%d chunks)", batchIdx+1, totalBatches, len(batchChunks))
 		hs.sendProgress(fmt.Sprintf("📦 Processing batch %d/%d (%d chunks, ~%d messages)...", 
 			batchIdx+1, totalBatches, len(batchChunks), len(batchChunks)*30))
@@ -112,7 +154,7 @@ func (hs *HierarchicalSummarizer) summarizeMessagesStreaming(messages []db.Messa
 		batchEndTime := batchChunks[len(batchChunks)-1][len(batchChunks[len(batchChunks)-1])-1].Timestamp
 		
 		// Merge summaries for this batch
-		partialSummary, err := hs.mergeSummariesDirect(chunkSummaries, groupName, batchStartTime, batchEndTime)
+		partialSummary, err := hs.mergeSummariesDirect(chunkSummaries, groupName, batchStartTime, batchEndTime, batchFacts)
 		if err != nil {
 			logger.Error("Failed to merge batch %d: %v", batchIdx+1, err)
 			return "", fmt.Errorf("failed to merge batch %d/%d: %w", batchIdx+1, totalBatches, err)
@@ -121,7 +163,7 @@ func (hs *HierarchicalSummarizer) summarizeMessagesStreaming(messages []db.Messa
 		logger.Info("✅ Batch %d/%d merged (%d chars)", batchIdx+1, totalBatches, len(partialSummary))
 		
 		// Send partial summary to user
-		hs.sendPartialSummary(partialSummary, batchIdx+1, totalBatches, groupName, batchStartTime, batchEndTime, len(batchChunks)*30)
+		hs.sendPartialSummary(partialSummary, batchIdx+1, totalBatches, groupName, batchStartTime, batchEndTime, len(batchMessages))
 	}
 	
 	// Return completion message with elegant formatting
@@ -183,11 +225,11 @@ func (hs *HierarchicalSummarizer) summarizeMessagesRecursive(messages []db.Messa
 	// Now merge the summaries
 	hs.sendProgress(fmt.Sprintf("🔄 Merging %d summaries...", len(chunkSummaries)))
 	
-	return hs.mergeSummariesRecursive(chunkSummaries, groupName, startTime, endTime, depth+1)
+	return hs.mergeSummariesRecursive(chunkSummaries, groupName, startTime, endTime, depth+1, hs.factsOf(messages))
 }
 
-// mergeSummariesRecursive handles recursive summary merging
-func (hs *HierarchicalSummarizer) mergeSummariesRecursive(summaries []string, groupName string, startTime, endTime time.Time, depth int) (string, error) {
+// mergeSummariesRecursive handles recursive summary merging; facts are the facts of all summarized messages
+func (hs *HierarchicalSummarizer) mergeSummariesRecursive(summaries []string, groupName string, startTime, endTime time.Time, depth int, facts *Facts) (string, error) {
 	// Check recursion depth
 	if depth > hs.maxRecursionDepth {
 		return "", fmt.Errorf("maximum recursion depth (%d) reached during merge", hs.maxRecursionDepth)
@@ -199,7 +241,7 @@ func (hs *HierarchicalSummarizer) mergeSummariesRecursive(summaries []string, gr
 	if !hs.chunkManager.ShouldSplitSummaries(summaries) {
 		// Small enough - direct merge
 		logger.Info("Summaries small enough for direct merge")
-		return hs.mergeSummariesDirect(summaries, groupName, startTime, endTime)
+		return hs.mergeSummariesDirect(summaries, groupName, startTime, endTime, facts)
 	}
 	
 	// Too large - need recursive merge
@@ -217,7 +259,7 @@ func (hs *HierarchicalSummarizer) mergeSummariesRecursive(summaries []string, gr
 		
 		logger.Info("Merging group %d/%d (%d summaries)", i+1, len(groups), len(group))
 		
-		metaSummary, err := hs.mergeSummariesDirect(group, groupName, startTime, endTime)
+		metaSummary, err := hs.mergeSummariesDirect(group, groupName, startTime, endTime, nil)
 		if err != nil {
 			logger.Error("Failed to merge group %d: %v", i+1, err)
 			return "", fmt.Errorf("failed to merge group %d/%d: %w", i+1, len(groups), err)
@@ -228,7 +270,7 @@ func (hs *HierarchicalSummarizer) mergeSummariesRecursive(summaries []string, gr
 	}
 	
 	// Recursively merge the meta-summaries
-	return hs.mergeSummariesRecursive(metaSummaries, groupName, startTime, endTime, depth+1)
+	return hs.mergeSummariesRecursive(metaSummaries, groupName, startTime, endTime, depth+1, facts)
 }
 
 // summarizeChunkDirect summarizes a single chunk with fallback
@@ -238,8 +280,10 @@ func (hs *HierarchicalSummarizer) summarizeChunkDirect(messages []db.Message, gr
 	// Format messages
 	messagesText := hs.chunkManager.FormatMessagesForPrompt(messages)
 	
-	// Build prompt
-	prompt := hs.promptManager.GetManual24HPrompt(messagesText, groupName, startTime, endTime)
+	// Build prompt with the counts and entities extracted from the messages
+	facts := hs.factsOf(messages)
+	prompt := hs.promptManager.GetPrompt(hs.promptType, messagesText, groupName, startTime, endTime)
+	prompt = hs.promptManager.WithFacts(prompt, facts)
 	
 	logger.Debug("Prompt size: %d chars", len(prompt))
 	
@@ -250,11 +294,12 @@ func (hs *HierarchicalSummarizer) summarizeChunkDirect(messages []db.Message, gr
 	}
 	
 	logger.Info("✅ Summary generated: %d chars", len(summary))
-	return summary, nil
+	return hs.formatter.ApplyFacts(summary, facts), nil
 }
 
-// mergeSummariesDirect merges summaries directly with fallback
-func (hs *HierarchicalSummarizer) mergeSummariesDirect(summaries []string, groupName string, startTime, endTime time.Time) (string, error) {
+// mergeSummariesDirect merges summaries directly with fallback; facts (may be nil)
+// are the facts of the messages the summaries cover
+func (hs *HierarchicalSummarizer) mergeSummariesDirect(summaries []string, groupName string, startTime, endTime time.Time, facts *Facts) (string, error) {
 	logger.Info("Direct merge of %d summaries", len(summaries))
 	
 	// Combine summaries into one text
@@ -265,6 +310,10 @@ func (hs *HierarchicalSummarizer) mergeSummariesDirect(summaries []string, group
 	
 	// Build merge prompt
 	prompt := hs.buildMergePrompt(combined.String(), groupName, startTime, endTime)
+	prompt = hs.promptManager.WithFacts(prompt, facts)
+	if hs.mergeFacts != "" {
+		prompt += "\n" + hs.mergeFacts
+	}
 	
 	logger.Debug("Merge prompt size: %d chars", len(prompt))
 	
@@ -275,11 +324,15 @@ func (hs *HierarchicalSummarizer) mergeSummariesDirect(summaries []string, group
 	}
 	
 	logger.Info("✅ Merged summary generated: %d chars", len(finalSummary))
-	return finalSummary, nil
+	return hs.formatter.ApplyFacts(finalSummary, facts), nil
 }
 
 // buildMergePrompt builds a compact prompt for merging multiple summaries
 func (hs *HierarchicalSummarizer) buildMergePrompt(summariesText, groupName string, startTime, endTime time.Time) string {
+	if hs.promptType == PromptTypeDigest {
+		return hs.promptManager.GetDigestPrompt(summariesText, startTime, endTime)
+	}
+	
 	// More compact prompt to save space
 	prompt := fmt.Sprintf(`Gabungkan ringkasan partial berikut menjadi SATU ringkasan lengkap dan koheren dalam BAHASA INDONESIA.
 
@@ -295,7 +348,7 @@ Instruksi:
 4. Format: Struktur ringkasan 24 jam standar dengan section lengkap
 5. Koheren, rinci, dan mudah dibaca
 
-Output ringkasan final:`, groupName, startTime.Format("2006-01-02 15:04"), endTime.Format("15:04"), summariesText)
+Output ringkasan final:`, groupName, startTime.Format("2006-01-02 15:04"), endTime.Format("15:04 MST"), summariesText)
 	
 	return prompt
 }
@@ -308,6 +361,16 @@ func (hs *HierarchicalSummarizer) sendProgress(message string) {
 	logger.Info("Progress: %s", message)
 }
 
+// inLocation returns copies of messages with their timestamps in a timezone
+func inLocation(messages []db.Message, loc *time.Location) []db.Message {
+	localized := make([]db.Message, len(messages))
+	for i, msg := range messages {
+		msg.Timestamp = msg.Timestamp.In(loc)
+		localized[i] = msg
+	}
+	return localized
+}
+
 // escapeMarkdownSimple escapes basic markdown characters for Telegram
 func escapeMarkdownSimple(text string) string {
 	replacer := strings.NewReplacer(
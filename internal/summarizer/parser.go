@@ -6,6 +6,7 @@ import (
 	"regexp"
 	"strings"
 	"telegram-summarizer/internal/db"
+	"telegram-summarizer/internal/familycode"
 	"telegram-summarizer/internal/logger"
 )
 
@@ -71,7 +72,7 @@ func (p *MetadataParser) extractSentiment(text string) string {
 	
 	// Try various patterns
 	patterns := []string{
-		`Sentiment\s+(?:umum|harian):\s*(\w+)`,
+		`Sentiment\s+(?:umum|harian|mingguan|bulanan):\s*(\w+)`,
 		`Sentiment\s*:\s*(\w+)`,
 	}
 	
@@ -164,14 +165,18 @@ func (p *MetadataParser) extractProductDetails(productName, text string) db.Prod
 			fmt.Sscanf(mentionMatches[1], "%d", &product.MentionCount)
 		}
 		
-		// Extract price: "Harga: Rp 30.000" or "Rp 50.000"
-		pricePattern := `(?:Harga|Price):\s*((?:Rp\s*)?[\d.,]+)`
+		// Extract price: "Harga: Rp 30.000", "Harga: 150rb - 200rb" or "Harga: 1,5jt"
+		pricePattern := `(?:Harga|Price):\s*((?:Rp\.?\s*)?\d[^\n]*)`
 		re = regexp.MustCompile(`(?i)` + pricePattern)
 		priceMatches := re.FindStringSubmatch(details)
 		if len(priceMatches) > 1 {
 			product.PriceMentioned = strings.TrimSpace(priceMatches[1])
 		}
 		
+		// Extract FamilyCode UUIDs: "FC (FamilyCode): 23b71540-8785-4abe-816d-e9b4efa48f95"
+		codes := familycode.Extract(details)
+		product.FamilyCodes = strings.Join(codes, ",")
+		
 		// Extract credibility: "Rating kredibilitas: High" or "⭐⭐⭐⭐⭐"
 		product.CredibilityScore = p.extractCredibilityScore(details)
 		
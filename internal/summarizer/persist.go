@@ -0,0 +1,156 @@
+package summarizer
+
+import (
+	"errors"
+	"fmt"
+	"telegram-summarizer/internal/db"
+	"telegram-summarizer/internal/logger"
+	"time"
+)
+
+// ErrNotStored is returned (wrapped) when a summary was generated but could not be
+// stored. The returned Result is still valid, so the summary can be delivered.
+var ErrNotStored = errors.New("summary not stored")
+
+// Request describes a summary to generate and store
+type Request struct {
+	ChatID      int64
+	GroupName   string
+	SummaryType string // e.g. "1h", "daily", "manual-24h"
+	PromptType  string // Prompt of the message summaries (default PromptType24Hour)
+	StartTime   time.Time
+	EndTime     time.Time
+	Messages    []db.Message
+	
+	// MessageCount overrides the stored message count (default: len(Messages)),
+	// e.g. for daily summaries built from hourly summaries
+	MessageCount int
+	
+	// Facts are the counts and entities stated in the summary (default: those of
+	// Messages), e.g. the day's messages for daily summaries built from hourly summaries
+	Facts *Facts
+	
+	// NoProducts stores the summary without product mentions, e.g. for rollups of
+	// summaries whose mentions are already stored
+	NoProducts bool
+	
+	// Optional callbacks of hierarchical summarization
+	OnProgress func(string)
+	OnPartial  func(string)
+}
+
+// Result is a generated summary with its parsed metadata
+type Result struct {
+	Summary  *db.Summary         // Stored summary (ID is 0 if it was not stored)
+	Products []db.ProductMention // Stored product mentions
+	Metadata SummaryMetadata
+}
+
+// Text returns the summary text
+func (r *Result) Text() string {
+	return r.Summary.SummaryText
+}
+
+// StoredHook is called after a summary and its product mentions were committed
+type StoredHook func(result *Result)
+
+// OnSummaryStored registers a hook for stored summaries, e.g. for delivery or analytics.
+// Hooks run synchronously in the goroutine that stored the summary.
+func (s *Summarizer) OnSummaryStored(hook StoredHook) {
+	s.hooksMu.Lock()
+	defer s.hooksMu.Unlock()
+	s.storedHooks = append(s.storedHooks, hook)
+}
+
+// SummarizeAndStore generates a summary, parses its metadata and stores the summary
+// together with all product mentions in one transaction.
+// If generation fails nothing is stored and the result is nil. If storing fails the
+// result is returned with an error wrapping ErrNotStored.
+func (s *Summarizer) SummarizeAndStore(req Request) (*Result, error) {
+	progressCallback := req.OnProgress
+	if progressCallback == nil {
+		progressCallback = func(progressMsg string) {
+			logger.Debug("%s summary progress: %s", req.SummaryType, progressMsg)
+		}
+	}
+	
+	summaryCallback := req.OnPartial
+	if summaryCallback == nil {
+		summaryCallback = func(partialSummary string) {
+			logger.Debug("%s summary partial generated (%d chars)", req.SummaryType, len(partialSummary))
+		}
+	}
+	
+	text, err := s.generateHierarchical(req.PromptType, req.Messages, req.Facts, req.GroupName, req.StartTime, req.EndTime, progressCallback, summaryCallback)
+	if err != nil {
+		return nil, err
+	}
+	
+	return s.Store(req, text)
+}
+
+// Store parses the metadata of a generated summary and stores it atomically
+func (s *Summarizer) Store(req Request, text string) (*Result, error) {
+	metadata := s.metadataParser.Parse(text)
+	
+	messageCount := req.MessageCount
+	if messageCount == 0 {
+		messageCount = len(req.Messages)
+	}
+	
+	result := &Result{
+		Summary: &db.Summary{
+			ChatID:            req.ChatID,
+			SummaryType:       req.SummaryType,
+			PeriodStart:       req.StartTime,
+			PeriodEnd:         req.EndTime,
+			SummaryText:       text,
+			MessageCount:      messageCount,
+			Sentiment:         metadata.Sentiment,
+			CredibilityScore:  metadata.CredibilityScore,
+			ProductsMentioned: metadata.ProductsJSON,
+			RedFlagsCount:     metadata.RedFlagsCount,
+			ValidationStatus:  metadata.ValidationStatus,
+		},
+		Products: metadata.Products,
+		Metadata: metadata,
+	}
+	if req.NoProducts {
+		result.Products = nil
+	}
+	
+	if err := s.database.SaveSummaryWithProducts(result.Summary, result.Products); err != nil {
+		result.Summary.ID = 0
+		return result, fmt.Errorf("%w: %v", ErrNotStored, err)
+	}
+	
+	s.notifyStored(result)
+	return result, nil
+}
+
+// linkProducts links the product mentions of a stored summary to the catalog.
+// Products and aliases are only created for committed mentions; mentions that fail to
+// link stay unlinked until Backfill.
+func (s *Summarizer) linkProducts(result *Result) {
+	if err := s.catalog.Link(result.Products, result.Summary.ChatID, result.Summary.PeriodEnd); err != nil {
+		logger.Warn("Failed to link product mentions to catalog: %v", err)
+	}
+}
+
+// notifyStored runs the stored-summary hooks; a panicking hook does not affect the others
+func (s *Summarizer) notifyStored(result *Result) {
+	s.hooksMu.Lock()
+	hooks := append([]StoredHook(nil), s.storedHooks...)
+	s.hooksMu.Unlock()
+	
+	for _, hook := range hooks {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.Error("Summary hook panicked: %v", r)
+				}
+			}()
+			hook(result)
+		}()
+	}
+}
@@ -0,0 +1,62 @@
+package summarizer
+
+import (
+	"errors"
+	"path/filepath"
+	"telegram-summarizer/internal/db"
+	"testing"
+	"time"
+)
+
+// failingStore fails to save summaries
+type failingStore struct {
+	db.Store
+}
+
+func (failingStore) SaveSummaryWithProducts(*db.Summary, []db.ProductMention) error {
+	return errors.New("disk full")
+}
+
+func TestStoreLinksProductsOnlyAfterSave(t *testing.T) {
+	store, err := db.Open(db.DriverSQLite, filepath.Join(t.TempDir(), "summaries.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer store.Close()
+	
+	// The hooks see the IDs of the summaries stored by each summarizer
+	var stored []int64
+	newSummarizer := func(database db.Store) *Summarizer {
+		s := NewSummarizer(database, nil)
+		s.OnSummaryStored(func(result *Result) { stored = append(stored, result.Summary.ID) })
+		return s
+	}
+	end := time.Now().UTC().Truncate(time.Hour)
+	req := Request{ChatID: db.ChannelChatID(1234).Int64(), SummaryType: "1h", StartTime: end.Add(-time.Hour), EndTime: end,
+		MessageCount: 5}
+	text := "## 📦 PAKET/PRODUK YANG DIBAHAS\n**Kuota Xtra 50GB**\n- Harga: Rp 50.000\n\n## 💬 LAINNYA\n"
+	
+	// A failed save creates no products or aliases
+	result, err := newSummarizer(failingStore{store}).Store(req, text)
+	if !errors.Is(err, ErrNotStored) || result == nil || len(result.Products) != 1 {
+		t.Fatalf("Store with a failing save: %+v, err %v; want the product with ErrNotStored", result, err)
+	}
+	if aliases, err := store.GetProductAliases(0); err != nil || len(aliases) != 0 {
+		t.Errorf("failed save left aliases %+v (err=%v)", aliases, err)
+	}
+	if len(stored) != 0 {
+		t.Errorf("stored hook ran for %v after a failed save, want it not run", stored)
+	}
+	
+	result, err = newSummarizer(store).Store(req, text)
+	if err != nil || result.Summary.ID == 0 || len(result.Products) != 1 || result.Products[0].ProductID == 0 {
+		t.Fatalf("Store: %+v, err %v; want a stored summary with a linked product", result, err)
+	}
+	if len(stored) != 1 || stored[0] != result.Summary.ID {
+		t.Errorf("stored hook ran for %v, want once for summary %d", stored, result.Summary.ID)
+	}
+	mentions, err := store.GetProductMentions(result.Products[0].ProductID, 1)
+	if err != nil || len(mentions) != 1 || mentions[0].SummaryID != result.Summary.ID {
+		t.Errorf("got mentions %+v (err=%v), want the stored mention linked", mentions, err)
+	}
+}
@@ -5,6 +5,33 @@ import (
 	"time"
 )
 
+// Prompt types of scheduled summaries
+const (
+	PromptType1Hour   = "1h"      // Short summary of a 1-hour window
+	PromptType4Hour   = "4h"      // Detailed summary of a 4-hour window
+	PromptType24Hour  = "24h"     // Full summary of the messages of a window (default)
+	PromptTypeDaily   = "daily"   // Summary of the day's scheduled summaries
+	PromptTypeWeekly  = "weekly"  // Rollup of a week's daily summaries
+	PromptTypeMonthly = "monthly" // Rollup of a month's daily summaries
+)
+
+// PromptTypeDigest is the prompt of the cross-group digest. It is not a prompt type of
+// group summaries, so it is not in PromptTypes.
+const PromptTypeDigest = "digest"
+
+// PromptTypes lists the valid prompt types
+var PromptTypes = []string{PromptType1Hour, PromptType4Hour, PromptType24Hour, PromptTypeDaily, PromptTypeWeekly, PromptTypeMonthly}
+
+// ValidPromptType reports whether a prompt type is known
+func ValidPromptType(promptType string) bool {
+	for _, t := range PromptTypes {
+		if t == promptType {
+			return true
+		}
+	}
+	return false
+}
+
 // PromptManager manages prompt templates for different summary types
 type PromptManager struct{}
 
@@ -13,6 +40,18 @@ func NewPromptManager() *PromptManager {
 	return &PromptManager{}
 }
 
+// GetPrompt builds the prompt of a prompt type for a window of messages; unknown
+// types and PromptTypeDaily use the 24-hour prompt
+func (pm *PromptManager) GetPrompt(promptType, messages, groupName string, startTime, endTime time.Time) string {
+	switch promptType {
+	case PromptType1Hour:
+		return pm.Get1HourPrompt(messages, groupName, startTime, endTime)
+	case PromptType4Hour:
+		return pm.Get4HourPrompt(messages, groupName, startTime, endTime)
+	}
+	return pm.GetManual24HPrompt(messages, groupName, startTime, endTime)
+}
+
 // Get4HourPrompt builds detailed prompt for 4-hour summaries
 func (pm *PromptManager) Get4HourPrompt(messages, groupName string, startTime, endTime time.Time) string {
 	prompt := fmt.Sprintf(`Anda adalah analis ahli untuk komunitas tech/VPN/networking Indonesia. Analisis segmen chat 4 jam ini dan berikan laporan detail BERBASIS DATA yang ada di chat.
@@ -29,6 +68,8 @@ Periode Waktu: %s sampai %s
 Pesan-pesan:
 %s
 
+Format lampiran: [📷 FOTO], [🎥 VIDEO], [📎 FILE], dll menandakan media yang dibagikan. [OCR: ...] adalah teks yang terbaca dari gambar (misal hasil speed test/screenshot). Media dan teks OCR adalah BUKTI yang BENAR-BENAR dibagikan di chat.
+
 INSTRUKSI PENTING:
 1. Gunakan BAHASA INDONESIA untuk seluruh analisis
 2. HANYA analisis berdasarkan DATA FAKTUAL yang ada di chat
@@ -74,6 +115,7 @@ Analisis kredibilitas testimoni dan klaim HANYA berdasarkan data di chat:
 **Testimoni dengan Bukti Kuat:**
 - [Product]: [X] user konfirmasi dengan detail teknis
   - Detail teknis: [speed test/screenshot/config/log yang BENAR-BENAR dibagikan]
+  - Bukti media: [foto/screenshot dan isi OCR-nya, jika ada - misal "screenshot speed test 45 Mbps"]
   - Bukti inject berhasil: [ya/tidak - jika ada bukti di chat]
   - Bukti FC work: [ya/tidak - jika ada konfirmasi di chat]
   - Rating kredibilitas: [High/Medium/Low]
@@ -119,8 +161,8 @@ CATATAN PENTING:
 - Rating kredibilitas berdasarkan bukti (detail teknis, konfirmasi banyak user, opini seimbang)
 - Tandai "High" kredibilitas hanya jika ada bukti solid
 - Tandai "Low" jika hanya promotional atau kurang detail
-`, groupName, startTime.Format("15:04"), endTime.Format("15:04"), messages,
-		startTime.Format("15:04"), endTime.Format("15:04"))
+`, groupName, startTime.Format("15:04"), endTime.Format("15:04 MST"), messages,
+		startTime.Format("15:04"), endTime.Format("15:04 MST"))
 	
 	return prompt
 }
@@ -275,6 +317,8 @@ Periode: %s sampai %s (24 jam)
 Pesan-pesan:
 %s
 
+Format lampiran: [📷 FOTO], [🎥 VIDEO], [📎 FILE], dll menandakan media yang dibagikan. [OCR: ...] adalah teks yang terbaca dari gambar (misal hasil speed test/screenshot). Media dan teks OCR adalah BUKTI yang BENAR-BENAR dibagikan di chat.
+
 INSTRUKSI PENTING:
 1. Gunakan BAHASA INDONESIA
 2. HANYA analisis data FAKTUAL dari pesan
@@ -319,7 +363,7 @@ Gunakan struktur ini (HANYA ini, jangan tambah section lain):
 - [Produk]: [X] user konfirmasi
   - Bukti inject: [ya/tidak - jika ada]
   - Bukti FC work: [ya/tidak - jika ada]
-  - Detail: [bukti konkret yang dibagikan]
+  - Detail: [bukti konkret yang dibagikan, termasuk foto/screenshot dan isi OCR-nya]
 
 **Testimoni Belum Cukup Bukti:**
 - [Produk]: [perlu lebih banyak konfirmasi]
@@ -342,8 +386,8 @@ CATATAN PENTING:
 - Gunakan BAHASA INDONESIA untuk seluruh respons
 - Tetap objektif dan berbasis fakta
 - Identifikasi konten genuine dan mencurigakan
-`, groupName, startTime.Format("2006-01-02 15:04"), endTime.Format("2006-01-02 15:04"),
-		messages, startTime.Format("2006-01-02 15:04"), endTime.Format("2006-01-02 15:04"))
+`, groupName, startTime.Format("2006-01-02 15:04"), endTime.Format("2006-01-02 15:04 MST"),
+		messages, startTime.Format("2006-01-02 15:04"), endTime.Format("2006-01-02 15:04 MST"))
 	
 	return prompt
 }
@@ -359,6 +403,8 @@ Periode: %s sampai %s (1 jam)
 Pesan-pesan:
 %s
 
+Format lampiran: [📷 FOTO], [🎥 VIDEO], [📎 FILE], dll menandakan media yang dibagikan. [OCR: ...] adalah teks yang terbaca dari gambar (misal hasil speed test/screenshot). Media dan teks OCR adalah BUKTI yang BENAR-BENAR dibagikan di chat.
+
 INSTRUKSI PENTING:
 1. Gunakan BAHASA INDONESIA
 2. Buat ringkasan SINGKAT (maksimal 2500 karakter)
@@ -389,7 +435,189 @@ Format RINGKAS:
 [1-2 kalimat ringkasan periode 1 jam ini]
 
 PENTING: MAKSIMAL 2500 karakter! Fokus pada poin utama saja.
-`, groupName, startTime.Format("15:04"), endTime.Format("15:04"), messages)
+`, groupName, startTime.Format("15:04"), endTime.Format("15:04 MST"), messages)
+	
+	return prompt
+}
+
+// GetWeeklyPrompt builds the prompt of a weekly rollup of daily summaries
+func (pm *PromptManager) GetWeeklyPrompt(summaries, groupName string, startTime, endTime time.Time) string {
+	prompt := fmt.Sprintf(`Anda adalah analis ahli untuk komunitas tech/VPN/networking Indonesia. Buat laporan mingguan HANYA berdasarkan ringkasan harian dan data faktual yang ada.
+
+Context: Grup "%s" - Grup Telegram tentang paket data operator untuk VPN/tunneling, FamilyCode (FC) MyXL, V2Ray, Xray, config dan SSH.
+
+Periode: %s - %s
+
+Input Ringkasan Harian:
+%s
+
+INSTRUKSI PENTING:
+1. Gunakan BAHASA INDONESIA
+2. HANYA sintesis informasi yang ADA di ringkasan harian dan DATA FAKTUAL
+3. Angka produk naik/turun, aktor red flag, sentimen dan user aktif WAJIB diambil dari DATA FAKTUAL
+4. Bandingkan hari-hari dalam minggu ini: apa yang berubah, apa yang konsisten
+5. JANGAN judge sebagai ilegal - ini diskusi teknis networking yang legal
+
+Gunakan struktur PERSIS seperti ini:
+
+## 📅 RINGKASAN MINGGUAN
+- Periode: %s - %s
+- Total pesan: [dari DATA FAKTUAL]
+- Sentiment mingguan: [positive/neutral/negative]
+- Hari paling ramai: [hari dengan aktivitas terbanyak]
+
+## 🔥 TOPIK UTAMA MINGGU INI
+1. [Topik] - [ringkasan dan hari-hari topik ini dibahas]
+[Maksimal 5 topik]
+
+## 🏆 PRODUK PEMENANG & PECUNDANG
+**Naik:**
+- [Produk] - [perubahan sebutan] - [alasan dari diskusi: harga, performa, testimoni]
+**Turun:**
+- [Produk] - [perubahan sebutan] - [alasan dari diskusi: masalah, keluhan, diganti produk lain]
+
+## 🚩 RED FLAGS
+- [@aktor] - [pola yang berulang: spam, promosi berlebihan, klaim tanpa bukti] - [jumlah hari]
+[Tulis "Tidak ada" jika tidak ada aktor red flag berulang]
+
+## 📈 TREN SENTIMEN
+- [Bagaimana sentimen bergerak dari hari ke hari dan penyebabnya]
+
+## 👥 USER PALING AKTIF
+- [@user] - [jumlah pesan] - [peran: penjual, teknisi, penanya, dll. jika terlihat dari ringkasan]
+
+## 🎬 KESIMPULAN MINGGUAN
+[2 paragraf: fokus utama minggu ini, produk yang layak diperhatikan, dan peringatan penting]
+
+---
+CATATAN PENTING:
+- Gunakan format PERSIS ini dengan semua header section
+- Objektif dan berbasis fakta
+`, groupName, startTime.Format("2006-01-02"), endTime.Format("2006-01-02 MST"), summaries,
+		startTime.Format("2006-01-02"), endTime.Format("2006-01-02"))
+	
+	return prompt
+}
+
+// GetMonthlyPrompt builds the prompt of a monthly rollup of daily summaries
+func (pm *PromptManager) GetMonthlyPrompt(summaries, groupName string, startTime, endTime time.Time) string {
+	prompt := fmt.Sprintf(`Anda adalah analis ahli untuk komunitas tech/VPN/networking Indonesia. Buat laporan bulanan HANYA berdasarkan ringkasan harian dan data faktual yang ada.
+
+Context: Grup "%s" - Grup Telegram tentang paket data operator untuk VPN/tunneling, FamilyCode (FC) MyXL, V2Ray, Xray, config dan SSH.
+
+Bulan: %s (%s - %s)
+
+Input Ringkasan Harian:
+%s
+
+INSTRUKSI PENTING:
+1. Gunakan BAHASA INDONESIA
+2. HANYA sintesis informasi yang ADA di ringkasan harian dan DATA FAKTUAL
+3. Angka produk naik/turun, aktor red flag, sentimen dan user aktif WAJIB diambil dari DATA FAKTUAL
+4. Lihat gambaran besar: tren per minggu, produk yang bertahan, produk yang hilang
+5. JANGAN judge sebagai ilegal - ini diskusi teknis networking yang legal
+
+Gunakan struktur PERSIS seperti ini:
+
+## 📅 RINGKASAN BULANAN
+- Bulan: %s
+- Total pesan: [dari DATA FAKTUAL]
+- Sentiment bulanan: [positive/neutral/negative]
+- Minggu paling ramai: [minggu dengan aktivitas terbanyak]
+
+## 🗓️ PERKEMBANGAN PER MINGGU
+- Minggu 1: [fokus diskusi utama]
+- Minggu 2: [fokus diskusi utama]
+[Lanjutkan untuk setiap minggu]
+
+## 🏆 PRODUK PEMENANG & PECUNDANG
+**Naik:**
+- [Produk] - [perubahan sebutan dibanding bulan lalu] - [alasan dari diskusi]
+**Turun:**
+- [Produk] - [perubahan sebutan dibanding bulan lalu] - [alasan dari diskusi]
+
+## 🚩 RED FLAGS
+- [@aktor] - [pola yang berulang sepanjang bulan] - [jumlah hari]
+[Tulis "Tidak ada" jika tidak ada aktor red flag berulang]
+
+## 📈 TREN SENTIMEN
+- [Arah sentimen sepanjang bulan, titik balik dan penyebabnya]
+
+## 👥 USER PALING AKTIF
+- [@user] - [jumlah pesan] - [peran jika terlihat dari ringkasan]
+
+## 🎬 KESIMPULAN BULANAN
+[2-3 paragraf: perubahan terbesar bulan ini, produk yang terbukti bagus, risiko yang perlu diwaspadai bulan depan]
+
+---
+CATATAN PENTING:
+- Gunakan format PERSIS ini dengan semua header section
+- Objektif dan berbasis fakta
+`, groupName, startTime.Format("January 2006"), startTime.Format("2006-01-02"), endTime.Format("2006-01-02 MST"),
+		summaries, startTime.Format("January 2006"))
+	
+	return prompt
+}
+
+// GetDigestPrompt builds the prompt merging the daily summaries of several groups into
+// the cross-group digest
+func (pm *PromptManager) GetDigestPrompt(summaries string, startTime, endTime time.Time) string {
+	prompt := fmt.Sprintf(`Anda adalah analis ahli untuk komunitas tech/VPN/networking Indonesia. Gabungkan ringkasan harian dari BEBERAPA grup berikut menjadi SATU digest lintas grup dalam BAHASA INDONESIA.
+
+Context: Grup-grup Telegram tentang paket data operator untuk VPN/tunneling, FamilyCode (FC) MyXL, V2Ray, Xray, config dan SSH.
+
+Periode: %s - %s
+
+Ringkasan Per Grup:
+%s
+
+INSTRUKSI PENTING:
+1. Fokus pada hal yang muncul di LEBIH DARI SATU grup - jangan ulangi setiap ringkasan grup
+2. HANYA gunakan informasi yang ADA di ringkasan dan DATA FAKTUAL
+3. Produk lintas grup, promo cross-post dan red flag lintas grup WAJIB diambil dari DATA FAKTUAL
+4. Sebutkan nama grup saat membahas sesuatu yang spesifik
+5. JANGAN judge sebagai ilegal - ini diskusi teknis networking yang legal
+
+Gunakan struktur PERSIS seperti ini:
+
+## 🌐 DIGEST LINTAS GRUP
+- Periode: %s - %s
+- Grup: [jumlah grup dari DATA FAKTUAL]
+- Total pesan: [dari DATA FAKTUAL]
+- Sentiment umum: [positive/neutral/negative]
+
+## 🔥 PRODUK TRENDING DI BEBERAPA GRUP
+1. [Produk] - [grup-grup yang membahas] - [harga, performa, testimoni]
+[Tulis "Tidak ada" jika tidak ada produk yang dibahas di lebih dari satu grup]
+
+## 📢 PROMO CROSS-POST
+- [Isi promo singkat] - [jumlah grup] - [penilaian: wajar atau spam]
+[Tulis "Tidak ada" jika tidak ada]
+
+## 🚩 RED FLAGS LINTAS GRUP
+- [@aktor atau produk] - [grup-grup] - [pola yang mencurigakan]
+[Tulis "Tidak ada" jika tidak ada]
+
+## 🗂️ SOROTAN PER GRUP
+- [Grup]: [1 kalimat hal paling penting hari ini]
+
+## 🎬 KESIMPULAN
+[1-2 paragraf: gambaran besar hari ini di semua grup dan peringatan penting]
+
+---
+CATATAN PENTING:
+- Gunakan format PERSIS ini dengan semua header section
+- Objektif dan berbasis fakta
+`, startTime.Format("2006-01-02 15:04"), endTime.Format("2006-01-02 15:04 MST"), summaries,
+		startTime.Format("2006-01-02 15:04"), endTime.Format("2006-01-02 15:04 MST"))
 	
 	return prompt
 }
+
+// WithFacts adds the facts extracted from the messages to a prompt as ground truth
+func (pm *PromptManager) WithFacts(prompt string, facts *Facts) string {
+	if facts == nil {
+		return prompt
+	}
+	return prompt + "\n" + facts.PromptBlock()
+}
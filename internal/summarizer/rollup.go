@@ -0,0 +1,240 @@
+package summarizer
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"telegram-summarizer/internal/analytics"
+	"telegram-summarizer/internal/db"
+	"telegram-summarizer/internal/logger"
+	"time"
+)
+
+// Rollup settings
+const (
+	rollupMovers    = 5     // Winning and losing products listed
+	rollupTopUsers  = 10    // Most active users listed
+	rollupInputSize = 60000 // Characters of daily summaries sent to the AI
+)
+
+// ErrNoDailySummaries is returned for rollup periods without daily summaries
+var ErrNoDailySummaries = errors.New("no daily summaries in period")
+
+// redFlagSectionPattern matches the red flags section of a summary
+var redFlagSectionPattern = regexp.MustCompile(`(?s)##\s*🚩\s*RED FLAGS.*?(?:##|$)`)
+
+// IsRollup reports whether a prompt type is a weekly or monthly rollup
+func IsRollup(promptType string) bool {
+	return promptType == PromptTypeWeekly || promptType == PromptTypeMonthly
+}
+
+// RollupWindow returns the week (Monday to Monday) or month of a rollup type that
+// contains t, in the timezone of t
+func RollupWindow(promptType string, t time.Time) (start, end time.Time) {
+	y, m, d := t.Date()
+	if promptType == PromptTypeMonthly {
+		start = time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+		return start, start.AddDate(0, 1, 0)
+	}
+	
+	start = time.Date(y, m, d-(int(t.Weekday())+6)%7, 0, 0, 0, 0, t.Location())
+	return start, start.AddDate(0, 0, 7)
+}
+
+// DailySentiment is the sentiment of a daily summary in a rollup period
+type DailySentiment struct {
+	Date        time.Time
+	Sentiment   string
+	Credibility int
+	RedFlags    int
+}
+
+// RollupFacts are the statistics of a rollup period computed from stored daily
+// summaries, product mentions and user activity, so the rollup can state them exactly
+type RollupFacts struct {
+	Days          int // Daily summaries in the period
+	TotalMessages int
+	Winners       []analytics.ProductTrend // Products gaining mentions compared to the period before
+	Losers        []analytics.ProductTrend // Products losing mentions compared to the period before
+	RedFlagActors []EntityCount            // @usernames in the red flags of more than one day, by days
+	Sentiment     []DailySentiment
+	TopUsers      []db.ActiveUser
+}
+
+// SummarizeRollup generates and stores the weekly or monthly rollup of a group's daily
+// summaries in a window. Returns ErrNoDailySummaries if the window has none.
+// Rollups are stored without product mentions, which the daily summaries already hold.
+func (s *Summarizer) SummarizeRollup(group db.TrackedGroup, promptType string, startTime, endTime time.Time) (*Result, *RollupFacts, error) {
+	daily := s.database.GetSummariesByTimeRange(group.ChatID, PromptTypeDaily, startTime, endTime)
+	if len(daily) == 0 {
+		return nil, nil, ErrNoDailySummaries
+	}
+	logger.Info("Generating %s rollup of %s from %d daily summaries", promptType, group.GroupName, len(daily))
+	
+	facts, err := s.rollupFacts(group.ChatID, promptType, daily, startTime, endTime)
+	if err != nil {
+		return nil, nil, err
+	}
+	
+	// Longer periods get less of each daily summary
+	limit := rollupInputSize / len(daily)
+	var input strings.Builder
+	for _, summary := range daily {
+		input.WriteString(fmt.Sprintf("## %s (%d pesan)\n", summary.PeriodStart.In(startTime.Location()).Format("Mon 2006-01-02"), summary.MessageCount))
+		input.WriteString(truncateRunes(summary.SummaryText, limit))
+		input.WriteString("\n\n---\n\n")
+	}
+	
+	var prompt string
+	if promptType == PromptTypeMonthly {
+		prompt = s.promptManager.GetMonthlyPrompt(input.String(), group.GroupName, startTime, endTime)
+	} else {
+		prompt = s.promptManager.GetWeeklyPrompt(input.String(), group.GroupName, startTime, endTime)
+	}
+	prompt += "\n" + facts.PromptBlock()
+	logger.Debug("Rollup prompt size: %d chars", len(prompt))
+	
+	text, err := s.fallbackManager.GenerateSummary(prompt)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fallback chain failed: %w", err)
+	}
+	
+	result, err := s.Store(Request{
+		ChatID:       group.ChatID,
+		GroupName:    group.GroupName,
+		SummaryType:  promptType,
+		StartTime:    startTime,
+		EndTime:      endTime,
+		MessageCount: facts.TotalMessages,
+		NoProducts:   true,
+	}, text)
+	return result, facts, err
+}
+
+// rollupFacts computes the facts of a rollup period from its daily summaries and the
+// stored product mentions and user activity
+func (s *Summarizer) rollupFacts(chatID int64, promptType string, daily []db.Summary, startTime, endTime time.Time) (*RollupFacts, error) {
+	facts := &RollupFacts{Days: len(daily)}
+	
+	actors := newCounter()
+	for _, summary := range daily {
+		facts.TotalMessages += summary.MessageCount
+		facts.Sentiment = append(facts.Sentiment, DailySentiment{
+			Date:        summary.PeriodStart.In(startTime.Location()),
+			Sentiment:   summary.Sentiment,
+			Credibility: summary.CredibilityScore,
+			RedFlags:    summary.RedFlagsCount,
+		})
+	
+		var keys, values []string
+		for _, m := range usernamePattern.FindAllStringSubmatch(redFlagSectionPattern.FindString(summary.SummaryText), -1) {
+			keys = append(keys, strings.ToLower(m[1]))
+			values = append(values, "@"+m[1])
+		}
+		actors.add(keys, values)
+	}
+	for _, actor := range actors.byCount() {
+		if actor.Count > 1 {
+			facts.RedFlagActors = append(facts.RedFlagActors, actor)
+		}
+	}
+	
+	// Products compared to the period before
+	previousStart, _ := RollupWindow(promptType, startTime.Add(-time.Minute))
+	current, err := s.database.GetProductActivity(0, PromptTypeDaily, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := s.database.GetProductActivity(0, PromptTypeDaily, previousStart, startTime)
+	if err != nil {
+		return nil, err
+	}
+	facts.Winners, facts.Losers = analytics.Movers(ofChat(current, chatID), ofChat(previous, chatID), rollupMovers)
+	
+	if facts.TopUsers, err = s.database.GetTopUsers(chatID, startTime, endTime, rollupTopUsers); err != nil {
+		return nil, err
+	}
+	return facts, nil
+}
+
+// ofChat returns the product activity of one chat
+func ofChat(activity []db.ProductActivity, chatID int64) []db.ProductActivity {
+	var filtered []db.ProductActivity
+	for _, a := range activity {
+		if a.ChatID == chatID {
+			filtered = append(filtered, a)
+		}
+	}
+	return filtered
+}
+
+// SentimentLine renders the daily sentiments as a sparkline from negative to positive
+func (f *RollupFacts) SentimentLine() string {
+	values := make([]float64, len(f.Sentiment))
+	for i, day := range f.Sentiment {
+		values[i] = analytics.SentimentValue(day.Sentiment)
+	}
+	return analytics.Sparkline(values)
+}
+
+// PromptBlock renders the facts as a ground-truth section for the AI prompt
+func (f *RollupFacts) PromptBlock() string {
+	var b strings.Builder
+	
+	b.WriteString("DATA FAKTUAL PERIODE (dihitung otomatis dari data tersimpan, PASTI BENAR):\n")
+	b.WriteString(fmt.Sprintf("- Ringkasan harian: %d\n", f.Days))
+	b.WriteString(fmt.Sprintf("- Total pesan: %d\n", f.TotalMessages))
+	
+	writeMovers(&b, "Produk naik", f.Winners)
+	writeMovers(&b, "Produk turun", f.Losers)
+	writeFactList(&b, "Aktor red flag berulang", f.RedFlagActors, " hari")
+	
+	if len(f.Sentiment) > 0 {
+		days := make([]string, len(f.Sentiment))
+		for i, day := range f.Sentiment {
+			days[i] = fmt.Sprintf("%s %s (kredibilitas %d/5, %d red flag)", day.Date.Format("01-02"), day.Sentiment, day.Credibility, day.RedFlags)
+		}
+		b.WriteString(fmt.Sprintf("- Sentimen per hari: %s\n", strings.Join(days, ", ")))
+	}
+	
+	if len(f.TopUsers) > 0 {
+		users := make([]string, len(f.TopUsers))
+		for i, u := range f.TopUsers {
+			users[i] = fmt.Sprintf("%s (%d pesan)", u.DisplayName(), u.PeriodMessages)
+		}
+		b.WriteString(fmt.Sprintf("- User paling aktif: %s\n", strings.Join(users, ", ")))
+	}
+	
+	b.WriteString("\nGunakan angka di atas PERSIS apa adanya - JANGAN hitung ulang.\n")
+	return b.String()
+}
+
+// writeMovers writes a list of products with their change in mentions
+func writeMovers(b *strings.Builder, label string, trends []analytics.ProductTrend) {
+	if len(trends) == 0 {
+		return
+	}
+	
+	items := make([]string, len(trends))
+	for i, t := range trends {
+		switch {
+		case t.New:
+			items[i] = fmt.Sprintf("%s (baru, %d sebutan)", t.Name, t.Mentions)
+		case t.Mentions == 0:
+			items[i] = fmt.Sprintf("%s (%d sebutan, tidak disebut lagi)", t.Name, t.MentionsDelta)
+		default:
+			items[i] = fmt.Sprintf("%s (%+d sebutan, total %d, sentimen %+.2f)", t.Name, t.MentionsDelta, t.Mentions, t.Sentiment)
+		}
+	}
+	b.WriteString(fmt.Sprintf("- %s: %s\n", label, strings.Join(items, ", ")))
+}
+
+// truncateRunes shortens a text to at most limit runes, marking the cut
+func truncateRunes(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit]) + "…"
+}
@@ -0,0 +1,124 @@
+package summarizer
+
+import (
+	"path/filepath"
+	"telegram-summarizer/internal/analytics"
+	"telegram-summarizer/internal/catalog"
+	"telegram-summarizer/internal/db"
+	"testing"
+	"time"
+)
+
+func TestRollupWindow(t *testing.T) {
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+	day := func(month time.Month, d, hour int) time.Time {
+		return time.Date(2026, month, d, hour, 0, 0, 0, jakarta)
+	}
+	
+	// 2026-01-05 is a Monday
+	tests := []struct {
+		promptType string
+		t          time.Time
+		start, end time.Time
+	}{
+		{PromptTypeWeekly, day(1, 7, 15), day(1, 5, 0), day(1, 12, 0)},
+		{PromptTypeWeekly, day(1, 5, 0), day(1, 5, 0), day(1, 12, 0)},
+		{PromptTypeWeekly, day(1, 11, 23), day(1, 5, 0), day(1, 12, 0)},
+		{PromptTypeWeekly, day(1, 1, 12), day(12, 29, 0).AddDate(-1, 0, 0), day(1, 5, 0)},
+		{PromptTypeMonthly, day(1, 31, 23), day(1, 1, 0), day(2, 1, 0)},
+		{PromptTypeMonthly, day(12, 1, 0), day(12, 1, 0), day(12, 1, 0).AddDate(0, 1, 0)},
+	}
+	for _, tt := range tests {
+		start, end := RollupWindow(tt.promptType, tt.t)
+		if !start.Equal(tt.start) || !end.Equal(tt.end) || start.Location() != jakarta {
+			t.Errorf("RollupWindow(%s, %s) = %s - %s, want %s - %s", tt.promptType, tt.t, start, end, tt.start, tt.end)
+		}
+	}
+}
+
+func TestRollupFacts(t *testing.T) {
+	store, err := db.Open(db.DriverSQLite, filepath.Join(t.TempDir(), "rollup.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer store.Close()
+	s := &Summarizer{database: store, catalog: catalog.New(store)}
+	
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+	chatID := db.ChannelChatID(1234).Int64()
+	start, end := RollupWindow(PromptTypeWeekly, time.Date(2026, 1, 7, 12, 0, 0, 0, jakarta))
+	
+	saveDaily := func(dayStart time.Time, messages int, sentiment, text string, products map[string]int) {
+		t.Helper()
+		summary := &db.Summary{ChatID: chatID, SummaryType: PromptTypeDaily, PeriodStart: dayStart,
+			PeriodEnd: dayStart.AddDate(0, 0, 1), SummaryText: text, MessageCount: messages, Sentiment: sentiment}
+		var mentions []db.ProductMention
+		for name, count := range products {
+			mentions = append(mentions, db.ProductMention{ProductName: name, MentionCount: count})
+		}
+		if err := store.SaveSummaryWithProducts(summary, mentions); err != nil {
+			t.Fatalf("SaveSummaryWithProducts: %v", err)
+		}
+		if err := s.catalog.Link(mentions, chatID, summary.PeriodEnd); err != nil {
+			t.Fatalf("Link: %v", err)
+		}
+	}
+	saveDaily(start.AddDate(0, 0, -4), 50, "positive", "Minggu lalu", map[string]int{"Telkomsel Orbit": 3, "Indosat Freedom": 1})
+	saveDaily(start, 10, "positive", "## 🚩 RED FLAGS\n- @Scammer minta transfer dulu\n## 📦 PRODUK", map[string]int{"Telkomsel Orbit": 1, "XL Akrab": 2})
+	saveDaily(start.AddDate(0, 0, 1), 20, "negative", "## 🚩 RED FLAGS\n- @scammer lagi, juga @once_only\n", map[string]int{"XL Akrab": 1})
+	saveDaily(start.AddDate(0, 0, 2), 5, "neutral", "Aman, @once_only di luar red flags", nil)
+	
+	for i, sighting := range []struct {
+		userID int64
+		at     time.Time
+	}{
+		{1, start.Add(time.Hour)}, {1, start.Add(2 * time.Hour)}, {2, start.Add(3 * time.Hour)}, {1, start.Add(25 * time.Hour)},
+		{3, start.Add(-time.Hour)}, // The week before
+	} {
+		if err := store.TrackUser(db.UserSighting{UserID: sighting.userID, ChatID: chatID, Username: "user", Timestamp: sighting.at}); err != nil {
+			t.Fatalf("TrackUser %d: %v", i, err)
+		}
+	}
+	
+	daily := store.GetSummariesByTimeRange(chatID, PromptTypeDaily, start, end)
+	facts, err := s.rollupFacts(chatID, PromptTypeWeekly, daily, start, end)
+	if err != nil {
+		t.Fatalf("rollupFacts: %v", err)
+	}
+	
+	if facts.Days != 3 || facts.TotalMessages != 35 {
+		t.Errorf("got %d days with %d messages, want 3 with 35", facts.Days, facts.TotalMessages)
+	}
+	if len(facts.RedFlagActors) != 1 || facts.RedFlagActors[0] != (EntityCount{"@Scammer", 2}) {
+		t.Errorf("got red flag actors %v, want @Scammer on 2 days", facts.RedFlagActors)
+	}
+	if len(facts.Sentiment) != 3 || facts.Sentiment[1].Sentiment != "negative" || !facts.Sentiment[1].Date.Equal(start.AddDate(0, 0, 1)) ||
+		facts.Sentiment[1].Date.Location() != jakarta {
+		t.Errorf("got daily sentiments %+v, want one per day in the group's timezone", facts.Sentiment)
+	}
+	
+	names := func(trends []analytics.ProductTrend) []string {
+		var names []string
+		for _, t := range trends {
+			names = append(names, t.Name)
+		}
+		return names
+	}
+	if got := names(facts.Winners); len(got) != 1 || got[0] != "XL Akrab" || !facts.Winners[0].New || facts.Winners[0].Mentions != 3 {
+		t.Errorf("got winners %+v, want the new XL Akrab with 3 mentions", facts.Winners)
+	}
+	if got := names(facts.Losers); len(got) != 2 || got[0] != "Telkomsel Orbit" || got[1] != "Indosat Freedom" ||
+		facts.Losers[0].MentionsDelta != -2 || facts.Losers[1].MentionsDelta != -1 {
+		t.Errorf("got losers %+v, want Telkomsel Orbit (-2) and Indosat Freedom (-1)", facts.Losers)
+	}
+	
+	if len(facts.TopUsers) != 2 || facts.TopUsers[0].UserID != 1 || facts.TopUsers[0].PeriodMessages != 3 || facts.TopUsers[1].UserID != 2 {
+		t.Errorf("got top users %+v, want user 1 with 3 messages, then user 2", facts.TopUsers)
+	}
+}
@@ -3,26 +3,33 @@ package summarizer
 import (
 	"fmt"
 	"strings"
+	"sync"
 	"telegram-summarizer/internal/ai"
+	"telegram-summarizer/internal/catalog"
 	"telegram-summarizer/internal/db"
 	"telegram-summarizer/internal/gemini"
 	"telegram-summarizer/internal/logger"
+	"telegram-summarizer/internal/timezone"
 	"time"
 )
 
 // Summarizer handles chat summarization
 type Summarizer struct {
-	database           *db.DB
+	database           db.Store
 	geminiClient       *gemini.Client
 	aiProvider         ai.AIProvider // Fallback-enabled AI provider
 	promptManager      *PromptManager
 	metadataParser     *MetadataParser
 	fallbackManager    *ai.FallbackManager // Direct access to fallback manager
 	chunkManager       *ChunkManager       // Chunk manager for message splitting
+	catalog            *catalog.Catalog    // Links product mentions to canonical products
+	
+	hooksMu     sync.Mutex
+	storedHooks []StoredHook // Called after a summary was stored
 }
 
 // NewSummarizer creates a new summarizer instance with fallback AI providers
-func NewSummarizer(database *db.DB, geminiClient *gemini.Client) *Summarizer {
+func NewSummarizer(database db.Store, geminiClient *gemini.Client) *Summarizer {
 	// Setup extended fallback chain with multiple API sources
 	providers := []ai.AIProvider{
 		// PRIMARY: Official Google Gemini API
@@ -66,7 +73,7 @@ func NewSummarizer(database *db.DB, geminiClient *gemini.Client) *Summarizer {
 	logger.Info("   Total: 18 AI providers with automatic fallback!")
 	logger.Info("   Hierarchical chunking: Enabled for large chats")
 	
-	return &Summarizer{
+	s := &Summarizer{
 		database:        database,
 		geminiClient:    geminiClient,
 		aiProvider:      fallbackManager,
@@ -74,7 +81,18 @@ func NewSummarizer(database *db.DB, geminiClient *gemini.Client) *Summarizer {
 		metadataParser:  NewMetadataParser(),
 		fallbackManager: fallbackManager,
 		chunkManager:    NewChunkManager(),
+		catalog:         catalog.New(database),
 	}
+	
+	// Product analytics read the catalog, so mentions are linked as summaries are stored
+	s.OnSummaryStored(s.linkProducts)
+	return s
+}
+
+// SetProviders replaces the chain of AI providers, tried in order
+func (s *Summarizer) SetProviders(providers ...ai.AIProvider) {
+	s.fallbackManager = ai.NewFallbackManager(providers)
+	s.aiProvider = s.fallbackManager
 }
 
 // GenerateSummary generates a summary with fallback support
@@ -94,10 +112,18 @@ func (s *Summarizer) GenerateSummary(messageText string, summaryType string) (st
 // GenerateSummaryHierarchical generates a summary using hierarchical chunking
 // This method automatically splits large message sets into manageable chunks
 func (s *Summarizer) GenerateSummaryHierarchical(messages []db.Message, groupName string, startTime, endTime time.Time, progressCallback func(string), summaryCallback func(string)) (string, error) {
+	return s.generateHierarchical(PromptType24Hour, messages, nil, groupName, startTime, endTime, progressCallback, summaryCallback)
+}
+
+// generateHierarchical generates a summary using hierarchical chunking with the prompt of a
+// prompt type; facts (may be nil) replace the facts of the messages
+func (s *Summarizer) generateHierarchical(promptType string, messages []db.Message, facts *Facts, groupName string, startTime, endTime time.Time, progressCallback func(string), summaryCallback func(string)) (string, error) {
 	logger.Info("Starting hierarchical summary generation for %d messages", len(messages))
 	
 	// Create hierarchical summarizer with both callbacks
 	hierarchical := NewHierarchicalSummarizer(s.fallbackManager, progressCallback, summaryCallback)
+	hierarchical.SetPromptType(promptType)
+	hierarchical.SetFacts(facts)
 	
 	// Generate summary with automatic chunking (may send multiple partial summaries)
 	summary, err := hierarchical.SummarizeMessages(messages, groupName, startTime, endTime)
@@ -109,6 +135,31 @@ func (s *Summarizer) GenerateSummaryHierarchical(messages []db.Message, groupNam
 	return summary, nil
 }
 
+// PeriodFacts returns the facts of the stored messages of a period, in the timezone of
+// startTime, for summaries built from the summaries of the period. messageCount (if
+// not 0) replaces the number of messages, which may have been deleted since they were
+// summarized; active users are then counted from the user activity.
+func (s *Summarizer) PeriodFacts(chatID int64, startTime, endTime time.Time, messageCount int) (*Facts, error) {
+	messages, err := s.database.GetMessagesByTimeRange(chatID, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	
+	facts := ExtractFacts(inLocation(messages, startTime.Location()))
+	if messageCount == 0 || messageCount == len(messages) {
+		return facts, nil
+	}
+	facts.TotalMessages = messageCount
+	if len(messages) == 0 {
+		users, err := s.database.GetTopUsers(chatID, startTime, endTime, messageCount)
+		if err != nil {
+			return nil, err
+		}
+		facts.ActiveUsers = len(users)
+	}
+	return facts, nil
+}
+
 // CreateIncrementalSummary creates a summary for the specified time window
 func (s *Summarizer) CreateIncrementalSummary(chatID int64, duration time.Duration) (string, error) {
 	logger.Info("Creating incremental summary for chat %d (duration: %v)", chatID, duration)
@@ -135,13 +186,22 @@ func (s *Summarizer) CreateIncrementalSummary(chatID int64, duration time.Durati
 		return "", fmt.Errorf("no messages in time range")
 	}
 	
-	if len(messages) < 5 {
+	// The minimum is the one of the group's window policy, times are in its timezone
+	minMessages := db.DefaultMinMessages
+	loc := timezone.Default()
+	if group := s.database.GetTrackedGroup(chatID); group != nil {
+		minMessages = group.Windows.Effective().MinMessages
+		loc = timezone.Of(*group)
+	}
+	if len(messages) < minMessages {
 		logger.Warn("Too few messages to summarize (%d)", len(messages))
-		return "", fmt.Errorf("insufficient messages for summary (minimum 5)")
+		return "", fmt.Errorf("insufficient messages for summary (minimum %d)", minMessages)
 	}
 	
-	// Format messages for Gemini
-	formattedMessages := s.formatMessagesForSummary(messages)
+	// Format messages for Gemini, with the counts it must not recompute
+	messages = inLocation(messages, loc)
+	facts := ExtractFacts(messages)
+	formattedMessages := s.formatMessagesForSummary(messages) + "\n" + facts.PromptBlock()
 	logger.Debug("Formatted messages length: %d characters", len(formattedMessages))
 	
 	// Generate summary using Gemini
@@ -149,6 +209,7 @@ func (s *Summarizer) CreateIncrementalSummary(chatID int64, duration time.Durati
 	if err != nil {
 		return "", fmt.Errorf("failed to generate summary: %w", err)
 	}
+	summary = NewSummaryFormatter().ApplyFacts(summary, facts)
 	
 	// Save summary to database
 	summaryRecord := &db.Summary{
@@ -244,7 +305,7 @@ func (s *Summarizer) formatMessagesForSummary(messages []db.Message) string {
 	
 	for _, msg := range messages {
 		timestamp := msg.Timestamp.Format("15:04")
-		builder.WriteString(fmt.Sprintf("[%s] %s: %s\n", timestamp, msg.Username, msg.MessageText))
+		builder.WriteString(fmt.Sprintf("[%s] %s: %s\n", timestamp, msg.Username, formatMessageContent(msg)))
 	}
 	
 	return builder.String()
@@ -275,6 +336,11 @@ func (s *Summarizer) GetMetadataParser() *MetadataParser {
 	return s.metadataParser
 }
 
+// GetCatalog returns the product catalog instance
+func (s *Summarizer) GetCatalog() *catalog.Catalog {
+	return s.catalog
+}
+
 // GetChatStats returns statistics for a chat
 func (s *Summarizer) GetChatStats(chatID int64, duration time.Duration) (*ChatStats, error) {
 	logger.Debug("Getting chat stats for chat %d (duration: %v)", chatID, duration)
@@ -0,0 +1,123 @@
+// Package timezone resolves the timezones summary windows, daily boundaries and
+// formatted times use: the deployment's default zone and each group's own zone.
+package timezone
+
+import (
+	"fmt"
+	"sync"
+	"telegram-summarizer/internal/db"
+	"telegram-summarizer/internal/logger"
+	"time"
+	
+	// Embedded zone database, for hosts without /usr/share/zoneinfo
+	_ "time/tzdata"
+)
+
+var (
+	mu              sync.RWMutex
+	defaultLocation = time.Local
+	locations       = make(map[string]*time.Location) // Loaded group timezones by name
+)
+
+// Load loads an IANA timezone such as "Asia/Jakarta". An empty name is the server's local time.
+func Load(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	
+	mu.RLock()
+	loc, ok := locations[name]
+	mu.RUnlock()
+	if ok {
+		return loc, nil
+	}
+	
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q: %w", name, err)
+	}
+	
+	mu.Lock()
+	locations[name] = loc
+	mu.Unlock()
+	return loc, nil
+}
+
+// SetDefault sets the timezone of the deployment, used by groups without a timezone
+func SetDefault(loc *time.Location) {
+	mu.Lock()
+	defer mu.Unlock()
+	defaultLocation = loc
+}
+
+// Default returns the timezone of the deployment
+func Default() *time.Location {
+	mu.RLock()
+	defer mu.RUnlock()
+	return defaultLocation
+}
+
+// Of returns the timezone of a group: its own if set, the deployment's otherwise
+func Of(group db.TrackedGroup) *time.Location {
+	if group.Timezone == "" {
+		return Default()
+	}
+	loc, err := Load(group.Timezone)
+	if err != nil {
+		logger.Warn("⚠️  Group %d: %v, using %s", group.ChatID, err, Default())
+		return Default()
+	}
+	return loc
+}
+
+// HourStart returns the start of the hour of t in a timezone. Zones with offsets that
+// are not whole hours (e.g. Asia/Kolkata) start their hours at other minutes than UTC.
+// The offset in effect at t is used, so hours stay one hour long across DST changes.
+func HourStart(t time.Time, loc *time.Location) time.Time {
+	_, offset := t.In(loc).Zone()
+	shift := time.Duration(offset) * time.Second
+	return t.Add(shift).Truncate(time.Hour).Add(-shift).In(loc)
+}
+
+// DayStart returns midnight of the day of t in a timezone. Days around DST changes
+// are 23 or 25 hours long.
+func DayStart(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
+
+// NextClock returns the first time after t that the clock of a timezone shows hour:min
+func NextClock(t time.Time, loc *time.Location, hour, min int) time.Time {
+	y, m, d := t.In(loc).Date()
+	next := clock(y, m, d, hour, min, loc)
+	if !next.After(t) {
+		next = clock(y, m, d+1, hour, min, loc)
+	}
+	return next
+}
+
+// clock returns hour:min of a day in a timezone. A time skipped by a DST change is
+// moved forward by the length of the gap, e.g. 02:30 becomes 03:30.
+func clock(y int, m time.Month, d, hour, min int, loc *time.Location) time.Time {
+	t := time.Date(y, m, d, hour, min, 0, 0, loc)
+	if h, mm, _ := t.Clock(); h == hour && mm == min {
+		return t
+	}
+	
+	// Use the offset in effect before the gap
+	_, offset := t.Add(-12 * time.Hour).Zone()
+	return time.Date(y, m, d, hour, min, 0, 0, time.UTC).Add(-time.Duration(offset) * time.Second).In(loc)
+}
+
+// Name returns the name of a timezone for display
+func Name(loc *time.Location) string {
+	if loc == time.Local {
+		return "server local time"
+	}
+	return loc.String()
+}
+
+// Label returns the zone abbreviation of t shown with formatted times, e.g. "WIB"
+func Label(t time.Time) string {
+	return t.Format("MST")
+}
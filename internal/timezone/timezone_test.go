@@ -0,0 +1,98 @@
+package timezone
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := Load(name)
+	if err != nil {
+		t.Fatalf("Load(%q): %v", name, err)
+	}
+	return loc
+}
+
+func utc(month time.Month, day, hour, min int) time.Time {
+	return time.Date(2025, month, day, hour, min, 0, 0, time.UTC)
+}
+
+func TestHourStartAcrossDST(t *testing.T) {
+	newYork := mustLoad(t, "America/New_York")
+	kolkata := mustLoad(t, "Asia/Kolkata")
+	
+	tests := []struct {
+		name string
+		t    time.Time
+		loc  *time.Location
+		want time.Time
+	}{
+		{"before spring forward", utc(time.March, 9, 6, 30), newYork, utc(time.March, 9, 6, 0)},           // 01:30 EST
+		{"after spring forward", utc(time.March, 9, 7, 30), newYork, utc(time.March, 9, 7, 0)},            // 03:30 EDT
+		{"first 01:00 of fall back", utc(time.November, 2, 5, 30), newYork, utc(time.November, 2, 5, 0)},  // 01:30 EDT
+		{"second 01:00 of fall back", utc(time.November, 2, 6, 30), newYork, utc(time.November, 2, 6, 0)}, // 01:30 EST
+		{"half-hour offset", utc(time.March, 9, 10, 10), kolkata, utc(time.March, 9, 9, 30)},              // 15:40 IST
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := HourStart(tt.t, tt.loc)
+			if !got.Equal(tt.want) {
+				t.Errorf("HourStart(%s) = %s, want %s", tt.t, got.UTC(), tt.want)
+			}
+			if next := HourStart(got.Add(time.Hour), tt.loc); !next.Equal(got.Add(time.Hour)) {
+				t.Errorf("next hour starts at %s, want %s", next.UTC(), got.Add(time.Hour))
+			}
+		})
+	}
+}
+
+func TestDayStartAcrossDST(t *testing.T) {
+	newYork := mustLoad(t, "America/New_York")
+	
+	tests := []struct {
+		name   string
+		t      time.Time
+		want   time.Time
+		length time.Duration
+	}{
+		{"spring forward", utc(time.March, 9, 16, 0), utc(time.March, 9, 5, 0), 23 * time.Hour},
+		{"fall back", utc(time.November, 2, 17, 0), utc(time.November, 2, 4, 0), 25 * time.Hour},
+		{"regular day", utc(time.November, 3, 17, 0), utc(time.November, 3, 5, 0), 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := DayStart(tt.t, newYork)
+			if !start.Equal(tt.want) {
+				t.Errorf("DayStart(%s) = %s, want %s", tt.t, start.UTC(), tt.want)
+			}
+			end := DayStart(start.Add(tt.length+time.Hour), newYork)
+			if length := end.Sub(start); length != tt.length {
+				t.Errorf("day is %s long, want %s", length, tt.length)
+			}
+		})
+	}
+}
+
+func TestNextClockAcrossDST(t *testing.T) {
+	newYork := mustLoad(t, "America/New_York")
+	
+	tests := []struct {
+		name      string
+		t         time.Time
+		hour, min int
+		want      time.Time
+	}{
+		{"daily run before spring forward", utc(time.March, 9, 4, 59), 23, 59, utc(time.March, 10, 3, 59)}, // 23:59 EST → 23:59 EDT
+		{"daily run before fall back", utc(time.November, 2, 3, 59), 23, 59, utc(time.November, 3, 4, 59)}, // 23:59 EDT → 23:59 EST
+		{"skipped time moves forward", utc(time.March, 9, 5, 0), 2, 30, utc(time.March, 9, 7, 30)},         // 02:30 → 03:30 EDT
+		{"same day", utc(time.March, 9, 8, 0), 12, 0, utc(time.March, 9, 16, 0)},                           // 12:00 EDT
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NextClock(tt.t, newYork, tt.hour, tt.min); !got.Equal(tt.want) {
+				t.Errorf("NextClock(%s, %02d:%02d) = %s, want %s", tt.t, tt.hour, tt.min, got.UTC(), tt.want)
+			}
+		})
+	}
+}